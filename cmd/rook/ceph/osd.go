@@ -51,6 +51,10 @@ var osdStartCmd = &cobra.Command{
 	Use:   "start",
 	Short: "Starts the osd daemon", // OSDs that were provisioned by ceph-volume
 }
+var osdEncryptionKeyCmd = &cobra.Command{
+	Use:   "encryption-key",
+	Short: "Fetches the osd encryption key from the KMS",
+}
 var osdRemoveCmd = &cobra.Command{
 	Use:   "remove",
 	Short: "Removes a set of OSDs from the cluster",
@@ -70,6 +74,10 @@ var (
 	blockPath               string
 	lvBackedPV              bool
 	osdIDsToRemove          string
+	osdPVCName              string
+	osdKeyName              string
+	osdKeyPath              string
+	osdReplacements         string
 )
 
 func addOSDFlags(command *cobra.Command) {
@@ -84,6 +92,7 @@ func addOSDFlags(command *cobra.Command) {
 	provisionCmd.Flags().BoolVar(&cfg.forceFormat, "force-format", false,
 		"true to force the format of any specified devices, even if they already have a filesystem.  BE CAREFUL!")
 	provisionCmd.Flags().BoolVar(&cfg.pvcBacked, "pvc-backed-osd", false, "true to specify a block mode pvc is backing the OSD")
+	provisionCmd.Flags().StringVar(&osdReplacements, "replace-osds", "", "the IDs of the destroyed OSDs to re-create by the path of their new device, in json")
 	// flags for generating the osd config
 	osdConfigCmd.Flags().IntVar(&osdID, "osd-id", -1, "osd id for which to generate config")
 	osdConfigCmd.Flags().BoolVar(&osdIsDevice, "is-device", false, "whether the osd is a device")
@@ -99,11 +108,17 @@ func addOSDFlags(command *cobra.Command) {
 	// flags for removing OSDs that are unhealthy or otherwise should be purged from the cluster
 	osdRemoveCmd.Flags().StringVar(&osdIDsToRemove, "osd-ids", "", "OSD IDs to remove from the cluster")
 
+	// flags for fetching the encryption key of an OSD on PVC or on the devices of a node
+	osdEncryptionKeyCmd.Flags().StringVar(&osdPVCName, "pvc-name", "", "the name of the PVC backing the OSD")
+	osdEncryptionKeyCmd.Flags().StringVar(&osdKeyName, "key-name", "", "the name of the key of the OSDs on the devices of a node")
+	osdEncryptionKeyCmd.Flags().StringVar(&osdKeyPath, "key-path", "", "the file to write the encryption key to")
+
 	// add the subcommands to the parent osd command
 	osdCmd.AddCommand(osdConfigCmd,
 		provisionCmd,
 		osdStartCmd,
-		osdRemoveCmd)
+		osdRemoveCmd,
+		osdEncryptionKeyCmd)
 }
 
 func addOSDConfigFlags(command *cobra.Command) {
@@ -117,6 +132,7 @@ func addOSDConfigFlags(command *cobra.Command) {
 	command.Flags().IntVar(&cfg.storeConfig.DatabaseSizeMB, "osd-database-size", 0, "default size (MB) for OSD database (bluestore)")
 	command.Flags().IntVar(&cfg.storeConfig.OSDsPerDevice, "osds-per-device", 1, "the number of OSDs per device")
 	command.Flags().BoolVar(&cfg.storeConfig.EncryptedDevice, "encrypted-device", false, "whether to encrypt the OSD with dmcrypt")
+	command.Flags().BoolVar(&cfg.storeConfig.Encrypted, "encrypted", false, "whether to encrypt the OSDs with a key held by the kms")
 	command.Flags().StringVar(&cfg.storeConfig.DeviceClass, "osd-crush-device-class", "", "The device class for all OSDs configured on this node")
 }
 
@@ -128,11 +144,13 @@ func init() {
 	flags.SetFlagsFromEnv(provisionCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(osdStartCmd.Flags(), rook.RookEnvVarPrefix)
 	flags.SetFlagsFromEnv(osdRemoveCmd.Flags(), rook.RookEnvVarPrefix)
+	flags.SetFlagsFromEnv(osdEncryptionKeyCmd.Flags(), rook.RookEnvVarPrefix)
 
 	osdConfigCmd.RunE = writeOSDConfig
 	provisionCmd.RunE = prepareOSD
 	osdStartCmd.RunE = startOSD
 	osdRemoveCmd.RunE = removeOSDs
+	osdEncryptionKeyCmd.RunE = fetchOSDEncryptionKey
 }
 
 // Start the osd daemon if provisioned by ceph-volume
@@ -210,6 +228,13 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 		}
 	}
 
+	replaceOSDs := map[string]int{}
+	if osdReplacements != "" {
+		if err := json.Unmarshal([]byte(osdReplacements), &replaceOSDs); err != nil {
+			rook.TerminateFatal(errors.Wrapf(err, "failed to parse the osds to replace (%q)", osdReplacements))
+		}
+	}
+
 	context := createContext()
 	commonOSDInit(provisionCmd)
 	crushLocation, topologyAffinity, err := getLocation(context.Clientset)
@@ -225,7 +250,7 @@ func prepareOSD(cmd *cobra.Command, args []string) error {
 	clusterInfo.OwnerInfo = ownerInfo
 	kv := k8sutil.NewConfigMapKVStore(clusterInfo.Namespace, context.Clientset, ownerInfo)
 	agent := osddaemon.NewAgent(context, dataDevices, cfg.metadataDevice, forceFormat,
-		cfg.storeConfig, &clusterInfo, cfg.nodeName, kv, cfg.pvcBacked)
+		cfg.storeConfig, &clusterInfo, cfg.nodeName, kv, cfg.pvcBacked, replaceOSDs)
 
 	err = osddaemon.Provision(context, agent, crushLocation, topologyAffinity)
 	if err != nil {
@@ -266,6 +291,32 @@ func removeOSDs(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// Fetch the encryption key of an OSD from the KMS
+func fetchOSDEncryptionKey(cmd *cobra.Command, args []string) error {
+	required := []string{"key-path"}
+	if err := flags.VerifyRequiredFlags(osdEncryptionKeyCmd, required); err != nil {
+		return err
+	}
+	keyName := osdPVCName
+	if keyName == "" {
+		keyName = osdKeyName
+	}
+	if keyName == "" {
+		return errors.New("one of --pvc-name and --key-name must be specified")
+	}
+
+	rook.SetLogLevel()
+	rook.LogStartupInfo(osdEncryptionKeyCmd.Flags())
+
+	context := createContext()
+
+	err := osddaemon.WriteKEKToFile(context, &clusterInfo, keyName, osdKeyPath)
+	if err != nil {
+		rook.TerminateFatal(err)
+	}
+	return nil
+}
+
 func commonOSDInit(cmd *cobra.Command) {
 	rook.SetLogLevel()
 	rook.LogStartupInfo(cmd.Flags())
@@ -304,6 +355,7 @@ func parseDevices(devices string) ([]osddaemon.DesiredDevice, error) {
 		d.DatabaseSizeMB = cd.StoreConfig.DatabaseSizeMB
 		d.DeviceClass = cd.StoreConfig.DeviceClass
 		d.MetadataDevice = cd.StoreConfig.MetadataDevice
+		d.Encrypted = cd.StoreConfig.Encrypted
 
 		if d.OSDsPerDevice < 1 {
 			return nil, errors.Errorf("osds per device should be greater than 0 (%q)", d.OSDsPerDevice)
@@ -33,11 +33,49 @@ func (c *ClusterSpec) IsStretchCluster() bool {
 	return c.Mon.StretchCluster != nil && len(c.Mon.StretchCluster.Zones) > 0
 }
 
+// StretchReplicasPerZone is the number of replicas of the pools in each data zone of a stretch cluster
+const StretchReplicasPerZone = 2
+
+// DataZones returns the zones of the stretch cluster that store data, all the zones except the arbiter
+func (s *StretchClusterSpec) DataZones() []StretchClusterZoneSpec {
+	zones := []StretchClusterZoneSpec{}
+	for _, zone := range s.Zones {
+		if !zone.Arbiter {
+			zones = append(zones, zone)
+		}
+	}
+	return zones
+}
+
+// IsStretchMode returns whether Ceph stretch mode is enabled for the stretch cluster. Ceph stretch mode supports
+// exactly two data zones, the clusters stretched across more data zones rely on the CRUSH rule and the min_size of
+// the pools to survive the loss of a zone.
+func (c *ClusterSpec) IsStretchMode() bool {
+	return c.IsStretchCluster() && len(c.Mon.StretchCluster.DataZones()) == 2
+}
+
+// StretchPoolSize returns the replica count of the pools in a stretch cluster
+func (c *ClusterSpec) StretchPoolSize() uint {
+	return uint(StretchReplicasPerZone * len(c.Mon.StretchCluster.DataZones()))
+}
+
+// StretchPoolMinSize returns the min_size of the pools in a stretch cluster, the replicas in all the data zones but
+// one so the pools remain writable when a data zone is lost
+func (c *ClusterSpec) StretchPoolMinSize() uint {
+	return c.StretchPoolSize() - StretchReplicasPerZone
+}
+
+// ZonesRequired returns whether the mons are spread across zones, either in a stretch cluster or with the zones
+// or the failure domain label of the mon spec
+func (c *ClusterSpec) ZonesRequired() bool {
+	return c.IsStretchCluster() || len(c.Mon.Zones) > 0 || c.Mon.FailureDomainLabel != ""
+}
+
 func (c *CephCluster) ValidateCreate() error {
 	logger.Infof("validate create cephcluster %q", c.ObjectMeta.Name)
 	//If external mode enabled, then check if other fields are empty
 	if c.Spec.External.Enable {
-		if c.Spec.Mon != (MonSpec{}) || c.Spec.Dashboard != (DashboardSpec{}) || !reflect.DeepEqual(c.Spec.Monitoring, (MonitoringSpec{})) || c.Spec.DisruptionManagement != (DisruptionManagementSpec{}) || len(c.Spec.Mgr.Modules) > 0 || len(c.Spec.Network.Provider) > 0 || len(c.Spec.Network.Selectors) > 0 {
+		if !reflect.DeepEqual(c.Spec.Mon, MonSpec{}) || c.Spec.Dashboard != (DashboardSpec{}) || !reflect.DeepEqual(c.Spec.Monitoring, (MonitoringSpec{})) || c.Spec.DisruptionManagement != (DisruptionManagementSpec{}) || len(c.Spec.Mgr.Modules) > 0 || len(c.Spec.Network.Provider) > 0 || len(c.Spec.Network.Selectors) > 0 {
 			return errors.New("invalid create : external mode enabled cannot have mon,dashboard,monitoring,network,disruptionManagement,storage fields in CR")
 		}
 	}
@@ -89,3 +89,24 @@ func TestCephClusterValidateUpdate(t *testing.T) {
 	err = uc.ValidateUpdate(c)
 	assert.Error(t, err)
 }
+
+func TestStretchPoolSize(t *testing.T) {
+	spec := ClusterSpec{Mon: MonSpec{StretchCluster: &StretchClusterSpec{Zones: []StretchClusterZoneSpec{
+		{Name: "a", Arbiter: true},
+		{Name: "b"},
+		{Name: "c"},
+	}}}}
+	assert.True(t, spec.IsStretchMode())
+	assert.Equal(t, uint(4), spec.StretchPoolSize())
+	assert.Equal(t, uint(2), spec.StretchPoolMinSize())
+
+	// ceph stretch mode is not enabled with more than two data zones
+	spec.Mon.StretchCluster.Zones = append(spec.Mon.StretchCluster.Zones, StretchClusterZoneSpec{Name: "d"})
+	assert.False(t, spec.IsStretchMode())
+	assert.Equal(t, 3, len(spec.Mon.StretchCluster.DataZones()))
+	assert.Equal(t, uint(6), spec.StretchPoolSize())
+	assert.Equal(t, uint(4), spec.StretchPoolMinSize())
+
+	spec.Mon.StretchCluster = nil
+	assert.False(t, spec.IsStretchMode())
+}
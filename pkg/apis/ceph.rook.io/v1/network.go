@@ -22,3 +22,24 @@ func (net *NetworkSpec) IsHost() bool {
 	rookNet := net.NetworkSpec
 	return (net.HostNetwork && net.Provider == "") || rookNet.IsHost()
 }
+
+// IsDualStack returns whether the daemons and the services have both an IPv4 and an IPv6 address
+func (net *NetworkSpec) IsDualStack() bool {
+	return net.IPFamily == DualStack
+}
+
+// BindIPv6 returns whether the daemons bind an IPv6 address, which they do in single stack IPv6 and in dual stack
+func (net *NetworkSpec) BindIPv6() bool {
+	return net.IPFamily == IPv6 || net.IsDualStack()
+}
+
+// IsEncryptionEnabled returns whether the data is encrypted on the wire
+func (net *NetworkSpec) IsEncryptionEnabled() bool {
+	return net.Connections != nil && net.Connections.Encryption != nil && net.Connections.Encryption.Enabled
+}
+
+// RequireMsgr2 returns whether the daemons and the clients only communicate with the msgr2 protocol. The encryption
+// requires msgr2 since the msgr1 protocol does not support it.
+func (net *NetworkSpec) RequireMsgr2() bool {
+	return (net.Connections != nil && net.Connections.RequireMsgr2) || net.IsEncryptionEnabled()
+}
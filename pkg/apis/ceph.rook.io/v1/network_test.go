@@ -45,3 +45,32 @@ func TestNetworkCeph_IsHostLegacy(t *testing.T) {
 
 	assert.True(t, net.IsHost())
 }
+
+func TestNetworkCeph_RequireMsgr2(t *testing.T) {
+	net := NetworkSpec{}
+	assert.False(t, net.IsEncryptionEnabled())
+	assert.False(t, net.RequireMsgr2())
+
+	net.Connections = &ConnectionsSpec{RequireMsgr2: true}
+	assert.False(t, net.IsEncryptionEnabled())
+	assert.True(t, net.RequireMsgr2())
+
+	// the encryption requires msgr2
+	net.Connections = &ConnectionsSpec{Encryption: &EncryptionSpec{Enabled: true}}
+	assert.True(t, net.IsEncryptionEnabled())
+	assert.True(t, net.RequireMsgr2())
+}
+
+func TestNetworkCeph_IPFamily(t *testing.T) {
+	net := NetworkSpec{}
+	assert.False(t, net.IsDualStack())
+	assert.False(t, net.BindIPv6())
+
+	net.IPFamily = IPv6
+	assert.False(t, net.IsDualStack())
+	assert.True(t, net.BindIPv6())
+
+	net.IPFamily = DualStack
+	assert.True(t, net.IsDualStack())
+	assert.True(t, net.BindIPv6())
+}
@@ -89,15 +89,22 @@ func (p *CephBlockPool) ValidateUpdate(old runtime.Object) error {
 	if err != nil {
 		return err
 	}
+	// a replicated pool is converted by the operator to erasure coded, the data of its images is migrated
 	if p.Spec.ErasureCoded.CodingChunks > 0 || p.Spec.ErasureCoded.DataChunks > 0 || p.Spec.ErasureCoded.Algorithm != "" {
 		if ocbp.Spec.Replicated.Size > 0 || ocbp.Spec.Replicated.TargetSizeRatio > 0 {
-			return errors.New("invalid update: replicated field is set already in previous object. cannot be changed to use erasurecoded")
+			if p.Spec.Mirroring.Enabled {
+				return errors.New("invalid update: a mirrored pool cannot be converted to use erasurecoded")
+			}
 		}
 	}
 
+	// only an erasure coded pool converted by the operator can be converted back to replicated, the data of its
+	// images is in the data pool of the conversion
 	if p.Spec.Replicated.Size > 0 || p.Spec.Replicated.TargetSizeRatio > 0 {
 		if ocbp.Spec.ErasureCoded.CodingChunks > 0 || ocbp.Spec.ErasureCoded.DataChunks > 0 || ocbp.Spec.ErasureCoded.Algorithm != "" {
-			return errors.New("invalid update: erasurecoded field is set already in previous object. cannot be changed to use replicated")
+			if ocbp.Status == nil || ocbp.Status.Conversion == nil || ocbp.Status.Conversion.DataPool == "" {
+				return errors.New("invalid update: erasurecoded field is set already in previous object. cannot be changed to use replicated")
+			}
 		}
 	}
 	return nil
@@ -57,6 +57,26 @@ func TestCephBlockPoolValidateUpdate(t *testing.T) {
 	up.Spec.ErasureCoded.CodingChunks = 1
 	err := up.ValidateUpdate(p)
 	assert.Error(t, err)
+
+	// a replicated pool can be converted to erasure coded
+	up.Spec.Replicated = ReplicatedSpec{}
+	err = up.ValidateUpdate(p)
+	assert.NoError(t, err)
+
+	// unless it is mirrored
+	up.Spec.Mirroring.Enabled = true
+	err = up.ValidateUpdate(p)
+	assert.Error(t, err)
+
+	// an erasure coded pool cannot be converted to replicated
+	up.Spec.Mirroring.Enabled = false
+	err = p.ValidateUpdate(up)
+	assert.Error(t, err)
+
+	// unless it was converted to erasure coded by the operator
+	up.Status = &CephBlockPoolStatus{Conversion: &PoolConversionStatus{DataPool: "ec-pool-data"}}
+	err = p.ValidateUpdate(up)
+	assert.NoError(t, err)
 }
 
 func TestMirroringSpec_SnapshotSchedulesEnabled(t *testing.T) {
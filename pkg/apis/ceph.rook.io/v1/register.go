@@ -60,6 +60,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephClusterList{},
 		&CephBlockPool{},
 		&CephBlockPoolList{},
+		&CephBlockPoolRadosNamespace{},
+		&CephBlockPoolRadosNamespaceList{},
 		&CephFilesystem{},
 		&CephFilesystemList{},
 		&CephNFS{},
@@ -78,6 +80,10 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 		&CephRBDMirrorList{},
 		&CephFilesystemMirror{},
 		&CephFilesystemMirrorList{},
+		&CephOSDRemoval{},
+		&CephOSDRemovalList{},
+		&CephNodeMaintenance{},
+		&CephNodeMaintenanceList{},
 	)
 	metav1.AddToGroupVersion(scheme, SchemeGroupVersion)
 	return nil
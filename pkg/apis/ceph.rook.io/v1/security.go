@@ -25,3 +25,13 @@ func (kms *KeyManagementServiceSpec) IsEnabled() bool {
 func (kms *KeyManagementServiceSpec) IsTokenAuthEnabled() bool {
 	return kms.TokenSecretName != ""
 }
+
+// IsMasterKeyEnabled return whether the OSD encryption keys stored in Kubernetes Secrets are wrapped by a master key
+func (kms *KeyManagementServiceSpec) IsMasterKeyEnabled() bool {
+	return kms.MasterKey != nil && (kms.MasterKey.SecretName != "" || kms.MasterKey.FilePath != "")
+}
+
+// IsKeyRotationEnabled return whether the OSD encryption keys are rotated
+func (kms *KeyManagementServiceSpec) IsKeyRotationEnabled() bool {
+	return kms.KeyRotation != nil && kms.KeyRotation.Enabled
+}
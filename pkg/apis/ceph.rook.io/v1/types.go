@@ -148,6 +148,11 @@ type ClusterSpec struct {
 	// +optional
 	WaitTimeoutForHealthyOSDInMinutes time.Duration `json:"waitTimeoutForHealthyOSDInMinutes,omitempty"`
 
+	// OSDUpdateStrategy defines how the OSDs are restarted when their deployments are updated
+	// +optional
+	// +nullable
+	OSDUpdateStrategy OSDUpdateStrategySpec `json:"osdUpdateStrategy,omitempty"`
+
 	// A spec for configuring disruption management.
 	// +nullable
 	// +optional
@@ -188,6 +193,10 @@ type ClusterSpec struct {
 	// +optional
 	RemoveOSDsIfOutAndSafeToRemove bool `json:"removeOSDsIfOutAndSafeToRemove,omitempty"`
 
+	// Replace the OSDs whose failed device was swapped with a new one, keeping the same OSD ID and CRUSH location
+	// +optional
+	ReplaceSwappedOSDs bool `json:"replaceSwappedOSDs,omitempty"`
+
 	// Indicates user intent when deleting a cluster; blocks orchestration and should not be set if cluster
 	// deletion is not imminent.
 	// +optional
@@ -208,6 +217,73 @@ type ClusterSpec struct {
 	// +optional
 	// +nullable
 	LogCollector LogCollectorSpec `json:"logCollector,omitempty"`
+
+	// Recovery represents the recovery and backfill settings of the OSDs
+	// +optional
+	// +nullable
+	Recovery RecoverySpec `json:"recovery,omitempty"`
+}
+
+// OSDUpdateStrategySpec represents how the OSDs are restarted when their deployments are updated
+type OSDUpdateStrategySpec struct {
+	// Parallel restarts all the OSDs of a CRUSH failure domain at once instead of one OSD at a time.
+	// The PGs must be active+clean before the OSDs of the next failure domain are restarted.
+	// +optional
+	Parallel bool `json:"parallel,omitempty"`
+	// FailureDomain is the CRUSH level whose OSDs are restarted together, "host" if not set.
+	// It must not be larger than the failure domain of any pool.
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+	// MaxConcurrent is the maximum number of OSDs restarted at once, all the OSDs of a failure domain if not set
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxConcurrent int `json:"maxConcurrent,omitempty"`
+}
+
+// RecoveryProfileType is the mClock profile balancing the client and recovery traffic of the OSDs
+type RecoveryProfileType string
+
+const (
+	// RecoveryProfileBalanced gives the same share of the OSD throughput to the client and recovery operations
+	RecoveryProfileBalanced RecoveryProfileType = "balanced"
+	// RecoveryProfileHighClientOps favors the client operations over the recovery
+	RecoveryProfileHighClientOps RecoveryProfileType = "high_client_ops"
+	// RecoveryProfileHighRecoveryOps favors the recovery over the client operations
+	RecoveryProfileHighRecoveryOps RecoveryProfileType = "high_recovery_ops"
+)
+
+// RecoverySpec represents the recovery and backfill settings of the OSDs
+type RecoverySpec struct {
+	// Profile is the mClock profile of the OSDs, the Ceph default is used if not set
+	// +kubebuilder:validation:Enum=balanced;high_client_ops;high_recovery_ops
+	// +optional
+	Profile RecoveryProfileType `json:"profile,omitempty"`
+	// Limits caps the recovery and backfill traffic of each OSD
+	// +optional
+	// +nullable
+	Limits *RecoveryLimitsSpec `json:"limits,omitempty"`
+}
+
+// RecoveryLimitsSpec caps the recovery and backfill traffic of each OSD, the Ceph defaults are used for the limits not set
+type RecoveryLimitsSpec struct {
+	// MaxBackfills is the maximum number of concurrent backfills to or from an OSD
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxBackfills int `json:"maxBackfills,omitempty"`
+	// MaxActive is the maximum number of active recovery operations of an OSD
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxActive int `json:"maxActive,omitempty"`
+	// SleepMilliseconds is the time an OSD sleeps between two recovery or backfill operations
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	SleepMilliseconds int `json:"sleepMilliseconds,omitempty"`
+	// MaxBandwidthPercent is the share of the OSD capacity the recovery and backfill can use. It requires the mClock
+	// scheduler and cannot be set with a profile.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxBandwidthPercent int `json:"maxBandwidthPercent,omitempty"`
 }
 
 // LogCollectorSpec is the logging spec
@@ -238,6 +314,37 @@ type KeyManagementServiceSpec struct {
 	// TokenSecretName is the kubernetes secret containing the KMS token
 	// +optional
 	TokenSecretName string `json:"tokenSecretName,omitempty"`
+	// MasterKey wraps the OSD encryption keys stored in Kubernetes Secrets with a master key (envelope encryption)
+	// +optional
+	// +nullable
+	MasterKey *MasterKeySpec `json:"masterKey,omitempty"`
+	// KeyRotation periodically replaces the encryption keys of the OSDs on PVC
+	// +optional
+	// +nullable
+	KeyRotation *KeyRotationSpec `json:"keyRotation,omitempty"`
+}
+
+// MasterKeySpec references the master key used to wrap the OSD encryption keys
+type MasterKeySpec struct {
+	// SecretName is the kubernetes secret containing the master key under "key" and, during a rotation, the previous master key under "previous-key"
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// FilePath is the path of a file containing the master key, it must be present on every OSD node and in the operator pod
+	// +optional
+	FilePath string `json:"filePath,omitempty"`
+	// PreviousFilePath is the path of a file containing the previous master key during a rotation
+	// +optional
+	PreviousFilePath string `json:"previousFilePath,omitempty"`
+}
+
+// KeyRotationSpec represents the settings for the rotation of the OSD encryption keys
+type KeyRotationSpec struct {
+	// Enabled determines whether the OSD encryption keys are rotated
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+	// Schedule is the interval between two rotations, either a duration such as "720h" or one of "@daily", "@weekly" and "@monthly". Defaults to "@weekly".
+	// +optional
+	Schedule string `json:"schedule,omitempty"`
 }
 
 // CephVersionSpec represents the settings for the Ceph version that Rook is orchestrating.
@@ -297,6 +404,50 @@ type ClusterStatus struct {
 	CephStatus  *CephStatus     `json:"ceph,omitempty"`
 	CephStorage *CephStorage    `json:"storage,omitempty"`
 	CephVersion *ClusterVersion `json:"version,omitempty"`
+	// KeyRotation is the status of the OSD encryption key rotation
+	// +optional
+	KeyRotation *KeyRotationStatus `json:"keyRotation,omitempty"`
+	// OSDUpdate is the progress of the parallel update of the OSDs
+	// +optional
+	OSDUpdate *OSDUpdateStatus `json:"osdUpdate,omitempty"`
+	// Recovery is the recovery profile active on the OSDs
+	// +optional
+	Recovery *RecoveryStatus `json:"recovery,omitempty"`
+}
+
+// KeyRotationStatus represents the status of the OSD encryption key rotation
+type KeyRotationStatus struct {
+	// LastRotationTime is the time the keys of all the encrypted OSDs were last rotated
+	// +optional
+	// +nullable
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// Message is the reason the last rotation failed, if any
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// RecoveryStatus represents the recovery settings active on the OSDs
+type RecoveryStatus struct {
+	// Profile is the mClock profile reported by the OSDs
+	// +optional
+	Profile string `json:"profile,omitempty"`
+	// Options are the centralized config options of the OSDs set by the operator for the recovery settings
+	// +optional
+	Options []string `json:"options,omitempty"`
+}
+
+// OSDUpdateStatus represents the progress of the parallel update of the OSDs
+type OSDUpdateStatus struct {
+	// FailureDomain is the failure domain whose OSDs are being restarted
+	// +optional
+	FailureDomain string `json:"failureDomain,omitempty"`
+	// UpdatedOSDs is the number of OSDs updated so far
+	UpdatedOSDs int `json:"updatedOSDs"`
+	// TotalOSDs is the number of OSDs to update
+	TotalOSDs int `json:"totalOSDs"`
+	// Message is the reason the update is stalled, if any
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // CephStatus is the details health of a Ceph Cluster
@@ -363,6 +514,18 @@ const (
 	ClusterDeletingReason ClusterReasonType = "ClusterDeleting"
 	// ClusterConnectingReason is cluster connecting reason
 	ClusterConnectingReason ClusterReasonType = "ClusterConnecting"
+	// MonDiskLowReason is the reason when a mon is low on disk space
+	MonDiskLowReason ClusterReasonType = "MonDiskLow"
+	// MonDiskAvailableReason is the reason when the mons have enough disk space
+	MonDiskAvailableReason ClusterReasonType = "MonDiskAvailable"
+	// NetworkValidReason is the reason when the network attachment definitions of the cluster are valid
+	NetworkValidReason ClusterReasonType = "NetworkValid"
+	// NetworkInvalidReason is the reason when a network attachment definition of the cluster is invalid
+	NetworkInvalidReason ClusterReasonType = "NetworkInvalid"
+	// PoolNearFullReason is the reason when the usage of a pool is above the near full ratio
+	PoolNearFullReason ClusterReasonType = "PoolNearFull"
+	// PoolUsageNormalReason is the reason when the usage of a pool is below the near full ratio
+	PoolUsageNormalReason ClusterReasonType = "PoolUsageNormal"
 )
 
 // ConditionType represent a resource's status
@@ -381,6 +544,12 @@ const (
 	ConditionFailure ConditionType = "Failure"
 	// ConditionDeleting represents Deleting state of an object
 	ConditionDeleting ConditionType = "Deleting"
+	// ConditionMonDiskLow represents the disk usage of the mons being near capacity
+	ConditionMonDiskLow ConditionType = "MonDiskLow"
+	// ConditionNetworkValidated represents the result of the validation of the networks of the cluster
+	ConditionNetworkValidated ConditionType = "NetworkValidated"
+	// ConditionPoolNearFull represents the usage of a pool being near its capacity or its quotas
+	ConditionPoolNearFull ConditionType = "PoolNearFull"
 )
 
 // ClusterState represents the state of a Ceph Cluster
@@ -412,10 +581,46 @@ type MonSpec struct {
 	// StretchCluster is the stretch cluster specification
 	// +optional
 	StretchCluster *StretchClusterSpec `json:"stretchCluster,omitempty"`
+	// Zones are the zones to spread the mons across when the cluster is not stretched. The mons are kept
+	// balanced across the zones when they are failed over.
+	// +optional
+	// +nullable
+	Zones []MonZoneSpec `json:"zones,omitempty"`
+	// FailureDomainLabel is the node label of the zones to spread the mons across when the cluster is not
+	// stretched (e,g: topology.kubernetes.io/zone). If no zones are listed, the mons are spread across all the
+	// values of the label on the nodes.
+	// +optional
+	FailureDomainLabel string `json:"failureDomainLabel,omitempty"`
 	// VolumeClaimTemplate is the PVC definition
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +optional
 	VolumeClaimTemplate *v1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+	// Store is the settings to manage the disk usage of the mon store
+	// +optional
+	Store MonStoreSpec `json:"store,omitempty"`
+}
+
+// MonZoneSpec represents the specification of a zone to spread the mons across
+type MonZoneSpec struct {
+	// Name is the name of the zone
+	Name string `json:"name"`
+	// VolumeClaimTemplate is the PVC template of the mons in the zone
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	VolumeClaimTemplate *v1.PersistentVolumeClaim `json:"volumeClaimTemplate,omitempty"`
+}
+
+// MonStoreSpec represents the settings to manage the disk usage of the mon store
+type MonStoreSpec struct {
+	// CompactThresholdGB is the size of the mon store in GB above which the operator compacts the store.
+	// Ceph also raises the MON_DISK_BIG health warning above this size. If not set, the store is not compacted.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	CompactThresholdGB int `json:"compactThresholdGB,omitempty"`
+	// ExpandVolume allows the operator to expand the mon PVCs when the mons are low on disk space
+	// and the storage class of the PVC allows volume expansion
+	// +optional
+	ExpandVolume bool `json:"expandVolume,omitempty"`
 }
 
 // StretchClusterSpec represents the specification of a stretched Ceph Cluster
@@ -511,6 +716,45 @@ type CephBlockPoolList struct {
 	Items           []CephBlockPool `json:"items"`
 }
 
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephBlockPoolRadosNamespace represents a RADOS namespace of a CephBlockPool, the name of the CR is the name of the
+// RADOS namespace
+type CephBlockPoolRadosNamespace struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              RadosNamespaceSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *RadosNamespaceStatus `json:"status,omitempty"`
+}
+
+// CephBlockPoolRadosNamespaceList is a list of CephBlockPoolRadosNamespace
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+type CephBlockPoolRadosNamespaceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephBlockPoolRadosNamespace `json:"items"`
+}
+
+// RadosNamespaceSpec represents the pool of a RADOS namespace
+type RadosNamespaceSpec struct {
+	// BlockPoolName is the name of the CephBlockPool the RADOS namespace is created in
+	BlockPoolName string `json:"blockPoolName"`
+}
+
+// RadosNamespaceStatus represents the status of a CephBlockPoolRadosNamespace
+type RadosNamespaceStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// Info contains the clusterID of the RADOS namespace in the CSI configuration
+	// +optional
+	// +nullable
+	Info map[string]string `json:"info,omitempty"`
+}
+
 const (
 	// DefaultFailureDomain for PoolSpec
 	DefaultFailureDomain = "host"
@@ -547,6 +791,10 @@ type PoolSpec struct {
 	// +optional
 	ErasureCoded ErasureCodedSpec `json:"erasureCoded,omitempty"`
 
+	// The placement group autoscaler settings
+	// +optional
+	Autoscaler PGAutoscalerSpec `json:"autoscaler,omitempty"`
+
 	// Parameters is a list of properties to enable on a given pool
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +optional
@@ -574,6 +822,24 @@ type MirrorHealthCheckSpec struct {
 	// +optional
 	// +nullable
 	Mirror HealthCheckSpec `json:"mirror,omitempty"`
+	// Usage is the periodic check of the capacity and quota utilization of the pool
+	// +optional
+	Usage PoolUsageCheckSpec `json:"usage,omitempty"`
+}
+
+// PoolUsageCheckSpec represents the check of the capacity and quota utilization of a Ceph Storage Pool
+type PoolUsageCheckSpec struct {
+	// +optional
+	Disabled bool `json:"disabled,omitempty"`
+	// Interval is the time interval to refresh the usage of the pool (default 60s)
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// NearFullRatio is the ratio of the capacity or of a quota of the pool above which the pool is reported
+	// near full (default 0.85)
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1
+	// +optional
+	NearFullRatio float64 `json:"nearFullRatio,omitempty"`
 }
 
 // CephBlockPoolStatus represents the mirroring status of Ceph Storage Pool
@@ -590,6 +856,144 @@ type CephBlockPoolStatus struct {
 	// +optional
 	// +nullable
 	Info map[string]string `json:"info,omitempty"`
+	// +optional
+	PGAutoscaler *PGAutoscalerStatus `json:"pgAutoscaler,omitempty"`
+	// +optional
+	Usage *PoolUsageStatus `json:"usage,omitempty"`
+	// +optional
+	Conditions []Condition `json:"conditions,omitempty"`
+	// +optional
+	Conversion *PoolConversionStatus `json:"conversion,omitempty"`
+	// +optional
+	ErasureCodeProfile *ErasureCodeProfileStatus `json:"erasureCodeProfile,omitempty"`
+}
+
+// ErasureCodeProfileStatus is the erasure code profile of a pool compared to the erasure code settings of its spec
+type ErasureCodeProfileStatus struct {
+	// Name is the name of the erasure code profile
+	// +optional
+	Name string `json:"name,omitempty"`
+	// InUse is true when the profile is used by a pool, a profile in use is never changed by the operator
+	// +optional
+	InUse bool `json:"inUse,omitempty"`
+	// Drift lists the settings of the profile which differ from the spec
+	// +optional
+	Drift []string `json:"drift,omitempty"`
+	// LastChecked is the last time the profile was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+}
+
+// PoolConversionPhase is the phase of the conversion of a pool
+type PoolConversionPhase string
+
+const (
+	// PoolConversionMigrating is the phase of a conversion while the images of the pool are migrated
+	PoolConversionMigrating PoolConversionPhase = "Migrating"
+	// PoolConversionCompleted is the phase of a conversion once the images are migrated
+	PoolConversionCompleted PoolConversionPhase = "Completed"
+)
+
+// PoolConversionTarget is the type a pool is converted to
+type PoolConversionTarget string
+
+const (
+	// PoolConversionToErasureCoded is the target of the conversion of a replicated pool to erasure coded
+	PoolConversionToErasureCoded PoolConversionTarget = "ErasureCoded"
+	// PoolConversionToReplicated is the target of the conversion of a converted pool back to replicated
+	PoolConversionToReplicated PoolConversionTarget = "Replicated"
+)
+
+// ImageMigrationState is the state of the live migration of an rbd image
+type ImageMigrationState string
+
+const (
+	// ImageMigrationPending is the state of an image before its migration is prepared
+	ImageMigrationPending ImageMigrationState = "Pending"
+	// ImageMigrationPrepared is the state of an image once its migration is prepared
+	ImageMigrationPrepared ImageMigrationState = "Prepared"
+	// ImageMigrationExecuting is the state of an image while its data is copied to the new pool
+	ImageMigrationExecuting ImageMigrationState = "Executing"
+	// ImageMigrationExecuted is the state of an image once its data is copied to the new pool
+	ImageMigrationExecuted ImageMigrationState = "Executed"
+	// ImageMigrationCommitted is the state of an image once its migration is committed
+	ImageMigrationCommitted ImageMigrationState = "Committed"
+)
+
+// PoolConversionStatus is the status of the conversion of a pool between replicated and erasure coded
+type PoolConversionStatus struct {
+	// Phase is the phase of the conversion
+	// +optional
+	Phase PoolConversionPhase `json:"phase,omitempty"`
+	// Target is the type the pool is converted to
+	// +optional
+	Target PoolConversionTarget `json:"target,omitempty"`
+	// DataPool is the erasure coded pool owned by the CephBlockPool that stores the data of the images of the
+	// pool, it is deleted with the CephBlockPool or once the pool is converted back to replicated
+	// +optional
+	DataPool string `json:"dataPool,omitempty"`
+	// Images is the migration status of each image of the pool
+	// +optional
+	Images []ImageMigrationStatus `json:"images,omitempty"`
+	// LastChecked is the last time the conversion was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+}
+
+// ImageMigrationStatus is the status of the live migration of an rbd image
+type ImageMigrationStatus struct {
+	// Name is the name of the image
+	Name string `json:"name"`
+	// State is the state of the migration of the image
+	// +optional
+	State ImageMigrationState `json:"state,omitempty"`
+	// Message contains the reason the migration of the image is blocked
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PoolUsageStatus is the capacity and quota utilization of a pool
+type PoolUsageStatus struct {
+	// StoredBytes is the amount of data stored in the pool by the clients
+	// +optional
+	StoredBytes uint64 `json:"storedBytes,omitempty"`
+	// Objects is the number of objects stored in the pool
+	// +optional
+	Objects uint64 `json:"objects,omitempty"`
+	// MaxAvailBytes is the amount of data that can still be written to the pool
+	// +optional
+	MaxAvailBytes uint64 `json:"maxAvailBytes,omitempty"`
+	// PercentUsed is the percentage of the capacity of the pool that is used
+	// +optional
+	PercentUsed float64 `json:"percentUsed,omitempty"`
+	// QuotaBytesPercentUsed is the percentage of the bytes quota of the pool that is used
+	// +optional
+	QuotaBytesPercentUsed *float64 `json:"quotaBytesPercentUsed,omitempty"`
+	// QuotaObjectsPercentUsed is the percentage of the objects quota of the pool that is used
+	// +optional
+	QuotaObjectsPercentUsed *float64 `json:"quotaObjectsPercentUsed,omitempty"`
+	// LastChecked is the last time the usage was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
+	// Details contains potential status errors
+	// +optional
+	Details string `json:"details,omitempty"`
+}
+
+// PGAutoscalerStatus is the placement group count of a pool as reported by the autoscaler
+type PGAutoscalerStatus struct {
+	// Mode is the autoscale mode of the pool
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// PGNum is the current number of placement groups of the pool
+	// +optional
+	PGNum int `json:"pgNum,omitempty"`
+	// TargetPGNum is the number of placement groups the autoscaler targets for the pool
+	// +optional
+	TargetPGNum int `json:"targetPGNum,omitempty"`
+	// LastChecked is the last time the autoscaler status was checked
+	// +optional
+	LastChecked string `json:"lastChecked,omitempty"`
 }
 
 // MirroringStatusSpec is the status of the pool mirroring
@@ -817,6 +1221,34 @@ type QuotaSpec struct {
 	MaxObjects *uint64 `json:"maxObjects,omitempty"`
 }
 
+// PGAutoscalerSpec represents the settings of the placement group autoscaler for a pool
+type PGAutoscalerSpec struct {
+	// Mode is the autoscale mode of the pool (options are: on, warn, off)
+	// +kubebuilder:validation:Enum=on;warn;off;""
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// PGNumMin is the minimum number of placement groups the autoscaler may set for the pool
+	// +optional
+	PGNumMin uint `json:"pgNumMin,omitempty"`
+
+	// TargetSizeRatio gives a hint (%) to Ceph in terms of expected consumption of the total cluster capacity,
+	// it applies to both replicated and erasure coded pools
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TargetSizeRatio float64 `json:"targetSizeRatio,omitempty"`
+
+	// TargetSize gives a hint to Ceph of the expected size of the pool as a string
+	// +kubebuilder:validation:Pattern=`^[0-9]+[\.]?[0-9]*([KMGTPE]i|[kMGTPE])?$`
+	// +optional
+	TargetSize *string `json:"targetSize,omitempty"`
+
+	// Bulk flags the pool as expected to be large, the autoscaler then starts it with the full complement of
+	// placement groups instead of growing them with the usage
+	// +optional
+	Bulk bool `json:"bulk,omitempty"`
+}
+
 // ErasureCodedSpec represents the spec for erasure code in a pool
 type ErasureCodedSpec struct {
 	// Number of coding chunks per object in an erasure coded storage pool (required for erasure-coded pool type)
@@ -828,6 +1260,49 @@ type ErasureCodedSpec struct {
 	// The algorithm for erasure coding
 	// +optional
 	Algorithm string `json:"algorithm,omitempty"`
+
+	// Plugin is the erasure code plugin and its options, the plugin of the default erasure code profile of the
+	// cluster is used if not set
+	// +optional
+	// +nullable
+	Plugin *ErasureCodePluginSpec `json:"plugin,omitempty"`
+}
+
+// ErasureCodePluginSpec represents the erasure code plugin of a pool and its options
+type ErasureCodePluginSpec struct {
+	// Name is the erasure code plugin (options are: jerasure, isa, lrc, shec, clay)
+	// +kubebuilder:validation:Enum=jerasure;isa;lrc;shec;clay
+	Name string `json:"name"`
+
+	// Technique is the erasure code technique of the jerasure, isa and shec plugins, or of the scalar MDS plugin
+	// of the clay plugin. The default technique of the plugin is used if not set.
+	// +optional
+	Technique string `json:"technique,omitempty"`
+
+	// Locality is the number of chunks of a locality group of the lrc plugin (l), data and coding chunks must be
+	// a multiple of it
+	// +optional
+	Locality uint `json:"locality,omitempty"`
+
+	// DurabilityEstimator is the number of coding chunks of the shec plugin each data chunk is included in (c),
+	// it cannot exceed the coding chunks
+	// +optional
+	DurabilityEstimator uint `json:"durabilityEstimator,omitempty"`
+
+	// HelperChunks is the number of chunks read by the clay plugin to recover a lost chunk (d), between the data
+	// chunks plus one and the data and coding chunks minus one
+	// +optional
+	HelperChunks uint `json:"helperChunks,omitempty"`
+
+	// ScalarMDS is the plugin the clay plugin is built on (options are: jerasure, isa, shec)
+	// +kubebuilder:validation:Enum=jerasure;isa;shec;""
+	// +optional
+	ScalarMDS string `json:"scalarMDS,omitempty"`
+
+	// StripeUnit is the amount of data in a data chunk per stripe as a string
+	// +kubebuilder:validation:Pattern=`^[0-9]+[\.]?[0-9]*([KMGTPE]i|[kMGTPE])?$`
+	// +optional
+	StripeUnit *string `json:"stripeUnit,omitempty"`
 }
 
 // +genclient
@@ -1298,10 +1773,36 @@ type NetworkSpec struct {
 	// +optional
 	HostNetwork bool `json:"hostNetwork,omitempty"`
 
-	// IPFamily is the single stack IPv6 or IPv4 protocol
+	// IPFamily is the single stack IPv6 or IPv4 protocol, or DualStack for both protocols
 	// +nullable
 	// +optional
 	IPFamily IPFamilyType `json:"ipFamily,omitempty"`
+
+	// Connections are the settings of the network connections between the Ceph daemons and the clients
+	// +nullable
+	// +optional
+	Connections *ConnectionsSpec `json:"connections,omitempty"`
+}
+
+// ConnectionsSpec represents the settings of the network connections between the Ceph daemons and the clients
+type ConnectionsSpec struct {
+	// RequireMsgr2 disables the msgr1 protocol so the daemons and the clients only communicate with the msgr2
+	// protocol on port 3300
+	// +optional
+	RequireMsgr2 bool `json:"requireMsgr2,omitempty"`
+
+	// Encryption is the on-wire encryption of the connections
+	// +nullable
+	// +optional
+	Encryption *EncryptionSpec `json:"encryption,omitempty"`
+}
+
+// EncryptionSpec represents the on-wire encryption of the connections
+type EncryptionSpec struct {
+	// Enabled encrypts the data sent between the Ceph daemons and the clients with the secure mode of the msgr2
+	// protocol. The msgr1 protocol is disabled since it does not support encryption.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
 }
 
 // DisruptionManagementSpec configures management of daemon disruptions
@@ -1530,7 +2031,159 @@ type FilesystemMirroringSpec struct {
 	PriorityClassName string `json:"priorityClassName,omitempty"`
 }
 
-// IPFamilyType represents the single stack Ipv4 or Ipv6 protocol.
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephOSDRemoval drains and removes a list of OSDs from the cluster
+type CephOSDRemoval struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              OSDRemovalSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *OSDRemovalStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephOSDRemovalList is a list of CephOSDRemoval
+type CephOSDRemovalList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephOSDRemoval `json:"items"`
+}
+
+// OSDRemovalSpec represents the OSDs to remove
+type OSDRemovalSpec struct {
+	// OSDs is the list of OSD IDs to remove
+	// +optional
+	OSDs []int `json:"osds,omitempty"`
+
+	// Hosts is the list of CRUSH hosts whose OSDs are all removed
+	// +optional
+	Hosts []string `json:"hosts,omitempty"`
+
+	// PreservePVC keeps the PVCs of the OSDs running on PVC once they are removed
+	// +optional
+	PreservePVC bool `json:"preservePVC,omitempty"`
+}
+
+// OSDRemovalPhase is the progress of the removal of an OSD
+type OSDRemovalPhase string
+
+const (
+	// OSDRemovalPending is the phase of an OSD that was not marked out yet
+	OSDRemovalPending OSDRemovalPhase = "Pending"
+	// OSDRemovalDraining is the phase of an OSD marked out whose placement groups are moving to other OSDs
+	OSDRemovalDraining OSDRemovalPhase = "Draining"
+	// OSDRemovalRemoving is the phase of a drained OSD whose deployment is deleted, it is purged once safe to destroy
+	OSDRemovalRemoving OSDRemovalPhase = "Removing"
+	// OSDRemovalRemoved is the phase of an OSD purged from the cluster
+	OSDRemovalRemoved OSDRemovalPhase = "Removed"
+	// OSDRemovalFailed is the phase of an OSD that cannot be removed
+	OSDRemovalFailed OSDRemovalPhase = "Failed"
+)
+
+// OSDRemovalStatus represents the status of a CephOSDRemoval
+type OSDRemovalStatus struct {
+	// +optional
+	Phase ConditionType `json:"phase,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// OSDs is the progress of the removal of each OSD
+	// +optional
+	OSDs []OSDRemovalProgress `json:"osds,omitempty"`
+}
+
+// OSDRemovalProgress represents the progress of the removal of an OSD
+type OSDRemovalProgress struct {
+	// ID is the ID of the OSD
+	ID int `json:"id"`
+	// Host is the CRUSH host of the OSD
+	// +optional
+	Host string `json:"host,omitempty"`
+	// Phase is the progress of the removal
+	Phase OSDRemovalPhase `json:"phase"`
+	// PGsRemaining is the number of placement groups still mapped to the OSD
+	// +optional
+	PGsRemaining int `json:"pgsRemaining"`
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// +genclient
+// +genclient:noStatus
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephNodeMaintenance stops the OSDs of a node or CRUSH host for maintenance without rebalancing their data
+type CephNodeMaintenance struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              NodeMaintenanceSpec `json:"spec"`
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	Status *NodeMaintenanceStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// CephNodeMaintenanceList is a list of CephNodeMaintenance
+type CephNodeMaintenanceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+	Items           []CephNodeMaintenance `json:"items"`
+}
+
+// NodeMaintenanceSpec represents the node or CRUSH host to put in maintenance
+type NodeMaintenanceSpec struct {
+	// Node is the name of the Kubernetes node whose OSDs are stopped
+	// +optional
+	Node string `json:"node,omitempty"`
+
+	// Host is the CRUSH host whose OSDs are stopped, it is found from the OSDs running on the node if not set
+	// +optional
+	Host string `json:"host,omitempty"`
+
+	// Until is the time the maintenance expires and the OSDs are started again.
+	// The maintenance lasts until the CR is deleted if not set.
+	// +optional
+	// +nullable
+	Until *metav1.Time `json:"until,omitempty"`
+}
+
+// NodeMaintenancePhase is the progress of the maintenance of a node
+type NodeMaintenancePhase string
+
+const (
+	// NodeMaintenanceStopping is the phase of a host whose OSDs are being stopped one at a time
+	NodeMaintenanceStopping NodeMaintenancePhase = "Stopping"
+	// NodeMaintenanceActive is the phase of a host whose OSDs are all stopped
+	NodeMaintenanceActive NodeMaintenancePhase = "InMaintenance"
+	// NodeMaintenanceExpired is the phase of a host whose OSDs were started again when the maintenance expired
+	NodeMaintenanceExpired NodeMaintenancePhase = "Expired"
+	// NodeMaintenanceFailed is the phase of a maintenance that cannot start
+	NodeMaintenanceFailed NodeMaintenancePhase = "Failed"
+)
+
+// NodeMaintenanceStatus represents the status of a CephNodeMaintenance
+type NodeMaintenanceStatus struct {
+	// +optional
+	Phase NodeMaintenancePhase `json:"phase,omitempty"`
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Host is the CRUSH host in maintenance
+	// +optional
+	Host string `json:"host,omitempty"`
+	// StoppedOSDs is the list of the IDs of the OSDs stopped for the maintenance
+	// +optional
+	StoppedOSDs []int `json:"stoppedOSDs,omitempty"`
+	// NoRebalance is whether the norebalance flag of the cluster is held by the maintenance
+	// +optional
+	NoRebalance bool `json:"noRebalance,omitempty"`
+}
+
+// IPFamilyType represents the single stack Ipv4 or Ipv6 protocol, or the dual stack of both.
 type IPFamilyType string
 
 const (
@@ -1538,4 +2191,6 @@ const (
 	IPv6 IPFamilyType = "IPv6"
 	// IPv4 internet protocol version
 	IPv4 IPFamilyType = "IPv4"
+	// DualStack is both the IPv4 and IPv6 internet protocol versions
+	DualStack IPFamilyType = "DualStack"
 )
@@ -145,6 +145,71 @@ func (in *CephBlockPoolList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolRadosNamespace) DeepCopyInto(out *CephBlockPoolRadosNamespace) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(RadosNamespaceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolRadosNamespace.
+func (in *CephBlockPoolRadosNamespace) DeepCopy() *CephBlockPoolRadosNamespace {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolRadosNamespace)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPoolRadosNamespace) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephBlockPoolRadosNamespaceList) DeepCopyInto(out *CephBlockPoolRadosNamespaceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephBlockPoolRadosNamespace, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephBlockPoolRadosNamespaceList.
+func (in *CephBlockPoolRadosNamespaceList) DeepCopy() *CephBlockPoolRadosNamespaceList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephBlockPoolRadosNamespaceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephBlockPoolRadosNamespaceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephBlockPoolStatus) DeepCopyInto(out *CephBlockPoolStatus) {
 	*out = *in
@@ -170,6 +235,33 @@ func (in *CephBlockPoolStatus) DeepCopyInto(out *CephBlockPoolStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.PGAutoscaler != nil {
+		in, out := &in.PGAutoscaler, &out.PGAutoscaler
+		*out = new(PGAutoscalerStatus)
+		**out = **in
+	}
+	if in.Usage != nil {
+		in, out := &in.Usage, &out.Usage
+		*out = new(PoolUsageStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conversion != nil {
+		in, out := &in.Conversion, &out.Conversion
+		*out = new(PoolConversionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ErasureCodeProfile != nil {
+		in, out := &in.ErasureCodeProfile, &out.ErasureCodeProfile
+		*out = new(ErasureCodeProfileStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -575,6 +667,136 @@ func (in *CephNFSList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephNodeMaintenance) DeepCopyInto(out *CephNodeMaintenance) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(NodeMaintenanceStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephNodeMaintenance.
+func (in *CephNodeMaintenance) DeepCopy() *CephNodeMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(CephNodeMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephNodeMaintenance) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephNodeMaintenanceList) DeepCopyInto(out *CephNodeMaintenanceList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephNodeMaintenance, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephNodeMaintenanceList.
+func (in *CephNodeMaintenanceList) DeepCopy() *CephNodeMaintenanceList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephNodeMaintenanceList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephNodeMaintenanceList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephOSDRemoval) DeepCopyInto(out *CephOSDRemoval) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = new(OSDRemovalStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephOSDRemoval.
+func (in *CephOSDRemoval) DeepCopy() *CephOSDRemoval {
+	if in == nil {
+		return nil
+	}
+	out := new(CephOSDRemoval)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephOSDRemoval) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CephOSDRemovalList) DeepCopyInto(out *CephOSDRemovalList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]CephOSDRemoval, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CephOSDRemovalList.
+func (in *CephOSDRemovalList) DeepCopy() *CephOSDRemovalList {
+	if in == nil {
+		return nil
+	}
+	out := new(CephOSDRemovalList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *CephOSDRemovalList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CephObjectRealm) DeepCopyInto(out *CephObjectRealm) {
 	*out = *in
@@ -1127,6 +1349,7 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 			(*out)[key] = val
 		}
 	}
+	out.OSDUpdateStrategy = in.OSDUpdateStrategy
 	out.DisruptionManagement = in.DisruptionManagement
 	in.Mon.DeepCopyInto(&out.Mon)
 	out.CrashCollector = in.CrashCollector
@@ -1138,6 +1361,7 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 	in.HealthCheck.DeepCopyInto(&out.HealthCheck)
 	in.Security.DeepCopyInto(&out.Security)
 	out.LogCollector = in.LogCollector
+	in.Recovery.DeepCopyInto(&out.Recovery)
 	return
 }
 
@@ -1176,6 +1400,21 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 		*out = new(ClusterVersion)
 		**out = **in
 	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OSDUpdate != nil {
+		in, out := &in.OSDUpdate, &out.OSDUpdate
+		*out = new(OSDUpdateStatus)
+		**out = **in
+	}
+	if in.Recovery != nil {
+		in, out := &in.Recovery, &out.Recovery
+		*out = new(RecoveryStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1223,6 +1462,27 @@ func (in *Condition) DeepCopy() *Condition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConnectionsSpec) DeepCopyInto(out *ConnectionsSpec) {
+	*out = *in
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(EncryptionSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConnectionsSpec.
+func (in *ConnectionsSpec) DeepCopy() *ConnectionsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ConnectionsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CrashCollectorSpec) DeepCopyInto(out *CrashCollectorSpec) {
 	*out = *in
@@ -1306,9 +1566,72 @@ func (in *DisruptionManagementSpec) DeepCopy() *DisruptionManagementSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EncryptionSpec) DeepCopyInto(out *EncryptionSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EncryptionSpec.
+func (in *EncryptionSpec) DeepCopy() *EncryptionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(EncryptionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErasureCodePluginSpec) DeepCopyInto(out *ErasureCodePluginSpec) {
+	*out = *in
+	if in.StripeUnit != nil {
+		in, out := &in.StripeUnit, &out.StripeUnit
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErasureCodePluginSpec.
+func (in *ErasureCodePluginSpec) DeepCopy() *ErasureCodePluginSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ErasureCodePluginSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ErasureCodeProfileStatus) DeepCopyInto(out *ErasureCodeProfileStatus) {
+	*out = *in
+	if in.Drift != nil {
+		in, out := &in.Drift, &out.Drift
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ErasureCodeProfileStatus.
+func (in *ErasureCodeProfileStatus) DeepCopy() *ErasureCodeProfileStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ErasureCodeProfileStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ErasureCodedSpec) DeepCopyInto(out *ErasureCodedSpec) {
 	*out = *in
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(ErasureCodePluginSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1515,6 +1838,22 @@ func (in *HealthCheckSpec) DeepCopy() *HealthCheckSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageMigrationStatus) DeepCopyInto(out *ImageMigrationStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageMigrationStatus.
+func (in *ImageMigrationStatus) DeepCopy() *ImageMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *KeyManagementServiceSpec) DeepCopyInto(out *KeyManagementServiceSpec) {
 	*out = *in
@@ -1525,6 +1864,16 @@ func (in *KeyManagementServiceSpec) DeepCopyInto(out *KeyManagementServiceSpec)
 			(*out)[key] = val
 		}
 	}
+	if in.MasterKey != nil {
+		in, out := &in.MasterKey, &out.MasterKey
+		*out = new(MasterKeySpec)
+		**out = **in
+	}
+	if in.KeyRotation != nil {
+		in, out := &in.KeyRotation, &out.KeyRotation
+		*out = new(KeyRotationSpec)
+		**out = **in
+	}
 	return
 }
 
@@ -1539,12 +1888,48 @@ func (in *KeyManagementServiceSpec) DeepCopy() *KeyManagementServiceSpec {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *LogCollectorSpec) DeepCopyInto(out *LogCollectorSpec) {
+func (in *KeyRotationSpec) DeepCopyInto(out *KeyRotationSpec) {
 	*out = *in
 	return
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorSpec.
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRotationSpec.
+func (in *KeyRotationSpec) DeepCopy() *KeyRotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeyRotationStatus) DeepCopyInto(out *KeyRotationStatus) {
+	*out = *in
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeyRotationStatus.
+func (in *KeyRotationStatus) DeepCopy() *KeyRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeyRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LogCollectorSpec) DeepCopyInto(out *LogCollectorSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LogCollectorSpec.
 func (in *LogCollectorSpec) DeepCopy() *LogCollectorSpec {
 	if in == nil {
 		return nil
@@ -1554,6 +1939,22 @@ func (in *LogCollectorSpec) DeepCopy() *LogCollectorSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MasterKeySpec) DeepCopyInto(out *MasterKeySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MasterKeySpec.
+func (in *MasterKeySpec) DeepCopy() *MasterKeySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MasterKeySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetadataServerSpec) DeepCopyInto(out *MetadataServerSpec) {
 	*out = *in
@@ -1611,6 +2012,7 @@ func (in *MgrSpec) DeepCopy() *MgrSpec {
 func (in *MirrorHealthCheckSpec) DeepCopyInto(out *MirrorHealthCheckSpec) {
 	*out = *in
 	out.Mirror = in.Mirror
+	out.Usage = in.Usage
 	return
 }
 
@@ -1707,11 +2109,19 @@ func (in *MonSpec) DeepCopyInto(out *MonSpec) {
 		*out = new(StretchClusterSpec)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Zones != nil {
+		in, out := &in.Zones, &out.Zones
+		*out = make([]MonZoneSpec, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.VolumeClaimTemplate != nil {
 		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
 		*out = new(corev1.PersistentVolumeClaim)
 		(*in).DeepCopyInto(*out)
 	}
+	out.Store = in.Store
 	return
 }
 
@@ -1725,6 +2135,43 @@ func (in *MonSpec) DeepCopy() *MonSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonZoneSpec) DeepCopyInto(out *MonZoneSpec) {
+	*out = *in
+	if in.VolumeClaimTemplate != nil {
+		in, out := &in.VolumeClaimTemplate, &out.VolumeClaimTemplate
+		*out = new(corev1.PersistentVolumeClaim)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonZoneSpec.
+func (in *MonZoneSpec) DeepCopy() *MonZoneSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonZoneSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MonStoreSpec) DeepCopyInto(out *MonStoreSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MonStoreSpec.
+func (in *MonStoreSpec) DeepCopy() *MonStoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MonStoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MonitoringSpec) DeepCopyInto(out *MonitoringSpec) {
 	*out = *in
@@ -1770,6 +2217,11 @@ func (in *NFSGaneshaSpec) DeepCopy() *NFSGaneshaSpec {
 func (in *NetworkSpec) DeepCopyInto(out *NetworkSpec) {
 	*out = *in
 	in.NetworkSpec.DeepCopyInto(&out.NetworkSpec)
+	if in.Connections != nil {
+		in, out := &in.Connections, &out.Connections
+		*out = new(ConnectionsSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -1783,6 +2235,142 @@ func (in *NetworkSpec) DeepCopy() *NetworkSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMaintenanceSpec) DeepCopyInto(out *NodeMaintenanceSpec) {
+	*out = *in
+	if in.Until != nil {
+		in, out := &in.Until, &out.Until
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMaintenanceSpec.
+func (in *NodeMaintenanceSpec) DeepCopy() *NodeMaintenanceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMaintenanceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMaintenanceStatus) DeepCopyInto(out *NodeMaintenanceStatus) {
+	*out = *in
+	if in.StoppedOSDs != nil {
+		in, out := &in.StoppedOSDs, &out.StoppedOSDs
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMaintenanceStatus.
+func (in *NodeMaintenanceStatus) DeepCopy() *NodeMaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDRemovalProgress) DeepCopyInto(out *OSDRemovalProgress) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalProgress.
+func (in *OSDRemovalProgress) DeepCopy() *OSDRemovalProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDRemovalProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDRemovalSpec) DeepCopyInto(out *OSDRemovalSpec) {
+	*out = *in
+	if in.OSDs != nil {
+		in, out := &in.OSDs, &out.OSDs
+		*out = make([]int, len(*in))
+		copy(*out, *in)
+	}
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalSpec.
+func (in *OSDRemovalSpec) DeepCopy() *OSDRemovalSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDRemovalSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDRemovalStatus) DeepCopyInto(out *OSDRemovalStatus) {
+	*out = *in
+	if in.OSDs != nil {
+		in, out := &in.OSDs, &out.OSDs
+		*out = make([]OSDRemovalProgress, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDRemovalStatus.
+func (in *OSDRemovalStatus) DeepCopy() *OSDRemovalStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDRemovalStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDUpdateStatus) DeepCopyInto(out *OSDUpdateStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDUpdateStatus.
+func (in *OSDUpdateStatus) DeepCopy() *OSDUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OSDUpdateStrategySpec) DeepCopyInto(out *OSDUpdateStrategySpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OSDUpdateStrategySpec.
+func (in *OSDUpdateStrategySpec) DeepCopy() *OSDUpdateStrategySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OSDUpdateStrategySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ObjectRealmSpec) DeepCopyInto(out *ObjectRealmSpec) {
 	*out = *in
@@ -1922,6 +2510,43 @@ func (in *ObjectZoneSpec) DeepCopy() *ObjectZoneSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGAutoscalerSpec) DeepCopyInto(out *PGAutoscalerSpec) {
+	*out = *in
+	if in.TargetSize != nil {
+		in, out := &in.TargetSize, &out.TargetSize
+		*out = new(string)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGAutoscalerSpec.
+func (in *PGAutoscalerSpec) DeepCopy() *PGAutoscalerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PGAutoscalerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PGAutoscalerStatus) DeepCopyInto(out *PGAutoscalerStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PGAutoscalerStatus.
+func (in *PGAutoscalerStatus) DeepCopy() *PGAutoscalerStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PGAutoscalerStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PeersSpec) DeepCopyInto(out *PeersSpec) {
 	*out = *in
@@ -1938,6 +2563,27 @@ func (in *PeersSpec) DeepCopy() *PeersSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolConversionStatus) DeepCopyInto(out *PoolConversionStatus) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]ImageMigrationStatus, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolConversionStatus.
+func (in *PoolConversionStatus) DeepCopy() *PoolConversionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolConversionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PoolMirroringInfo) DeepCopyInto(out *PoolMirroringInfo) {
 	*out = *in
@@ -2001,7 +2647,8 @@ func (in *PoolMirroringStatusSummarySpec) DeepCopy() *PoolMirroringStatusSummary
 func (in *PoolSpec) DeepCopyInto(out *PoolSpec) {
 	*out = *in
 	out.Replicated = in.Replicated
-	out.ErasureCoded = in.ErasureCoded
+	in.ErasureCoded.DeepCopyInto(&out.ErasureCoded)
+	in.Autoscaler.DeepCopyInto(&out.Autoscaler)
 	if in.Parameters != nil {
 		in, out := &in.Parameters, &out.Parameters
 		*out = make(map[string]string, len(*in))
@@ -2025,6 +2672,48 @@ func (in *PoolSpec) DeepCopy() *PoolSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolUsageCheckSpec) DeepCopyInto(out *PoolUsageCheckSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolUsageCheckSpec.
+func (in *PoolUsageCheckSpec) DeepCopy() *PoolUsageCheckSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolUsageCheckSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolUsageStatus) DeepCopyInto(out *PoolUsageStatus) {
+	*out = *in
+	if in.QuotaBytesPercentUsed != nil {
+		in, out := &in.QuotaBytesPercentUsed, &out.QuotaBytesPercentUsed
+		*out = new(float64)
+		**out = **in
+	}
+	if in.QuotaObjectsPercentUsed != nil {
+		in, out := &in.QuotaObjectsPercentUsed, &out.QuotaObjectsPercentUsed
+		*out = new(float64)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolUsageStatus.
+func (in *PoolUsageStatus) DeepCopy() *PoolUsageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolUsageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PullSpec) DeepCopyInto(out *PullSpec) {
 	*out = *in
@@ -2126,6 +2815,103 @@ func (in *RBDMirroringSpec) DeepCopy() *RBDMirroringSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RadosNamespaceSpec) DeepCopyInto(out *RadosNamespaceSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RadosNamespaceSpec.
+func (in *RadosNamespaceSpec) DeepCopy() *RadosNamespaceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RadosNamespaceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RadosNamespaceStatus) DeepCopyInto(out *RadosNamespaceStatus) {
+	*out = *in
+	if in.Info != nil {
+		in, out := &in.Info, &out.Info
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RadosNamespaceStatus.
+func (in *RadosNamespaceStatus) DeepCopy() *RadosNamespaceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RadosNamespaceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryLimitsSpec) DeepCopyInto(out *RecoveryLimitsSpec) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryLimitsSpec.
+func (in *RecoveryLimitsSpec) DeepCopy() *RecoveryLimitsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryLimitsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoverySpec) DeepCopyInto(out *RecoverySpec) {
+	*out = *in
+	if in.Limits != nil {
+		in, out := &in.Limits, &out.Limits
+		*out = new(RecoveryLimitsSpec)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoverySpec.
+func (in *RecoverySpec) DeepCopy() *RecoverySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoverySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RecoveryStatus) DeepCopyInto(out *RecoveryStatus) {
+	*out = *in
+	if in.Options != nil {
+		in, out := &in.Options, &out.Options
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RecoveryStatus.
+func (in *RecoveryStatus) DeepCopy() *RecoveryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RecoveryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReplicatedSpec) DeepCopyInto(out *ReplicatedSpec) {
 	*out = *in
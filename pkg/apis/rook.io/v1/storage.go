@@ -81,6 +81,14 @@ func (s *StorageScopeSpec) resolveNodeSelection(node *Node) {
 		}
 	}
 
+	if node.Selection.Encrypted == nil {
+		if s.Selection.Encrypted != nil {
+			node.Selection.Encrypted = s.Selection.Encrypted
+		} else {
+			node.Selection.Encrypted = newBool(false)
+		}
+	}
+
 	resolveString(&(node.Selection.DeviceFilter), s.Selection.DeviceFilter, "")
 	resolveString(&(node.Selection.DevicePathFilter), s.Selection.DevicePathFilter, "")
 
@@ -119,6 +127,11 @@ func (s *Selection) GetUseAllDevices() bool {
 	return s.UseAllDevices != nil && *(s.UseAllDevices)
 }
 
+// GetEncrypted returns whether the OSDs on the selected devices must be encrypted.
+func (s *Selection) GetEncrypted() bool {
+	return s.Encrypted != nil && *(s.Encrypted)
+}
+
 func resolveString(setting *string, parent, defaultVal string) {
 	if *setting == "" {
 		if parent != "" {
@@ -158,3 +171,28 @@ func (s *StorageScopeSpec) IsOnPVCEncrypted() bool {
 
 	return false
 }
+
+// IsOnHostEncrypted returns whether some OSDs on host devices will be encrypted
+func (s *StorageScopeSpec) IsOnHostEncrypted() bool {
+	if s.Selection.GetEncrypted() || anyDeviceEncrypted(s.Devices) {
+		return true
+	}
+
+	for _, n := range s.Nodes {
+		if n.Selection.GetEncrypted() || anyDeviceEncrypted(n.Devices) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func anyDeviceEncrypted(devices []Device) bool {
+	for _, d := range devices {
+		if d.Encrypted {
+			return true
+		}
+	}
+
+	return false
+}
@@ -118,6 +118,29 @@ func TestResolveNodeUseAllDevices(t *testing.T) {
 	assert.True(t, node.Selection.GetUseAllDevices())
 }
 
+func TestResolveNodeEncrypted(t *testing.T) {
+	storageSpec := StorageScopeSpec{
+		Selection: Selection{Encrypted: newBool(true)},
+		Nodes: []Node{
+			{Name: "node1"},
+			{Name: "node2", Selection: Selection{Encrypted: newBool(false)}},
+		},
+	}
+
+	node := storageSpec.ResolveNode("node1")
+	assert.NotNil(t, node)
+	assert.True(t, node.Selection.GetEncrypted())
+
+	node = storageSpec.ResolveNode("node2")
+	assert.NotNil(t, node)
+	assert.False(t, node.Selection.GetEncrypted())
+
+	storageSpec = StorageScopeSpec{Nodes: []Node{{Name: "node1"}}}
+	node = storageSpec.ResolveNode("node1")
+	assert.NotNil(t, node)
+	assert.False(t, node.Selection.GetEncrypted())
+}
+
 func TestUseAllDevices(t *testing.T) {
 	storageSpec := StorageScopeSpec{}
 	assert.False(t, storageSpec.AnyUseAllDevices())
@@ -255,3 +278,14 @@ func TestIsOnPVCEncrypted(t *testing.T) {
 	}
 	assert.True(t, s.IsOnPVCEncrypted())
 }
+
+func TestIsOnHostEncrypted(t *testing.T) {
+	s := &StorageScopeSpec{}
+	assert.False(t, s.IsOnHostEncrypted())
+
+	s.Selection.Encrypted = newBool(true)
+	assert.True(t, s.IsOnHostEncrypted())
+
+	s = &StorageScopeSpec{Nodes: []Node{{Name: "node1", Selection: Selection{Devices: []Device{{Name: "sdb"}, {Name: "sdc", Encrypted: true}}}}}}
+	assert.True(t, s.IsOnHostEncrypted())
+}
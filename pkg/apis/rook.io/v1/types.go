@@ -70,12 +70,18 @@ type Device struct {
 	// +kubebuilder:pruning:PreserveUnknownFields
 	// +optional
 	Config map[string]string `json:"config,omitempty"`
+	// Whether to encrypt the OSDs on this device with dm-crypt, the key is managed by the KMS of the cluster
+	// +optional
+	Encrypted bool `json:"encrypted,omitempty"`
 }
 
 type Selection struct {
 	// Whether to consume all the storage devices found on a machine
 	// +optional
 	UseAllDevices *bool `json:"useAllDevices,omitempty"`
+	// Whether to encrypt the OSDs on the selected devices with dm-crypt, the key is managed by the KMS of the cluster
+	// +optional
+	Encrypted *bool `json:"encrypted,omitempty"`
 	// A regular expression to allow more fine-grained selection of devices on nodes across the cluster
 	// +optional
 	DeviceFilter string `json:"deviceFilter,omitempty"`
@@ -365,6 +365,11 @@ func (in *Selection) DeepCopyInto(out *Selection) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.Encrypted != nil {
+		in, out := &in.Encrypted, &out.Encrypted
+		*out = new(bool)
+		**out = **in
+	}
 	if in.Devices != nil {
 		in, out := &in.Devices, &out.Devices
 		*out = make([]Device, len(*in))
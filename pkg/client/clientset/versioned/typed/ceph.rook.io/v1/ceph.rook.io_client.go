@@ -27,16 +27,19 @@ import (
 type CephV1Interface interface {
 	RESTClient() rest.Interface
 	CephBlockPoolsGetter
+	CephBlockPoolRadosNamespacesGetter
 	CephClientsGetter
 	CephClustersGetter
 	CephFilesystemsGetter
 	CephFilesystemMirrorsGetter
 	CephNFSesGetter
+	CephNodeMaintenancesGetter
 	CephObjectRealmsGetter
 	CephObjectStoresGetter
 	CephObjectStoreUsersGetter
 	CephObjectZonesGetter
 	CephObjectZoneGroupsGetter
+	CephOSDRemovalsGetter
 	CephRBDMirrorsGetter
 }
 
@@ -49,6 +52,10 @@ func (c *CephV1Client) CephBlockPools(namespace string) CephBlockPoolInterface {
 	return newCephBlockPools(c, namespace)
 }
 
+func (c *CephV1Client) CephBlockPoolRadosNamespaces(namespace string) CephBlockPoolRadosNamespaceInterface {
+	return newCephBlockPoolRadosNamespaces(c, namespace)
+}
+
 func (c *CephV1Client) CephClients(namespace string) CephClientInterface {
 	return newCephClients(c, namespace)
 }
@@ -69,6 +76,10 @@ func (c *CephV1Client) CephNFSes(namespace string) CephNFSInterface {
 	return newCephNFSes(c, namespace)
 }
 
+func (c *CephV1Client) CephNodeMaintenances(namespace string) CephNodeMaintenanceInterface {
+	return newCephNodeMaintenances(c, namespace)
+}
+
 func (c *CephV1Client) CephObjectRealms(namespace string) CephObjectRealmInterface {
 	return newCephObjectRealms(c, namespace)
 }
@@ -89,6 +100,10 @@ func (c *CephV1Client) CephObjectZoneGroups(namespace string) CephObjectZoneGrou
 	return newCephObjectZoneGroups(c, namespace)
 }
 
+func (c *CephV1Client) CephOSDRemovals(namespace string) CephOSDRemovalInterface {
+	return newCephOSDRemovals(c, namespace)
+}
+
 func (c *CephV1Client) CephRBDMirrors(namespace string) CephRBDMirrorInterface {
 	return newCephRBDMirrors(c, namespace)
 }
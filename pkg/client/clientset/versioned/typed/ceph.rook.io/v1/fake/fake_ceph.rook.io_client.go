@@ -32,6 +32,10 @@ func (c *FakeCephV1) CephBlockPools(namespace string) v1.CephBlockPoolInterface
 	return &FakeCephBlockPools{c, namespace}
 }
 
+func (c *FakeCephV1) CephBlockPoolRadosNamespaces(namespace string) v1.CephBlockPoolRadosNamespaceInterface {
+	return &FakeCephBlockPoolRadosNamespaces{c, namespace}
+}
+
 func (c *FakeCephV1) CephClients(namespace string) v1.CephClientInterface {
 	return &FakeCephClients{c, namespace}
 }
@@ -52,6 +56,10 @@ func (c *FakeCephV1) CephNFSes(namespace string) v1.CephNFSInterface {
 	return &FakeCephNFSes{c, namespace}
 }
 
+func (c *FakeCephV1) CephNodeMaintenances(namespace string) v1.CephNodeMaintenanceInterface {
+	return &FakeCephNodeMaintenances{c, namespace}
+}
+
 func (c *FakeCephV1) CephObjectRealms(namespace string) v1.CephObjectRealmInterface {
 	return &FakeCephObjectRealms{c, namespace}
 }
@@ -72,6 +80,10 @@ func (c *FakeCephV1) CephObjectZoneGroups(namespace string) v1.CephObjectZoneGro
 	return &FakeCephObjectZoneGroups{c, namespace}
 }
 
+func (c *FakeCephV1) CephOSDRemovals(namespace string) v1.CephOSDRemovalInterface {
+	return &FakeCephOSDRemovals{c, namespace}
+}
+
 func (c *FakeCephV1) CephRBDMirrors(namespace string) v1.CephRBDMirrorInterface {
 	return &FakeCephRBDMirrors{c, namespace}
 }
@@ -20,6 +20,8 @@ package v1
 
 type CephBlockPoolExpansion interface{}
 
+type CephBlockPoolRadosNamespaceExpansion interface{}
+
 type CephClientExpansion interface{}
 
 type CephClusterExpansion interface{}
@@ -30,6 +32,8 @@ type CephFilesystemMirrorExpansion interface{}
 
 type CephNFSExpansion interface{}
 
+type CephNodeMaintenanceExpansion interface{}
+
 type CephObjectRealmExpansion interface{}
 
 type CephObjectStoreExpansion interface{}
@@ -40,4 +44,6 @@ type CephObjectZoneExpansion interface{}
 
 type CephObjectZoneGroupExpansion interface{}
 
+type CephOSDRemovalExpansion interface{}
+
 type CephRBDMirrorExpansion interface{}
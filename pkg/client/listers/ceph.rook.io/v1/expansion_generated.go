@@ -26,6 +26,14 @@ type CephBlockPoolListerExpansion interface{}
 // CephBlockPoolNamespaceLister.
 type CephBlockPoolNamespaceListerExpansion interface{}
 
+// CephBlockPoolRadosNamespaceListerExpansion allows custom methods to be added to
+// CephBlockPoolRadosNamespaceLister.
+type CephBlockPoolRadosNamespaceListerExpansion interface{}
+
+// CephBlockPoolRadosNamespaceNamespaceListerExpansion allows custom methods to be added to
+// CephBlockPoolRadosNamespaceNamespaceLister.
+type CephBlockPoolRadosNamespaceNamespaceListerExpansion interface{}
+
 // CephClientListerExpansion allows custom methods to be added to
 // CephClientLister.
 type CephClientListerExpansion interface{}
@@ -66,6 +74,14 @@ type CephNFSListerExpansion interface{}
 // CephNFSNamespaceLister.
 type CephNFSNamespaceListerExpansion interface{}
 
+// CephNodeMaintenanceListerExpansion allows custom methods to be added to
+// CephNodeMaintenanceLister.
+type CephNodeMaintenanceListerExpansion interface{}
+
+// CephNodeMaintenanceNamespaceListerExpansion allows custom methods to be added to
+// CephNodeMaintenanceNamespaceLister.
+type CephNodeMaintenanceNamespaceListerExpansion interface{}
+
 // CephObjectRealmListerExpansion allows custom methods to be added to
 // CephObjectRealmLister.
 type CephObjectRealmListerExpansion interface{}
@@ -106,6 +122,14 @@ type CephObjectZoneGroupListerExpansion interface{}
 // CephObjectZoneGroupNamespaceLister.
 type CephObjectZoneGroupNamespaceListerExpansion interface{}
 
+// CephOSDRemovalListerExpansion allows custom methods to be added to
+// CephOSDRemovalLister.
+type CephOSDRemovalListerExpansion interface{}
+
+// CephOSDRemovalNamespaceListerExpansion allows custom methods to be added to
+// CephOSDRemovalNamespaceLister.
+type CephOSDRemovalNamespaceListerExpansion interface{}
+
 // CephRBDMirrorListerExpansion allows custom methods to be added to
 // CephRBDMirrorLister.
 type CephRBDMirrorListerExpansion interface{}
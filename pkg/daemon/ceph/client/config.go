@@ -255,24 +255,41 @@ func PopulateMonHostMembers(monitors map[string]*MonInfo) ([]string, []string) {
 	i := 0
 	for _, monitor := range monitors {
 		monMembers[i] = monitor.Name
-		monIP := cephutil.GetIPFromEndpoint(monitor.Endpoint)
-
-		// This tries to detect the current port if the mon already exists
-		// This basically handles the transition between monitors running on 6790 to msgr2
-		// So whatever the previous monitor port was we keep it
-		currentMonPort := cephutil.GetPortFromEndpoint(monitor.Endpoint)
-
-		monPorts := [2]string{strconv.Itoa(int(Msgr2port)), strconv.Itoa(int(currentMonPort))}
-		msgr2Endpoint := net.JoinHostPort(monIP, monPorts[0])
-		msgr1Endpoint := net.JoinHostPort(monIP, monPorts[1])
-
-		monHosts[i] = "[v2:" + msgr2Endpoint + ",v1:" + msgr1Endpoint + "]"
+		monHosts[i] = MonAddrvec(monitor)
 		i++
 	}
 
 	return monMembers, monHosts
 }
 
+// MonAddrvec returns the msgr2 and msgr1 addresses of a mon in the Ceph addrvec format. A mon of a dual-stack cluster
+// has the addresses of both IP families.
+func MonAddrvec(monitor *MonInfo) string {
+	addrs := []string{}
+	for _, endpoint := range monitor.Endpoints() {
+		addrs = append(addrs, monAddrs(endpoint)...)
+	}
+	return "[" + strings.Join(addrs, ",") + "]"
+}
+
+// monAddrs returns the msgr2 and msgr1 addresses of a mon endpoint
+func monAddrs(endpoint string) []string {
+	monIP := cephutil.GetIPFromEndpoint(endpoint)
+
+	// This tries to detect the current port if the mon already exists
+	// This basically handles the transition between monitors running on 6790 to msgr2
+	// So whatever the previous monitor port was we keep it
+	currentMonPort := cephutil.GetPortFromEndpoint(endpoint)
+
+	msgr2Endpoint := net.JoinHostPort(monIP, strconv.Itoa(int(Msgr2port)))
+	if currentMonPort == Msgr2port {
+		// The mon endpoint is on the msgr2 port when the msgr1 protocol is disabled
+		return []string{"v2:" + msgr2Endpoint}
+	}
+	msgr1Endpoint := net.JoinHostPort(monIP, strconv.Itoa(int(currentMonPort)))
+	return []string{"v2:" + msgr2Endpoint, "v1:" + msgr1Endpoint}
+}
+
 // WriteCephConfig writes the ceph config so ceph commands can be executed
 func WriteCephConfig(context *clusterd.Context, clusterInfo *ClusterInfo) error {
 	// create the ceph.conf with the default settings
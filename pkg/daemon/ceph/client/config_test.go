@@ -170,6 +170,23 @@ func verifyConfig(t *testing.T, cephConfig *CephConfig, cluster *ClusterInfo, lo
 	}
 }
 
+func TestPopulateMonHostMembers(t *testing.T) {
+	monitors := map[string]*MonInfo{"a": {Name: "a", Endpoint: "10.0.0.1:6789"}}
+	members, hosts := PopulateMonHostMembers(monitors)
+	assert.Equal(t, []string{"a"}, members)
+	assert.Equal(t, []string{"[v2:10.0.0.1:3300,v1:10.0.0.1:6789]"}, hosts)
+
+	// the msgr1 protocol is disabled
+	monitors["a"].Endpoint = "10.0.0.1:3300"
+	_, hosts = PopulateMonHostMembers(monitors)
+	assert.Equal(t, []string{"[v2:10.0.0.1:3300]"}, hosts)
+
+	// the mon has an endpoint in each family of a dual-stack cluster
+	monitors["a"] = &MonInfo{Name: "a", Endpoint: "10.0.0.1:6789", SecondaryEndpoint: "[fd00::1]:6789"}
+	_, hosts = PopulateMonHostMembers(monitors)
+	assert.Equal(t, []string{"[v2:10.0.0.1:3300,v1:10.0.0.1:6789,v2:[fd00::1]:3300,v1:[fd00::1]:6789]"}, hosts)
+}
+
 func verifyConfigValue(t *testing.T, actualConf *ini.File, section, key, expectedVal string) {
 	s, err := actualConf.GetSection(section)
 	if !assert.Nil(t, err) {
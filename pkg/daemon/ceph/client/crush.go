@@ -151,6 +151,16 @@ func GetCrushHostName(context *clusterd.Context, clusterInfo *ClusterInfo, osdID
 	return result.Location["host"], nil
 }
 
+// RemoveCrushBucket removes a bucket from the CRUSH map, ceph refuses to remove a bucket that is not empty
+func RemoveCrushBucket(context *clusterd.Context, clusterInfo *ClusterInfo, name string) error {
+	args := []string{"osd", "crush", "rm", name}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to remove crush bucket %q. %s", name, string(buf))
+	}
+	return nil
+}
+
 // NormalizeCrushName replaces . with -
 func NormalizeCrushName(name string) string {
 	return strings.Replace(name, ".", "-", -1)
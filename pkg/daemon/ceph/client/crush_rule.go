@@ -23,10 +23,11 @@ import (
 )
 
 const (
-	crushReplicatedType      = 1
-	ruleMinSizeDefault       = 1
-	ruleMaxSizeDefault       = 10
-	twoStepCRUSHRuleTemplate = `
+	crushReplicatedType             = 1
+	ruleMinSizeDefault              = 1
+	ruleMaxSizeDefault              = 10
+	defaultReplicasPerFailureDomain = 2
+	twoStepCRUSHRuleTemplate        = `
 rule %s {
         id %d
         type replicated
@@ -34,7 +35,7 @@ rule %s {
         max_size %d
         step take %s %s
         step choose firstn 0 type %s
-        step chooseleaf firstn 2 type %s
+        step chooseleaf firstn %d type %s
         step emit
 }
 `
@@ -54,10 +55,11 @@ func buildTwoStepPlainCrushRule(crushMap CrushMap, ruleName string, pool cephv1.
 		ruleName,
 		generateRuleID(crushMap.Rules),
 		ruleMinSizeDefault,
-		ruleMaxSizeDefault,
+		ruleMaxSize(pool),
 		pool.CrushRoot,
 		crushRuleInsert,
 		pool.FailureDomain,
+		pool.Replicated.ReplicasPerFailureDomain,
 		pool.Replicated.SubFailureDomain,
 	)
 }
@@ -86,11 +88,20 @@ func buildTwoStepCrushRule(crushMap CrushMap, ruleName string, pool cephv1.PoolS
 		Ruleset: ruleID,
 		Type:    crushReplicatedType,
 		MinSize: ruleMinSizeDefault,
-		MaxSize: ruleMaxSizeDefault,
+		MaxSize: ruleMaxSize(pool),
 		Steps:   buildTwoStepCrushSteps(pool),
 	}
 }
 
+// ruleMaxSize returns the max size of a two-step rule, which must allow the pools spread across many failure domains
+// such as a stretch cluster with more than two data zones
+func ruleMaxSize(pool cephv1.PoolSpec) int {
+	if int(pool.Replicated.Size) > ruleMaxSizeDefault {
+		return int(pool.Replicated.Size)
+	}
+	return ruleMaxSizeDefault
+}
+
 func buildTwoStepCrushSteps(pool cephv1.PoolSpec) []stepSpec {
 	// Create CRUSH rule steps
 	steps := []stepSpec{}
@@ -103,9 +114,9 @@ func buildTwoStepCrushSteps(pool cephv1.PoolSpec) []stepSpec {
 	}
 	steps = append(steps, *stepTakeDefault)
 
-	// Steps two
+	// Step two, as many failure domains as available up to the pool size
 	stepTakeFailureDomain := &stepSpec{
-		Operation: "chooseleaf_firstn",
+		Operation: "choose_firstn",
 		Number:    0,
 		Type:      pool.FailureDomain,
 	}
@@ -42,6 +42,35 @@ func TestBuildStretchClusterCrushRule(t *testing.T) {
 
 	rule := buildTwoStepCrushRule(crushMap, "stretched", *pool)
 	assert.Equal(t, 2, rule.ID)
+	assert.Equal(t, ruleMaxSizeDefault, rule.MaxSize)
+
+	// the rule allows the pools spread across many zones
+	pool.Replicated.Size = 12
+	rule = buildTwoStepCrushRule(crushMap, "stretched", *pool)
+	assert.Equal(t, 12, rule.MaxSize)
+}
+
+func TestBuildTwoStepPlainCrushRule(t *testing.T) {
+	var crushMap CrushMap
+	err := json.Unmarshal([]byte(testCrushMap), &crushMap)
+	assert.NoError(t, err)
+
+	pool := cephv1.PoolSpec{
+		FailureDomain: "zone",
+		CrushRoot:     cephv1.DefaultCRUSHRoot,
+		DeviceClass:   "ssd",
+		Replicated: cephv1.ReplicatedSpec{
+			Size:                     6,
+			ReplicasPerFailureDomain: 2,
+			SubFailureDomain:         "host",
+		},
+	}
+	rule := buildTwoStepPlainCrushRule(crushMap, "stretched", pool)
+	assert.Contains(t, rule, "rule stretched {")
+	assert.Contains(t, rule, "max_size 10")
+	assert.Contains(t, rule, "step take default class ssd")
+	assert.Contains(t, rule, "step choose firstn 0 type zone")
+	assert.Contains(t, rule, "step chooseleaf firstn 2 type host")
 }
 
 func TestBuildCrushSteps(t *testing.T) {
@@ -55,6 +84,7 @@ func TestBuildCrushSteps(t *testing.T) {
 	steps := buildTwoStepCrushSteps(*pool)
 	assert.Equal(t, 4, len(steps))
 	assert.Equal(t, cephv1.DefaultCRUSHRoot, steps[0].ItemName)
+	assert.Equal(t, "choose_firstn", steps[1].Operation)
 	assert.Equal(t, "datacenter", steps[1].Type)
 	assert.Equal(t, uint(2), steps[2].Number)
 }
@@ -18,10 +18,14 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 
 	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type CephErasureCodeProfile struct {
@@ -65,6 +69,9 @@ func GetErasureCodeProfileDetails(context *clusterd.Context, clusterInfo *Cluste
 	return ecProfileDetails, nil
 }
 
+// CreateErasureCodeProfile creates the erasure code profile of a pool or updates it when its settings differ from
+// the pool spec. A profile used by a pool is never changed since ceph does not support changing the erasure code
+// settings of a pool, the drift is only logged.
 func CreateErasureCodeProfile(context *clusterd.Context, clusterInfo *ClusterInfo, profileName string, pool cephv1.PoolSpec) error {
 	// look up the default profile so we can use the default plugin/technique
 	defaultProfile, err := GetErasureCodeProfileDetails(context, clusterInfo, "default")
@@ -73,11 +80,130 @@ func CreateErasureCodeProfile(context *clusterd.Context, clusterInfo *ClusterInf
 	}
 
 	// define the profile with a set of key/value pairs
+	profilePairs, err := erasureCodeProfileSettings(defaultProfile, pool)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the settings of erasure code profile %q", profileName)
+	}
+
+	args := []string{"osd", "erasure-code-profile", "set", profileName}
+	args = append(args, profilePairs...)
+
+	// the profile is created if it cannot be found
+	current, err := getErasureCodeProfileSettings(context, clusterInfo, profileName)
+	if err == nil {
+		drift := erasureCodeProfileDrift(current, profilePairs)
+		if len(drift) == 0 {
+			logger.Debugf("erasure code profile %q is up to date", profileName)
+			return nil
+		}
+		pools, err := GetErasureCodeProfilePools(context, clusterInfo, profileName)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if erasure code profile %q is in use", profileName)
+		}
+		if len(pools) > 0 {
+			logger.Warningf("erasure code profile %q is used by pool(s) %v and cannot be changed, its settings differ from the spec: %v", profileName, pools, drift)
+			return nil
+		}
+		logger.Infof("updating erasure code profile %q, its settings differ from the spec: %v", profileName, drift)
+		args = append(args, "--force")
+	}
+
+	_, err = NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrap(err, "failed to set ec-profile")
+	}
+
+	return nil
+}
+
+// GetErasureCodeProfileDrift returns the settings of an erasure code profile which differ from the pool spec
+func GetErasureCodeProfileDrift(context *clusterd.Context, clusterInfo *ClusterInfo, profileName string, pool cephv1.PoolSpec) ([]string, error) {
+	defaultProfile, err := GetErasureCodeProfileDetails(context, clusterInfo, "default")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to look up default erasure code profile")
+	}
+	profilePairs, err := erasureCodeProfileSettings(defaultProfile, pool)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the settings of erasure code profile %q", profileName)
+	}
+	current, err := getErasureCodeProfileSettings(context, clusterInfo, profileName)
+	if err != nil {
+		return nil, err
+	}
+
+	return erasureCodeProfileDrift(current, profilePairs), nil
+}
+
+// GetErasureCodeProfilePools returns the pools using an erasure code profile
+func GetErasureCodeProfilePools(context *clusterd.Context, clusterInfo *ClusterInfo, profileName string) ([]string, error) {
+	osdDump, err := GetOSDDump(context, clusterInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	pools := []string{}
+	for _, pool := range osdDump.Pools {
+		if pool.ErasureCodeProfile == profileName {
+			pools = append(pools, pool.PoolName)
+		}
+	}
+	return pools, nil
+}
+
+// ErasureCodePluginParameters returns the options of an erasure code plugin as erasure code profile settings
+func ErasureCodePluginParameters(plugin cephv1.ErasureCodePluginSpec) (map[string]string, error) {
+	parameters := map[string]string{}
+	if plugin.Technique != "" {
+		parameters["technique"] = plugin.Technique
+	}
+	if plugin.Locality != 0 {
+		parameters["l"] = strconv.FormatUint(uint64(plugin.Locality), 10)
+	}
+	if plugin.DurabilityEstimator != 0 {
+		parameters["c"] = strconv.FormatUint(uint64(plugin.DurabilityEstimator), 10)
+	}
+	if plugin.HelperChunks != 0 {
+		parameters["d"] = strconv.FormatUint(uint64(plugin.HelperChunks), 10)
+	}
+	if plugin.ScalarMDS != "" {
+		parameters["scalar_mds"] = plugin.ScalarMDS
+	}
+	if plugin.StripeUnit != nil {
+		stripeUnit, err := resource.ParseQuantity(*plugin.StripeUnit)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse stripe unit %q", *plugin.StripeUnit)
+		}
+		parameters["stripe_unit"] = strconv.FormatInt(stripeUnit.Value(), 10)
+	}
+	return parameters, nil
+}
+
+// erasureCodeProfileSettings returns the key/value pairs of the erasure code profile of a pool, the plugin and the
+// technique of the default profile are used when the pool spec has no plugin
+func erasureCodeProfileSettings(defaultProfile CephErasureCodeProfile, pool cephv1.PoolSpec) ([]string, error) {
 	profilePairs := []string{
 		fmt.Sprintf("k=%d", pool.ErasureCoded.DataChunks),
 		fmt.Sprintf("m=%d", pool.ErasureCoded.CodingChunks),
-		fmt.Sprintf("plugin=%s", defaultProfile.Plugin),
-		fmt.Sprintf("technique=%s", defaultProfile.Technique),
+	}
+	if pool.ErasureCoded.Plugin == nil {
+		profilePairs = append(profilePairs,
+			fmt.Sprintf("plugin=%s", defaultProfile.Plugin),
+			fmt.Sprintf("technique=%s", defaultProfile.Technique))
+	} else {
+		parameters, err := ErasureCodePluginParameters(*pool.ErasureCoded.Plugin)
+		if err != nil {
+			return nil, err
+		}
+		profilePairs = append(profilePairs, fmt.Sprintf("plugin=%s", pool.ErasureCoded.Plugin.Name))
+		// sort the options to always set them in the same order
+		keys := make([]string, 0, len(parameters))
+		for key := range parameters {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			profilePairs = append(profilePairs, fmt.Sprintf("%s=%s", key, parameters[key]))
+		}
 	}
 	if pool.FailureDomain != "" {
 		profilePairs = append(profilePairs, fmt.Sprintf("crush-failure-domain=%s", pool.FailureDomain))
@@ -88,15 +214,36 @@ func CreateErasureCodeProfile(context *clusterd.Context, clusterInfo *ClusterInf
 	if pool.DeviceClass != "" {
 		profilePairs = append(profilePairs, fmt.Sprintf("crush-device-class=%s", pool.DeviceClass))
 	}
+	return profilePairs, nil
+}
+
+// getErasureCodeProfileSettings returns all the key/value pairs of an erasure code profile
+func getErasureCodeProfileSettings(context *clusterd.Context, clusterInfo *ClusterInfo, name string) (map[string]string, error) {
+	args := []string{"osd", "erasure-code-profile", "get", name}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get erasure-code-profile for %q", name)
+	}
 
-	args := []string{"osd", "erasure-code-profile", "set", profileName}
-	args = append(args, profilePairs...)
-	_, err = NewCephCommand(context, clusterInfo, args).Run()
+	var settings map[string]string
+	err = json.Unmarshal(buf, &settings)
 	if err != nil {
-		return errors.Wrap(err, "failed to set ec-profile")
+		return nil, errors.Wrapf(err, "unmarshal failed raw buffer response %s", string(buf))
 	}
 
-	return nil
+	return settings, nil
+}
+
+// erasureCodeProfileDrift returns the settings of a profile which differ from the expected key/value pairs
+func erasureCodeProfileDrift(current map[string]string, profilePairs []string) []string {
+	drift := []string{}
+	for _, pair := range profilePairs {
+		setting := strings.SplitN(pair, "=", 2)
+		if current[setting[0]] != setting[1] {
+			drift = append(drift, fmt.Sprintf("%s=%s (spec: %s)", setting[0], current[setting[0]], setting[1]))
+		}
+	}
+	return drift
 }
 
 func DeleteErasureCodeProfile(context *clusterd.Context, clusterInfo *ClusterInfo, profileName string) error {
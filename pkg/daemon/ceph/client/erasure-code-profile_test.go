@@ -17,6 +17,7 @@ package client
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -56,8 +57,11 @@ func testCreateProfile(t *testing.T, failureDomain, crushRoot, deviceClass strin
 		logger.Infof("Command: %s %v", command, args)
 		if args[1] == "erasure-code-profile" {
 			if args[2] == "get" {
-				assert.Equal(t, "default", args[3])
-				return `{"plugin":"myplugin","technique":"t"}`, nil
+				if args[3] == "default" {
+					return `{"plugin":"myplugin","technique":"t"}`, nil
+				}
+				assert.Equal(t, "myapp", args[3])
+				return "", errors.New("unknown erasure code profile")
 			}
 			if args[2] == "set" {
 				assert.Equal(t, "myapp", args[3])
@@ -86,3 +90,98 @@ func testCreateProfile(t *testing.T, failureDomain, crushRoot, deviceClass strin
 	err := CreateErasureCodeProfile(context, AdminClusterInfo("mycluster"), "myapp", spec)
 	assert.Nil(t, err)
 }
+
+func TestCreateProfileWithPlugin(t *testing.T) {
+	stripeUnit := "8Ki"
+	spec := cephv1.PoolSpec{
+		ErasureCoded: cephv1.ErasureCodedSpec{
+			DataChunks:   4,
+			CodingChunks: 2,
+			Plugin:       &cephv1.ErasureCodePluginSpec{Name: "lrc", Locality: 3, StripeUnit: &stripeUnit},
+		},
+	}
+
+	var profile []string
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[1] == "erasure-code-profile" {
+			if args[2] == "get" && args[3] == "default" {
+				return `{"plugin":"jerasure","technique":"reed_sol_van"}`, nil
+			}
+			if args[2] == "get" {
+				return "", errors.New("unknown erasure code profile")
+			}
+			if args[2] == "set" {
+				profile = erasureCodeProfileArgs(args[4:])
+				return "", nil
+			}
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	err := CreateErasureCodeProfile(context, AdminClusterInfo("mycluster"), "myapp", spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"k=4", "m=2", "plugin=lrc", "l=3", "stripe_unit=8192"}, profile)
+}
+
+func TestUpdateProfile(t *testing.T) {
+	spec := cephv1.PoolSpec{
+		ErasureCoded: cephv1.ErasureCodedSpec{
+			DataChunks:   2,
+			CodingChunks: 1,
+			Plugin:       &cephv1.ErasureCodePluginSpec{Name: "isa", Technique: "cauchy"},
+		},
+	}
+
+	poolsUsingProfile := `[{"pool_name":"mypool","erasure_code_profile":"myapp"}]`
+	var setArgs []string
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[0] == "osd" && args[1] == "dump" {
+			return `{"pools":` + poolsUsingProfile + `}`, nil
+		}
+		if args[1] == "erasure-code-profile" {
+			if args[2] == "get" && args[3] == "default" {
+				return `{"plugin":"jerasure","technique":"reed_sol_van"}`, nil
+			}
+			if args[2] == "get" {
+				return `{"k":"2","m":"1","plugin":"isa","technique":"reed_sol_van","crush-failure-domain":"host"}`, nil
+			}
+			if args[2] == "set" {
+				setArgs = erasureCodeProfileArgs(args[3:])
+				return "", nil
+			}
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	clusterInfo := AdminClusterInfo("mycluster")
+
+	drift, err := GetErasureCodeProfileDrift(context, clusterInfo, "myapp", spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"technique=reed_sol_van (spec: cauchy)"}, drift)
+
+	// the profile is not changed while a pool uses it
+	err = CreateErasureCodeProfile(context, clusterInfo, "myapp", spec)
+	assert.NoError(t, err)
+	assert.Nil(t, setArgs)
+
+	// the profile is overwritten once no pool uses it
+	poolsUsingProfile = `[{"pool_name":"otherpool","erasure_code_profile":"otherprofile"}]`
+	err = CreateErasureCodeProfile(context, clusterInfo, "myapp", spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"myapp", "k=2", "m=1", "plugin=isa", "technique=cauchy", "--force"}, setArgs)
+}
+
+// erasureCodeProfileArgs returns the arguments of a command before the arguments added to every ceph command
+func erasureCodeProfileArgs(args []string) []string {
+	profileArgs := []string{}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--connect-timeout") {
+			break
+		}
+		profileArgs = append(profileArgs, arg)
+	}
+	return profileArgs
+}
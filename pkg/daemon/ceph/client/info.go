@@ -51,6 +51,8 @@ type ClusterInfo struct {
 type MonInfo struct {
 	Name     string `json:"name"`
 	Endpoint string `json:"endpoint"`
+	// SecondaryEndpoint is the endpoint of the mon in the second IP family of a dual-stack cluster
+	SecondaryEndpoint string `json:"secondaryEndpoint,omitempty"`
 }
 
 // CephCred represents the Ceph cluster username and key used by the operator.
@@ -131,6 +133,14 @@ func NewMonInfo(name, ip string, port int32) *MonInfo {
 	return &MonInfo{Name: name, Endpoint: net.JoinHostPort(ip, fmt.Sprintf("%d", port))}
 }
 
+// Endpoints returns the endpoints of the mon, with an endpoint in each IP family of a dual-stack cluster
+func (m *MonInfo) Endpoints() []string {
+	if m.SecondaryEndpoint == "" {
+		return []string{m.Endpoint}
+	}
+	return []string{m.Endpoint, m.SecondaryEndpoint}
+}
+
 func NewMinimumOwnerInfo(t *testing.T) *k8sutil.OwnerInfo {
 	cluster := &cephv1.CephCluster{}
 	scheme := runtime.NewScheme()
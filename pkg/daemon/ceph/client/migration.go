@@ -0,0 +1,152 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+const (
+	// ImageMigrationStatePrepared is the state of an image migration once it is prepared
+	ImageMigrationStatePrepared = "prepared"
+	// ImageMigrationStateExecuting is the state of an image migration while its data is copied
+	ImageMigrationStateExecuting = "executing"
+	// ImageMigrationStateExecuted is the state of an image migration once its data is copied
+	ImageMigrationStateExecuted = "executed"
+
+	defaultDataPoolConfig = "rbd_default_data_pool"
+)
+
+// CephBlockImageStatus is the status of an image reported by rbd
+type CephBlockImageStatus struct {
+	Watchers []struct {
+		Address string `json:"address"`
+	} `json:"watchers"`
+	Migration *struct {
+		State string `json:"state"`
+	} `json:"migration,omitempty"`
+}
+
+// CephBlockImageInfo is the information of an image reported by rbd
+type CephBlockImageInfo struct {
+	Name     string `json:"name"`
+	DataPool string `json:"data_pool,omitempty"`
+}
+
+// GetImageStatus returns the watchers and the migration state of an image
+func GetImageStatus(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName string) (*CephBlockImageStatus, error) {
+	args := []string{"status", getImageSpec(imageName, poolName)}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+	output, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get status of image %q in pool %q. %s", imageName, poolName, string(output))
+	}
+
+	var status CephBlockImageStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal image status response")
+	}
+
+	return &status, nil
+}
+
+// GetImageInfo returns the information of an image
+func GetImageInfo(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName string) (*CephBlockImageInfo, error) {
+	args := []string{"info", getImageSpec(imageName, poolName)}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+	output, err := cmd.Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get info of image %q in pool %q. %s", imageName, poolName, string(output))
+	}
+
+	var info CephBlockImageInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal image info response")
+	}
+
+	return &info, nil
+}
+
+// PrepareImageMigration prepares the live migration of the data of an image to another pool. The image keeps its
+// name and its header in its pool, only its data is migrated to the data pool. When the data pool is the pool of the
+// image, the data is migrated back to the pool.
+func PrepareImageMigration(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName, dataPoolName string) error {
+	logger.Infof("preparing the migration of image %q of pool %q to data pool %q", imageName, poolName, dataPoolName)
+	args := []string{"migration", "prepare", getImageSpec(imageName, poolName), "--data-pool", dataPoolName}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to prepare the migration of image %q of pool %q. %s", imageName, poolName, string(output))
+	}
+	return nil
+}
+
+// ExecuteImageMigration copies the data of an image which migration is prepared
+func ExecuteImageMigration(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName string) error {
+	logger.Infof("executing the migration of image %q of pool %q", imageName, poolName)
+	args := []string{"migration", "execute", getImageSpec(imageName, poolName)}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to execute the migration of image %q of pool %q. %s", imageName, poolName, string(output))
+	}
+	return nil
+}
+
+// CommitImageMigration commits the migration of an image once its data is copied, the source of the image is
+// deleted
+func CommitImageMigration(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, imageName string) error {
+	logger.Infof("committing the migration of image %q of pool %q", imageName, poolName)
+	args := []string{"migration", "commit", getImageSpec(imageName, poolName)}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to commit the migration of image %q of pool %q. %s", imageName, poolName, string(output))
+	}
+	return nil
+}
+
+// SetDefaultDataPool sets the pool the new images of a pool store their data in, their headers stay in the pool
+func SetDefaultDataPool(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, dataPoolName string) error {
+	logger.Infof("setting the default data pool of pool %q to %q", poolName, dataPoolName)
+	args := []string{"config", "pool", "set", poolName, defaultDataPoolConfig, dataPoolName}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set the default data pool of pool %q. %s", poolName, string(output))
+	}
+	return nil
+}
+
+// RemoveDefaultDataPool removes the default data pool of a pool, the new images of the pool store their data in the
+// pool. It does nothing if the pool has no default data pool.
+func RemoveDefaultDataPool(context *clusterd.Context, clusterInfo *ClusterInfo, poolName string) error {
+	logger.Infof("removing the default data pool of pool %q", poolName)
+	args := []string{"config", "pool", "remove", poolName, defaultDataPoolConfig}
+	output, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.ENOENT) {
+			logger.Debugf("pool %q has no default data pool", poolName)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to remove the default data pool of pool %q. %s", poolName, string(output))
+	}
+	return nil
+}
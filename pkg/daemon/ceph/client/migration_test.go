@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetImageStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == "rbd" && args[0] == "status" {
+			assert.Equal(t, "replicapool/image1", args[1])
+			return `{"watchers":[{"address":"10.0.0.1:0/123","client":4567,"cookie":1}],"migration":{"source_pool_name":"replicapool","state":"prepared"}}`, nil
+		}
+		return "", errors.Errorf("unexpected rbd command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	status, err := GetImageStatus(context, AdminClusterInfo("mycluster"), "replicapool", "image1")
+	assert.NoError(t, err)
+	assert.Len(t, status.Watchers, 1)
+	assert.NotNil(t, status.Migration)
+	assert.Equal(t, ImageMigrationStatePrepared, status.Migration.State)
+}
+
+func TestImageMigration(t *testing.T) {
+	steps := []string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == "rbd" && args[0] == "migration" {
+			assert.Equal(t, "replicapool/image1", args[2])
+			if args[1] == "prepare" {
+				assert.Equal(t, []string{"--data-pool", "replicapool-data"}, args[3:5])
+			}
+			steps = append(steps, args[1])
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected rbd command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminClusterInfo("mycluster")
+
+	err := PrepareImageMigration(context, clusterInfo, "replicapool", "image1", "replicapool-data")
+	assert.NoError(t, err)
+	err = ExecuteImageMigration(context, clusterInfo, "replicapool", "image1")
+	assert.NoError(t, err)
+	err = CommitImageMigration(context, clusterInfo, "replicapool", "image1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"prepare", "execute", "commit"}, steps)
+}
+
+func TestDefaultDataPool(t *testing.T) {
+	config := map[string]string{}
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == "rbd" && args[0] == "config" && args[1] == "pool" {
+			assert.Equal(t, "replicapool", args[3])
+			assert.Equal(t, "rbd_default_data_pool", args[4])
+			switch args[2] {
+			case "set":
+				config[args[4]] = args[5]
+				return "", nil
+			case "remove":
+				delete(config, args[4])
+				return "", nil
+			}
+		}
+		return "", errors.Errorf("unexpected rbd command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminClusterInfo("mycluster")
+
+	err := SetDefaultDataPool(context, clusterInfo, "replicapool", "replicapool-data")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"rbd_default_data_pool": "replicapool-data"}, config)
+	err = RemoveDefaultDataPool(context, clusterInfo, "replicapool")
+	assert.NoError(t, err)
+	assert.Empty(t, config)
+}
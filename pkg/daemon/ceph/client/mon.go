@@ -109,6 +109,17 @@ func GetMonDump(context *clusterd.Context, clusterInfo *ClusterInfo) (MonDump, e
 	return response, nil
 }
 
+// CompactMonStore compacts the store of a mon. The command returns when the compaction is completed.
+func CompactMonStore(context *clusterd.Context, clusterInfo *ClusterInfo, monName string) error {
+	args := []string{"tell", fmt.Sprintf("mon.%s", monName), "compact"}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to compact the store of mon %q. %s", monName, string(buf))
+	}
+	logger.Infof("successfully compacted the store of mon %q", monName)
+	return nil
+}
+
 // EnableStretchElectionStrategy enables the mon connectivity algorithm for stretch clusters
 func EnableStretchElectionStrategy(context *clusterd.Context, clusterInfo *ClusterInfo) error {
 	args := []string{"mon", "set", "election_strategy", "connectivity"}
@@ -122,9 +133,14 @@ func EnableStretchElectionStrategy(context *clusterd.Context, clusterInfo *Clust
 
 // CreateDefaultStretchCrushRule creates the default CRUSH rule for the stretch cluster
 func CreateDefaultStretchCrushRule(context *clusterd.Context, clusterInfo *ClusterInfo, clusterSpec *cephv1.ClusterSpec, failureDomain string) error {
+	// The replicas are spread across all the data zones of the stretch cluster
 	pool := cephv1.PoolSpec{
 		FailureDomain: failureDomain,
-		Replicated:    cephv1.ReplicatedSpec{SubFailureDomain: clusterSpec.Mon.StretchCluster.SubFailureDomain},
+		Replicated: cephv1.ReplicatedSpec{
+			Size:                     clusterSpec.StretchPoolSize(),
+			ReplicasPerFailureDomain: cephv1.StretchReplicasPerZone,
+			SubFailureDomain:         clusterSpec.Mon.StretchCluster.SubFailureDomain,
+		},
 	}
 	if err := createTwoStepCrushRule(context, clusterInfo, clusterSpec, defaultStretchCrushRuleName, pool); err != nil {
 		return errors.Wrap(err, "failed to create default stretch crush rule")
@@ -126,6 +126,22 @@ func TestStretchClusterMonTiebreaker(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestCompactMonStore(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "tell" && args[1] == "mon.a" && args[2] == "compact" {
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminClusterInfo("mycluster")
+
+	err := CompactMonStore(context, clusterInfo, "a")
+	assert.NoError(t, err)
+}
+
 func TestMonDump(t *testing.T) {
 	executor := &exectest.MockExecutor{}
 	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
@@ -17,6 +17,8 @@ package client
 
 import (
 	"encoding/json"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -66,6 +68,10 @@ type OSDDump struct {
 	} `json:"osds"`
 	Flags          string              `json:"flags"`
 	CrushNodeFlags map[string][]string `json:"crush_node_flags"`
+	Pools          []struct {
+		PoolName           string `json:"pool_name"`
+		ErasureCodeProfile string `json:"erasure_code_profile"`
+	} `json:"pools"`
 }
 
 // IsFlagSet checks if an OSD flag is set
@@ -136,6 +142,28 @@ func UnsetFlagOnCrushUnit(context *clusterd.Context, clusterInfo *ClusterInfo, c
 	return nil
 }
 
+// SetFlag sets the specified flag on the whole cluster
+func SetFlag(context *clusterd.Context, clusterInfo *ClusterInfo, flag string) error {
+	args := []string{"osd", "set", flag}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	_, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to set flag %s", flag)
+	}
+	return nil
+}
+
+// UnsetFlag unsets the specified flag on the whole cluster
+func UnsetFlag(context *clusterd.Context, clusterInfo *ClusterInfo, flag string) error {
+	args := []string{"osd", "unset", flag}
+	cmd := NewCephCommand(context, clusterInfo, args)
+	_, err := cmd.Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to unset flag %s", flag)
+	}
+	return nil
+}
+
 type SafeToDestroyStatus struct {
 	SafeToDestroy []int `json:"safe_to_destroy"`
 }
@@ -252,6 +280,26 @@ func OSDOut(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) (str
 	return string(buf), err
 }
 
+// PurgeOSD removes an OSD from the CRUSH map, deletes its auth key and removes it from the OSD map
+func PurgeOSD(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) error {
+	args := []string{"osd", "purge", fmt.Sprintf("osd.%d", osdID), "--force", "--yes-i-really-mean-it"}
+	_, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to purge osd.%d", osdID)
+	}
+	return nil
+}
+
+// DestroyOSD deletes the auth key of an OSD and marks it destroyed, its ID and CRUSH location are kept for a new OSD to reuse
+func DestroyOSD(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) error {
+	args := []string{"osd", "destroy", fmt.Sprintf("osd.%d", osdID), "--yes-i-really-mean-it"}
+	_, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "failed to destroy osd.%d", osdID)
+	}
+	return nil
+}
+
 func OsdSafeToDestroy(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) (bool, error) {
 	args := []string{"osd", "safe-to-destroy", strconv.Itoa(osdID)}
 	cmd := NewCephCommand(context, clusterInfo, args)
@@ -270,6 +318,19 @@ func OsdSafeToDestroy(context *clusterd.Context, clusterInfo *ClusterInfo, osdID
 	return false, nil
 }
 
+// OSDsOkToStop returns an error if stopping all the OSDs at once would make some PGs unavailable
+func OSDsOkToStop(context *clusterd.Context, clusterInfo *ClusterInfo, osdIDs []int) error {
+	args := []string{"osd", "ok-to-stop"}
+	for _, osdID := range osdIDs {
+		args = append(args, strconv.Itoa(osdID))
+	}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return errors.Wrapf(err, "osds %v cannot be stopped at once. %s", osdIDs, string(buf))
+	}
+	return nil
+}
+
 // HostTree returns the osd tree
 func HostTree(context *clusterd.Context, clusterInfo *ClusterInfo) (OsdTree, error) {
 	var output OsdTree
@@ -288,6 +349,63 @@ func HostTree(context *clusterd.Context, clusterInfo *ClusterInfo) (OsdTree, err
 	return output, nil
 }
 
+// DestroyedOSDsOnHost returns the IDs of the destroyed OSDs under the CRUSH host
+func DestroyedOSDsOnHost(context *clusterd.Context, clusterInfo *ClusterInfo, host string) ([]int, error) {
+	var output OsdTree
+	args := []string{"osd", "tree", "destroyed"}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get destroyed osds")
+	}
+	if err := json.Unmarshal(buf, &output); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal 'osd tree destroyed' response")
+	}
+
+	destroyed := map[int]bool{}
+	for _, node := range output.Nodes {
+		if node.Type == "osd" && node.Status == "destroyed" {
+			destroyed[node.ID] = true
+		}
+	}
+
+	ids := []int{}
+	for _, node := range output.Nodes {
+		if node.Type != "host" || !IsNormalizedCrushNameEqual(host, node.Name) {
+			continue
+		}
+		for _, id := range node.Children {
+			if destroyed[id] {
+				ids = append(ids, id)
+			}
+		}
+	}
+	sort.Ints(ids)
+
+	return ids, nil
+}
+
+// OSDMetadata is the metadata reported by an OSD
+type OSDMetadata struct {
+	ID                 int    `json:"id"`
+	BlueFSDedicatedDB  string `json:"bluefs_dedicated_db"`
+	BlueFSDedicatedWAL string `json:"bluefs_dedicated_wal"`
+}
+
+// GetOSDMetadata returns the metadata reported by the OSD
+func GetOSDMetadata(context *clusterd.Context, clusterInfo *ClusterInfo, osdID int) (*OSDMetadata, error) {
+	var output OSDMetadata
+	args := []string{"osd", "metadata", strconv.Itoa(osdID)}
+	buf, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the metadata of osd.%d", osdID)
+	}
+	if err := json.Unmarshal(buf, &output); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal 'osd metadata' response")
+	}
+
+	return &output, nil
+}
+
 // OsdListNum returns the list of OSDs
 func OsdListNum(context *clusterd.Context, clusterInfo *ClusterInfo) (OsdList, error) {
 	var output OsdList
@@ -17,6 +17,7 @@ limitations under the License.
 package client
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
@@ -110,3 +111,76 @@ func TestOsdListNum(t *testing.T) {
 	assert.Error(t, err)
 	assert.Equal(t, 0, len(list))
 }
+
+func TestDestroyedOSDsOnHost(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "osd" && args[1] == "tree" && args[2] == "destroyed" {
+			return `{"nodes":[
+				{"id":-1,"name":"default","type":"root","children":[-3,-2]},
+				{"id":-3,"name":"minikube","type":"host","children":[2,1,0]},
+				{"id":2,"name":"osd.2","type":"osd","status":"destroyed"},
+				{"id":0,"name":"osd.0","type":"osd","status":"destroyed"},
+				{"id":-2,"name":"minikube-2","type":"host","children":[3]},
+				{"id":3,"name":"osd.3","type":"osd","status":"destroyed"}]}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	ids, err := DestroyedOSDsOnHost(&clusterd.Context{Executor: executor}, AdminClusterInfo("mycluster"), "minikube")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 2}, ids)
+
+	ids, err = DestroyedOSDsOnHost(&clusterd.Context{Executor: executor}, AdminClusterInfo("mycluster"), "other")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(ids))
+}
+
+func TestOSDsOkToStop(t *testing.T) {
+	var okToStopArgs []string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "osd" && args[1] == "ok-to-stop" {
+			okToStopArgs = []string{}
+			for _, arg := range args[2:] {
+				if strings.HasPrefix(arg, "--") {
+					break
+				}
+				okToStopArgs = append(okToStopArgs, arg)
+			}
+			if len(okToStopArgs) > 2 {
+				return "", errors.New("would make pgs unavailable")
+			}
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	err := OSDsOkToStop(&clusterd.Context{Executor: executor}, AdminClusterInfo("mycluster"), []int{1, 4})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "4"}, okToStopArgs)
+
+	err = OSDsOkToStop(&clusterd.Context{Executor: executor}, AdminClusterInfo("mycluster"), []int{1, 4, 7})
+	assert.Error(t, err)
+}
+
+func TestGetOSDMetadata(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "osd" && args[1] == "metadata" && args[2] == "1" {
+			return `{"id":1,"bluefs":"1","bluefs_dedicated_db":"0","bluefs_dedicated_wal":"0","devices":"sdb"}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	metadata, err := GetOSDMetadata(&clusterd.Context{Executor: executor}, AdminClusterInfo("mycluster"), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, metadata.ID)
+	assert.Equal(t, "0", metadata.BlueFSDedicatedDB)
+
+	_, err = GetOSDMetadata(&clusterd.Context{Executor: executor}, AdminClusterInfo("mycluster"), 2)
+	assert.Error(t, err)
+}
@@ -34,6 +34,9 @@ const (
 	confirmFlag             = "--yes-i-really-mean-it"
 	reallyConfirmFlag       = "--yes-i-really-really-mean-it"
 	targetSizeRatioProperty = "target_size_ratio"
+	targetSizeBytesProperty = "target_size_bytes"
+	pgNumMinProperty        = "pg_num_min"
+	bulkProperty            = "bulk"
 	compressionModeProperty = "compression_mode"
 	PgAutoscaleModeProperty = "pg_autoscale_mode"
 	PgAutoscaleModeOn       = "on"
@@ -57,13 +60,24 @@ type CephStoragePoolDetails struct {
 	RequireSafeReplicaSize bool    `json:"requireSafeReplicaSize,omitempty"`
 }
 
+// PoolAutoscaleStatus is the status of a pool reported by the placement group autoscaler
+type PoolAutoscaleStatus struct {
+	PoolName    string `json:"pool_name"`
+	Mode        string `json:"pg_autoscale_mode"`
+	PGNumTarget int    `json:"pg_num_target"`
+	PGNumFinal  int    `json:"pg_num_final"`
+	WouldAdjust bool   `json:"would_adjust"`
+}
+
 type CephStoragePoolStats struct {
 	Pools []struct {
 		Name  string `json:"name"`
 		ID    int    `json:"id"`
 		Stats struct {
+			Stored       float64 `json:"stored"`
 			BytesUsed    float64 `json:"bytes_used"`
 			RawBytesUsed float64 `json:"raw_bytes_used"`
+			PercentUsed  float64 `json:"percent_used"`
 			MaxAvail     float64 `json:"max_avail"`
 			Objects      float64 `json:"objects"`
 			DirtyObjects float64 `json:"dirty"`
@@ -246,6 +260,14 @@ func setCommonPoolProperties(context *clusterd.Context, clusterInfo *ClusterInfo
 		pool.Parameters[targetSizeRatioProperty] = strconv.FormatFloat(pool.Replicated.TargetSizeRatio, 'f', -1, 32)
 	}
 
+	autoscalerParameters, err := PGAutoscalerParameters(pool.Autoscaler)
+	if err != nil {
+		return errors.Wrapf(err, "failed to apply the pg autoscaler settings of pool %q", poolName)
+	}
+	for propName, propValue := range autoscalerParameters {
+		pool.Parameters[propName] = propValue
+	}
+
 	if pool.IsCompressionEnabled() {
 		pool.Parameters[compressionModeProperty] = pool.CompressionMode
 	}
@@ -258,6 +280,14 @@ func setCommonPoolProperties(context *clusterd.Context, clusterInfo *ClusterInfo
 		}
 	}
 
+	// Reset the autoscaler settings removed from the spec, bulk is unknown to the older Ceph versions
+	for propName, propValue := range pgAutoscalerResetParameters(pool.Autoscaler, pool.Parameters) {
+		err := SetPoolProperty(context, clusterInfo, poolName, propName, propValue)
+		if err != nil {
+			logger.Debugf("failed to reset property %q of pool %q to %q. %v", propName, poolName, propValue, err)
+		}
+	}
+
 	// ensure that the newly created pool gets an application tag
 	if appName != "" {
 		err := givePoolAppTag(context, clusterInfo, poolName, appName)
@@ -317,6 +347,66 @@ func setCommonPoolProperties(context *clusterd.Context, clusterInfo *ClusterInfo
 	return nil
 }
 
+// PGAutoscalerParameters returns the pool properties set by the placement group autoscaler settings of a pool
+func PGAutoscalerParameters(autoscaler cephv1.PGAutoscalerSpec) (map[string]string, error) {
+	parameters := map[string]string{}
+	if autoscaler.Mode != "" {
+		parameters[PgAutoscaleModeProperty] = autoscaler.Mode
+	}
+	if autoscaler.PGNumMin != 0 {
+		parameters[pgNumMinProperty] = strconv.FormatUint(uint64(autoscaler.PGNumMin), 10)
+	}
+	if autoscaler.TargetSizeRatio != 0 {
+		parameters[targetSizeRatioProperty] = strconv.FormatFloat(autoscaler.TargetSizeRatio, 'f', -1, 32)
+	}
+	if autoscaler.TargetSize != nil {
+		targetSize, err := resource.ParseQuantity(*autoscaler.TargetSize)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse target size %q", *autoscaler.TargetSize)
+		}
+		parameters[targetSizeBytesProperty] = strconv.FormatInt(targetSize.Value(), 10)
+	}
+	if autoscaler.Bulk {
+		parameters[bulkProperty] = "true"
+	}
+	return parameters, nil
+}
+
+// pgAutoscalerResetParameters returns the pool properties of the autoscaler settings which are not set, they are reset
+// to the Ceph defaults unless they are set in the parameters of the pool
+func pgAutoscalerResetParameters(autoscaler cephv1.PGAutoscalerSpec, parameters map[string]string) map[string]string {
+	resetParameters := map[string]string{}
+	if autoscaler.PGNumMin == 0 {
+		resetParameters[pgNumMinProperty] = "0"
+	}
+	if autoscaler.TargetSize == nil {
+		resetParameters[targetSizeBytesProperty] = "0"
+	}
+	if !autoscaler.Bulk {
+		resetParameters[bulkProperty] = "false"
+	}
+	for propName := range parameters {
+		delete(resetParameters, propName)
+	}
+	return resetParameters
+}
+
+// GetPoolAutoscaleStatus returns the status of the pools reported by the placement group autoscaler
+func GetPoolAutoscaleStatus(context *clusterd.Context, clusterInfo *ClusterInfo) ([]PoolAutoscaleStatus, error) {
+	args := []string{"osd", "pool", "autoscale-status"}
+	output, err := NewCephCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get pool autoscale status")
+	}
+
+	var status []PoolAutoscaleStatus
+	if err := json.Unmarshal(output, &status); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal pool autoscale status response")
+	}
+
+	return status, nil
+}
+
 func GetErasureCodeProfileForPool(baseName string) string {
 	return fmt.Sprintf("%s_ecprofile", baseName)
 }
@@ -371,13 +461,22 @@ func CreateReplicatedPoolForApp(context *clusterd.Context, clusterInfo *ClusterI
 		return errors.Wrapf(err, "failed to create replicated pool %s. %s", poolName, string(output))
 	}
 
-	if !clusterSpec.IsStretchCluster() {
+	if !clusterSpec.IsStretchMode() {
 		// the pool is type replicated, set the size for the pool now that it's been created
 		if err := SetPoolReplicatedSizeProperty(context, clusterInfo, poolName, strconv.FormatUint(uint64(pool.Replicated.Size), 10)); err != nil {
 			return errors.Wrapf(err, "failed to set size property to replicated pool %q to %d", poolName, pool.Replicated.Size)
 		}
 	}
 
+	if clusterSpec.IsStretchCluster() && !clusterSpec.IsStretchMode() {
+		// Ceph stretch mode sets the min_size of the pools only with two data zones, with more data zones the
+		// min_size keeps the pool writable when a data zone is lost
+		minSize := strconv.FormatUint(uint64(clusterSpec.StretchPoolMinSize()), 10)
+		if err := SetPoolProperty(context, clusterInfo, poolName, "min_size", minSize); err != nil {
+			return errors.Wrapf(err, "failed to set min_size property to stretched pool %q to %s", poolName, minSize)
+		}
+	}
+
 	if err = setCommonPoolProperties(context, clusterInfo, pool, poolName, appName); err != nil {
 		return err
 	}
@@ -395,6 +494,10 @@ func createTwoStepCrushRule(context *clusterd.Context, clusterInfo *ClusterInfo,
 	if pool.Replicated.SubFailureDomain == "" {
 		pool.Replicated.SubFailureDomain = cephv1.DefaultFailureDomain
 	}
+	// set the replicas per failure domain to two if not already specified
+	if pool.Replicated.ReplicasPerFailureDomain == 0 {
+		pool.Replicated.ReplicasPerFailureDomain = defaultReplicasPerFailureDomain
+	}
 
 	if pool.FailureDomain == pool.Replicated.SubFailureDomain {
 		return errors.Errorf("failure and subfailure domains cannot be identical, current is %q", pool.FailureDomain)
@@ -230,6 +230,79 @@ func TestGetPoolStatistics(t *testing.T) {
 	assert.Nil(t, stats)
 }
 
+func TestCreatePoolWithAutoscaler(t *testing.T) {
+	targetSize := "100Gi"
+	p := cephv1.PoolSpec{
+		FailureDomain: "host",
+		ErasureCoded:  cephv1.ErasureCodedSpec{},
+		Autoscaler: cephv1.PGAutoscalerSpec{
+			Mode:       "warn",
+			PGNumMin:   16,
+			TargetSize: &targetSize,
+			Bulk:       true,
+		},
+	}
+	properties := map[string]string{}
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[1] == "pool" {
+			if args[2] == "set" {
+				assert.Equal(t, "mypool", args[3])
+				properties[args[4]] = args[5]
+			}
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	err := CreateECPoolForApp(context, AdminClusterInfo("mycluster"), "mypool", "mypoolprofile", p, DefaultPGCount, "myapp", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "warn", properties["pg_autoscale_mode"])
+	assert.Equal(t, "16", properties["pg_num_min"])
+	assert.Equal(t, "107374182400", properties["target_size_bytes"])
+	assert.Equal(t, "true", properties["bulk"])
+	_, ok := properties["target_size_ratio"]
+	assert.False(t, ok)
+
+	// the target size ratio of an erasure coded pool, the settings removed from the spec are reset
+	p.Autoscaler = cephv1.PGAutoscalerSpec{TargetSizeRatio: 0.2}
+	err = CreateECPoolForApp(context, AdminClusterInfo("mycluster"), "mypool", "mypoolprofile", p, DefaultPGCount, "myapp", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "0.2", properties["target_size_ratio"])
+	assert.Equal(t, "0", properties["pg_num_min"])
+	assert.Equal(t, "0", properties["target_size_bytes"])
+	assert.Equal(t, "false", properties["bulk"])
+
+	// the settings set in the parameters are not reset
+	properties = map[string]string{}
+	p.Autoscaler = cephv1.PGAutoscalerSpec{}
+	p.Parameters = map[string]string{"pg_num_min": "8"}
+	err = CreateECPoolForApp(context, AdminClusterInfo("mycluster"), "mypool", "mypoolprofile", p, DefaultPGCount, "myapp", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "8", properties["pg_num_min"])
+	assert.Equal(t, "false", properties["bulk"])
+}
+
+func TestGetPoolAutoscaleStatus(t *testing.T) {
+	executor := &exectest.MockExecutor{}
+	context := &clusterd.Context{Executor: executor}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		logger.Infof("Command: %s %v", command, args)
+		if args[0] == "osd" && args[1] == "pool" && args[2] == "autoscale-status" {
+			return `[{"pool_name":"replicapool","pool_id":1,"pg_autoscale_mode":"on","pg_num_target":32,"pg_num_final":128,"would_adjust":true},` +
+				`{"pool_name":"device_health_metrics","pool_id":2,"pg_autoscale_mode":"on","pg_num_target":1,"pg_num_final":1,"would_adjust":false}]`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+
+	status, err := GetPoolAutoscaleStatus(context, AdminClusterInfo("mycluster"))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(status))
+	assert.Equal(t, PoolAutoscaleStatus{PoolName: "replicapool", Mode: "on", PGNumTarget: 32, PGNumFinal: 128, WouldAdjust: true}, status[0])
+}
+
 func TestSetPoolReplicatedSizeProperty(t *testing.T) {
 	poolName := "mypool"
 	executor := &exectest.MockExecutor{}
@@ -0,0 +1,90 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"encoding/json"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/util/exec"
+)
+
+// CreateRadosNamespace creates a rbd namespace in a pool, it does nothing if the namespace already exists
+func CreateRadosNamespace(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string) error {
+	logger.Infof("creating rados namespace %q in pool %q", namespace, poolName)
+	args := []string{"namespace", "create", "--pool", poolName, "--namespace", namespace}
+	buf, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.EEXIST) {
+			logger.Debugf("rados namespace %q already exists in pool %q", namespace, poolName)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to create rados namespace %q in pool %q, output: %s", namespace, poolName, string(buf))
+	}
+
+	return nil
+}
+
+// DeleteRadosNamespace deletes a rbd namespace from a pool. The namespace is not deleted if it still contains
+// images or snapshots.
+func DeleteRadosNamespace(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string) error {
+	err := checkForImagesInRadosNamespace(context, clusterInfo, poolName, namespace)
+	if err != nil {
+		return errors.Wrapf(err, "failed to check if rados namespace %q of pool %q has rbd images", namespace, poolName)
+	}
+
+	logger.Infof("deleting rados namespace %q from pool %q", namespace, poolName)
+	args := []string{"namespace", "remove", "--pool", poolName, "--namespace", namespace}
+	buf, err := NewRBDCommand(context, clusterInfo, args).Run()
+	if err != nil {
+		if code, ok := exec.ExitStatus(err); ok && code == int(syscall.ENOENT) {
+			logger.Debugf("rados namespace %q not found in pool %q", namespace, poolName)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to delete rados namespace %q from pool %q, output: %s", namespace, poolName, string(buf))
+	}
+
+	return nil
+}
+
+func checkForImagesInRadosNamespace(context *clusterd.Context, clusterInfo *ClusterInfo, poolName, namespace string) error {
+	logger.Debugf("checking any images/snapshosts present in rados namespace %q of pool %q", namespace, poolName)
+	args := []string{"pool", "stats", poolName, "--namespace", namespace}
+	cmd := NewRBDCommand(context, clusterInfo, args)
+	cmd.JsonOutput = true
+	output, err := cmd.Run()
+	if err != nil {
+		if strings.Contains(string(output), "No such file or directory") || strings.Contains(err.Error(), "No such file or directory") {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to list images/snapshosts in rados namespace %q of pool %q", namespace, poolName)
+	}
+
+	var stats PoolStatistics
+	if err := json.Unmarshal(output, &stats); err != nil {
+		return errors.Wrap(err, "failed to unmarshal rados namespace stats response")
+	}
+	if stats.Images.Count == 0 && stats.Images.SnapCount == 0 {
+		logger.Infof("no images/snapshosts present in rados namespace %q of pool %q", namespace, poolName)
+		return nil
+	}
+
+	return errors.Errorf("rados namespace %q of pool %q contains images/snapshosts", namespace, poolName)
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateRadosNamespace(t *testing.T) {
+	createCalled := false
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		if command == "rbd" && args[0] == "namespace" && args[1] == "create" {
+			assert.Equal(t, []string{"--pool", "replicapool", "--namespace", "team-a"}, args[2:6])
+			createCalled = true
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected rbd command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+
+	err := CreateRadosNamespace(context, AdminClusterInfo("mycluster"), "replicapool", "team-a")
+	assert.NoError(t, err)
+	assert.True(t, createCalled)
+}
+
+func TestDeleteRadosNamespace(t *testing.T) {
+	removeCalled := false
+	stats := `{"images":{"count":1,"provisioned_bytes":1048576,"snap_count":0},"trash":{"count":0,"provisioned_bytes":0,"snap_count":0}}`
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutput = func(command string, args ...string) (string, error) {
+		switch {
+		case command == "rbd" && args[0] == "pool" && args[1] == "stats":
+			assert.Equal(t, []string{"replicapool", "--namespace", "team-a"}, args[2:5])
+			return stats, nil
+		case command == "rbd" && args[0] == "namespace" && args[1] == "remove":
+			removeCalled = true
+			return "", nil
+		}
+		return "", errors.Errorf("unexpected rbd command %q", args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	clusterInfo := AdminClusterInfo("mycluster")
+
+	// the namespace is not deleted while it contains images
+	err := DeleteRadosNamespace(context, clusterInfo, "replicapool", "team-a")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "contains images/snapshosts")
+	assert.False(t, removeCalled)
+
+	// the namespace is deleted once it is empty
+	stats = `{"images":{"count":0,"provisioned_bytes":0,"snap_count":0},"trash":{"count":0,"provisioned_bytes":0,"snap_count":0}}`
+	err = DeleteRadosNamespace(context, clusterInfo, "replicapool", "team-a")
+	assert.NoError(t, err)
+	assert.True(t, removeCalled)
+}
@@ -37,11 +37,16 @@ type OsdAgent struct {
 	storeConfig    config.StoreConfig
 	kv             *k8sutil.ConfigMapKVStore
 	pvcBacked      bool
+	// device mapper paths of the devices encrypted by Rook during this provisioning
+	encryptedBlocks []string
+	// IDs of the destroyed OSDs of the host to re-create by the path of their new device
+	replaceOSDs map[string]int
 }
 
 // NewAgent is the instantiation of the OSD agent
 func NewAgent(context *clusterd.Context, devices []DesiredDevice, metadataDevice string, forceFormat bool,
-	storeConfig config.StoreConfig, clusterInfo *cephclient.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore, pvcBacked bool) *OsdAgent {
+	storeConfig config.StoreConfig, clusterInfo *cephclient.ClusterInfo, nodeName string, kv *k8sutil.ConfigMapKVStore, pvcBacked bool,
+	replaceOSDs map[string]int) *OsdAgent {
 
 	return &OsdAgent{
 		devices:        devices,
@@ -52,9 +57,32 @@ func NewAgent(context *clusterd.Context, devices []DesiredDevice, metadataDevice
 		nodeName:       nodeName,
 		kv:             kv,
 		pvcBacked:      pvcBacked,
+		replaceOSDs:    replaceOSDs,
 	}
 }
 
+// anyDeviceEncrypted returns whether Rook must encrypt some of the devices of the node
+func (a *OsdAgent) anyDeviceEncrypted() bool {
+	if a.pvcBacked {
+		return false
+	}
+	if a.storeConfig.Encrypted {
+		return true
+	}
+	for _, device := range a.devices {
+		if device.Encrypted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDeviceEncrypted returns whether Rook must encrypt the device before preparing the osd on it
+func (a *OsdAgent) isDeviceEncrypted(device *DeviceOsdIDEntry) bool {
+	return !a.pvcBacked && (a.storeConfig.Encrypted || device.Config.Encrypted)
+}
+
 func getDeviceLVPath(context *clusterd.Context, deviceName string) string {
 	output, err := context.Executor.ExecuteCommandWithOutput("pvdisplay", "-C", "-o", "lvpath", "--noheadings", deviceName)
 	if err != nil {
@@ -166,7 +166,13 @@ func configRawDevice(name string, context *clusterd.Context) (*sys.LocalDisk, er
 func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation, topologyAffinity string) error {
 	if agent.pvcBacked {
 		// Init KMS store, retrieve the KEK and store it as an env var for ceph-volume
-		err := setKEKinEnv(context, agent.clusterInfo)
+		err := setKEKinEnv(context, agent.clusterInfo, os.Getenv(oposd.PVCNameEnvVarName))
+		if err != nil {
+			return errors.Wrap(err, "failed to set kek as an environment variable")
+		}
+	} else if agent.anyDeviceEncrypted() {
+		// Rook encrypts the devices of the node itself with the key of the node
+		err := setKEKinEnv(context, agent.clusterInfo, os.Getenv(oposd.KeyNameEnvVarName))
 		if err != nil {
 			return errors.Wrap(err, "failed to set kek as an environment variable")
 		}
@@ -211,6 +217,18 @@ func Provision(context *clusterd.Context, agent *OsdAgent, crushLocation, topolo
 
 	context.Devices = rawDevices
 
+	// The destroyed OSDs of the host are re-created on their new devices with the same IDs
+	if !agent.pvcBacked && len(agent.replaceOSDs) > 0 {
+		destroyedIDs, err := client.DestroyedOSDsOnHost(context, agent.clusterInfo, crushHostFromLocation(crushLocation))
+		if err != nil {
+			return errors.Wrap(err, "failed to get the destroyed osds of the host")
+		}
+		agent.replaceOSDs = destroyedOSDReplacements(agent.replaceOSDs, destroyedIDs)
+		for device, osdID := range agent.replaceOSDs {
+			logger.Infof("destroyed osd.%d will be re-created on device %q", osdID, device)
+		}
+	}
+
 	logger.Info("creating and starting the osds")
 
 	// determine the set of devices that can/should be used for OSDs.
@@ -301,6 +319,12 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 			continue
 		}
 
+		// Ignore the devices of the node already opened by Rook, the osd is prepared on top of them when they are created
+		if device.Type == sys.CryptType && isHostEncryptedDevice(device) {
+			logger.Infof("skipping encrypted device %q opened by rook", device.Name)
+			continue
+		}
+
 		// Ignore device with filesystem signature since c-v inventory
 		// cannot detect that correctly
 		// see: https://tracker.ceph.com/issues/43585
@@ -464,6 +488,32 @@ func getAvailableDevices(context *clusterd.Context, agent *OsdAgent) (*DeviceOsd
 	return available, nil
 }
 
+// crushHostFromLocation returns the CRUSH host of a CRUSH location like "root=default host=node-a"
+func crushHostFromLocation(crushLocation string) string {
+	for _, location := range strings.Fields(crushLocation) {
+		if strings.HasPrefix(location, "host=") {
+			return strings.TrimPrefix(location, "host=")
+		}
+	}
+	return ""
+}
+
+// destroyedOSDReplacements keeps the replacements of the OSDs that are still destroyed on the host, the others were
+// already re-created or purged
+func destroyedOSDReplacements(replaceOSDs map[string]int, destroyedIDs []int) map[string]int {
+	destroyed := map[int]bool{}
+	for _, id := range destroyedIDs {
+		destroyed[id] = true
+	}
+	replacements := map[string]int{}
+	for device, osdID := range replaceOSDs {
+		if destroyed[osdID] {
+			replacements[device] = osdID
+		}
+	}
+	return replacements
+}
+
 // releaseLVMDevice deactivates the LV to release the device.
 func releaseLVMDevice(context *clusterd.Context, volumeGroupName string) error {
 	if op, err := context.Executor.ExecuteCommandWithCombinedOutput("lvchange", "-an", "-vv", volumeGroupName); err != nil {
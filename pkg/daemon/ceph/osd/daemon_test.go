@@ -392,3 +392,15 @@ func TestGetVolumeGroupName(t *testing.T) {
 	vgName = getVolumeGroupName(invalidLVPath2)
 	assert.Equal(t, vgName, "")
 }
+
+func TestCrushHostFromLocation(t *testing.T) {
+	assert.Equal(t, "node-a", crushHostFromLocation("root=default host=node-a"))
+	assert.Equal(t, "node-a", crushHostFromLocation("root=default rack=rack1 host=node-a zone=z1"))
+	assert.Equal(t, "", crushHostFromLocation("root=default"))
+}
+
+func TestDestroyedOSDReplacements(t *testing.T) {
+	replaceOSDs := map[string]int{"/dev/disk/by-path/pci-a": 1, "/dev/disk/by-path/pci-b": 2}
+	assert.Equal(t, map[string]int{"/dev/disk/by-path/pci-b": 2}, destroyedOSDReplacements(replaceOSDs, []int{2, 5}))
+	assert.Equal(t, 0, len(destroyedOSDReplacements(replaceOSDs, []int{})))
+}
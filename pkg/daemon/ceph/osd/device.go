@@ -44,6 +44,7 @@ type DesiredDevice struct {
 	DeviceClass        string
 	IsFilter           bool
 	IsDevicePathFilter bool
+	Encrypted          bool
 }
 
 // DeviceOsdMapping represents the mapping of an OSD on disk
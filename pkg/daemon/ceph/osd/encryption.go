@@ -17,14 +17,19 @@ limitations under the License.
 package osd
 
 import (
+	"io/ioutil"
 	"os"
+	"path"
+	"strings"
 
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	v1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
 	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/util/sys"
 )
 
 const (
@@ -54,13 +59,13 @@ func dmsetupVersion(context *clusterd.Context) error {
 	return nil
 }
 
-func setKEKinEnv(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo) error {
+func setKEKinEnv(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, keyName string) error {
 	// KMS details are passed by the Operator as env variables in the pod
 	// The token if any is mounted in the provisioner pod as an env variable so the secrets lib will pick it up
 	kmsConfig := kms.NewConfig(context, &v1.ClusterSpec{Security: v1.SecuritySpec{KeyManagementService: v1.KeyManagementServiceSpec{ConnectionDetails: kms.ConfigEnvsToMapString()}}}, clusterInfo)
-	if kmsConfig.IsVault() {
+	if kmsConfig.IsVault() || kmsConfig.IsKMIP() || kmsConfig.IsMasterKeyEnabled() {
 		// Fetch the KEK
-		kek, err := kmsConfig.GetSecret(os.Getenv(oposd.PVCNameEnvVarName))
+		kek, err := kmsConfig.GetSecret(keyName)
 		if err != nil {
 			return errors.Wrapf(err, "failed to retrieve key encryption key from %q kms", kmsConfig.Provider)
 		}
@@ -74,3 +79,97 @@ func setKEKinEnv(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo)
 
 	return nil
 }
+
+// openHostEncryptedDevice formats a device of the node with LUKS and opens it with the key of the node
+// The device is left opened, the osd is then prepared on top of the returned device mapper path
+func (a *OsdAgent) openHostEncryptedDevice(context *clusterd.Context, device string) (string, error) {
+	// The key has been fetched from the KMS by setKEKinEnv() or comes from the Kubernetes Secret
+	key := os.Getenv(oposd.CephVolumeEncryptedKeyEnvVarName)
+	if key == "" {
+		return "", errors.Errorf("failed to find the encryption key of node %q", a.nodeName)
+	}
+
+	keyFile, err := ioutil.TempFile("", "luks-key")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create encryption key file")
+	}
+	defer os.Remove(keyFile.Name())
+	err = keyFile.Chmod(0400)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to set permissions of encryption key file %q", keyFile.Name())
+	}
+	_, err = keyFile.WriteString(key)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write encryption key file %q", keyFile.Name())
+	}
+	err = keyFile.Close()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to close encryption key file %q", keyFile.Name())
+	}
+
+	// The LUKS UUID names the device mapper so the osd deployment can open the device after a reboot
+	luksUUID := uuid.New().String()
+	dmName := oposd.HostEncryptionDMName(luksUUID)
+
+	logger.Infof("encrypting device %q with luks uuid %q", device, luksUUID)
+	args := []string{"--batch-mode", "--verbose", "--uuid", luksUUID, "--key-file", keyFile.Name(), "luksFormat", device}
+	cryptsetupOut, err := context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, args...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to format encrypted device %q. %s", device, cryptsetupOut)
+	}
+
+	args = []string{"luksOpen", "--verbose", "--disable-keyring", "--allow-discards", "--key-file", keyFile.Name(), device, dmName}
+	cryptsetupOut, err = context.Executor.ExecuteCommandWithCombinedOutput(cryptsetupBinary, args...)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to open encrypted device %q. %s", device, cryptsetupOut)
+	}
+
+	return path.Join("/dev/mapper", dmName), nil
+}
+
+// hostEncryptedDeviceUUID returns the LUKS UUID of a device of the node encrypted by Rook from its device mapper path
+// An empty string is returned for any other device
+func hostEncryptedDeviceUUID(dmPath string) string {
+	dmName := strings.TrimPrefix(dmPath, "/dev/mapper/")
+	if dmName == dmPath {
+		return ""
+	}
+	luksUUID := strings.TrimSuffix(dmName, "-"+oposd.DmcryptBlockType)
+	if luksUUID == dmName {
+		return ""
+	}
+	// The device mapper of the encrypted OSDs on PVC is named after the PVC
+	if _, err := uuid.Parse(luksUUID); err != nil {
+		return ""
+	}
+
+	return luksUUID
+}
+
+// isHostEncryptedDevice returns whether a discovered device is the device mapper of a device encrypted by Rook
+func isHostEncryptedDevice(device *sys.LocalDisk) bool {
+	paths := append(strings.Fields(device.DevLinks), device.Name, path.Join("/dev", device.Name))
+	for _, p := range paths {
+		if hostEncryptedDeviceUUID(p) != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WriteKEKToFile fetches the OSD key encryption key from the KMS and writes it where cryptsetup expects it
+func WriteKEKToFile(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, pvcName, keyPath string) error {
+	kmsConfig := kms.NewConfig(context, &v1.ClusterSpec{Security: v1.SecuritySpec{KeyManagementService: v1.KeyManagementServiceSpec{ConnectionDetails: kms.ConfigEnvsToMapString()}}}, clusterInfo)
+	kek, err := kmsConfig.GetSecret(pvcName)
+	if err != nil {
+		return errors.Wrapf(err, "failed to retrieve key encryption key from %q kms", kmsConfig.Provider)
+	}
+
+	err = ioutil.WriteFile(keyPath, []byte(kek), 0400)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write key encryption key to %q", keyPath)
+	}
+
+	return nil
+}
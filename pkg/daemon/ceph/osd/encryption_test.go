@@ -17,10 +17,14 @@ limitations under the License.
 package osd
 
 import (
+	"io/ioutil"
+	"os"
 	"testing"
 
 	"github.com/pkg/errors"
 	"github.com/rook/rook/pkg/clusterd"
+	oposd "github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
 )
@@ -60,3 +64,45 @@ Driver version:    4.40.0
 	err := dmsetupVersion(context)
 	assert.NoError(t, err)
 }
+
+func TestHostEncryptedDeviceUUID(t *testing.T) {
+	assert.Equal(t, "43e9efed-0676-4731-b75a-a4c42ece1bb1", hostEncryptedDeviceUUID("/dev/mapper/43e9efed-0676-4731-b75a-a4c42ece1bb1-block-dmcrypt"))
+	// Encrypted OSD on PVC
+	assert.Equal(t, "", hostEncryptedDeviceUUID("/dev/mapper/set1-data-0-7dwll-block-dmcrypt"))
+	assert.Equal(t, "", hostEncryptedDeviceUUID("/dev/mapper/43e9efed-0676-4731-b75a-a4c42ece1bb1-db-dmcrypt"))
+	assert.Equal(t, "", hostEncryptedDeviceUUID("/dev/sda"))
+	assert.Equal(t, "", hostEncryptedDeviceUUID(""))
+}
+
+func TestOpenHostEncryptedDevice(t *testing.T) {
+	var luksUUID string
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithCombinedOutput = func(command string, args ...string) (string, error) {
+		logger.Infof("%s %v", command, args)
+		if command == "cryptsetup" && args[2] == "--uuid" && args[6] == "luksFormat" && args[7] == "/dev/sdb" {
+			luksUUID = args[3]
+			key, err := ioutil.ReadFile(args[5])
+			assert.NoError(t, err)
+			assert.Equal(t, "my-dmcrypt-key", string(key))
+			return "", nil
+		}
+		if command == "cryptsetup" && args[0] == "luksOpen" && args[6] == "/dev/sdb" && args[7] == luksUUID+"-block-dmcrypt" {
+			return "", nil
+		}
+
+		return "", errors.Errorf("unknown command %s %s", command, args)
+	}
+	context := &clusterd.Context{Executor: executor}
+	a := &OsdAgent{nodeName: "node1", storeConfig: config.StoreConfig{Encrypted: true}}
+
+	// No key
+	_, err := a.openHostEncryptedDevice(context, "/dev/sdb")
+	assert.Error(t, err)
+
+	os.Setenv(oposd.CephVolumeEncryptedKeyEnvVarName, "my-dmcrypt-key")
+	defer os.Unsetenv(oposd.CephVolumeEncryptedKeyEnvVarName)
+	dmPath, err := a.openHostEncryptedDevice(context, "/dev/sdb")
+	assert.NoError(t, err)
+	assert.Equal(t, "/dev/mapper/"+luksUUID+"-block-dmcrypt", dmPath)
+	assert.Equal(t, luksUUID, hostEncryptedDeviceUUID(dmPath))
+}
@@ -29,7 +29,7 @@ import (
 )
 
 var (
-	knownKMSPrefix = []string{"VAULT_"}
+	knownKMSPrefix = []string{"VAULT_", "KMIP_", "KMS_"}
 )
 
 // VaultTokenEnvVarFromSecret returns the kms token secret value as an env var
@@ -69,18 +69,26 @@ func VaultConfigToEnvVar(spec cephv1.ClusterSpec) []v1.EnvVar {
 	// Set BACKEND_PATH to the API's default if not passed
 	if backendPath == "" {
 		spec.Security.KeyManagementService.ConnectionDetails[vault.VaultBackendPathKey] = vault.DefaultBackendPath
+		if VaultTransitEnabled(spec.Security.KeyManagementService.ConnectionDetails) {
+			spec.Security.KeyManagementService.ConnectionDetails[vault.VaultBackendPathKey] = defaultVaultTransitBackendPath
+		}
 	}
 	for k, v := range spec.Security.KeyManagementService.ConnectionDetails {
-		// Skip TLS and token env var to avoid env being set multiple times
-		toSkip := append(vaultTLSConnectionDetails, api.EnvVaultToken)
+		// Skip TLS, token and token path env var to avoid env being set multiple times
+		toSkip := append(vaultTLSConnectionDetails, api.EnvVaultToken, vault.AuthKubernetesTokenPath)
 		if client.StringInSlice(k, toSkip) {
 			continue
 		}
 		envs = append(envs, v1.EnvVar{Name: k, Value: v})
 	}
 
-	// Add the VAULT_TOKEN
-	envs = append(envs, vaultTokenEnvVarFromSecret(spec.Security.KeyManagementService.TokenSecretName))
+	// Add the VAULT_TOKEN, or the projected service account token used to login
+	if spec.Security.KeyManagementService.IsTokenAuthEnabled() {
+		envs = append(envs, vaultTokenEnvVarFromSecret(spec.Security.KeyManagementService.TokenSecretName))
+	}
+	if VaultKubernetesAuthEnabled(spec.Security.KeyManagementService.ConnectionDetails) {
+		envs = append(envs, v1.EnvVar{Name: vault.AuthKubernetesTokenPath, Value: path.Join(VaultServiceAccountTokenDir, vaultServiceAccountTokenFileName)})
+	}
 
 	// Add TLS env if any
 	envs = append(envs, vaultTLSEnvVarFromSecret(spec.Security.KeyManagementService.ConnectionDetails)...)
@@ -90,6 +98,68 @@ func VaultConfigToEnvVar(spec cephv1.ClusterSpec) []v1.EnvVar {
 	return envs
 }
 
+// VaultTransitCiphertextEnvVar returns the ciphertext of the OSD key as an env variable
+func VaultTransitCiphertextEnvVar(secretName string) v1.EnvVar {
+	return v1.EnvVar{
+		Name: VaultTransitCiphertextKey,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: GenerateOSDEncryptionSecretName(secretName),
+				},
+				Key: VaultTransitCiphertextSecretKeyName,
+			},
+		},
+	}
+}
+
+// KMIPConfigToEnvVar populates the kmip config as env variables
+// The TLS Secret name is replaced by its mount path and the key identifier is read from the OSD Secret
+func KMIPConfigToEnvVar(spec cephv1.ClusterSpec, secretName string) []v1.EnvVar {
+	envs := []v1.EnvVar{}
+	for k, v := range spec.Security.KeyManagementService.ConnectionDetails {
+		if k == KMIPTLSSecretNameKey {
+			v = EtcKMIPDir
+		}
+		envs = append(envs, v1.EnvVar{Name: k, Value: v})
+	}
+
+	// Add the identifier of the key
+	envs = append(envs, v1.EnvVar{
+		Name: KMIPUniqueIdentifierKey,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{
+					Name: GenerateOSDEncryptionSecretName(secretName),
+				},
+				Key: KMIPUniqueIdentifierSecretKeyName,
+			},
+		},
+	})
+
+	logger.Debugf("kms envs are %v", envs)
+
+	return envs
+}
+
+// MasterKeyConfigToEnvVar populates the master key location and the wrapped OSD key as env variables
+func MasterKeyConfigToEnvVar(secretName string) []v1.EnvVar {
+	return []v1.EnvVar{
+		{Name: MasterKeyPathKey, Value: EtcMasterKeyDir},
+		{
+			Name: WrappedKeyKey,
+			ValueFrom: &v1.EnvVarSource{
+				SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: GenerateOSDEncryptionSecretName(secretName),
+					},
+					Key: OsdEncryptionSecretNameKeyName,
+				},
+			},
+		},
+	}
+}
+
 // ConfigEnvsToMapString returns all the env variables in map from a known KMS
 func ConfigEnvsToMapString() map[string]string {
 	envs := make(map[string]string)
@@ -44,6 +44,22 @@ func TestVaultTLSEnvVarFromSecret(t *testing.T) {
 	assert.Contains(t, envVars, v1.EnvVar{Name: "VAULT_CACERT", Value: "/etc/vault/vault.ca"})
 	assert.Contains(t, envVars, v1.EnvVar{Name: "VAULT_TOKEN", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "vault-token"}, Key: "token"}}})
 
+	// Kubernetes auth and transit
+	spec = cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{"KMS_PROVIDER": "vault", "VAULT_ADDR": "http://1.1.1.1:8200", "VAULT_SECRET_ENGINE": "transit", "VAULT_AUTH_METHOD": "kubernetes", "VAULT_AUTH_KUBERNETES_ROLE": "rook-ceph-osd", "VAULT_AUTH_KUBERNETES_TOKEN_PATH": "/foo"}}}}
+	envVars = VaultConfigToEnvVar(spec)
+	assert.Equal(t, 7, len(envVars))
+	assert.Contains(t, envVars, v1.EnvVar{Name: "VAULT_BACKEND_PATH", Value: "transit"})
+	assert.Contains(t, envVars, v1.EnvVar{Name: "VAULT_AUTH_KUBERNETES_TOKEN_PATH", Value: "/var/run/secrets/rook-vault/token"})
+	for _, env := range envVars {
+		assert.NotEqual(t, "VAULT_TOKEN", env.Name)
+	}
+}
+
+func TestVaultTransitCiphertextEnvVar(t *testing.T) {
+	env := VaultTransitCiphertextEnvVar("set1-data-0-7dwll")
+	assert.Equal(t, "VAULT_TRANSIT_CIPHERTEXT", env.Name)
+	assert.Equal(t, "rook-ceph-osd-encryption-key-set1-data-0-7dwll", env.ValueFrom.SecretKeyRef.Name)
+	assert.Equal(t, "vault-transit-ciphertext", env.ValueFrom.SecretKeyRef.Key)
 }
 
 func TestConfigEnvsToMapString(t *testing.T) {
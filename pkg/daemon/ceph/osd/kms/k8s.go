@@ -43,6 +43,15 @@ const (
 // storeSecretInKubernetes stores the dmcrypt key in a Kubernetes Secret
 func (c *Config) storeSecretInKubernetes(pvcName, key string) error {
 	ctx := context.TODO()
+	// With envelope encryption only the wrapped key is stored
+	if c.IsMasterKeyEnabled() {
+		wrapped, err := c.wrapKey(pvcName, key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to wrap ceph osd encryption key for pvc %q", pvcName)
+		}
+		key = wrapped
+	}
+
 	s, err := generateOSDEncryptedKeySecret(pvcName, key, c.clusterInfo)
 	if err != nil {
 		return err
@@ -57,6 +66,34 @@ func (c *Config) storeSecretInKubernetes(pvcName, key string) error {
 	return nil
 }
 
+// updateSecretInKubernetes replaces the dmcrypt key in the Kubernetes Secret
+func (c *Config) updateSecretInKubernetes(pvcName, key string) error {
+	ctx := context.TODO()
+	if c.IsMasterKeyEnabled() {
+		wrapped, err := c.wrapKey(pvcName, key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to wrap ceph osd encryption key for pvc %q", pvcName)
+		}
+		key = wrapped
+	}
+
+	s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(pvcName), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get ceph osd encryption key secret for pvc %q", pvcName)
+	}
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+	s.Data[OsdEncryptionSecretNameKeyName] = []byte(key)
+
+	_, err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Update(ctx, s, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update ceph osd encryption key secret for pvc %q", pvcName)
+	}
+
+	return nil
+}
+
 func generateOSDEncryptedKeySecret(pvcName, key string, clusterInfo *cephclient.ClusterInfo) (*v1.Secret, error) {
 	s := &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
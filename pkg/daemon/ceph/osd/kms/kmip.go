@@ -0,0 +1,435 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TypeKMIP is the KMIP KMS provider type
+	TypeKMIP = "kmip"
+	// EtcKMIPDir is the directory where the KMIP TLS material is mounted
+	EtcKMIPDir = "/etc/kmip"
+	// KMIPEndpointKey is the address (host:port) of the KMIP server
+	KMIPEndpointKey = "KMIP_ENDPOINT"
+	// KMIPTLSSecretNameKey is the name of the Secret holding the CA and client certificate
+	KMIPTLSSecretNameKey = "KMIP_TLS_SECRET_NAME"
+	// KMIPTLSServerNameKey overrides the server name used to verify the KMIP server certificate
+	KMIPTLSServerNameKey = "KMIP_TLS_SERVER_NAME"
+	// KMIPUniqueIdentifierKey carries the KMIP object identifier of an OSD key to the OSD pods
+	KMIPUniqueIdentifierKey = "KMIP_UNIQUE_IDENTIFIER"
+	// KMIPUniqueIdentifierSecretKeyName is the key of the OSD Secret holding the KMIP object identifier
+	KMIPUniqueIdentifierSecretKeyName = "kmip-unique-identifier"
+
+	// Key names of the KMIP TLS Secret, they follow the kubernetes.io/tls Secret layout
+	kmipCACertSecretKeyName = "ca.crt"
+
+	kmipDefaultTimeout = 10 * time.Second
+
+	// KMIP protocol values
+	kmipProtocolMajor              = 1
+	kmipProtocolMinor              = 4
+	kmipOperationRegister   uint32 = 0x03
+	kmipOperationGet        uint32 = 0x0A
+	kmipOperationDestroy    uint32 = 0x14
+	kmipObjectSecretData    uint32 = 0x07
+	kmipSecretDataPassword  uint32 = 0x01
+	kmipKeyFormatOpaque     uint32 = 0x0F
+	kmipNameUninterpreted   uint32 = 0x01
+	kmipResultStatusSuccess uint32 = 0x00
+)
+
+var (
+	kmipMandatoryConnectionDetails = []string{KMIPEndpointKey, KMIPTLSSecretNameKey}
+	kmipTLSSecretKeys              = []string{kmipCACertSecretKeyName, v1.TLSCertKey, v1.TLSPrivateKeyKey}
+)
+
+// kmipClient talks to a KMIP server over mutually authenticated TLS
+type kmipClient struct {
+	endpoint  string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+}
+
+// newKMIPClient builds a KMIP client from the KMS connection details
+func newKMIPClient(context *clusterd.Context, namespace string, config map[string]string) (*kmipClient, error) {
+	tlsMaterial, err := kmipTLSMaterial(context, namespace, config)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load kmip tls material")
+	}
+
+	tlsConfig, err := kmipTLSConfig(tlsMaterial, GetParam(config, KMIPTLSServerNameKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build kmip tls configuration")
+	}
+
+	return &kmipClient{
+		endpoint:  GetParam(config, KMIPEndpointKey),
+		tlsConfig: tlsConfig,
+		timeout:   kmipDefaultTimeout,
+	}, nil
+}
+
+// kmipTLSMaterial returns the CA, client certificate and key
+// In the operator they come from the Secret, in the OSD pods the Secret is mounted under EtcKMIPDir
+func kmipTLSMaterial(clusterdContext *clusterd.Context, namespace string, config map[string]string) (map[string][]byte, error) {
+	ctx := context.TODO()
+	tlsSecretName := GetParam(config, KMIPTLSSecretNameKey)
+	material := make(map[string][]byte)
+
+	if strings.HasPrefix(tlsSecretName, EtcKMIPDir) {
+		for _, key := range kmipTLSSecretKeys {
+			b, err := ioutil.ReadFile(path.Join(tlsSecretName, key))
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to read kmip tls file %q", key)
+			}
+			material[key] = b
+		}
+		return material, nil
+	}
+
+	secret, err := clusterdContext.Clientset.CoreV1().Secrets(namespace).Get(ctx, tlsSecretName, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to fetch kmip tls k8s secret %q", tlsSecretName)
+	}
+	for _, key := range kmipTLSSecretKeys {
+		material[key] = secret.Data[key]
+	}
+
+	return material, nil
+}
+
+func kmipTLSConfig(material map[string][]byte, serverName string) (*tls.Config, error) {
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(material[kmipCACertSecretKeyName]) {
+		return nil, errors.New("failed to parse kmip ca certificate")
+	}
+
+	clientCert, err := tls.X509KeyPair(material[v1.TLSCertKey], material[v1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse kmip client certificate")
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		RootCAs:      caPool,
+		Certificates: []tls.Certificate{clientCert},
+		ServerName:   serverName,
+	}, nil
+}
+
+// send runs a single operation against the KMIP server and returns the response payload
+func (k *kmipClient) send(operation uint32, payload ttlvItem) (ttlvItem, error) {
+	request := ttlvStruct(tagRequestMessage,
+		ttlvStruct(tagRequestHeader,
+			ttlvStruct(tagProtocolVersion,
+				ttlvInt(tagProtocolVersionMajor, kmipProtocolMajor),
+				ttlvInt(tagProtocolVersionMinor, kmipProtocolMinor),
+			),
+			ttlvInt(tagBatchCount, 1),
+		),
+		ttlvStruct(tagBatchItem,
+			ttlvEnum(tagOperation, operation),
+			payload,
+		),
+	)
+	b, err := request.marshal()
+	if err != nil {
+		return ttlvItem{}, errors.Wrap(err, "failed to encode kmip request")
+	}
+
+	dialer := &net.Dialer{Timeout: k.timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", k.endpoint, k.tlsConfig)
+	if err != nil {
+		return ttlvItem{}, errors.Wrapf(err, "failed to connect to kmip server %q", k.endpoint)
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(k.timeout))
+	if err != nil {
+		return ttlvItem{}, errors.Wrap(err, "failed to set kmip connection deadline")
+	}
+
+	if _, err := conn.Write(b); err != nil {
+		return ttlvItem{}, errors.Wrap(err, "failed to send kmip request")
+	}
+
+	response, err := readTTLV(conn)
+	if err != nil {
+		return ttlvItem{}, errors.Wrap(err, "failed to read kmip response")
+	}
+	if response.tag != tagResponseMessage {
+		return ttlvItem{}, errors.Errorf("unexpected kmip response tag %06x", response.tag)
+	}
+
+	batchItem, ok := response.child(tagBatchItem)
+	if !ok {
+		return ttlvItem{}, errors.New("kmip response has no batch item")
+	}
+	status, ok := batchItem.child(tagResultStatus)
+	if !ok {
+		return ttlvItem{}, errors.New("kmip response has no result status")
+	}
+	if status.enum() != kmipResultStatusSuccess {
+		reason, _ := batchItem.child(tagResultReason)
+		message, _ := batchItem.child(tagResultMessage)
+		return ttlvItem{}, errors.Errorf("kmip operation %x failed with status %d reason %d. %s", operation, status.enum(), reason.enum(), message.text())
+	}
+
+	responsePayload, _ := batchItem.child(tagResponsePayload)
+	return responsePayload, nil
+}
+
+// register stores a secret in the KMIP server and returns its unique identifier
+func (k *kmipClient) register(name, secretValue string) (string, error) {
+	payload := ttlvStruct(tagRequestPayload,
+		ttlvEnum(tagObjectType, kmipObjectSecretData),
+		ttlvStruct(tagTemplateAttribute,
+			ttlvStruct(tagAttribute,
+				ttlvText(tagAttributeName, "Name"),
+				ttlvStruct(tagAttributeValue,
+					ttlvText(tagNameValue, name),
+					ttlvEnum(tagNameType, kmipNameUninterpreted),
+				),
+			),
+		),
+		ttlvStruct(tagSecretData,
+			ttlvEnum(tagSecretDataType, kmipSecretDataPassword),
+			ttlvStruct(tagKeyBlock,
+				ttlvEnum(tagKeyFormatType, kmipKeyFormatOpaque),
+				ttlvStruct(tagKeyValue,
+					ttlvBytes(tagKeyMaterial, []byte(secretValue)),
+				),
+			),
+		),
+	)
+
+	response, err := k.send(kmipOperationRegister, payload)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to register key %q", name)
+	}
+	uid, ok := response.child(tagUniqueIdentifier)
+	if !ok || uid.text() == "" {
+		return "", errors.Errorf("kmip server did not return an identifier for key %q", name)
+	}
+
+	return uid.text(), nil
+}
+
+// get retrieves the secret stored under the unique identifier
+func (k *kmipClient) get(uid string) (string, error) {
+	response, err := k.send(kmipOperationGet, ttlvStruct(tagRequestPayload, ttlvText(tagUniqueIdentifier, uid)))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get key %q", uid)
+	}
+	material, ok := response.path(tagSecretData, tagKeyBlock, tagKeyValue, tagKeyMaterial)
+	if !ok {
+		return "", errors.Errorf("kmip server returned no secret data for key %q", uid)
+	}
+
+	return string(material.bytes()), nil
+}
+
+// destroy removes the secret stored under the unique identifier
+func (k *kmipClient) destroy(uid string) error {
+	_, err := k.send(kmipOperationDestroy, ttlvStruct(tagRequestPayload, ttlvText(tagUniqueIdentifier, uid)))
+	if err != nil {
+		return errors.Wrapf(err, "failed to destroy key %q", uid)
+	}
+
+	return nil
+}
+
+// IsKMIP determines whether the configured KMS is KMIP
+func (c *Config) IsKMIP() bool {
+	return c.Provider == TypeKMIP
+}
+
+// putKMIP registers the key in the KMIP server and keeps its identifier in a Kubernetes Secret
+func (c *Config) putKMIP(secretName, secretValue string) error {
+	ctx := context.TODO()
+	// The key is only registered once, the operator calls PutSecret on every reconcile
+	_, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(secretName), metav1.GetOptions{})
+	if err == nil {
+		logger.Debugf("key %q already exists in kmip", secretName)
+		return nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get kmip identifier secret for %q", secretName)
+	}
+
+	k, err := newKMIPClient(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return errors.Wrap(err, "failed to init kmip kms")
+	}
+	uid, err := k.register(kmipKeyName(c.clusterInfo.Namespace, secretName), secretValue)
+	if err != nil {
+		return err
+	}
+
+	s, err := generateOSDEncryptedKeySecret(secretName, "", c.clusterInfo)
+	if err != nil {
+		return err
+	}
+	s.StringData = nil
+	s.Data = map[string][]byte{KMIPUniqueIdentifierSecretKeyName: []byte(uid)}
+	_, err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Create(ctx, s, metav1.CreateOptions{})
+	if err != nil {
+		// Do not leave an orphan key behind in the KMIP server
+		if destroyErr := k.destroy(uid); destroyErr != nil {
+			logger.Errorf("failed to destroy orphan kmip key %q. %v", uid, destroyErr)
+		}
+		return errors.Wrapf(err, "failed to save kmip identifier secret for %q", secretName)
+	}
+
+	return nil
+}
+
+// updateKMIP registers a new key, points the Kubernetes Secret to it and destroys the previous one
+func (c *Config) updateKMIP(secretName, secretValue string) error {
+	ctx := context.TODO()
+	s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(secretName), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get kmip identifier secret for %q", secretName)
+	}
+	previousUID := string(s.Data[KMIPUniqueIdentifierSecretKeyName])
+
+	k, err := newKMIPClient(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return errors.Wrap(err, "failed to init kmip kms")
+	}
+	uid, err := k.register(kmipKeyName(c.clusterInfo.Namespace, secretName), secretValue)
+	if err != nil {
+		return err
+	}
+
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+	s.Data[KMIPUniqueIdentifierSecretKeyName] = []byte(uid)
+	_, err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Update(ctx, s, metav1.UpdateOptions{})
+	if err != nil {
+		if destroyErr := k.destroy(uid); destroyErr != nil {
+			logger.Errorf("failed to destroy orphan kmip key %q. %v", uid, destroyErr)
+		}
+		return errors.Wrapf(err, "failed to update kmip identifier secret for %q", secretName)
+	}
+
+	// The previous key is not needed anymore, failing to destroy it does not fail the update
+	if previousUID != "" {
+		if err := k.destroy(previousUID); err != nil {
+			logger.Errorf("failed to destroy previous kmip key %q of %q. %v", previousUID, secretName, err)
+		}
+	}
+
+	return nil
+}
+
+// kmipUniqueIdentifier returns the KMIP identifier of an OSD key
+// The OSD pods receive it as an env variable since they cannot read Secrets
+func (c *Config) kmipUniqueIdentifier(secretName string) (string, error) {
+	ctx := context.TODO()
+	if uid := GetParam(c.clusterSpec.Security.KeyManagementService.ConnectionDetails, KMIPUniqueIdentifierKey); uid != "" {
+		return uid, nil
+	}
+
+	s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(secretName), metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get kmip identifier secret for %q", secretName)
+	}
+	uid, ok := s.Data[KMIPUniqueIdentifierSecretKeyName]
+	if !ok || len(uid) == 0 {
+		return "", errors.Errorf("kmip identifier secret for %q has no key %q", secretName, KMIPUniqueIdentifierSecretKeyName)
+	}
+
+	return string(uid), nil
+}
+
+func (c *Config) getKMIP(secretName string) (string, error) {
+	uid, err := c.kmipUniqueIdentifier(secretName)
+	if err != nil {
+		return "", err
+	}
+	k, err := newKMIPClient(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to init kmip kms")
+	}
+
+	return k.get(uid)
+}
+
+func (c *Config) deleteKMIP(secretName string) error {
+	uid, err := c.kmipUniqueIdentifier(secretName)
+	if err != nil {
+		return err
+	}
+	k, err := newKMIPClient(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return errors.Wrap(err, "failed to init kmip kms")
+	}
+
+	return k.destroy(uid)
+}
+
+// kmipKeyName is the name given to the key object in the KMIP server, useful to audit the HSM content
+func kmipKeyName(namespace, secretName string) string {
+	return fmt.Sprintf("%s-%s", namespace, GenerateOSDEncryptionSecretName(secretName))
+}
+
+func validateKMIPConnectionDetails(clusterdContext *clusterd.Context, ns string, kmsConfig map[string]string) error {
+	ctx := context.TODO()
+	for _, option := range kmipMandatoryConnectionDetails {
+		if GetParam(kmsConfig, option) == "" {
+			return errors.Errorf("failed to find connection details %q", option)
+		}
+	}
+
+	if _, _, err := net.SplitHostPort(GetParam(kmsConfig, KMIPEndpointKey)); err != nil {
+		return errors.Wrapf(err, "failed to parse %q, expected host:port", KMIPEndpointKey)
+	}
+
+	tlsSecretName := GetParam(kmsConfig, KMIPTLSSecretNameKey)
+	s, err := clusterdContext.Clientset.CoreV1().Secrets(ns).Get(ctx, tlsSecretName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Errorf("failed to find TLS connection details k8s secret %q", tlsSecretName)
+	}
+	for _, key := range kmipTLSSecretKeys {
+		if len(s.Data[key]) == 0 {
+			return errors.Errorf("failed to find TLS connection key %q in k8s secret %q", key, tlsSecretName)
+		}
+	}
+	if _, err := kmipTLSConfig(s.Data, GetParam(kmsConfig, KMIPTLSServerNameKey)); err != nil {
+		return errors.Wrapf(err, "failed to validate TLS connection details in k8s secret %q", tlsSecretName)
+	}
+
+	return nil
+}
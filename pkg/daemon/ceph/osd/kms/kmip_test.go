@@ -0,0 +1,321 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeKMIPServer is a minimal KMIP stand-in that keeps registered secrets in memory
+type fakeKMIPServer struct {
+	listener net.Listener
+	mutex    sync.Mutex
+	objects  map[string][]byte
+	nextID   int
+}
+
+func newFakeKMIPServer(t *testing.T, serverCert tls.Certificate, caPool *x509.CertPool) *fakeKMIPServer {
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	assert.NoError(t, err)
+
+	s := &fakeKMIPServer{listener: listener, objects: map[string][]byte{}}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+
+	return s
+}
+
+func (s *fakeKMIPServer) handle(conn net.Conn) {
+	defer conn.Close()
+	request, err := readTTLV(conn)
+	if err != nil {
+		return
+	}
+	operation, _ := request.path(tagBatchItem, tagOperation)
+	payload, _ := request.path(tagBatchItem, tagRequestPayload)
+
+	s.mutex.Lock()
+	var responsePayload []ttlvItem
+	status := kmipResultStatusSuccess
+	switch operation.enum() {
+	case kmipOperationRegister:
+		material, _ := payload.path(tagSecretData, tagKeyBlock, tagKeyValue, tagKeyMaterial)
+		s.nextID++
+		uid := fmt.Sprintf("%d", s.nextID)
+		s.objects[uid] = material.bytes()
+		responsePayload = []ttlvItem{ttlvText(tagUniqueIdentifier, uid)}
+	case kmipOperationGet:
+		uid, _ := payload.child(tagUniqueIdentifier)
+		material, ok := s.objects[uid.text()]
+		if !ok {
+			status = 1
+			break
+		}
+		responsePayload = []ttlvItem{
+			ttlvEnum(tagObjectType, kmipObjectSecretData),
+			ttlvText(tagUniqueIdentifier, uid.text()),
+			ttlvStruct(tagSecretData,
+				ttlvEnum(tagSecretDataType, kmipSecretDataPassword),
+				ttlvStruct(tagKeyBlock,
+					ttlvEnum(tagKeyFormatType, kmipKeyFormatOpaque),
+					ttlvStruct(tagKeyValue, ttlvBytes(tagKeyMaterial, material)),
+				),
+			),
+		}
+	case kmipOperationDestroy:
+		uid, _ := payload.child(tagUniqueIdentifier)
+		if _, ok := s.objects[uid.text()]; !ok {
+			status = 1
+			break
+		}
+		// the builtin delete is shadowed by the vault helper of the package
+		objects := map[string][]byte{}
+		for id, material := range s.objects {
+			if id != uid.text() {
+				objects[id] = material
+			}
+		}
+		s.objects = objects
+		responsePayload = []ttlvItem{ttlvText(tagUniqueIdentifier, uid.text())}
+	default:
+		status = 1
+	}
+	s.mutex.Unlock()
+
+	batchItem := []ttlvItem{ttlvEnum(tagOperation, operation.enum()), ttlvEnum(tagResultStatus, status)}
+	if status == kmipResultStatusSuccess {
+		batchItem = append(batchItem, ttlvStruct(tagResponsePayload, responsePayload...))
+	} else {
+		batchItem = append(batchItem, ttlvEnum(tagResultReason, 1), ttlvText(tagResultMessage, "item not found"))
+	}
+	response := ttlvStruct(tagResponseMessage,
+		ttlvStruct(tagResponseHeader, ttlvInt(tagBatchCount, 1)),
+		ttlvStruct(tagBatchItem, batchItem...),
+	)
+	b, err := response.marshal()
+	if err != nil {
+		return
+	}
+	_, _ = conn.Write(b)
+}
+
+// generateTestCertificates returns a CA and two certificates signed by it in PEM format
+func generateTestCertificates(t *testing.T) (caPEM, serverCertPEM, serverKeyPEM, clientCertPEM, clientKeyPEM []byte) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kmip-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	assert.NoError(t, err)
+
+	issue := func(serial int64, usage x509.ExtKeyUsage) ([]byte, []byte) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: "localhost"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		assert.NoError(t, err)
+		keyDER, err := x509.MarshalECPrivateKey(key)
+		assert.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	}
+
+	caPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})
+	serverCertPEM, serverKeyPEM = issue(2, x509.ExtKeyUsageServerAuth)
+	clientCertPEM, clientKeyPEM = issue(3, x509.ExtKeyUsageClientAuth)
+	return
+}
+
+func TestKMIPSecretLifecycle(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	context := &clusterd.Context{Clientset: test.New(t, 3)}
+
+	caPEM, serverCertPEM, serverKeyPEM, clientCertPEM, clientKeyPEM := generateTestCertificates(t)
+	serverCert, err := tls.X509KeyPair(serverCertPEM, serverKeyPEM)
+	assert.NoError(t, err)
+	caPool := x509.NewCertPool()
+	assert.True(t, caPool.AppendCertsFromPEM(caPEM))
+	server := newFakeKMIPServer(t, serverCert, caPool)
+	defer server.listener.Close()
+
+	tlsSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kmip-tls", Namespace: ns},
+		Data:       map[string][]byte{"ca.crt": caPEM, "tls.crt": clientCertPEM, "tls.key": clientKeyPEM},
+	}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Create(ctx, tlsSecret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	clusterSpec := &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{
+		"KMS_PROVIDER":         "kmip",
+		"KMIP_ENDPOINT":        server.listener.Addr().String(),
+		"KMIP_TLS_SECRET_NAME": "kmip-tls",
+	}}}}
+	clusterInfo := cephclient.AdminClusterInfo(ns)
+	c := NewConfig(context, clusterSpec, clusterInfo)
+	assert.True(t, c.IsKMIP())
+
+	// Register the key
+	err = c.PutSecret("set1-data-0-7dwll", "my-dmcrypt-key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(server.objects))
+	s, err := context.Clientset.CoreV1().Secrets(ns).Get(ctx, "rook-ceph-osd-encryption-key-set1-data-0-7dwll", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "1", string(s.Data["kmip-unique-identifier"]))
+
+	// A second put is a no-op
+	err = c.PutSecret("set1-data-0-7dwll", "another-key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(server.objects))
+
+	// Fetch the key
+	value, err := c.GetSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-dmcrypt-key", value)
+
+	// The identifier passed as env variable takes precedence over the Secret
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["KMIP_UNIQUE_IDENTIFIER"] = "42"
+	_, err = c.GetSecret("set1-data-0-7dwll")
+	assert.Error(t, err)
+	connectionDetails := map[string]string{}
+	for key, value := range clusterSpec.Security.KeyManagementService.ConnectionDetails {
+		if key != "KMIP_UNIQUE_IDENTIFIER" {
+			connectionDetails[key] = value
+		}
+	}
+	clusterSpec.Security.KeyManagementService.ConnectionDetails = connectionDetails
+
+	// Destroy the key
+	err = c.DeleteSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(server.objects))
+	_, err = c.GetSecret("set1-data-0-7dwll")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "item not found")
+
+	// The client certificate is mandatory
+	tlsSecret.Data = map[string][]byte{"ca.crt": caPEM}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, tlsSecret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	err = c.PutSecret("set1-data-1-xfmrs", "my-dmcrypt-key")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse kmip client certificate")
+}
+
+func TestValidateKMIPConnectionDetails(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	context := &clusterd.Context{Clientset: test.New(t, 3)}
+	clusterSpec := &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{"KMS_PROVIDER": "kmip"}}}}
+
+	// Error: no endpoint, but no token is required
+	err := ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate kmip connection details: failed to find connection details \"KMIP_ENDPOINT\"")
+
+	// Error: no TLS secret
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["KMIP_ENDPOINT"] = "kmip.example.com"
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate kmip connection details: failed to find connection details \"KMIP_TLS_SECRET_NAME\"")
+
+	// Error: endpoint has no port
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["KMIP_TLS_SECRET_NAME"] = "kmip-tls"
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to parse \"KMIP_ENDPOINT\", expected host:port")
+
+	// Error: TLS secret does not exist
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["KMIP_ENDPOINT"] = "kmip.example.com:5696"
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate kmip connection details: failed to find TLS connection details k8s secret \"kmip-tls\"")
+
+	// Error: TLS secret is incomplete
+	caPEM, _, _, clientCertPEM, clientKeyPEM := generateTestCertificates(t)
+	tlsSecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "kmip-tls", Namespace: ns},
+		Data:       map[string][]byte{"ca.crt": caPEM, "tls.crt": clientCertPEM},
+	}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Create(ctx, tlsSecret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate kmip connection details: failed to find TLS connection key \"tls.key\" in k8s secret \"kmip-tls\"")
+
+	// Success
+	tlsSecret.Data["tls.key"] = clientKeyPEM
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, tlsSecret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.NoError(t, err)
+}
+
+func TestKMIPConfigToEnvVar(t *testing.T) {
+	spec := cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{"KMS_PROVIDER": "kmip", "KMIP_ENDPOINT": "1.1.1.1:5696", "KMIP_TLS_SECRET_NAME": "kmip-tls"}}}}
+	envVars := KMIPConfigToEnvVar(spec, "set1-data-0-7dwll")
+	assert.Equal(t, 4, len(envVars))
+	assert.Contains(t, envVars, v1.EnvVar{Name: "KMS_PROVIDER", Value: "kmip"})
+	assert.Contains(t, envVars, v1.EnvVar{Name: "KMIP_ENDPOINT", Value: "1.1.1.1:5696"})
+	assert.Contains(t, envVars, v1.EnvVar{Name: "KMIP_TLS_SECRET_NAME", Value: "/etc/kmip"})
+	assert.Contains(t, envVars, v1.EnvVar{Name: "KMIP_UNIQUE_IDENTIFIER", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{LocalObjectReference: v1.LocalObjectReference{Name: "rook-ceph-osd-encryption-key-set1-data-0-7dwll"}, Key: "kmip-unique-identifier"}}})
+}
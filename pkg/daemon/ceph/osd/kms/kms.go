@@ -63,6 +63,8 @@ func NewConfig(context *clusterd.Context, clusterSpec *cephv1.ClusterSpec, clust
 		config.Provider = secrets.TypeK8s
 	case secrets.TypeVault:
 		config.Provider = secrets.TypeVault
+	case TypeKMIP:
+		config.Provider = TypeKMIP
 	default:
 		logger.Errorf("unsupported kms type %q", Provider)
 	}
@@ -80,7 +82,13 @@ func (c *Config) PutSecret(secretName, secretValue string) error {
 			return errors.Wrap(err, "failed to store secret in kubernetes secret")
 		}
 	}
-	if c.IsVault() {
+	if c.IsVaultTransit() {
+		// Encrypt the secret with Vault, only the ciphertext is stored
+		err := c.putVaultTransit(secretName, secretValue)
+		if err != nil {
+			return errors.Wrap(err, "failed to put secret in vault transit")
+		}
+	} else if c.IsVault() {
 		// Store the secret in Vault
 		v, err := InitVault(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
 		if err != nil {
@@ -92,6 +100,49 @@ func (c *Config) PutSecret(secretName, secretValue string) error {
 			return errors.Wrap(err, "failed to put secret in vault")
 		}
 	}
+	if c.IsKMIP() {
+		// Register the secret in the KMIP server
+		err := c.putKMIP(secretName, secretValue)
+		if err != nil {
+			return errors.Wrap(err, "failed to put secret in kmip")
+		}
+	}
+
+	return nil
+}
+
+// UpdateSecret replaces an encrypted key in a KMS
+// Unlike PutSecret, which never overrides a key that already exists, this is used to rotate the key
+func (c *Config) UpdateSecret(secretName, secretValue string) error {
+	if c.IsK8s() {
+		err := c.updateSecretInKubernetes(secretName, secretValue)
+		if err != nil {
+			return errors.Wrap(err, "failed to update secret in kubernetes secret")
+		}
+	}
+	if c.IsVaultTransit() {
+		err := c.updateVaultTransit(secretName, secretValue)
+		if err != nil {
+			return errors.Wrap(err, "failed to update secret in vault transit")
+		}
+	} else if c.IsVault() {
+		v, err := InitVault(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+		if err != nil {
+			return errors.Wrap(err, "failed to init vault kms")
+		}
+		k := buildKeyContext(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+		data := map[string]interface{}{GenerateOSDEncryptionSecretName(secretName): secretValue}
+		err = v.PutSecret(GenerateOSDEncryptionSecretName(secretName), data, k)
+		if err != nil {
+			return errors.Wrap(err, "failed to update secret in vault")
+		}
+	}
+	if c.IsKMIP() {
+		err := c.updateKMIP(secretName, secretValue)
+		if err != nil {
+			return errors.Wrap(err, "failed to update secret in kmip")
+		}
+	}
 
 	return nil
 }
@@ -99,7 +150,21 @@ func (c *Config) PutSecret(secretName, secretValue string) error {
 // GetSecret returns an encrypted key from a KMS
 func (c *Config) GetSecret(secretName string) (string, error) {
 	var value string
-	if c.IsVault() {
+	if c.IsK8s() && c.IsMasterKeyEnabled() {
+		// Unwrap the key stored in the Kubernetes Secret
+		var err error
+		value, err = c.unwrapKey(secretName)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to unwrap secret")
+		}
+	}
+	if c.IsVaultTransit() {
+		var err error
+		value, err = c.getVaultTransit(secretName)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get secret in vault transit")
+		}
+	} else if c.IsVault() {
 		// Store the secret in Vault
 		v, err := InitVault(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
 		if err != nil {
@@ -112,13 +177,21 @@ func (c *Config) GetSecret(secretName string) (string, error) {
 			return "", errors.Wrap(err, "failed to get secret in vault")
 		}
 	}
+	if c.IsKMIP() {
+		var err error
+		value, err = c.getKMIP(secretName)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to get secret in kmip")
+		}
+	}
 
 	return value, nil
 }
 
 // DeleteSecret deletes an encrypted key from a KMS
 func (c *Config) DeleteSecret(secretName string) error {
-	if c.IsVault() {
+	// Nothing is stored in Vault with the transit engine, the ciphertext goes away with the Kubernetes Secret
+	if c.IsVault() && !c.IsVaultTransit() {
 		// Store the secret in Vault
 		v, err := InitVault(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
 		if err != nil {
@@ -131,6 +204,12 @@ func (c *Config) DeleteSecret(secretName string) error {
 			return errors.Wrap(err, "failed to delete secret in vault")
 		}
 	}
+	if c.IsKMIP() {
+		err := c.deleteKMIP(secretName)
+		if err != nil {
+			return errors.Wrap(err, "failed to delete secret in kmip")
+		}
+	}
 
 	return nil
 }
@@ -146,8 +225,9 @@ func GetParam(kmsConfig map[string]string, param string) string {
 // ValidateConnectionDetails validates mandatory KMS connection details
 func ValidateConnectionDetails(clusterdContext *clusterd.Context, clusterSpec *cephv1.ClusterSpec, ns string) error {
 	ctx := context.TODO()
-	// A token must be specified
-	if !clusterSpec.Security.KeyManagementService.IsTokenAuthEnabled() {
+	// A token must be specified, KMIP authenticates with a TLS client certificate instead
+	// Vault can also authenticate with the service account token of the pods
+	if !clusterSpec.Security.KeyManagementService.IsTokenAuthEnabled() && GetParam(clusterSpec.Security.KeyManagementService.ConnectionDetails, Provider) != TypeKMIP && !VaultKubernetesAuthEnabled(clusterSpec.Security.KeyManagementService.ConnectionDetails) {
 		return errors.New("failed to validate kms configuration (missing token in spec)")
 	}
 
@@ -165,6 +245,11 @@ func ValidateConnectionDetails(clusterdContext *clusterd.Context, clusterSpec *c
 		if err != nil {
 			return errors.Wrap(err, "failed to validate vault connection details")
 		}
+	case TypeKMIP:
+		err := validateKMIPConnectionDetails(clusterdContext, ns, clusterSpec.Security.KeyManagementService.ConnectionDetails)
+		if err != nil {
+			return errors.Wrap(err, "failed to validate kmip connection details")
+		}
 	}
 
 	// Validate potential token Secret presence
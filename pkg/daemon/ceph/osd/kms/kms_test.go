@@ -114,6 +114,21 @@ func TestValidateConnectionDetails(t *testing.T) {
 	assert.NoError(t, err)
 	err = ValidateConnectionDetails(context, clusterSpec, ns)
 	assert.NoError(t, err, "")
+
+	// Kubernetes auth does not need a token
+	clusterSpec.Security.KeyManagementService.TokenSecretName = ""
+	clusterSpec.Security.KeyManagementService.ConnectionDetails = map[string]string{"KMS_PROVIDER": "vault", "VAULT_ADDR": "https://1.1.1.1:8200", "VAULT_AUTH_METHOD": "kubernetes"}
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate vault connection details: failed to find connection details \"VAULT_AUTH_KUBERNETES_ROLE\"")
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["VAULT_AUTH_KUBERNETES_ROLE"] = "rook-ceph-osd"
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.NoError(t, err, "")
+
+	// Error: unsupported auth method
+	clusterSpec.Security.KeyManagementService.TokenSecretName = "vault-token"
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["VAULT_AUTH_METHOD"] = "approle"
+	err = ValidateConnectionDetails(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate vault connection details: failed to validate \"VAULT_AUTH_METHOD\", only \"kubernetes\" is supported")
 }
 
 func TestSetTokenToEnvVar(t *testing.T) {
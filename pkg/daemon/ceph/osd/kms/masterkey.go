@@ -0,0 +1,325 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// EtcMasterKeyDir is the directory where the master key is mounted in the OSD pods
+	EtcMasterKeyDir = "/etc/rook-master-key"
+	// MasterKeyPathKey points the OSD pods to the directory holding the master key
+	MasterKeyPathKey = "KMS_MASTER_KEY_PATH"
+	// WrappedKeyKey carries the wrapped OSD encryption key to the OSD pods
+	WrappedKeyKey = "KMS_WRAPPED_KEY"
+	// MasterKeySecretKeyName is the key of the current master key in the master key Secret
+	MasterKeySecretKeyName = "key"
+	// PreviousMasterKeySecretKeyName is the key of the previous master key in the master key Secret
+	PreviousMasterKeySecretKeyName = "previous-key"
+
+	// wrapped keys look like "rook-wrapped-v1:<master key id>:<base64 of nonce and ciphertext>"
+	wrappedKeyPrefix = "rook-wrapped-v1"
+	// osdEncryptionSecretLabel is set on every OSD encryption key Secret
+	osdEncryptionSecretLabel = "pvc_name"
+)
+
+// masterKey wraps and unwraps the OSD encryption keys with AES-256-GCM
+type masterKey struct {
+	id   string
+	aead cipher.AEAD
+}
+
+func newMasterKey(material []byte) (*masterKey, error) {
+	material = []byte(strings.TrimSpace(string(material)))
+	if len(material) == 0 {
+		return nil, errors.New("master key is empty")
+	}
+
+	// Derive a 256 bits key so that the master key can be of any length
+	derived := sha256.Sum256(material)
+	block, err := aes.NewCipher(derived[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create master key cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create master key gcm")
+	}
+
+	// The id identifies the master key without disclosing it
+	fingerprint := sha256.Sum256(derived[:])
+	return &masterKey{id: hex.EncodeToString(fingerprint[:8]), aead: aead}, nil
+}
+
+// wrap encrypts the OSD key, the Secret name is authenticated so a wrapped key cannot be swapped between OSDs
+func (m *masterKey) wrap(secretName, key string) (string, error) {
+	nonce := make([]byte, m.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.Wrap(err, "failed to generate nonce")
+	}
+	sealed := m.aead.Seal(nonce, nonce, []byte(key), []byte(secretName))
+
+	return fmt.Sprintf("%s:%s:%s", wrappedKeyPrefix, m.id, base64.StdEncoding.EncodeToString(sealed)), nil
+}
+
+func (m *masterKey) unwrap(secretName, wrapped string) (string, error) {
+	id, payload, ok := parseWrappedKey(wrapped)
+	if !ok {
+		return "", errors.Errorf("key %q is not wrapped", secretName)
+	}
+	if id != m.id {
+		return "", errors.Errorf("key %q was wrapped by master key %q, not %q", secretName, id, m.id)
+	}
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode wrapped key %q", secretName)
+	}
+	if len(sealed) < m.aead.NonceSize() {
+		return "", errors.Errorf("wrapped key %q is too short", secretName)
+	}
+	nonce, ciphertext := sealed[:m.aead.NonceSize()], sealed[m.aead.NonceSize():]
+	key, err := m.aead.Open(nil, nonce, ciphertext, []byte(secretName))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to unwrap key %q", secretName)
+	}
+
+	return string(key), nil
+}
+
+// parseWrappedKey returns the master key id and the payload of a wrapped key
+func parseWrappedKey(value string) (string, string, bool) {
+	parts := strings.SplitN(value, ":", 3)
+	if len(parts) != 3 || parts[0] != wrappedKeyPrefix {
+		return "", "", false
+	}
+	return parts[1], parts[2], true
+}
+
+// IsMasterKeyEnabled determines whether the OSD keys stored in Kubernetes Secrets are wrapped
+func (c *Config) IsMasterKeyEnabled() bool {
+	return c.clusterSpec.Security.KeyManagementService.IsMasterKeyEnabled() ||
+		GetParam(c.clusterSpec.Security.KeyManagementService.ConnectionDetails, MasterKeyPathKey) != ""
+}
+
+// masterKeys loads the current master key and, during a rotation, the previous one
+func (c *Config) masterKeys() (*masterKey, *masterKey, error) {
+	ctx := context.TODO()
+	var current, previous []byte
+
+	spec := c.clusterSpec.Security.KeyManagementService.MasterKey
+	if dir := GetParam(c.clusterSpec.Security.KeyManagementService.ConnectionDetails, MasterKeyPathKey); dir != "" {
+		// In the OSD pods the master key is mounted as files
+		var err error
+		current, err = ioutil.ReadFile(path.Join(dir, MasterKeySecretKeyName))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to read master key")
+		}
+		previous, err = ioutil.ReadFile(path.Join(dir, PreviousMasterKeySecretKeyName))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, nil, errors.Wrap(err, "failed to read previous master key")
+		}
+	} else if spec != nil && spec.SecretName != "" {
+		s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, spec.SecretName, metav1.GetOptions{})
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to fetch master key secret %q", spec.SecretName)
+		}
+		current = s.Data[MasterKeySecretKeyName]
+		previous = s.Data[PreviousMasterKeySecretKeyName]
+	} else if spec != nil && spec.FilePath != "" {
+		var err error
+		current, err = ioutil.ReadFile(spec.FilePath)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to read master key file %q", spec.FilePath)
+		}
+		if spec.PreviousFilePath != "" {
+			previous, err = ioutil.ReadFile(spec.PreviousFilePath)
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "failed to read previous master key file %q", spec.PreviousFilePath)
+			}
+		}
+	}
+
+	currentKey, err := newMasterKey(current)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load master key")
+	}
+	if len(strings.TrimSpace(string(previous))) == 0 {
+		return currentKey, nil, nil
+	}
+	previousKey, err := newMasterKey(previous)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to load previous master key")
+	}
+
+	return currentKey, previousKey, nil
+}
+
+func (c *Config) wrapKey(secretName, key string) (string, error) {
+	current, _, err := c.masterKeys()
+	if err != nil {
+		return "", err
+	}
+
+	return current.wrap(GenerateOSDEncryptionSecretName(secretName), key)
+}
+
+// unwrapKey returns the OSD key, the wrapped value comes from the pod env or from the Secret
+func (c *Config) unwrapKey(secretName string) (string, error) {
+	ctx := context.TODO()
+	wrapped := GetParam(c.clusterSpec.Security.KeyManagementService.ConnectionDetails, WrappedKeyKey)
+	if wrapped == "" {
+		s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(secretName), metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get osd encryption key secret for %q", secretName)
+		}
+		wrapped = string(s.Data[OsdEncryptionSecretNameKeyName])
+	}
+
+	current, previous, err := c.masterKeys()
+	if err != nil {
+		return "", err
+	}
+
+	return unwrapWithMasterKeys(GenerateOSDEncryptionSecretName(secretName), wrapped, current, previous)
+}
+
+func unwrapWithMasterKeys(name, wrapped string, current, previous *masterKey) (string, error) {
+	id, _, ok := parseWrappedKey(wrapped)
+	if !ok {
+		return "", errors.Errorf("key %q is not wrapped by a master key", name)
+	}
+	// The Secret may not have been rewrapped yet
+	if previous != nil && id == previous.id {
+		return previous.unwrap(name, wrapped)
+	}
+
+	return current.unwrap(name, wrapped)
+}
+
+// RewrapKeys wraps every OSD key stored in a Kubernetes Secret with the current master key
+// Keys that are not wrapped yet or that are wrapped by the previous master key are updated
+func (c *Config) RewrapKeys() error {
+	ctx := context.TODO()
+	current, previous, err := c.masterKeys()
+	if err != nil {
+		return err
+	}
+
+	secrets, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).List(ctx, metav1.ListOptions{LabelSelector: osdEncryptionSecretLabel})
+	if err != nil {
+		return errors.Wrap(err, "failed to list osd encryption key secrets")
+	}
+
+	rewrapped := 0
+	for i := range secrets.Items {
+		s := &secrets.Items[i]
+		if !strings.HasPrefix(s.Name, osdEncryptionSecretNamePrefix) {
+			continue
+		}
+		value, ok := s.Data[OsdEncryptionSecretNameKeyName]
+		if !ok {
+			// KMIP and other providers only keep an identifier in the Secret
+			continue
+		}
+
+		var key string
+		id, _, wrapped := parseWrappedKey(string(value))
+		switch {
+		case wrapped && id == current.id:
+			continue
+		case wrapped:
+			if previous == nil || id != previous.id {
+				return errors.Errorf("failed to rewrap key %q, it was wrapped by unknown master key %q", s.Name, id)
+			}
+			key, err = previous.unwrap(s.Name, string(value))
+			if err != nil {
+				return err
+			}
+		default:
+			// The key was stored before the master key was configured
+			key = string(value)
+		}
+
+		newValue, err := current.wrap(s.Name, key)
+		if err != nil {
+			return errors.Wrapf(err, "failed to wrap key %q", s.Name)
+		}
+		s.Data[OsdEncryptionSecretNameKeyName] = []byte(newValue)
+		_, err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Update(ctx, s, metav1.UpdateOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to update osd encryption key secret %q", s.Name)
+		}
+		rewrapped++
+	}
+
+	if rewrapped > 0 {
+		logger.Infof("wrapped %d osd encryption key(s) with master key %q", rewrapped, current.id)
+	}
+	if previous != nil {
+		logger.Infof("all osd encryption keys are wrapped with master key %q, the previous master key can be removed", current.id)
+	}
+
+	return nil
+}
+
+// ValidateMasterKey validates the master key settings
+func ValidateMasterKey(clusterdContext *clusterd.Context, clusterSpec *cephv1.ClusterSpec, ns string) error {
+	ctx := context.TODO()
+	spec := clusterSpec.Security.KeyManagementService.MasterKey
+	if !clusterSpec.Security.KeyManagementService.IsMasterKeyEnabled() {
+		return nil
+	}
+	if clusterSpec.Security.KeyManagementService.IsEnabled() {
+		return errors.New("failed to validate master key, it only applies to keys stored in kubernetes secrets and cannot be used with a kms")
+	}
+	if spec.SecretName != "" && spec.FilePath != "" {
+		return errors.New("failed to validate master key, only one of secretName and filePath can be set")
+	}
+	if spec.PreviousFilePath != "" && spec.FilePath == "" {
+		return errors.New("failed to validate master key, previousFilePath requires filePath")
+	}
+
+	if spec.SecretName != "" {
+		s, err := clusterdContext.Clientset.CoreV1().Secrets(ns).Get(ctx, spec.SecretName, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch master key secret %q", spec.SecretName)
+		}
+		if len(s.Data[MasterKeySecretKeyName]) == 0 {
+			return errors.Errorf("failed to read master key secret %q key %q (not found or empty)", spec.SecretName, MasterKeySecretKeyName)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,197 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMasterKeyWrap(t *testing.T) {
+	m, err := newMasterKey([]byte("my-master-key\n"))
+	assert.NoError(t, err)
+
+	wrapped, err := m.wrap("rook-ceph-osd-encryption-key-set1-data-0-7dwll", "my-dmcrypt-key")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(wrapped, "rook-wrapped-v1:"+m.id+":"))
+	assert.NotContains(t, wrapped, "my-dmcrypt-key")
+
+	key, err := m.unwrap("rook-ceph-osd-encryption-key-set1-data-0-7dwll", wrapped)
+	assert.NoError(t, err)
+	assert.Equal(t, "my-dmcrypt-key", key)
+
+	// The wrapped key is bound to its Secret
+	_, err = m.unwrap("rook-ceph-osd-encryption-key-set1-data-1-bbgg8", wrapped)
+	assert.Error(t, err)
+
+	// Another master key cannot unwrap it
+	other, err := newMasterKey([]byte("another-master-key"))
+	assert.NoError(t, err)
+	assert.NotEqual(t, m.id, other.id)
+	_, err = other.unwrap("rook-ceph-osd-encryption-key-set1-data-0-7dwll", wrapped)
+	assert.Error(t, err)
+
+	// Plain keys are not wrapped
+	_, err = m.unwrap("rook-ceph-osd-encryption-key-set1-data-0-7dwll", "my-dmcrypt-key")
+	assert.Error(t, err)
+
+	_, err = newMasterKey([]byte(" \n"))
+	assert.Error(t, err)
+}
+
+func TestMasterKeyLifecycle(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	context := &clusterd.Context{Clientset: test.New(t, 3)}
+	masterKeySecret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-master-key", Namespace: ns},
+		Data:       map[string][]byte{"key": []byte("first-master-key")},
+	}
+	_, err := context.Clientset.CoreV1().Secrets(ns).Create(ctx, masterKeySecret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	clusterSpec := &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{MasterKey: &cephv1.MasterKeySpec{SecretName: "rook-master-key"}}}}
+	c := NewConfig(context, clusterSpec, cephclient.AdminClusterInfo(ns))
+	assert.True(t, c.IsK8s())
+	assert.True(t, c.IsMasterKeyEnabled())
+
+	// One key wrapped by the first master key and one stored before the master key was configured
+	wrapped, err := c.wrapKey("set1-data-0-7dwll", "first-dmcrypt-key")
+	assert.NoError(t, err)
+	for pvc, value := range map[string]string{"set1-data-0-7dwll": wrapped, "set1-data-1-bbgg8": "second-dmcrypt-key"} {
+		s := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: GenerateOSDEncryptionSecretName(pvc), Namespace: ns, Labels: map[string]string{"pvc_name": pvc}},
+			Data:       map[string][]byte{OsdEncryptionSecretNameKeyName: []byte(value)},
+		}
+		_, err = context.Clientset.CoreV1().Secrets(ns).Create(ctx, s, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	key, err := c.GetSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, "first-dmcrypt-key", key)
+	_, err = c.GetSecret("set1-data-1-bbgg8")
+	assert.Error(t, err)
+
+	// Rotate the master key, the first one becomes the previous key
+	masterKeySecret.Data = map[string][]byte{"key": []byte("second-master-key"), "previous-key": []byte("first-master-key")}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, masterKeySecret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	// Keys that are not rewrapped yet can still be read
+	key, err = c.GetSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, "first-dmcrypt-key", key)
+
+	err = c.RewrapKeys()
+	assert.NoError(t, err)
+	current, _, err := c.masterKeys()
+	assert.NoError(t, err)
+	for pvc, expected := range map[string]string{"set1-data-0-7dwll": "first-dmcrypt-key", "set1-data-1-bbgg8": "second-dmcrypt-key"} {
+		s, err := context.Clientset.CoreV1().Secrets(ns).Get(ctx, GenerateOSDEncryptionSecretName(pvc), metav1.GetOptions{})
+		assert.NoError(t, err)
+		id, _, ok := parseWrappedKey(string(s.Data[OsdEncryptionSecretNameKeyName]))
+		assert.True(t, ok)
+		assert.Equal(t, current.id, id)
+
+		key, err := c.GetSecret(pvc)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, key)
+	}
+
+	// Once the previous key is removed everything is still readable
+	masterKeySecret.Data = map[string][]byte{"key": []byte("second-master-key")}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, masterKeySecret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	key, err = c.GetSecret("set1-data-1-bbgg8")
+	assert.NoError(t, err)
+	assert.Equal(t, "second-dmcrypt-key", key)
+
+	// A key wrapped by an unknown master key is not overwritten
+	masterKeySecret.Data = map[string][]byte{"key": []byte("third-master-key")}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, masterKeySecret, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	err = c.RewrapKeys()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "wrapped by unknown master key")
+}
+
+func TestValidateMasterKey(t *testing.T) {
+	ctx := context.TODO()
+	ns := "rook-ceph"
+	context := &clusterd.Context{Clientset: test.New(t, 3)}
+
+	// No master key
+	clusterSpec := &cephv1.ClusterSpec{}
+	err := ValidateMasterKey(context, clusterSpec, ns)
+	assert.NoError(t, err)
+
+	// Error: used with a KMS
+	clusterSpec.Security.KeyManagementService = cephv1.KeyManagementServiceSpec{
+		ConnectionDetails: map[string]string{"KMS_PROVIDER": "vault"},
+		MasterKey:         &cephv1.MasterKeySpec{SecretName: "rook-master-key"},
+	}
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate master key, it only applies to keys stored in kubernetes secrets and cannot be used with a kms")
+
+	// Error: both sources
+	clusterSpec.Security.KeyManagementService = cephv1.KeyManagementServiceSpec{MasterKey: &cephv1.MasterKeySpec{SecretName: "rook-master-key", FilePath: "/etc/master-key"}}
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate master key, only one of secretName and filePath can be set")
+
+	// Error: previous file without file
+	clusterSpec.Security.KeyManagementService.MasterKey = &cephv1.MasterKeySpec{PreviousFilePath: "/etc/old-master-key"}
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.NoError(t, err, "a previous file alone does not enable the master key")
+	clusterSpec.Security.KeyManagementService.MasterKey = &cephv1.MasterKeySpec{SecretName: "rook-master-key", PreviousFilePath: "/etc/old-master-key"}
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to validate master key, previousFilePath requires filePath")
+
+	// Error: the Secret does not exist
+	clusterSpec.Security.KeyManagementService.MasterKey = &cephv1.MasterKeySpec{SecretName: "rook-master-key"}
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to fetch master key secret \"rook-master-key\"")
+
+	// Error: the Secret has no key
+	s := &v1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "rook-master-key", Namespace: ns}, Data: map[string][]byte{"previous-key": []byte("foo")}}
+	_, err = context.Clientset.CoreV1().Secrets(ns).Create(ctx, s, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.EqualError(t, err, "failed to read master key secret \"rook-master-key\" key \"key\" (not found or empty)")
+
+	// Success
+	s.Data["key"] = []byte("my-master-key")
+	_, err = context.Clientset.CoreV1().Secrets(ns).Update(ctx, s, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.NoError(t, err)
+
+	// A host file is validated by the osd pods
+	clusterSpec.Security.KeyManagementService.MasterKey = &cephv1.MasterKeySpec{FilePath: "/etc/master-key", PreviousFilePath: "/etc/old-master-key"}
+	err = ValidateMasterKey(context, clusterSpec, ns)
+	assert.NoError(t, err)
+}
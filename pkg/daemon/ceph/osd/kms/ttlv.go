@@ -0,0 +1,289 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// KMIP Tag-Type-Length-Value encoding as described in the KMIP 1.4 specification, section 9.1
+// Only the subset of the protocol needed to register, get and destroy secret data is implemented
+
+const (
+	ttlvHeaderLength = 8
+
+	// TTLV item types
+	ttlvStructure   byte = 0x01
+	ttlvInteger     byte = 0x02
+	ttlvLongInteger byte = 0x03
+	ttlvEnumeration byte = 0x05
+	ttlvBoolean     byte = 0x06
+	ttlvTextString  byte = 0x07
+	ttlvByteString  byte = 0x08
+	ttlvDateTime    byte = 0x09
+
+	// maximum size of a message we accept from the wire
+	ttlvMaxMessageLength = 1 << 20
+)
+
+// KMIP tags
+const (
+	tagAttribute            uint32 = 0x420008
+	tagAttributeName        uint32 = 0x42000A
+	tagAttributeValue       uint32 = 0x42000B
+	tagBatchCount           uint32 = 0x42000D
+	tagBatchItem            uint32 = 0x42000F
+	tagKeyBlock             uint32 = 0x420040
+	tagKeyFormatType        uint32 = 0x420042
+	tagKeyMaterial          uint32 = 0x420043
+	tagKeyValue             uint32 = 0x420045
+	tagNameType             uint32 = 0x420054
+	tagNameValue            uint32 = 0x420055
+	tagObjectType           uint32 = 0x420057
+	tagOperation            uint32 = 0x42005C
+	tagProtocolVersion      uint32 = 0x420069
+	tagProtocolVersionMajor uint32 = 0x42006A
+	tagProtocolVersionMinor uint32 = 0x42006B
+	tagRequestHeader        uint32 = 0x420077
+	tagRequestMessage       uint32 = 0x420078
+	tagRequestPayload       uint32 = 0x420079
+	tagResponseHeader       uint32 = 0x42007A
+	tagResponseMessage      uint32 = 0x42007B
+	tagResponsePayload      uint32 = 0x42007C
+	tagResultMessage        uint32 = 0x42007D
+	tagResultReason         uint32 = 0x42007E
+	tagResultStatus         uint32 = 0x42007F
+	tagSecretData           uint32 = 0x420085
+	tagSecretDataType       uint32 = 0x420086
+	tagTemplateAttribute    uint32 = 0x420091
+	tagUniqueIdentifier     uint32 = 0x420094
+)
+
+// ttlvItem is a single KMIP item, structures hold their children as []ttlvItem
+type ttlvItem struct {
+	tag   uint32
+	typ   byte
+	value interface{}
+}
+
+func ttlvStruct(tag uint32, children ...ttlvItem) ttlvItem {
+	return ttlvItem{tag: tag, typ: ttlvStructure, value: children}
+}
+
+func ttlvInt(tag uint32, value int32) ttlvItem {
+	return ttlvItem{tag: tag, typ: ttlvInteger, value: value}
+}
+
+func ttlvEnum(tag uint32, value uint32) ttlvItem {
+	return ttlvItem{tag: tag, typ: ttlvEnumeration, value: value}
+}
+
+func ttlvText(tag uint32, value string) ttlvItem {
+	return ttlvItem{tag: tag, typ: ttlvTextString, value: value}
+}
+
+func ttlvBytes(tag uint32, value []byte) ttlvItem {
+	return ttlvItem{tag: tag, typ: ttlvByteString, value: value}
+}
+
+// child returns the first direct child of a structure with the given tag
+func (t ttlvItem) child(tag uint32) (ttlvItem, bool) {
+	children, ok := t.value.([]ttlvItem)
+	if !ok {
+		return ttlvItem{}, false
+	}
+	for _, c := range children {
+		if c.tag == tag {
+			return c, true
+		}
+	}
+	return ttlvItem{}, false
+}
+
+// path walks down nested structures following the given tags
+func (t ttlvItem) path(tags ...uint32) (ttlvItem, bool) {
+	current := t
+	for _, tag := range tags {
+		var ok bool
+		current, ok = current.child(tag)
+		if !ok {
+			return ttlvItem{}, false
+		}
+	}
+	return current, true
+}
+
+func (t ttlvItem) text() string {
+	s, _ := t.value.(string)
+	return s
+}
+
+func (t ttlvItem) enum() uint32 {
+	e, _ := t.value.(uint32)
+	return e
+}
+
+func (t ttlvItem) bytes() []byte {
+	b, _ := t.value.([]byte)
+	return b
+}
+
+// marshal encodes the item and its children in TTLV
+func (t ttlvItem) marshal() ([]byte, error) {
+	var value []byte
+	switch t.typ {
+	case ttlvStructure:
+		children, ok := t.value.([]ttlvItem)
+		if !ok {
+			return nil, errors.Errorf("invalid structure value for tag %06x", t.tag)
+		}
+		for _, c := range children {
+			b, err := c.marshal()
+			if err != nil {
+				return nil, err
+			}
+			value = append(value, b...)
+		}
+	case ttlvInteger:
+		value = make([]byte, 4)
+		binary.BigEndian.PutUint32(value, uint32(t.value.(int32)))
+	case ttlvEnumeration:
+		value = make([]byte, 4)
+		binary.BigEndian.PutUint32(value, t.value.(uint32))
+	case ttlvLongInteger, ttlvDateTime:
+		value = make([]byte, 8)
+		binary.BigEndian.PutUint64(value, uint64(t.value.(int64)))
+	case ttlvBoolean:
+		value = make([]byte, 8)
+		if t.value.(bool) {
+			value[7] = 1
+		}
+	case ttlvTextString:
+		value = []byte(t.value.(string))
+	case ttlvByteString:
+		value = t.value.([]byte)
+	default:
+		return nil, errors.Errorf("unsupported ttlv type %x for tag %06x", t.typ, t.tag)
+	}
+
+	b := make([]byte, ttlvHeaderLength, ttlvHeaderLength+len(value)+7)
+	b[0] = byte(t.tag >> 16)
+	b[1] = byte(t.tag >> 8)
+	b[2] = byte(t.tag)
+	b[3] = t.typ
+	binary.BigEndian.PutUint32(b[4:], uint32(len(value)))
+	b = append(b, value...)
+
+	// Every item is padded to a multiple of eight bytes
+	if pad := len(value) % 8; pad != 0 {
+		b = append(b, make([]byte, 8-pad)...)
+	}
+
+	return b, nil
+}
+
+// unmarshalTTLV decodes a single item and returns the number of bytes consumed
+func unmarshalTTLV(b []byte) (ttlvItem, int, error) {
+	if len(b) < ttlvHeaderLength {
+		return ttlvItem{}, 0, errors.New("ttlv item too short")
+	}
+	t := ttlvItem{
+		tag: uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]),
+		typ: b[3],
+	}
+	length := int(binary.BigEndian.Uint32(b[4:8]))
+	padded := length
+	if pad := length % 8; pad != 0 {
+		padded += 8 - pad
+	}
+	if len(b) < ttlvHeaderLength+length {
+		return ttlvItem{}, 0, errors.Errorf("ttlv item %06x is truncated", t.tag)
+	}
+	value := b[ttlvHeaderLength : ttlvHeaderLength+length]
+
+	switch t.typ {
+	case ttlvStructure:
+		children := []ttlvItem{}
+		for offset := 0; offset < len(value); {
+			c, n, err := unmarshalTTLV(value[offset:])
+			if err != nil {
+				return ttlvItem{}, 0, err
+			}
+			children = append(children, c)
+			offset += n
+		}
+		t.value = children
+	case ttlvInteger, ttlvEnumeration:
+		if length != 4 {
+			return ttlvItem{}, 0, errors.Errorf("invalid length %d for ttlv item %06x", length, t.tag)
+		}
+		if t.typ == ttlvInteger {
+			t.value = int32(binary.BigEndian.Uint32(value))
+		} else {
+			t.value = binary.BigEndian.Uint32(value)
+		}
+	case ttlvLongInteger, ttlvDateTime:
+		if length != 8 {
+			return ttlvItem{}, 0, errors.Errorf("invalid length %d for ttlv item %06x", length, t.tag)
+		}
+		t.value = int64(binary.BigEndian.Uint64(value))
+	case ttlvBoolean:
+		if length != 8 {
+			return ttlvItem{}, 0, errors.Errorf("invalid length %d for ttlv item %06x", length, t.tag)
+		}
+		t.value = binary.BigEndian.Uint64(value) != 0
+	case ttlvTextString:
+		t.value = string(value)
+	case ttlvByteString:
+		t.value = append([]byte{}, value...)
+	default:
+		// Types we do not use (big integer, interval) are kept as raw bytes
+		t.value = append([]byte{}, value...)
+	}
+
+	// The last item of a message is not always padded by every server
+	consumed := ttlvHeaderLength + padded
+	if consumed > len(b) {
+		consumed = len(b)
+	}
+
+	return t, consumed, nil
+}
+
+// readTTLV reads a complete TTLV message from the reader
+func readTTLV(r io.Reader) (ttlvItem, error) {
+	header := make([]byte, ttlvHeaderLength)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return ttlvItem{}, errors.Wrap(err, "failed to read ttlv header")
+	}
+	length := binary.BigEndian.Uint32(header[4:])
+	if length > ttlvMaxMessageLength {
+		return ttlvItem{}, errors.Errorf("ttlv message of %d bytes exceeds the maximum size", length)
+	}
+
+	message := make([]byte, ttlvHeaderLength+int(length))
+	copy(message, header)
+	if _, err := io.ReadFull(r, message[ttlvHeaderLength:]); err != nil {
+		return ttlvItem{}, errors.Wrap(err, "failed to read ttlv message")
+	}
+
+	t, _, err := unmarshalTTLV(message)
+	return t, err
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTTLVMarshal(t *testing.T) {
+	// Example from the KMIP 1.4 specification, section 9.1.2
+	b, err := ttlvEnum(0x420020, 255).marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x42, 0x00, 0x20, 0x05, 0x00, 0x00, 0x00, 0x04, 0x00, 0x00, 0x00, 0xFF, 0x00, 0x00, 0x00, 0x00}, b)
+
+	// Text strings are padded to eight bytes
+	b, err = ttlvText(0x420020, "Hello World").marshal()
+	assert.NoError(t, err)
+	assert.Equal(t, 24, len(b))
+	assert.Equal(t, []byte{0x42, 0x00, 0x20, 0x07, 0x00, 0x00, 0x00, 0x0B}, b[:8])
+
+	// Unsupported type
+	_, err = ttlvItem{tag: 0x420020, typ: 0x0A, value: 1}.marshal()
+	assert.Error(t, err)
+}
+
+func TestTTLVRoundTrip(t *testing.T) {
+	message := ttlvStruct(tagRequestMessage,
+		ttlvStruct(tagRequestHeader,
+			ttlvStruct(tagProtocolVersion,
+				ttlvInt(tagProtocolVersionMajor, 1),
+				ttlvInt(tagProtocolVersionMinor, 4),
+			),
+		),
+		ttlvStruct(tagBatchItem,
+			ttlvEnum(tagOperation, kmipOperationGet),
+			ttlvStruct(tagRequestPayload, ttlvText(tagUniqueIdentifier, "my-key")),
+			ttlvBytes(tagKeyMaterial, []byte("secret")),
+		),
+	)
+	b, err := message.marshal()
+	assert.NoError(t, err)
+
+	decoded, err := readTTLV(bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, message, decoded)
+
+	uid, ok := decoded.path(tagBatchItem, tagRequestPayload, tagUniqueIdentifier)
+	assert.True(t, ok)
+	assert.Equal(t, "my-key", uid.text())
+	_, ok = decoded.path(tagBatchItem, tagResponsePayload)
+	assert.False(t, ok)
+
+	// Truncated message
+	_, err = readTTLV(bytes.NewReader(b[:len(b)-8]))
+	assert.Error(t, err)
+}
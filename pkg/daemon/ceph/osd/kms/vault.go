@@ -38,6 +38,20 @@ const (
 	VaultKVSecretEngineKey = "kv"
 	// VaultTransitSecretEngineKey is a transit secret engine type
 	VaultTransitSecretEngineKey = "transit"
+	// VaultTransitKeyNameKey is the name of the transit key encrypting the OSD keys
+	VaultTransitKeyNameKey = "VAULT_TRANSIT_KEY"
+	// VaultTransitCiphertextKey carries the ciphertext of an OSD key to the OSD pods
+	VaultTransitCiphertextKey = "VAULT_TRANSIT_CIPHERTEXT"
+	// VaultTransitCiphertextSecretKeyName is the key of the OSD Secret holding the ciphertext
+	VaultTransitCiphertextSecretKeyName = "vault-transit-ciphertext"
+	// VaultServiceAccountTokenDir is where the projected service account token used to login to Vault is mounted
+	VaultServiceAccountTokenDir = "/var/run/secrets/rook-vault"
+	// VaultAuthKubernetesAudienceKey is the audience of the projected service account token, if the Vault role requires one
+	VaultAuthKubernetesAudienceKey = "VAULT_AUTH_KUBERNETES_AUDIENCE"
+
+	defaultVaultTransitBackendPath = "transit"
+	defaultVaultTransitKeyName     = osdEncryptionSecretNamePrefix
+	defaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
 )
 
 var (
@@ -194,6 +208,16 @@ func (c *Config) IsVault() bool {
 	return c.Provider == "vault"
 }
 
+// VaultKubernetesAuthEnabled returns whether Vault is logged in with the service account token of the pods instead of a static token
+func VaultKubernetesAuthEnabled(kmsConfig map[string]string) bool {
+	return GetParam(kmsConfig, vault.AuthMethod) == vault.AuthMethodKubernetes
+}
+
+// VaultTransitEnabled returns whether the OSD keys are encrypted by the Vault transit engine instead of being stored in the kv engine
+func VaultTransitEnabled(kmsConfig map[string]string) bool {
+	return GetParam(kmsConfig, VaultSecretEngineKey) == VaultTransitSecretEngineKey
+}
+
 func validateVaultConnectionDetails(clusterdContext *clusterd.Context, ns string, kmsConfig map[string]string) error {
 	ctx := context.TODO()
 	for _, option := range vaultMandatoryConnectionDetails {
@@ -202,6 +226,16 @@ func validateVaultConnectionDetails(clusterdContext *clusterd.Context, ns string
 		}
 	}
 
+	// Only the Kubernetes auth method is supported besides the token
+	if authMethod := GetParam(kmsConfig, vault.AuthMethod); authMethod != "" {
+		if authMethod != vault.AuthMethodKubernetes {
+			return errors.Errorf("failed to validate %q, only %q is supported", vault.AuthMethod, vault.AuthMethodKubernetes)
+		}
+		if GetParam(kmsConfig, vault.AuthKubernetesRole) == "" {
+			return errors.Errorf("failed to find connection details %q", vault.AuthKubernetesRole)
+		}
+	}
+
 	// We do not support a directory with multiple CA since we fetch a k8s Secret and read its content
 	// So we operate with a single CA only
 	if GetParam(kmsConfig, api.EnvVaultCAPath) != "" {
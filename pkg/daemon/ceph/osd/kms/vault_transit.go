@@ -0,0 +1,305 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/libopenstorage/secrets/vault"
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/clusterd"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// vaultTransitClient encrypts and decrypts the OSD keys with a Vault transit key
+// Vault never stores the OSD keys, only their ciphertext is kept in Kubernetes Secrets
+type vaultTransitClient struct {
+	client      *api.Client
+	backendPath string
+	keyName     string
+}
+
+// newVaultClient returns a Vault client authenticated with a token or with the Kubernetes auth method
+func newVaultClient(clusterdContext *clusterd.Context, namespace string, config map[string]string) (*api.Client, error) {
+	// So that we don't alter the content of the spec when replacing the TLS Secret names by file names
+	oriConfig := make(map[string]string)
+	for k, v := range config {
+		oriConfig[k] = v
+	}
+	configWithTLS, err := configTLS(clusterdContext, namespace, oriConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to initialize vault tls configuration")
+	}
+
+	vaultConfig := api.DefaultConfig()
+	vaultConfig.Address = GetParam(configWithTLS, api.EnvVaultAddress)
+	tlsConfig := &api.TLSConfig{
+		CACert:        GetParam(configWithTLS, api.EnvVaultCACert),
+		ClientCert:    GetParam(configWithTLS, api.EnvVaultClientCert),
+		ClientKey:     GetParam(configWithTLS, api.EnvVaultClientKey),
+		TLSServerName: GetParam(configWithTLS, api.EnvVaultTLSServerName),
+	}
+	if skipVerify := GetParam(configWithTLS, api.EnvVaultInsecure); skipVerify != "" {
+		tlsConfig.Insecure, err = strconv.ParseBool(skipVerify)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse %q", api.EnvVaultInsecure)
+		}
+	}
+	err = vaultConfig.ConfigureTLS(tlsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to configure vault tls")
+	}
+
+	client, err := api.NewClient(vaultConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create vault client")
+	}
+	if vaultNamespace := GetParam(configWithTLS, api.EnvVaultNamespace); vaultNamespace != "" {
+		client.SetNamespace(vaultNamespace)
+	}
+
+	var token string
+	if VaultKubernetesAuthEnabled(configWithTLS) {
+		token, err = vaultKubernetesLogin(client, configWithTLS)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to login to vault with the kubernetes auth method")
+		}
+	} else {
+		// Like the secrets lib, the operator sets the token as an env variable
+		token = GetParam(configWithTLS, api.EnvVaultToken)
+		if token == "" {
+			token = os.Getenv(api.EnvVaultToken)
+		}
+	}
+	if token == "" {
+		return nil, errors.New("failed to find a vault token")
+	}
+	client.SetToken(token)
+
+	return client, nil
+}
+
+// vaultKubernetesLogin exchanges the service account token of the pod against a Vault token
+func vaultKubernetesLogin(client *api.Client, config map[string]string) (string, error) {
+	role := GetParam(config, vault.AuthKubernetesRole)
+	if role == "" {
+		return "", errors.Errorf("failed to find connection details %q", vault.AuthKubernetesRole)
+	}
+	mountPath := GetParam(config, vault.AuthMountPath)
+	if mountPath == "" {
+		mountPath = vault.AuthKubernetesMountPath
+	}
+	tokenPath := GetParam(config, vault.AuthKubernetesTokenPath)
+	if tokenPath == "" {
+		tokenPath = defaultServiceAccountTokenPath
+	}
+
+	jwt, err := ioutil.ReadFile(tokenPath)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to read service account token %q", tokenPath)
+	}
+	s, err := client.Logical().Write(path.Join("auth", strings.Trim(mountPath, "/"), "login"), map[string]interface{}{
+		"role": role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to login with role %q", role)
+	}
+	if s == nil || s.Auth == nil || s.Auth.ClientToken == "" {
+		return "", errors.Errorf("vault login with role %q returned no token", role)
+	}
+
+	return s.Auth.ClientToken, nil
+}
+
+func newVaultTransitClient(clusterdContext *clusterd.Context, namespace string, config map[string]string) (*vaultTransitClient, error) {
+	client, err := newVaultClient(clusterdContext, namespace, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &vaultTransitClient{
+		client:      client,
+		backendPath: vaultTransitBackendPath(config),
+		keyName:     vaultTransitKeyName(config),
+	}, nil
+}
+
+func (v *vaultTransitClient) encrypt(plaintext string) (string, error) {
+	s, err := v.client.Logical().Write(path.Join(v.backendPath, "encrypt", v.keyName), map[string]interface{}{
+		"plaintext": base64.StdEncoding.EncodeToString([]byte(plaintext)),
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to encrypt with vault transit key %q", v.keyName)
+	}
+	if s == nil {
+		return "", errors.Errorf("vault transit key %q returned no ciphertext", v.keyName)
+	}
+	ciphertext, ok := s.Data["ciphertext"].(string)
+	if !ok || ciphertext == "" {
+		return "", errors.Errorf("vault transit key %q returned no ciphertext", v.keyName)
+	}
+
+	return ciphertext, nil
+}
+
+func (v *vaultTransitClient) decrypt(ciphertext string) (string, error) {
+	s, err := v.client.Logical().Write(path.Join(v.backendPath, "decrypt", v.keyName), map[string]interface{}{
+		"ciphertext": ciphertext,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decrypt with vault transit key %q", v.keyName)
+	}
+	if s == nil {
+		return "", errors.Errorf("vault transit key %q returned no plaintext", v.keyName)
+	}
+	encoded, ok := s.Data["plaintext"].(string)
+	if !ok || encoded == "" {
+		return "", errors.Errorf("vault transit key %q returned no plaintext", v.keyName)
+	}
+	plaintext, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to decode plaintext from vault transit key %q", v.keyName)
+	}
+
+	return string(plaintext), nil
+}
+
+// IsVaultTransit determines whether the OSD keys are encrypted by the Vault transit engine
+func (c *Config) IsVaultTransit() bool {
+	return c.IsVault() && VaultTransitEnabled(c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+}
+
+// putVaultTransit encrypts the key with Vault and keeps the ciphertext in a Kubernetes Secret
+func (c *Config) putVaultTransit(secretName, secretValue string) error {
+	ctx := context.TODO()
+	// The key is only encrypted once, the operator calls PutSecret on every reconcile
+	_, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(secretName), metav1.GetOptions{})
+	if err == nil {
+		logger.Debugf("key %q already exists in vault transit", secretName)
+		return nil
+	}
+	if !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to get vault transit ciphertext secret for %q", secretName)
+	}
+
+	v, err := newVaultTransitClient(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return errors.Wrap(err, "failed to init vault transit kms")
+	}
+	ciphertext, err := v.encrypt(secretValue)
+	if err != nil {
+		return err
+	}
+
+	s, err := generateOSDEncryptedKeySecret(secretName, "", c.clusterInfo)
+	if err != nil {
+		return err
+	}
+	s.StringData = nil
+	s.Data = map[string][]byte{VaultTransitCiphertextSecretKeyName: []byte(ciphertext)}
+	_, err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Create(ctx, s, metav1.CreateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to save vault transit ciphertext secret for %q", secretName)
+	}
+
+	return nil
+}
+
+// updateVaultTransit replaces the ciphertext of the key in the Kubernetes Secret
+func (c *Config) updateVaultTransit(secretName, secretValue string) error {
+	ctx := context.TODO()
+	s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(secretName), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get vault transit ciphertext secret for %q", secretName)
+	}
+
+	v, err := newVaultTransitClient(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return errors.Wrap(err, "failed to init vault transit kms")
+	}
+	ciphertext, err := v.encrypt(secretValue)
+	if err != nil {
+		return err
+	}
+
+	if s.Data == nil {
+		s.Data = map[string][]byte{}
+	}
+	s.Data[VaultTransitCiphertextSecretKeyName] = []byte(ciphertext)
+	_, err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Update(ctx, s, metav1.UpdateOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to update vault transit ciphertext secret for %q", secretName)
+	}
+
+	return nil
+}
+
+// vaultTransitCiphertext returns the ciphertext of an OSD key
+// The OSD pods receive it as an env variable since they cannot read Secrets
+func (c *Config) vaultTransitCiphertext(secretName string) (string, error) {
+	ctx := context.TODO()
+	if ciphertext := GetParam(c.clusterSpec.Security.KeyManagementService.ConnectionDetails, VaultTransitCiphertextKey); ciphertext != "" {
+		return ciphertext, nil
+	}
+
+	s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, GenerateOSDEncryptionSecretName(secretName), metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get vault transit ciphertext secret for %q", secretName)
+	}
+	ciphertext, ok := s.Data[VaultTransitCiphertextSecretKeyName]
+	if !ok || len(ciphertext) == 0 {
+		return "", errors.Errorf("vault transit ciphertext secret for %q has no key %q", secretName, VaultTransitCiphertextSecretKeyName)
+	}
+
+	return string(ciphertext), nil
+}
+
+func (c *Config) getVaultTransit(secretName string) (string, error) {
+	ciphertext, err := c.vaultTransitCiphertext(secretName)
+	if err != nil {
+		return "", err
+	}
+	v, err := newVaultTransitClient(c.context, c.clusterInfo.Namespace, c.clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to init vault transit kms")
+	}
+
+	return v.decrypt(ciphertext)
+}
+
+func vaultTransitBackendPath(config map[string]string) string {
+	if backendPath := GetParam(config, vault.VaultBackendPathKey); backendPath != "" {
+		return backendPath
+	}
+	return defaultVaultTransitBackendPath
+}
+
+func vaultTransitKeyName(config map[string]string) string {
+	if keyName := GetParam(config, VaultTransitKeyNameKey); keyName != "" {
+		return keyName
+	}
+	return defaultVaultTransitKeyName
+}
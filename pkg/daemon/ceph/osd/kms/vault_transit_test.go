@@ -0,0 +1,259 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kms
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// fakeVault implements the kubernetes auth login, the kv v1 engine and the transit engine of Vault
+type fakeVault struct {
+	sync.Mutex
+	role    string
+	jwt     string
+	token   string
+	logins  int
+	kv      map[string]map[string]interface{}
+	transit map[string]string
+}
+
+func newFakeVault(role, jwt string) *fakeVault {
+	return &fakeVault{
+		role:    role,
+		jwt:     jwt,
+		token:   "s.fake-client-token",
+		kv:      map[string]map[string]interface{}{},
+		transit: map[string]string{},
+	}
+}
+
+func (f *fakeVault) reply(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if body != nil {
+		_ = json.NewEncoder(w).Encode(body)
+	}
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.Lock()
+	defer f.Unlock()
+
+	body := map[string]interface{}{}
+	if r.Body != nil {
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}
+
+	if r.URL.Path == "/v1/auth/kubernetes/login" {
+		if body["role"] != f.role || body["jwt"] != f.jwt {
+			f.reply(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+			return
+		}
+		f.logins++
+		f.reply(w, http.StatusOK, map[string]interface{}{"auth": map[string]interface{}{"client_token": f.token}})
+		return
+	}
+
+	if r.Header.Get("X-Vault-Token") != f.token {
+		f.reply(w, http.StatusForbidden, map[string]interface{}{"errors": []string{"permission denied"}})
+		return
+	}
+
+	switch {
+	case r.URL.Path == "/v1/transit/encrypt/my-transit-key":
+		plaintext, _ := body["plaintext"].(string)
+		ciphertext := fmt.Sprintf("vault:v1:%d", len(f.transit))
+		f.transit[ciphertext] = plaintext
+		f.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"ciphertext": ciphertext}})
+	case r.URL.Path == "/v1/transit/decrypt/my-transit-key":
+		ciphertext, _ := body["ciphertext"].(string)
+		plaintext, ok := f.transit[ciphertext]
+		if !ok {
+			f.reply(w, http.StatusBadRequest, map[string]interface{}{"errors": []string{"cipher: message authentication failed"}})
+			return
+		}
+		f.reply(w, http.StatusOK, map[string]interface{}{"data": map[string]interface{}{"plaintext": plaintext}})
+	case strings.HasPrefix(r.URL.Path, "/v1/secret/"):
+		name := strings.TrimPrefix(r.URL.Path, "/v1/secret/")
+		switch r.Method {
+		case http.MethodGet:
+			data, ok := f.kv[name]
+			if !ok || data == nil {
+				f.reply(w, http.StatusNotFound, nil)
+				return
+			}
+			f.reply(w, http.StatusOK, map[string]interface{}{"data": data})
+		case http.MethodPut, http.MethodPost:
+			f.kv[name] = body
+			f.reply(w, http.StatusNoContent, nil)
+		case http.MethodDelete:
+			f.kv[name] = nil
+			f.reply(w, http.StatusNoContent, nil)
+		}
+	default:
+		f.reply(w, http.StatusNotFound, map[string]interface{}{"errors": []string{}})
+	}
+}
+
+func writeServiceAccountToken(t *testing.T, jwt string) string {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	tokenPath := filepath.Join(dir, "token")
+	err = ioutil.WriteFile(tokenPath, []byte(jwt+"\n"), 0400)
+	assert.NoError(t, err)
+	return tokenPath
+}
+
+func TestVaultKubernetesAuth(t *testing.T) {
+	fake := newFakeVault("rook-ceph-osd", "my-service-account-jwt")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	tokenPath := writeServiceAccountToken(t, "my-service-account-jwt")
+	defer os.RemoveAll(filepath.Dir(tokenPath))
+
+	ns := "rook-ceph"
+	context := &clusterd.Context{Clientset: test.New(t, 3)}
+	clusterSpec := &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{
+		"KMS_PROVIDER":                     "vault",
+		"VAULT_ADDR":                       server.URL,
+		"VAULT_BACKEND":                    "kv",
+		"VAULT_AUTH_METHOD":                "kubernetes",
+		"VAULT_AUTH_KUBERNETES_ROLE":       "rook-ceph-osd",
+		"VAULT_AUTH_KUBERNETES_TOKEN_PATH": tokenPath,
+	}}}}
+	c := NewConfig(context, clusterSpec, cephclient.AdminClusterInfo(ns))
+	assert.True(t, c.IsVault())
+	assert.False(t, c.IsVaultTransit())
+
+	// The key is stored in the kv engine without any static token
+	err := c.PutSecret("set1-data-0-7dwll", "my-dmcrypt-key")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-dmcrypt-key", fake.kv["rook-ceph-osd-encryption-key-set1-data-0-7dwll"]["rook-ceph-osd-encryption-key-set1-data-0-7dwll"])
+	key, err := c.GetSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-dmcrypt-key", key)
+	assert.NotZero(t, fake.logins)
+
+	// The transit client logs in the same way
+	client, err := newVaultClient(context, ns, clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	assert.NoError(t, err)
+	assert.Equal(t, "s.fake-client-token", client.Token())
+
+	// Error: the role does not match
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["VAULT_AUTH_KUBERNETES_ROLE"] = "another-role"
+	_, err = newVaultClient(context, ns, clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to login with role \"another-role\"")
+
+	// Error: no service account token
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["VAULT_AUTH_KUBERNETES_ROLE"] = "rook-ceph-osd"
+	clusterSpec.Security.KeyManagementService.ConnectionDetails["VAULT_AUTH_KUBERNETES_TOKEN_PATH"] = "/does/not/exist"
+	_, err = newVaultClient(context, ns, clusterSpec.Security.KeyManagementService.ConnectionDetails)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read service account token \"/does/not/exist\"")
+}
+
+func TestVaultTransit(t *testing.T) {
+	ctx := context.TODO()
+	fake := newFakeVault("rook-ceph-osd", "my-service-account-jwt")
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	tokenPath := writeServiceAccountToken(t, "my-service-account-jwt")
+	defer os.RemoveAll(filepath.Dir(tokenPath))
+
+	ns := "rook-ceph"
+	context := &clusterd.Context{Clientset: test.New(t, 3)}
+	clusterInfo := cephclient.AdminClusterInfo(ns)
+	clusterInfo.OwnerInfo = cephclient.NewMinimumOwnerInfo(t)
+	clusterSpec := &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{ConnectionDetails: map[string]string{
+		"KMS_PROVIDER":                     "vault",
+		"VAULT_ADDR":                       server.URL,
+		"VAULT_SECRET_ENGINE":              "transit",
+		"VAULT_TRANSIT_KEY":                "my-transit-key",
+		"VAULT_AUTH_METHOD":                "kubernetes",
+		"VAULT_AUTH_KUBERNETES_ROLE":       "rook-ceph-osd",
+		"VAULT_AUTH_KUBERNETES_TOKEN_PATH": tokenPath,
+	}}}}
+	c := NewConfig(context, clusterSpec, clusterInfo)
+	assert.True(t, c.IsVaultTransit())
+
+	// Only the ciphertext is stored
+	err := c.PutSecret("set1-data-0-7dwll", "my-dmcrypt-key")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(fake.kv))
+	s, err := context.Clientset.CoreV1().Secrets(ns).Get(ctx, "rook-ceph-osd-encryption-key-set1-data-0-7dwll", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "vault:v1:0", string(s.Data[VaultTransitCiphertextSecretKeyName]))
+	assert.NotContains(t, s.Data, OsdEncryptionSecretNameKeyName)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("my-dmcrypt-key")), fake.transit["vault:v1:0"])
+
+	key, err := c.GetSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-dmcrypt-key", key)
+
+	// The key is only encrypted once
+	err = c.PutSecret("set1-data-0-7dwll", "another-dmcrypt-key")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(fake.transit))
+
+	// Rotation replaces the ciphertext
+	err = c.UpdateSecret("set1-data-0-7dwll", "new-dmcrypt-key")
+	assert.NoError(t, err)
+	key, err = c.GetSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, "new-dmcrypt-key", key)
+
+	// The OSD pods receive the ciphertext as an env variable
+	clusterSpec.Security.KeyManagementService.ConnectionDetails[VaultTransitCiphertextKey] = "vault:v1:0"
+	key, err = c.GetSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+	assert.Equal(t, "my-dmcrypt-key", key)
+
+	// Error: unknown ciphertext
+	clusterSpec.Security.KeyManagementService.ConnectionDetails[VaultTransitCiphertextKey] = "vault:v1:42"
+	_, err = c.GetSecret("set1-data-0-7dwll")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to decrypt with vault transit key \"my-transit-key\"")
+	clusterSpec.Security.KeyManagementService.ConnectionDetails[VaultTransitCiphertextKey] = ""
+
+	// Nothing to delete in Vault
+	err = c.DeleteSecret("set1-data-0-7dwll")
+	assert.NoError(t, err)
+
+	// Error: no ciphertext secret
+	_, err = c.GetSecret("set1-data-1-bbgg8")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get vault transit ciphertext secret for \"set1-data-1-bbgg8\"")
+}
@@ -17,8 +17,11 @@ limitations under the License.
 package kms
 
 import (
+	"path"
+
 	"github.com/hashicorp/vault/api"
 	"github.com/libopenstorage/secrets"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	v1 "k8s.io/api/core/v1"
 )
 
@@ -34,6 +37,16 @@ const (
 
 	// File name for token file
 	VaultFileName = "vault.token"
+
+	// File name of the projected service account token used to login to Vault
+	vaultServiceAccountTokenFileName = "token"
+	// The projected token is rotated by the kubelet before it expires
+	vaultServiceAccountTokenExpirationSeconds = 3600
+	vaultServiceAccountTokenVolumeName        = "vault-service-account-token"
+
+	// Volume names of the master key
+	masterKeyVolumeName         = "rook-master-key"
+	previousMasterKeyVolumeName = "rook-previous-master-key"
 )
 
 // TLSSecretVolumeAndMount return the volume and matching volume mount for mounting the secrets into /etc/vault
@@ -78,6 +91,35 @@ func VaultVolumeAndMount(config map[string]string) (v1.Volume, v1.VolumeMount) {
 	return v, m
 }
 
+// VaultServiceAccountTokenVolumeAndMount returns the projected service account token volume used to login to Vault and its mount in VaultServiceAccountTokenDir
+func VaultServiceAccountTokenVolumeAndMount(config map[string]string) (v1.Volume, v1.VolumeMount) {
+	expirationSeconds := int64(vaultServiceAccountTokenExpirationSeconds)
+	v := v1.Volume{
+		Name: vaultServiceAccountTokenVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Projected: &v1.ProjectedVolumeSource{
+				Sources: []v1.VolumeProjection{
+					{
+						ServiceAccountToken: &v1.ServiceAccountTokenProjection{
+							Audience:          GetParam(config, VaultAuthKubernetesAudienceKey),
+							ExpirationSeconds: &expirationSeconds,
+							Path:              vaultServiceAccountTokenFileName,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	m := v1.VolumeMount{
+		Name:      vaultServiceAccountTokenVolumeName,
+		ReadOnly:  true,
+		MountPath: VaultServiceAccountTokenDir,
+	}
+
+	return v, m
+}
+
 func tlsSecretPath(tlsOption string) string {
 	switch tlsOption {
 	case api.EnvVaultCACert:
@@ -103,3 +145,60 @@ func VaultTokenFileVolume(tokenSecretName string) v1.Volume {
 					{Key: KMSTokenSecretNameKey, Path: VaultFileName},
 				}}}}
 }
+
+// KMIPVolumeAndMount returns the KMIP TLS Secret volume and its mount in EtcKMIPDir
+func KMIPVolumeAndMount(config map[string]string) (v1.Volume, v1.VolumeMount) {
+	mode := int32(0400)
+	v := v1.Volume{
+		Name: TypeKMIP,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName:  GetParam(config, KMIPTLSSecretNameKey),
+				DefaultMode: &mode,
+			},
+		},
+	}
+
+	m := v1.VolumeMount{
+		Name:      TypeKMIP,
+		ReadOnly:  true,
+		MountPath: EtcKMIPDir,
+	}
+
+	return v, m
+}
+
+// MasterKeyVolumeAndMount returns the master key volume and its mount in EtcMasterKeyDir
+// The master key comes either from a Secret or from files on the host
+func MasterKeyVolumeAndMount(spec cephv1.KeyManagementServiceSpec) ([]v1.Volume, []v1.VolumeMount) {
+	mode := int32(0400)
+	if spec.MasterKey.SecretName != "" {
+		v := v1.Volume{
+			Name: masterKeyVolumeName,
+			VolumeSource: v1.VolumeSource{
+				Secret: &v1.SecretVolumeSource{
+					SecretName:  spec.MasterKey.SecretName,
+					DefaultMode: &mode,
+				},
+			},
+		}
+		m := v1.VolumeMount{Name: masterKeyVolumeName, ReadOnly: true, MountPath: EtcMasterKeyDir}
+		return []v1.Volume{v}, []v1.VolumeMount{m}
+	}
+
+	hostPathType := v1.HostPathFile
+	volumes := []v1.Volume{{
+		Name:         masterKeyVolumeName,
+		VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: spec.MasterKey.FilePath, Type: &hostPathType}},
+	}}
+	mounts := []v1.VolumeMount{{Name: masterKeyVolumeName, ReadOnly: true, MountPath: path.Join(EtcMasterKeyDir, MasterKeySecretKeyName)}}
+	if spec.MasterKey.PreviousFilePath != "" {
+		volumes = append(volumes, v1.Volume{
+			Name:         previousMasterKeyVolumeName,
+			VolumeSource: v1.VolumeSource{HostPath: &v1.HostPathVolumeSource{Path: spec.MasterKey.PreviousFilePath, Type: &hostPathType}},
+		})
+		mounts = append(mounts, v1.VolumeMount{Name: previousMasterKeyVolumeName, ReadOnly: true, MountPath: path.Join(EtcMasterKeyDir, PreviousMasterKeySecretKeyName)})
+	}
+
+	return volumes, mounts
+}
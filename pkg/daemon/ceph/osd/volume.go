@@ -44,6 +44,7 @@ const (
 	encryptedFlag        = "--dmcrypt"
 	databaseSizeFlag     = "--block-db-size"
 	dbDeviceFlag         = "--db-devices"
+	osdIDsFlag           = "--osd-ids"
 	cephVolumeCmd        = "ceph-volume"
 	cephVolumeMinDBSize  = 1024 // 1GB
 )
@@ -76,6 +77,8 @@ type osdInfo struct {
 	Name string  `json:"name"`
 	Path string  `json:"path"`
 	Tags osdTags `json:"tags"`
+	// the physical volumes of the logical volume
+	Devices []string `json:"devices"`
 	// "data" or "journal" for filestore and "block" for bluestore
 	Type string `json:"type"`
 }
@@ -216,8 +219,15 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 		return nil, errors.Wrap(err, "failed to determine which ceph-volume mode to use")
 	}
 
+	// ceph-volume can only re-create a destroyed OSD with the same ID in lvm mode, only the new devices of the
+	// destroyed OSDs are initialized in lvm mode
+	replaceDevices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}}
+	if useRawMode && !a.pvcBacked && len(a.replaceOSDs) > 0 {
+		replaceDevices, devices = a.splitReplaceDevices(devices)
+	}
+
 	// If not raw mode we must execute a few LVM prerequisites
-	if !useRawMode {
+	if !useRawMode || len(replaceDevices.Entries) > 0 {
 		err = lvmPreReq(context, a.pvcBacked, lvBackedPV)
 		if err != nil {
 			return nil, errors.Wrap(err, "failed to run lvm prerequisites")
@@ -230,6 +240,14 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 			return nil, errors.Wrap(err, "failed to initialize devices on PVC")
 		}
 	} else {
+		if len(replaceDevices.Entries) > 0 {
+			logger.Info("initializing the new devices of the destroyed osds with lvm mode")
+			err := a.initializeDevicesLVMMode(context, replaceDevices)
+			if err != nil {
+				return nil, errors.Wrap(err, "failed to re-create the destroyed osds")
+			}
+		}
+
 		// Initialize block device OSD without LVM
 		if useRawMode {
 			logger.Info("initializing osd disk with raw mode")
@@ -254,6 +272,17 @@ func (a *OsdAgent) configureCVDevices(context *clusterd.Context, devices *Device
 	}
 	osds = append(osds, lvmOsds...)
 
+	// List the OSDs on top of the devices encrypted by Rook first so they keep their device mapper path
+	if useRawMode {
+		for _, encryptedBlock := range a.encryptedBlocks {
+			rawOsds, err = GetCephVolumeRawOSDs(context, a.clusterInfo, a.clusterInfo.FSID, encryptedBlock, "", "", false)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to get encrypted device %q provisioned by ceph-volume raw", encryptedBlock)
+			}
+			osds = appendOSDInfo(osds, rawOsds)
+		}
+	}
+
 	// List THE configured OSD with ceph-volume raw mode
 	// When the block is encrypted we need to list against the encrypted device mapper
 	if !isEncrypted {
@@ -550,6 +579,16 @@ func (a *OsdAgent) initializeDevicesRawMode(context *clusterd.Context, devices *
 		if device.Data == -1 {
 			logger.Infof("configuring new device %q", deviceArg)
 
+			// The osd is prepared on top of the opened encrypted device
+			if a.isDeviceEncrypted(device) {
+				dmPath, err := a.openHostEncryptedDevice(context, deviceArg)
+				if err != nil {
+					return errors.Wrapf(err, "failed to encrypt device %q", deviceArg)
+				}
+				a.encryptedBlocks = append(a.encryptedBlocks, dmPath)
+				deviceArg = dmPath
+			}
+
 			immediateExecuteArgs := append(baseArgs, []string{
 				"--data",
 				deviceArg,
@@ -619,6 +658,19 @@ func (a *OsdAgent) initializeDevicesLVMMode(context *clusterd.Context, devices *
 				deviceOSDCount = sanitizeOSDsPerDevice(device.Config.OSDsPerDevice)
 			}
 
+			// The volume group of the osds is created on top of the opened encrypted device
+			if a.isDeviceEncrypted(device) {
+				if a.metadataDevice != "" || device.Config.MetadataDevice != "" {
+					return errors.Errorf("failed to configure device %q, a metadata device cannot be used with an encrypted device", deviceArg)
+				}
+				dmPath, err := a.openHostEncryptedDevice(context, deviceArg)
+				if err != nil {
+					return errors.Wrapf(err, "failed to encrypt device %q", deviceArg)
+				}
+				a.encryptedBlocks = append(a.encryptedBlocks, dmPath)
+				deviceArg = dmPath
+			}
+
 			if a.metadataDevice != "" || device.Config.MetadataDevice != "" {
 				// When mixed hdd/ssd devices are given, ceph-volume configures db lv on the ssd.
 				// the device will be configured as a batch at the end of the method
@@ -677,6 +729,15 @@ func (a *OsdAgent) initializeDevicesLVMMode(context *clusterd.Context, devices *
 					}...)
 				}
 
+				// re-create the destroyed osd of the device with the same id
+				if osdID, ok := a.replaceOSDID(name, device); ok && deviceOSDCount == "1" {
+					logger.Infof("re-creating destroyed osd.%d on device %s", osdID, deviceArg)
+					immediateExecuteArgs = append(immediateExecuteArgs, []string{
+						osdIDsFlag,
+						strconv.Itoa(osdID),
+					}...)
+				}
+
 				// Reporting
 				immediateReportArgs := append(immediateExecuteArgs, []string{
 					"--report",
@@ -797,6 +858,33 @@ func (a *OsdAgent) initializeDevicesLVMMode(context *clusterd.Context, devices *
 	return nil
 }
 
+// replaceOSDID returns the ID of the destroyed OSD to re-create on the device
+func (a *OsdAgent) replaceOSDID(name string, device *DeviceOsdIDEntry) (int, bool) {
+	if osdID, ok := a.replaceOSDs[path.Join("/dev", name)]; ok {
+		return osdID, true
+	}
+	for _, devLink := range device.PersistentDevicePaths {
+		if osdID, ok := a.replaceOSDs[devLink]; ok {
+			return osdID, true
+		}
+	}
+	return 0, false
+}
+
+// splitReplaceDevices splits the devices to configure into the new devices of the destroyed OSDs and the others
+func (a *OsdAgent) splitReplaceDevices(devices *DeviceOsdMapping) (*DeviceOsdMapping, *DeviceOsdMapping) {
+	replaceDevices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}}
+	otherDevices := &DeviceOsdMapping{Entries: map[string]*DeviceOsdIDEntry{}}
+	for name, device := range devices.Entries {
+		if _, ok := a.replaceOSDID(name, device); ok && device.Data == -1 {
+			replaceDevices.Entries[name] = device
+			continue
+		}
+		otherDevices.Entries[name] = device
+	}
+	return replaceDevices, otherDevices
+}
+
 func lvmPreReq(context *clusterd.Context, pvcBacked, lvBackedPV bool) error {
 	// Check for the presence of LVM on the host when NOT running on PVC
 	// since this scenario is still using LVM
@@ -854,7 +942,7 @@ func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 			logger.Errorf("bad osd returned from ceph-volume %q", name)
 			continue
 		}
-		var osdFSID string
+		var osdFSID, encryptedDeviceUUID, deviceSerial, devicePath string
 		store := "bluestore"
 		for _, osd := range osdInfo {
 			if osd.Tags.ClusterFSID != cephfsid {
@@ -871,6 +959,18 @@ func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 				lvPath = osd.Path
 			}
 
+			// The volume group sits on a device of the node encrypted by Rook
+			for _, device := range osd.Devices {
+				if luksUUID := hostEncryptedDeviceUUID(device); luksUUID != "" {
+					encryptedDeviceUUID = luksUUID
+				}
+			}
+
+			if osd.Type == "block" && len(osd.Devices) > 0 {
+				deviceSerial = localDiskSerial(context.Devices, osd.Devices[0])
+				devicePath = localDiskSlotPath(context.Devices, osd.Devices[0])
+			}
+
 		}
 
 		if len(osdFSID) == 0 {
@@ -893,6 +993,10 @@ func GetCephVolumeLVMOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 			LVBackedPV:    lvBackedPV,
 			CVMode:        cvMode,
 			Store:         store,
+			// Empty unless the device is encrypted by Rook
+			EncryptedDeviceUUID: encryptedDeviceUUID,
+			DeviceSerial:        deviceSerial,
+			DevicePath:          devicePath,
 		}
 		osds = append(osds, osd)
 	}
@@ -1000,8 +1104,16 @@ func GetCephVolumeRawOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 			Store:         "bluestore",
 		}
 
-		// If this is an encrypted OSD
-		if os.Getenv(oposd.CephVolumeEncryptedKeyEnvVarName) != "" {
+		// The OSD sits on a device of the node encrypted by Rook
+		osd.EncryptedDeviceUUID = hostEncryptedDeviceUUID(blockPath)
+		if !isOnPVC {
+			osd.DeviceSerial = localDiskSerial(context.Devices, osdInfo.Device)
+			osd.DevicePath = localDiskSlotPath(context.Devices, osdInfo.Device)
+		}
+
+		// If this is an encrypted OSD on PVC
+		// The devices of the node encrypted by Rook remain opened, they are not handled by ceph-volume
+		if isOnPVC && os.Getenv(oposd.CephVolumeEncryptedKeyEnvVarName) != "" {
 			// Close encrypted device
 			err = closeEncryptedDevice(context, block)
 			if err != nil {
@@ -1034,6 +1146,56 @@ func GetCephVolumeRawOSDs(context *clusterd.Context, clusterInfo *client.Cluster
 	return osds, nil
 }
 
+// localDiskSerial returns the serial of the disk of the node under the device path
+func localDiskSerial(devices []*sys.LocalDisk, devicePath string) string {
+	disk := localDiskUnder(devices, devicePath)
+	if disk == nil {
+		return ""
+	}
+	return disk.Serial
+}
+
+// localDiskSlotPath returns the by-path link of the disk of the node under the device path, the link follows the slot
+// of the disk so a new disk plugged in the same slot gets the same link
+func localDiskSlotPath(devices []*sys.LocalDisk, devicePath string) string {
+	disk := localDiskUnder(devices, devicePath)
+	if disk == nil {
+		return ""
+	}
+	for _, devLink := range strings.Fields(disk.DevLinks) {
+		if strings.HasPrefix(devLink, "/dev/disk/by-path/") {
+			return devLink
+		}
+	}
+	return ""
+}
+
+func localDiskUnder(devices []*sys.LocalDisk, devicePath string) *sys.LocalDisk {
+	disk := findLocalDisk(devices, devicePath)
+	// The OSD sits on a device mapper opened by Rook on top of the disk
+	if disk != nil && disk.Type == sys.CryptType && disk.Parent != "" {
+		disk = findLocalDisk(devices, disk.Parent)
+	}
+	return disk
+}
+
+func findLocalDisk(devices []*sys.LocalDisk, devicePath string) *sys.LocalDisk {
+	for _, device := range devices {
+		if device == nil {
+			continue
+		}
+		if device.Name == path.Base(devicePath) || device.RealPath == devicePath {
+			return device
+		}
+		for _, devLink := range strings.Fields(device.DevLinks) {
+			if devLink == devicePath {
+				return device
+			}
+		}
+	}
+	return nil
+}
+
 func callCephVolume(context *clusterd.Context, requiresCombinedOutput bool, args ...string) (string, error) {
 	// Use stdbuf to capture the python output buffer such that we can write to the pod log as the
 	// logging happens instead of using the default buffering that will log everything after
@@ -32,6 +32,7 @@ import (
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/test"
 	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/rook/rook/pkg/util/sys"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -593,6 +594,32 @@ func TestInitializeBlock(t *testing.T) {
 		logger.Info("success, go to next test")
 	}
 
+	// Test re-creating a destroyed osd with the same id
+	{
+		executor := &exectest.MockExecutor{}
+		executor.MockExecuteCommand = func(command string, args ...string) error {
+			logger.Infof("%s %v", command, args)
+
+			// Validate base common args
+			err := testBaseArgs(args)
+			if err != nil {
+				return err
+			}
+
+			if args[9] == "--osds-per-device" && args[10] == "1" && args[11] == "/dev/sda" && args[12] == "--osd-ids" && args[13] == "3" {
+				return nil
+			}
+
+			return errors.Errorf("unknown command %s %s", command, args)
+		}
+		a := &OsdAgent{clusterInfo: &cephclient.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 8}}, nodeName: "node1", replaceOSDs: map[string]int{"/dev/sda": 3}}
+		context := &clusterd.Context{Executor: executor}
+
+		err := a.initializeDevicesLVMMode(context, devices)
+		assert.NoError(t, err, "failed destroyed osd test")
+		logger.Info("success, go to next test")
+	}
+
 	// Test encryption behavior
 	{
 		executor := &exectest.MockExecutor{}
@@ -625,6 +652,55 @@ func TestInitializeBlock(t *testing.T) {
 		logger.Info("success, go to next test")
 	}
 
+	// Test encryption of the device by rook with the key of the node
+	{
+		os.Setenv(oposd.CephVolumeEncryptedKeyEnvVarName, "my-dmcrypt-key")
+		defer os.Unsetenv(oposd.CephVolumeEncryptedKeyEnvVarName)
+		var dmPath string
+		executor := &exectest.MockExecutor{}
+		executor.MockExecuteCommandWithCombinedOutput = func(command string, args ...string) (string, error) {
+			logger.Infof("%s %v", command, args)
+			if command == "cryptsetup" && args[6] == "luksFormat" && args[7] == "/dev/sda" {
+				return "", nil
+			}
+			if command == "cryptsetup" && args[0] == "luksOpen" && args[6] == "/dev/sda" {
+				dmPath = "/dev/mapper/" + args[7]
+				return "", nil
+			}
+
+			return "", errors.Errorf("unknown command %s %s", command, args)
+		}
+		executor.MockExecuteCommand = func(command string, args ...string) error {
+			logger.Infof("%s %v", command, args)
+
+			// Validate base common args
+			err := testBaseArgs(args)
+			if err != nil {
+				return err
+			}
+
+			// ceph-volume does not encrypt the device itself
+			if args[9] == "--osds-per-device" && args[10] == "1" && args[11] == dmPath {
+				return nil
+			}
+
+			return errors.Errorf("unknown command %s %s", command, args)
+		}
+		a := &OsdAgent{clusterInfo: &cephclient.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 8}}, nodeName: "node1", storeConfig: config.StoreConfig{Encrypted: true}}
+		context := &clusterd.Context{Executor: executor}
+
+		err := a.initializeDevicesLVMMode(context, devices)
+		assert.NoError(t, err, "failed host encryption test")
+		assert.NotEqual(t, "", hostEncryptedDeviceUUID(dmPath))
+		assert.Equal(t, []string{dmPath}, a.encryptedBlocks)
+
+		// A metadata device cannot be used with an encrypted device
+		a = &OsdAgent{clusterInfo: &cephclient.ClusterInfo{CephVersion: cephver.CephVersion{Major: 14, Minor: 2, Extra: 8}}, nodeName: "node1", metadataDevice: "nvme0n1", storeConfig: config.StoreConfig{Encrypted: true}}
+		err = a.initializeDevicesLVMMode(context, devices)
+		assert.Error(t, err)
+		logger.Info("success, go to next test")
+	}
+
 	// Test multiple OSD per device
 	{
 		executor := &exectest.MockExecutor{}
@@ -1362,3 +1438,46 @@ func TestAppendOSDInfo(t *testing.T) {
 		assert.Equal(t, 3, len(trimmedOSDs))
 	}
 }
+
+func TestLocalDiskSerial(t *testing.T) {
+	devices := []*sys.LocalDisk{
+		{Name: "sda", RealPath: "/dev/sda", Type: sys.DiskType, Serial: "serial-a", DevLinks: "/dev/disk/by-id/wwn-a /dev/disk/by-path/pci-a"},
+		{Name: "dm-0", RealPath: "/dev/mapper/ceph-crypt", Type: sys.CryptType, Parent: "sdb"},
+		{Name: "sdb", RealPath: "/dev/sdb", Type: sys.DiskType, Serial: "serial-b", DevLinks: "/dev/disk/by-path/pci-b"},
+	}
+
+	assert.Equal(t, "serial-a", localDiskSerial(devices, "/dev/sda"))
+	assert.Equal(t, "serial-a", localDiskSerial(devices, "/dev/disk/by-path/pci-a"))
+	assert.Equal(t, "serial-b", localDiskSerial(devices, "/dev/mapper/ceph-crypt"))
+	assert.Equal(t, "", localDiskSerial(devices, "/dev/sdc"))
+
+	assert.Equal(t, "/dev/disk/by-path/pci-a", localDiskSlotPath(devices, "/dev/sda"))
+	assert.Equal(t, "/dev/disk/by-path/pci-b", localDiskSlotPath(devices, "/dev/mapper/ceph-crypt"))
+	assert.Equal(t, "", localDiskSlotPath(devices, "/dev/sdc"))
+}
+
+func TestSplitReplaceDevices(t *testing.T) {
+	devices := &DeviceOsdMapping{
+		Entries: map[string]*DeviceOsdIDEntry{
+			"sda": {Data: -1, Config: DesiredDevice{Name: "/dev/sda"}},
+			"sdb": {Data: -1, Config: DesiredDevice{Name: "/dev/sdb"}, PersistentDevicePaths: []string{"/dev/disk/by-path/pci-b"}},
+			"sdc": {Data: 2, Config: DesiredDevice{Name: "/dev/sdc"}},
+		},
+	}
+
+	// only the new device of the destroyed osd is re-created in lvm mode
+	a := &OsdAgent{replaceOSDs: map[string]int{"/dev/disk/by-path/pci-b": 3}}
+	replaceDevices, otherDevices := a.splitReplaceDevices(devices)
+	assert.Equal(t, 1, len(replaceDevices.Entries))
+	assert.NotNil(t, replaceDevices.Entries["sdb"])
+	assert.Equal(t, 2, len(otherDevices.Entries))
+	osdID, ok := a.replaceOSDID("sdb", devices.Entries["sdb"])
+	assert.True(t, ok)
+	assert.Equal(t, 3, osdID)
+
+	// a device already configured is never re-created
+	a = &OsdAgent{replaceOSDs: map[string]int{"/dev/sdc": 3}}
+	replaceDevices, otherDevices = a.splitReplaceDevices(devices)
+	assert.Equal(t, 0, len(replaceDevices.Entries))
+	assert.Equal(t, 3, len(otherDevices.Entries))
+}
@@ -43,7 +43,6 @@ import (
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
-	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
@@ -149,6 +148,10 @@ func (c *cluster) doOrchestration(rookImage string, cephVersion cephver.CephVers
 		return errors.Wrap(err, "failed to start ceph osds")
 	}
 
+	if err := osds.ConfigureRecovery(); err != nil {
+		return errors.Wrap(err, "failed to configure the recovery of the ceph osds")
+	}
+
 	// If a stretch cluster, enable the arbiter after the OSDs are created with the CRUSH map
 	if c.Spec.IsStretchCluster() {
 		if err := c.mons.ConfigureArbiter(); err != nil {
@@ -208,6 +211,16 @@ func (c *ClusterController) initializeCluster(cluster *cluster, clusterObj *ceph
 			c.configureCephMonitoring(cluster, clusterInfo)
 		}
 
+		// The mon quorum is restored from a single mon when the mons are started if requested on the CephCluster
+		if healthyMon, ok := clusterObj.Annotations[mon.RestoreQuorumAnnotation]; ok {
+			cluster.mons.RequestQuorumRestore(clusterObj.Name, healthyMon)
+		}
+
+		// The mon store is rebuilt from the osds when the mons are started if requested on the CephCluster
+		if keptMon, ok := clusterObj.Annotations[mon.RebuildMonStoreAnnotation]; ok {
+			cluster.mons.RequestMonStoreRebuild(clusterObj.Name, keptMon)
+		}
+
 		err = c.configureLocalCephCluster(cluster)
 		if err != nil {
 			opcontroller.UpdateCondition(c.context, c.namespacedName, cephv1.ConditionProgressing, v1.ConditionFalse, cephv1.ClusterProgressingReason, err.Error())
@@ -371,31 +384,13 @@ func (c *ClusterController) preClusterStartValidation(cluster *cluster) error {
 			return errors.New("both network selector values for public and cluster selector cannot be empty for multus provider")
 		}
 
-		for _, selector := range config.NetworkSelectors {
-			// If one selector is empty, we continue
-			// This means a single interface is used both public and cluster network
-			if _, ok := cluster.Spec.Network.Selectors[selector]; !ok {
-				continue
-			}
-
-			multusNamespace, nad := config.GetMultusNamespace(cluster.Spec.Network.Selectors[selector])
-			if multusNamespace == "" {
-				multusNamespace = cluster.Namespace
-			}
-
-			// Get network attachment definition
-			_, err := c.context.NetworkClient.NetworkAttachmentDefinitions(multusNamespace).Get(ctx, nad, metav1.GetOptions{})
-			if err != nil {
-				if kerrors.IsNotFound(err) {
-					return errors.Wrapf(err, "specified network attachment definition for selector %q does not exist", selector)
-				}
-				return errors.Wrapf(err, "failed to fetch network attachment definition for selector %q", selector)
-			}
+		if err := c.validateNetwork(cluster); err != nil {
+			return err
 		}
 	}
 
-	// Validate on-PVC cluster encryption KMS settings
-	if cluster.Spec.Storage.IsOnPVCEncrypted() && cluster.Spec.Security.KeyManagementService.IsEnabled() {
+	// Validate on-PVC and on-host cluster encryption KMS settings
+	if (cluster.Spec.Storage.IsOnPVCEncrypted() || cluster.Spec.Storage.IsOnHostEncrypted()) && cluster.Spec.Security.KeyManagementService.IsEnabled() {
 		// Validate the KMS details
 		err := kms.ValidateConnectionDetails(c.context, cluster.Spec, cluster.Namespace)
 		if err != nil {
@@ -403,6 +398,12 @@ func (c *ClusterController) preClusterStartValidation(cluster *cluster) error {
 		}
 	}
 
+	// Validate the master key wrapping the keys stored in Kubernetes Secrets
+	err := kms.ValidateMasterKey(c.context, cluster.Spec, cluster.Namespace)
+	if err != nil {
+		return errors.Wrap(err, "failed to validate master key")
+	}
+
 	logger.Debug("cluster spec successfully validated")
 	return nil
 }
@@ -411,24 +412,34 @@ func validateStretchCluster(cluster *cluster) error {
 	if !cluster.Spec.IsStretchCluster() {
 		return nil
 	}
-	if len(cluster.Spec.Mon.StretchCluster.Zones) != 3 {
-		return errors.Errorf("expecting exactly three zones for the stretch cluster, but found %d", len(cluster.Spec.Mon.StretchCluster.Zones))
-	}
-	if cluster.Spec.Mon.Count != 3 && cluster.Spec.Mon.Count != 5 {
-		return errors.Errorf("invalid number of mons %d for a stretch cluster, expecting 5 (recommended) or 3 (minimal)", cluster.Spec.Mon.Count)
+	zones := cluster.Spec.Mon.StretchCluster.Zones
+	if len(zones) < 3 {
+		return errors.Errorf("expecting at least three zones for the stretch cluster, two data zones and an arbiter zone, but found %d", len(zones))
 	}
 	arbitersFound := 0
-	for _, zone := range cluster.Spec.Mon.StretchCluster.Zones {
+	zoneNames := map[string]bool{}
+	for _, zone := range zones {
 		if zone.Arbiter {
 			arbitersFound++
 		}
 		if zone.Name == "" {
 			return errors.New("missing zone name for the stretch cluster")
 		}
+		if zoneNames[zone.Name] {
+			return errors.Errorf("zone %q is listed more than once for the stretch cluster", zone.Name)
+		}
+		zoneNames[zone.Name] = true
 	}
 	if arbitersFound != 1 {
 		return errors.Errorf("expecting to find exactly one arbiter zone, but found %d", arbitersFound)
 	}
+	// The mons are spread evenly across the data zones, with a single mon in the arbiter zone
+	dataZones := len(zones) - 1
+	monCount := cluster.Spec.Mon.Count
+	if monCount < dataZones+1 || (monCount-1)%dataZones != 0 {
+		return errors.Errorf("invalid number of mons %d for a stretch cluster with %d data zones, expecting the same number of mons in each data zone and one mon in the arbiter zone, e.g. %d (recommended)",
+			monCount, dataZones, 2*dataZones+1)
+	}
 	return nil
 }
 
@@ -59,6 +59,23 @@ func TestPreClusterStartValidation(t *testing.T) {
 			{Name: "b"},
 			{Name: "c"},
 		}}}}}}, true},
+		{"duplicate zone name", args{&cluster{Spec: &cephv1.ClusterSpec{Mon: cephv1.MonSpec{Count: 3, StretchCluster: &cephv1.StretchClusterSpec{Zones: []cephv1.StretchClusterZoneSpec{
+			{Name: "a", Arbiter: true},
+			{Name: "b"},
+			{Name: "b"},
+		}}}}}}, true},
+		{"valid stretch cluster with three data zones", args{&cluster{Spec: &cephv1.ClusterSpec{Mon: cephv1.MonSpec{Count: 7, AllowMultiplePerNode: true, StretchCluster: &cephv1.StretchClusterSpec{Zones: []cephv1.StretchClusterZoneSpec{
+			{Name: "a", Arbiter: true},
+			{Name: "b"},
+			{Name: "c"},
+			{Name: "d"},
+		}}}}}}, false},
+		{"mons not spread evenly across three data zones", args{&cluster{Spec: &cephv1.ClusterSpec{Mon: cephv1.MonSpec{Count: 5, AllowMultiplePerNode: true, StretchCluster: &cephv1.StretchClusterSpec{Zones: []cephv1.StretchClusterZoneSpec{
+			{Name: "a", Arbiter: true},
+			{Name: "b"},
+			{Name: "c"},
+			{Name: "d"},
+		}}}}}}, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
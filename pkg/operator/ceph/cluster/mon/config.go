@@ -231,6 +231,9 @@ func loadMonConfig(clientset kubernetes.Interface, namespace string) (map[string
 	if info, ok := cm.Data[EndpointDataKey]; ok {
 		monEndpointMap = ParseMonEndpoints(info)
 	}
+	if info, ok := cm.Data[SecondaryEndpointDataKey]; ok {
+		parseMonSecondaryEndpoints(info, monEndpointMap)
+	}
 
 	// Parse the max monitor id
 	storedMaxMonID := -1
@@ -33,6 +33,19 @@ func FlattenMonEndpoints(mons map[string]*cephclient.MonInfo) string {
 	return strings.Join(endpoints, ",")
 }
 
+// FlattenMonSecondaryEndpoints returns a comma-delimited string of the mons and their endpoints in the second IP
+// family of a dual-stack cluster in the form <mon-name>=<mon-secondary-endpoint>. The mons without a secondary
+// endpoint are skipped.
+func FlattenMonSecondaryEndpoints(mons map[string]*cephclient.MonInfo) string {
+	endpoints := []string{}
+	for _, m := range mons {
+		if m.SecondaryEndpoint != "" {
+			endpoints = append(endpoints, fmt.Sprintf("%s=%s", m.Name, m.SecondaryEndpoint))
+		}
+	}
+	return strings.Join(endpoints, ",")
+}
+
 // ParseMonEndpoints parses a flattened representation of mons and endpoints in the form
 // <mon-name>=<mon-endpoint> and returns a list of Ceph mon configs.
 func ParseMonEndpoints(input string) map[string]*cephclient.MonInfo {
@@ -49,3 +62,19 @@ func ParseMonEndpoints(input string) map[string]*cephclient.MonInfo {
 	}
 	return mons
 }
+
+// parseMonSecondaryEndpoints sets the endpoints in the second IP family of a dual-stack cluster of the mons, from a
+// flattened representation in the form <mon-name>=<mon-secondary-endpoint>
+func parseMonSecondaryEndpoints(input string, mons map[string]*cephclient.MonInfo) {
+	logger.Infof("parsing mon secondary endpoints: %s", input)
+	for _, rawMon := range strings.Split(input, ",") {
+		parts := strings.Split(rawMon, "=")
+		if len(parts) != 2 {
+			logger.Warningf("ignoring invalid monitor secondary endpoint %s", rawMon)
+			continue
+		}
+		if mon, ok := mons[parts[0]]; ok {
+			mon.SecondaryEndpoint = parts[1]
+		}
+	}
+}
@@ -45,4 +45,18 @@ func TestMonFlattening(t *testing.T) {
 	assert.Equal(t, "1.2.3.4:5000", parsed["foo"].Endpoint)
 	assert.Equal(t, "bar", parsed["bar"].Name)
 	assert.Equal(t, "2.3.4.5:6000", parsed["bar"].Endpoint)
+	assert.Equal(t, "", parsed["bar"].SecondaryEndpoint)
+
+	// dual-stack endpoints are kept out of the endpoints of the first IP family
+	mons = map[string]*cephclient.MonInfo{
+		"foo": {Name: "foo", Endpoint: "1.2.3.4:6789", SecondaryEndpoint: "[fd00::1]:6789"},
+		"bar": {Name: "bar", Endpoint: "2.3.4.5:6789"},
+	}
+	flattened = FlattenMonEndpoints(mons)
+	parsed = ParseMonEndpoints(flattened)
+	assert.Equal(t, "", parsed["foo"].SecondaryEndpoint)
+	secondary := FlattenMonSecondaryEndpoints(mons)
+	assert.Equal(t, "foo=[fd00::1]:6789", secondary)
+	parseMonSecondaryEndpoints(secondary, parsed)
+	assert.Equal(t, mons, parsed)
 }
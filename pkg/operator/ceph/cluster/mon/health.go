@@ -19,6 +19,7 @@ package mon
 import (
 	"context"
 	"fmt"
+	"net"
 	"strings"
 	"time"
 
@@ -85,6 +86,10 @@ func (hc *HealthChecker) Check(stopCh chan struct{}) {
 			if err != nil {
 				logger.Warningf("failed to check mon health. %v", err)
 			}
+			err = hc.monCluster.checkStore()
+			if err != nil {
+				logger.Warningf("failed to check mon store. %v", err)
+			}
 		}
 	}
 }
@@ -329,11 +334,11 @@ func (c *Cluster) failoverMon(name string) error {
 		}
 	}()
 
-	// remove the failed mon from a local list of the existing mons for finding a stretch zone
+	// remove the failed mon from a local list of the existing mons for finding a zone that keeps the mons balanced
 	existingMons := c.clusterInfoToMonConfig(name)
-	zone, err := c.findAvailableZoneIfStretched(existingMons)
+	zone, err := c.findAvailableZone(existingMons)
 	if err != nil {
-		return errors.Wrap(err, "failed to find available stretch zone")
+		return errors.Wrap(err, "failed to find available zone")
 	}
 
 	// Start a new monitor
@@ -361,7 +366,7 @@ func (c *Cluster) failoverMon(name string) error {
 		}
 		m.PublicIP = serviceIP
 	}
-	c.ClusterInfo.Monitors[m.DaemonName] = cephclient.NewMonInfo(m.DaemonName, m.PublicIP, m.Port)
+	c.ClusterInfo.Monitors[m.DaemonName] = m.monInfo()
 
 	// Start the deployment
 	if err := c.startDeployments(mConf, true); err != nil {
@@ -518,6 +523,7 @@ func (c *Cluster) addOrRemoveExternalMonitor(status client.MonStatusResponse) (b
 				monPort := cephutil.GetPortFromEndpoint(endpoint)
 				logger.Infof("new external mon %q found: %s, adding it", mon.Name, endpoint)
 				c.ClusterInfo.Monitors[mon.Name] = cephclient.NewMonInfo(mon.Name, monIP, monPort)
+				c.ClusterInfo.Monitors[mon.Name].SecondaryEndpoint = secondaryMonEndpoint(mon, endpoint)
 			} else {
 				logger.Debugf("mon %q is not in quorum and not in ClusterInfo", mon.Name)
 			}
@@ -554,3 +560,19 @@ func (c *Cluster) addOrRemoveExternalMonitor(status client.MonStatusResponse) (b
 	logger.Debugf("ClusterInfo.Monitors is %+v", c.ClusterInfo.Monitors)
 	return changed, nil
 }
+
+// secondaryMonEndpoint returns the endpoint of a mon of a dual-stack cluster in the IP family that is not the one
+// of its public address, on the same port. It is empty if the mon only has addresses in one family.
+func secondaryMonEndpoint(mon cephclient.MonMapEntry, endpoint string) string {
+	isIPv4 := net.ParseIP(cephutil.GetIPFromEndpoint(endpoint)).To4() != nil
+	port := cephutil.GetPortFromEndpoint(endpoint)
+	for _, addr := range mon.PublicAddrs.Addrvec {
+		// the addresses of the addrvec are like "10.97.171.131:6789/0" too
+		secondary := strings.Split(addr.Addr, "/")[0]
+		ip := net.ParseIP(cephutil.GetIPFromEndpoint(secondary))
+		if ip != nil && (ip.To4() != nil) != isIPv4 && cephutil.GetPortFromEndpoint(secondary) == port {
+			return secondary
+		}
+	}
+	return ""
+}
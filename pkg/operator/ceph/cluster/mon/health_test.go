@@ -380,6 +380,28 @@ func TestAddOrRemoveExternalMonitor(t *testing.T) {
 	assert.True(t, changed)
 	// ClusterInfo should now have 2 monitors
 	assert.Equal(t, 2, len(c.ClusterInfo.Monitors))
+	assert.Equal(t, "", c.ClusterInfo.Monitors["b"].SecondaryEndpoint)
+
+	//
+	// TEST 4
+	//
+	// A new mon of a dual-stack external cluster has an endpoint in each IP family
+	fakeResp.MonMap.Mons = append(fakeResp.MonMap.Mons, client.MonMapEntry{Name: "c", PublicAddr: "172.17.0.6:3300/0"})
+	fakeResp.MonMap.Mons[2].PublicAddrs.Addrvec = []client.AddrvecEntry{
+		{Type: "v2", Addr: "172.17.0.6:3300"},
+		{Type: "v1", Addr: "172.17.0.6:6789"},
+		{Type: "v1", Addr: "[fd00::6]:6789"},
+		{Type: "v2", Addr: "[fd00::6]:3300"},
+	}
+	fakeResp.Quorum = []int{0, 1, 2}
+	for i := range fakeResp.MonMap.Mons {
+		fakeResp.MonMap.Mons[i].Rank = i
+	}
+	changed, err = c.addOrRemoveExternalMonitor(fakeResp)
+	assert.NoError(t, err)
+	assert.True(t, changed)
+	assert.Equal(t, "172.17.0.6:3300", c.ClusterInfo.Monitors["c"].Endpoint)
+	assert.Equal(t, "[fd00::6]:3300", c.ClusterInfo.Monitors["c"].SecondaryEndpoint)
 }
 
 func TestNewHealthChecker(t *testing.T) {
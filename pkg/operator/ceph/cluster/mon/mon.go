@@ -23,6 +23,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"reflect"
 	"strconv"
 	"strings"
@@ -57,6 +58,9 @@ const (
 	EndpointConfigMapName = "rook-ceph-mon-endpoints"
 	// EndpointDataKey is the name of the key inside the mon configmap to get the endpoints
 	EndpointDataKey = "data"
+	// SecondaryEndpointDataKey is the name of the key inside the mon configmap to get the endpoints in the second IP
+	// family of a dual-stack cluster
+	SecondaryEndpointDataKey = "secondaryData"
 	// MaxMonIDKey is the name of the max mon id used
 	MaxMonIDKey = "maxMonId"
 	// MappingKey is the name of the mapping for the mon->node and node->port
@@ -119,11 +123,22 @@ type Cluster struct {
 	maxMonID           int
 	waitForStart       bool
 	monTimeoutList     map[string]time.Time
-	mapping            *Mapping
-	ownerInfo          *k8sutil.OwnerInfo
-	csiConfigMutex     *sync.Mutex
-	isUpgrade          bool
-	arbiterMon         string
+	// the last compaction of the store of each mon, the mon being compacted and the mons that are low on disk space
+	monStoreCompactions map[string]time.Time
+	monStoreCompacting  string
+	monStoreMutex       sync.Mutex
+	monsLowOnDisk       map[string]bool
+	// the CephCluster requesting to restore the quorum from a single mon, and the name of the mon if requested
+	quorumRestoreCluster string
+	quorumRestoreMon     string
+	// the CephCluster requesting to rebuild the mon store from the osds, and the name of the mon to keep if requested
+	storeRebuildCluster string
+	storeRebuildMon     string
+	mapping             *Mapping
+	ownerInfo           *k8sutil.OwnerInfo
+	csiConfigMutex      *sync.Mutex
+	isUpgrade           bool
+	arbiterMon          string
 }
 
 // monConfig for a single monitor
@@ -134,6 +149,8 @@ type monConfig struct {
 	DaemonName string
 	// PublicIP is the IP of the mon's service that the mon will receive connections on
 	PublicIP string
+	// SecondaryPublicIP is the IP of the mon's service in the second IP family of a dual-stack cluster
+	SecondaryPublicIP string
 	// Port is the port on which the mon will listen for connections
 	Port int32
 	// The zone used for a stretch cluster
@@ -167,12 +184,14 @@ type SchedulingResult struct {
 // New creates an instance of a mon cluster
 func New(context *clusterd.Context, namespace string, spec cephv1.ClusterSpec, ownerInfo *k8sutil.OwnerInfo, csiConfigMutex *sync.Mutex) *Cluster {
 	return &Cluster{
-		context:        context,
-		spec:           spec,
-		Namespace:      namespace,
-		maxMonID:       -1,
-		waitForStart:   true,
-		monTimeoutList: map[string]time.Time{},
+		context:             context,
+		spec:                spec,
+		Namespace:           namespace,
+		maxMonID:            -1,
+		waitForStart:        true,
+		monTimeoutList:      map[string]time.Time{},
+		monStoreCompactions: map[string]time.Time{},
+		monsLowOnDisk:       map[string]bool{},
 		mapping: &Mapping{
 			Schedule: map[string]*MonScheduleInfo{},
 		},
@@ -198,6 +217,11 @@ func (c *Cluster) Start(clusterInfo *cephclient.ClusterInfo, rookVersion string,
 		return nil, errors.Errorf("refusing to deploy %d monitors on the same host with host networking and allowMultiplePerNode is %t. only one monitor per node is allowed", c.spec.Mon.Count, c.spec.Mon.AllowMultiplePerNode)
 	}
 
+	// the zones of a stretch cluster are only configured in the stretch cluster settings
+	if c.spec.IsStretchCluster() && (len(c.spec.Mon.Zones) > 0 || c.spec.Mon.FailureDomainLabel != "") {
+		return nil, errors.New("the mon zones and failureDomainLabel settings cannot be set in a stretch cluster, use the stretchCluster settings instead")
+	}
+
 	// Validate pod's memory if specified
 	err := controller.CheckPodMemory(cephv1.ResourcesKeyMon, cephv1.GetMonResources(c.spec.Resources), cephMonPodMinimumMemory)
 	if err != nil {
@@ -211,6 +235,18 @@ func (c *Cluster) Start(clusterInfo *cephclient.ClusterInfo, rookVersion string,
 		return nil, errors.Wrap(err, "failed to initialize ceph cluster info")
 	}
 
+	if c.quorumRestoreCluster != "" {
+		if err := c.restoreQuorum(); err != nil {
+			return nil, errors.Wrap(err, "failed to restore mon quorum")
+		}
+	}
+
+	if c.storeRebuildCluster != "" {
+		if err := c.rebuildMonStore(); err != nil {
+			return nil, errors.Wrap(err, "failed to rebuild mon store")
+		}
+	}
+
 	logger.Infof("targeting the mon count %d", c.spec.Mon.Count)
 
 	// create the mons for a new cluster or ensure mons are running in an existing cluster
@@ -332,6 +368,25 @@ func (c *Cluster) getArbiterZone() string {
 	return ""
 }
 
+// stretchMonsPerDataZone returns the number of mons in each data zone of the stretch cluster, the mons that are not
+// in the arbiter zone are spread evenly across the data zones
+func (c *Cluster) stretchMonsPerDataZone() int {
+	dataZones := len(c.spec.Mon.StretchCluster.DataZones())
+	if dataZones == 0 {
+		return 0
+	}
+	return (c.spec.Mon.Count - 1) / dataZones
+}
+
+func (c *Cluster) isStretchZone(zone string) bool {
+	for _, z := range c.spec.Mon.StretchCluster.Zones {
+		if z.Name == zone {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Cluster) isArbiterZone(zone string) bool {
 	if !c.spec.IsStretchCluster() {
 		return false
@@ -351,6 +406,9 @@ func (c *Cluster) assignStretchMonsToZones(mons []*monConfig) error {
 	// Set the location for each mon
 	domainName := c.stretchFailureDomainName()
 	for _, mon := range mons {
+		if !c.isStretchZone(mon.Zone) {
+			return errors.Errorf("mon %q is assigned to zone %q that is not a zone of the stretch cluster", mon.DaemonName, mon.Zone)
+		}
 		if mon.Zone == arbiterZone {
 			// remember the arbiter mon to be set later in the reconcile after the OSDs are configured
 			c.arbiterMon = mon.DaemonName
@@ -379,6 +437,12 @@ func (c *Cluster) assignStretchMonsToZones(mons []*monConfig) error {
 }
 
 func (c *Cluster) ConfigureArbiter() error {
+	if !c.spec.IsStretchMode() {
+		// Ceph stretch mode only supports two data zones, the pools of the other stretch clusters survive the loss
+		// of a zone with their CRUSH rule and min_size, and the mons with the connectivity election strategy
+		logger.Infof("not enabling ceph stretch mode for a stretch cluster with %d data zones", len(c.spec.Mon.StretchCluster.DataZones()))
+		return nil
+	}
 	if c.arbiterMon == "" {
 		return errors.New("arbiter not specified for the stretch cluster")
 	}
@@ -552,9 +616,9 @@ func (c *Cluster) initMonConfig(size int) (int, []*monConfig, error) {
 	existingCount := len(c.ClusterInfo.Monitors)
 	for i := len(c.ClusterInfo.Monitors); i < size; i++ {
 		c.maxMonID++
-		zone, err := c.findAvailableZoneIfStretched(mons)
+		zone, err := c.findAvailableZone(mons)
 		if err != nil {
-			return existingCount, mons, errors.Wrap(err, "stretch zone not available")
+			return existingCount, mons, errors.Wrap(err, "zone not available")
 		}
 		mons = append(mons, c.newMonConfig(c.maxMonID, zone))
 	}
@@ -574,12 +638,22 @@ func (c *Cluster) clusterInfoToMonConfig(excludedMon string) []*monConfig {
 		if schedule != nil {
 			zone = schedule.Zone
 		}
+		// The endpoints of the mons move to the msgr2 port when the msgr1 protocol is disabled, and back
+		port := cephutil.GetPortFromEndpoint(monitor.Endpoint)
+		if port == DefaultMsgr1Port || port == DefaultMsgr2Port {
+			port = c.monPort()
+		}
+		var secondaryIP string
+		if monitor.SecondaryEndpoint != "" {
+			secondaryIP = cephutil.GetIPFromEndpoint(monitor.SecondaryEndpoint)
+		}
 		mons = append(mons, &monConfig{
-			ResourceName: resourceName(monitor.Name),
-			DaemonName:   monitor.Name,
-			Port:         cephutil.GetPortFromEndpoint(monitor.Endpoint),
-			PublicIP:     cephutil.GetIPFromEndpoint(monitor.Endpoint),
-			Zone:         zone,
+			ResourceName:      resourceName(monitor.Name),
+			DaemonName:        monitor.Name,
+			Port:              port,
+			PublicIP:          cephutil.GetIPFromEndpoint(monitor.Endpoint),
+			SecondaryPublicIP: secondaryIP,
+			Zone:              zone,
 			DataPathMap: config.NewStatefulDaemonDataPathMap(
 				c.spec.DataDirHostPath, dataDirRelativeHostPath(monitor.Name), config.MonType, monitor.Name, c.Namespace),
 		})
@@ -587,23 +661,43 @@ func (c *Cluster) clusterInfoToMonConfig(excludedMon string) []*monConfig {
 	return mons
 }
 
+// monInfo returns the endpoints of the mon, with an endpoint in each IP family of a dual-stack cluster
+func (m *monConfig) monInfo() *cephclient.MonInfo {
+	info := cephclient.NewMonInfo(m.DaemonName, m.PublicIP, m.Port)
+	if m.SecondaryPublicIP != "" {
+		info.SecondaryEndpoint = net.JoinHostPort(m.SecondaryPublicIP, strconv.Itoa(int(m.Port)))
+	}
+	return info
+}
+
 func (c *Cluster) newMonConfig(monID int, zone string) *monConfig {
 	daemonName := k8sutil.IndexToName(monID)
 
 	return &monConfig{
 		ResourceName: resourceName(daemonName),
 		DaemonName:   daemonName,
-		Port:         DefaultMsgr1Port,
+		Port:         c.monPort(),
 		Zone:         zone,
 		DataPathMap: config.NewStatefulDaemonDataPathMap(
 			c.spec.DataDirHostPath, dataDirRelativeHostPath(daemonName), config.MonType, daemonName, c.Namespace),
 	}
 }
 
-func (c *Cluster) findAvailableZoneIfStretched(mons []*monConfig) (string, error) {
-	if !c.spec.IsStretchCluster() {
+// monPort returns the port of the mon endpoints, which is the msgr2 port when the msgr1 protocol is disabled
+func (c *Cluster) monPort() int32 {
+	if c.spec.Network.RequireMsgr2() {
+		return DefaultMsgr2Port
+	}
+	return DefaultMsgr1Port
+}
+
+func (c *Cluster) findAvailableZone(mons []*monConfig) (string, error) {
+	if !c.spec.ZonesRequired() {
 		return "", nil
 	}
+	if !c.spec.IsStretchCluster() {
+		return c.findBalancedZone(mons)
+	}
 
 	// Build the count of current mons per zone
 	zoneCount := map[string]int{}
@@ -615,14 +709,15 @@ func (c *Cluster) findAvailableZoneIfStretched(mons []*monConfig) (string, error
 	}
 
 	// Find a zone in the stretch cluster that still needs an assignment
+	monsPerDataZone := c.stretchMonsPerDataZone()
 	for _, zone := range c.spec.Mon.StretchCluster.Zones {
 		count, ok := zoneCount[zone.Name]
 		if !ok {
 			// The zone isn't currently assigned to any mon, so return it
 			return zone.Name, nil
 		}
-		if c.spec.Mon.Count == 5 && count == 1 && !zone.Arbiter {
-			// The zone only has 1 mon assigned, but needs 2 mons since it is not the arbiter
+		if !zone.Arbiter && count < monsPerDataZone {
+			// The data zone needs more mons, only the arbiter zone has a single mon
 			return zone.Name, nil
 		}
 	}
@@ -801,7 +896,7 @@ func (c *Cluster) initMonIPs(mons []*monConfig) error {
 			}
 			m.PublicIP = serviceIP
 		}
-		c.ClusterInfo.Monitors[m.DaemonName] = cephclient.NewMonInfo(m.DaemonName, m.PublicIP, m.Port)
+		c.ClusterInfo.Monitors[m.DaemonName] = m.monInfo()
 	}
 
 	return nil
@@ -903,7 +998,7 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 				logger.Infof("assignmon: mon %q placement using native scheduler", mon.DaemonName)
 			}
 
-			if c.spec.IsStretchCluster() {
+			if c.spec.ZonesRequired() && mon.Zone != "" {
 				if schedule == nil {
 					schedule = &MonScheduleInfo{}
 				}
@@ -928,6 +1023,12 @@ func (c *Cluster) assignMons(mons []*monConfig) error {
 }
 
 func (c *Cluster) monVolumeClaimTemplate(mon *monConfig) *v1.PersistentVolumeClaim {
+	// The zones of the mon spec can override the template from the default
+	for _, zone := range c.spec.Mon.Zones {
+		if zone.Name == mon.Zone && zone.VolumeClaimTemplate != nil {
+			return zone.VolumeClaimTemplate
+		}
+	}
 	if !c.spec.IsStretchCluster() {
 		return c.spec.Mon.VolumeClaimTemplate
 	}
@@ -1086,6 +1187,9 @@ func (c *Cluster) saveMonConfig() error {
 		MappingKey:    string(monMapping),
 		csi.ConfigKey: csiConfigValue,
 	}
+	if secondaryEndpoints := FlattenMonSecondaryEndpoints(c.ClusterInfo.Monitors); secondaryEndpoints != "" {
+		configMap.Data[SecondaryEndpointDataKey] = secondaryEndpoints
+	}
 
 	if _, err := c.context.Clientset.CoreV1().ConfigMaps(c.Namespace).Create(ctx, configMap, metav1.CreateOptions{}); err != nil {
 		if !kerrors.IsAlreadyExists(err) {
@@ -1293,7 +1397,9 @@ func (c *Cluster) startMon(m *monConfig, schedule *MonScheduleInfo) error {
 		}
 	}
 
-	if schedule == nil || schedule.Zone != "" {
+	// the mons assigned only to a zone are placed by the scheduler within the zone, the mons on the host path
+	// stay on the node of their data
+	if schedule == nil || (schedule.Zone != "" && schedule.Hostname == "") {
 		k8sutil.SetNodeAntiAffinityForPod(&d.Spec.Template.Spec, requiredDuringScheduling(&c.spec), v1.LabelHostname,
 			map[string]string{k8sutil.AppAttr: AppName}, nil)
 	} else {
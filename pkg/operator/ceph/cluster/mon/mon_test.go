@@ -423,7 +423,7 @@ func TestFindAvailableZoneForStretchedMon(t *testing.T) {
 
 	// No mons are assigned to a zone yet
 	existingMons := []*monConfig{}
-	availableZone, err := c.findAvailableZoneIfStretched(existingMons)
+	availableZone, err := c.findAvailableZone(existingMons)
 	assert.NoError(t, err)
 	assert.NotEqual(t, "", availableZone)
 
@@ -433,7 +433,7 @@ func TestFindAvailableZoneForStretchedMon(t *testing.T) {
 		{ResourceName: "y", Zone: "b"},
 	}
 	c.spec.Mon.Count = 3
-	availableZone, err = c.findAvailableZoneIfStretched(existingMons)
+	availableZone, err = c.findAvailableZone(existingMons)
 	assert.NoError(t, err)
 	assert.Equal(t, "c", availableZone)
 
@@ -444,7 +444,7 @@ func TestFindAvailableZoneForStretchedMon(t *testing.T) {
 		{ResourceName: "z", Zone: "c"},
 	}
 	c.spec.Mon.Count = 3
-	availableZone, err = c.findAvailableZoneIfStretched(existingMons)
+	availableZone, err = c.findAvailableZone(existingMons)
 	assert.Error(t, err)
 	assert.Equal(t, "", availableZone)
 
@@ -457,7 +457,7 @@ func TestFindAvailableZoneForStretchedMon(t *testing.T) {
 		{ResourceName: "q", Zone: "c"},
 	}
 	c.spec.Mon.Count = 5
-	availableZone, err = c.findAvailableZoneIfStretched(existingMons)
+	availableZone, err = c.findAvailableZone(existingMons)
 	assert.Error(t, err)
 	assert.Equal(t, "", availableZone)
 
@@ -468,7 +468,7 @@ func TestFindAvailableZoneForStretchedMon(t *testing.T) {
 		{ResourceName: "y", Zone: "b"},
 		{ResourceName: "z", Zone: "c"},
 	}
-	availableZone, err = c.findAvailableZoneIfStretched(existingMons)
+	availableZone, err = c.findAvailableZone(existingMons)
 	assert.NoError(t, err)
 	assert.Equal(t, "c", availableZone)
 
@@ -479,9 +479,29 @@ func TestFindAvailableZoneForStretchedMon(t *testing.T) {
 		{ResourceName: "y", Zone: "c"},
 		{ResourceName: "z", Zone: "c"},
 	}
-	availableZone, err = c.findAvailableZoneIfStretched(existingMons)
+	availableZone, err = c.findAvailableZone(existingMons)
 	assert.NoError(t, err)
 	assert.Equal(t, "a", availableZone)
+
+	// With 7 mons in three data zones, each data zone has two mons
+	c.spec.Mon.StretchCluster.Zones = append(c.spec.Mon.StretchCluster.Zones, cephv1.StretchClusterZoneSpec{Name: "d"})
+	c.spec.Mon.Count = 7
+	existingMons = []*monConfig{
+		{ResourceName: "u", Zone: "a"},
+		{ResourceName: "v", Zone: "b"},
+		{ResourceName: "w", Zone: "b"},
+		{ResourceName: "x", Zone: "c"},
+		{ResourceName: "y", Zone: "c"},
+		{ResourceName: "z", Zone: "d"},
+	}
+	availableZone, err = c.findAvailableZone(existingMons)
+	assert.NoError(t, err)
+	assert.Equal(t, "d", availableZone)
+
+	existingMons = append(existingMons, &monConfig{ResourceName: "q", Zone: "d"})
+	availableZone, err = c.findAvailableZone(existingMons)
+	assert.Error(t, err)
+	assert.Equal(t, "", availableZone)
 }
 
 func TestStretchMonVolumeClaimTemplate(t *testing.T) {
@@ -654,3 +674,26 @@ func TestCheckIfArbiterReady(t *testing.T) {
 	assert.False(t, ready)
 	assert.Error(t, err)
 }
+
+func TestMonEndpointPortMsgr2Only(t *testing.T) {
+	c := New(&clusterd.Context{}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	setCommonMonProperties(c, 1, cephv1.MonSpec{Count: 3}, "myversion")
+	c.ClusterInfo.Monitors["b"] = cephclient.NewMonInfo("b", "2.3.4.5", 6790)
+
+	// the mons keep their port by default
+	mons := c.clusterInfoToMonConfig("b")
+	assert.Equal(t, DefaultMsgr1Port, mons[0].Port)
+	assert.Equal(t, DefaultMsgr1Port, c.newMonConfig(3, "").Port)
+
+	// the mons move to the msgr2 port when msgr1 is disabled, except the legacy mons on a non-default port
+	c.spec.Network.Connections = &cephv1.ConnectionsSpec{RequireMsgr2: true}
+	mons = c.clusterInfoToMonConfig("")
+	for _, m := range mons {
+		if m.DaemonName == "b" {
+			assert.Equal(t, int32(6790), m.Port)
+		} else {
+			assert.Equal(t, DefaultMsgr2Port, m.Port)
+		}
+	}
+	assert.Equal(t, DefaultMsgr2Port, c.newMonConfig(3, "").Port)
+}
@@ -0,0 +1,513 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/config/keyring"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RebuildMonStoreAnnotation on the CephCluster requests the operator to rebuild the mon store from the osds when
+	// the stores of all the mons are lost. The value is the name of the mon that is kept, or empty for the first mon.
+	RebuildMonStoreAnnotation = "ceph.rook.io/rebuild-mon-store"
+
+	rebuildMonStoreAppName       = "rook-ceph-mon-store-rebuild"
+	rebuildMonStoreContainerName = "rebuild-mon-store"
+	rebuildMonStoreMountPath     = "/var/lib/ceph/mon-store-rebuild"
+	// the osd labels and data path, the osd package cannot be imported by the mon package
+	osdAppName             = "rook-ceph-osd"
+	osdIDLabelKey          = "ceph-osd-id"
+	osdMaintenanceLabelKey = "ceph.rook.io/maintenance"
+	osdDataPathPrefix      = "/var/lib/ceph/osd/ceph-"
+)
+
+// collectOSDMaps adds the cluster maps found on an osd to the mon store being rebuilt
+var collectOSDMaps = `
+set -xe
+
+mkdir -p "$MON_STORE"
+ceph-objectstore-tool --data-path "$OSD_DATA_PATH" --no-mon-config --op update-mon-db --mon-store-path "$MON_STORE"
+`
+
+// collectLVMOSDMaps activates an osd on pvc in lvm mode like "rook ceph osd start" does before collecting its maps,
+// and releases its volume group afterwards
+var collectLVMOSDMaps = `
+set -xe
+
+sed -i -e 's/udev_sync = 1/udev_sync = 0/' -e 's/udev_rules = 1/udev_rules = 0/' -e 's/use_lvmetad = 1/use_lvmetad = 0/' \
+  -e 's/obtain_device_list_from_udev = 1/obtain_device_list_from_udev = 0/' \
+  -e 's/allow_changes_with_duplicate_pvs = 0/allow_changes_with_duplicate_pvs = 1/' \
+  -e 's|scan = \[ "/dev" \]|scan = [ "/dev", "/mnt" ]|' /etc/lvm/lvm.conf
+
+VG=""
+if [ "$ROOK_LV_BACKED_PV" != "true" ]; then
+  VG=$(echo "$ROOK_BLOCK_PATH" | cut -d/ -f3)
+  vgchange -ay "$VG"
+fi
+ceph-volume lvm activate --no-systemd --bluestore "$ROOK_OSD_ID" "$ROOK_OSD_UUID"
+
+mkdir -p "$MON_STORE"
+ceph-objectstore-tool --data-path "$OSD_DATA_PATH" --no-mon-config --op update-mon-db --mon-store-path "$MON_STORE"
+
+if [ -n "$VG" ]; then
+  vgchange -an "$VG"
+fi
+`
+
+// replaceMonStore rebuilds the store from the maps collected on the osds with the keyrings of the mons and the admin,
+// replaces the store of the mon with it and injects a monmap with the mon only. The ceph-mon flags of the mon
+// container are passed as arguments to the script.
+var replaceMonStore = `
+set -xe
+
+KEYRING=/tmp/rebuild.keyring
+MONMAP=/tmp/monmap
+ceph-authtool "$KEYRING" --create-keyring --import-keyring /etc/ceph/keyring-store/keyring
+ceph-authtool "$KEYRING" --import-keyring /etc/ceph/admin-keyring-store/keyring
+ceph-authtool "$KEYRING" -n mon. --cap mon 'allow *'
+ceph-authtool "$KEYRING" -n client.admin --cap mon 'allow *' --cap osd 'allow *' --cap mds 'allow *' --cap mgr 'allow *'
+ceph-monstore-tool "$MON_STORE" rebuild -- --keyring "$KEYRING" --mon-ids "$MON_ID"
+
+if [ -d "$MON_DATA/store.db" ]; then
+  mv "$MON_DATA/store.db" "$MON_DATA/store.db.$(date +%s).bak"
+fi
+cp -r "$MON_STORE/store.db" "$MON_DATA/store.db"
+
+monmaptool --create --clobber --fsid "$FSID" --add "$MON_ID" "$MON_ENDPOINT" "$MONMAP"
+ceph-mon "$@" --inject-monmap="$MONMAP"
+chown -R ceph:ceph "$MON_DATA"
+`
+
+// RequestMonStoreRebuild requests to rebuild the mon store from the osds the next time the mons are started
+func (c *Cluster) RequestMonStoreRebuild(clusterName, mon string) {
+	c.storeRebuildCluster = clusterName
+	c.storeRebuildMon = mon
+}
+
+// rebuildMonStore recovers the cluster when the stores of all the mons are lost. The maps are collected from every
+// osd in a store on a dedicated pvc, the store is rebuilt with the keyrings of the mons and the admin, and a single
+// mon is started with the rebuilt store. New mons are started afterwards until the desired mon count is reached.
+// All the jobs are generated before any daemon is stopped, and the daemons are started again if the rebuild fails.
+func (c *Cluster) rebuildMonStore() (err error) {
+	// the cluster info was just loaded, it needs the name of the CephCluster to report the progress
+	c.ClusterInfo.SetName(c.storeRebuildCluster)
+	if c.spec.Mon.VolumeClaimTemplate == nil {
+		return errors.New("the mon store can only be rebuilt when the mons have a volumeClaimTemplate, the store is collected on a pvc attached to each osd in turn")
+	}
+	mon, err := c.pickStoreRebuildMon()
+	if err != nil {
+		return err
+	}
+	osds, err := c.listOSDDeployments()
+	if err != nil {
+		return err
+	}
+	if len(osds) == 0 {
+		return errors.New("failed to find osds to rebuild the mon store")
+	}
+
+	collectJobs := []*batch.Job{}
+	for _, d := range osds {
+		job, err := c.makeCollectOSDMapsJob(d)
+		if err != nil {
+			return errors.Wrap(err, "failed to generate job to collect the osd maps")
+		}
+		collectJobs = append(collectJobs, job)
+	}
+	monDeployment, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(context.TODO(), resourceName(mon), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get mon %q", mon)
+	}
+	rebuildJob, err := c.makeRebuildMonStoreJob(monDeployment.Spec.Template.Spec, mon)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate rebuild mon store job")
+	}
+	if err := c.createMonStoreRebuildPVC(); err != nil {
+		return err
+	}
+
+	mons := []string{}
+	for name := range c.ClusterInfo.Monitors {
+		mons = append(mons, name)
+	}
+	sort.Strings(mons)
+	defer func() {
+		if err != nil {
+			c.restartDaemonsAfterFailedRebuild(mons, osds)
+		}
+	}()
+
+	c.updateRebuildMonStoreCondition(fmt.Sprintf("Rebuilding mon store: stopping the mons and %d osds", len(osds)))
+	for _, name := range mons {
+		if err := c.updateMonDeploymentReplica(name, false); err != nil && !kerrors.IsNotFound(errors.Cause(err)) {
+			return errors.Wrapf(err, "failed to stop mon %q", name)
+		}
+	}
+	if err := c.scaleOSDDeployments(osds, 0); err != nil {
+		return err
+	}
+	if err := c.waitForMonPodToStop(mon); err != nil {
+		return err
+	}
+	if err := c.waitForPodsToStop(fmt.Sprintf("app=%s", osdAppName), "the osds"); err != nil {
+		return err
+	}
+
+	for i, job := range collectJobs {
+		c.updateRebuildMonStoreCondition(fmt.Sprintf("Rebuilding mon store: collecting the maps of osd %s (%d/%d)", osds[i].Labels[osdIDLabelKey], i+1, len(osds)))
+		if err := c.runMonMaintenanceJob(job); err != nil {
+			return err
+		}
+	}
+
+	c.updateRebuildMonStoreCondition(fmt.Sprintf("Rebuilding mon store: replacing the store of mon %q", mon))
+	if err := c.runMonMaintenanceJob(rebuildJob); err != nil {
+		return err
+	}
+
+	c.updateRebuildMonStoreCondition(fmt.Sprintf("Rebuilding mon store: updating the mon endpoints to mon %q", mon))
+	removedMons := []string{}
+	for _, name := range mons {
+		if name != mon {
+			removedMons = append(removedMons, name)
+		}
+	}
+	for _, name := range removedMons {
+		delete(c.ClusterInfo.Monitors, name)
+		delete(c.mapping.Schedule, name)
+		delete(c.monTimeoutList, name)
+	}
+	if err := c.saveMonConfig(); err != nil {
+		return errors.Wrap(err, "failed to save the mon endpoints")
+	}
+	// the removed mons are not started again if the rest of the rebuild fails
+	mons = []string{mon}
+
+	c.updateRebuildMonStoreCondition(fmt.Sprintf("Rebuilding mon store: starting mon %q and the osds", mon))
+	if err := c.updateMonDeploymentReplica(mon, true); err != nil {
+		return errors.Wrapf(err, "failed to start mon %q", mon)
+	}
+	if err := waitForQuorumWithMons(c.context, c.ClusterInfo, []string{mon}, 5, true); err != nil {
+		return errors.Wrapf(err, "failed to wait for mon %q to form a quorum", mon)
+	}
+	if err := c.scaleOSDDeployments(osds, 1); err != nil {
+		return err
+	}
+
+	for _, name := range removedMons {
+		if err := c.removeMon(name); err != nil {
+			return errors.Wrapf(err, "failed to remove mon %q", name)
+		}
+	}
+	if err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Delete(context.TODO(), rebuildMonStoreAppName, metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+		logger.Warningf("failed to delete the mon store rebuild pvc. %v", err)
+	}
+
+	logger.Infof("rebuilt the mon store of mon %q from %d osds", mon, len(osds))
+	return c.completeMonStoreRebuild()
+}
+
+// restartDaemonsAfterFailedRebuild starts the mons and the osds stopped by a rebuild of the mon store that failed
+func (c *Cluster) restartDaemonsAfterFailedRebuild(mons []string, osds []apps.Deployment) {
+	logger.Warningf("starting the mons %v and %d osds again after the failed rebuild of the mon store", mons, len(osds))
+	for _, name := range mons {
+		if err := c.updateMonDeploymentReplica(name, true); err != nil && !kerrors.IsNotFound(errors.Cause(err)) {
+			logger.Errorf("failed to start mon %q. %v", name, err)
+		}
+	}
+	if err := c.scaleOSDDeployments(osds, 1); err != nil {
+		logger.Errorf("failed to start the osds. %v", err)
+	}
+}
+
+// pickStoreRebuildMon returns the mon requested by the user, or the first mon
+func (c *Cluster) pickStoreRebuildMon() (string, error) {
+	if c.storeRebuildMon != "" {
+		if _, ok := c.ClusterInfo.Monitors[c.storeRebuildMon]; !ok {
+			return "", errors.Errorf("cannot rebuild the store of unknown mon %q", c.storeRebuildMon)
+		}
+		return c.storeRebuildMon, nil
+	}
+
+	names := []string{}
+	for name := range c.ClusterInfo.Monitors {
+		names = append(names, name)
+	}
+	if len(names) == 0 {
+		return "", errors.New("failed to find a mon to rebuild the mon store")
+	}
+	sort.Strings(names)
+	return names[0], nil
+}
+
+// listOSDDeployments returns the osd deployments sorted by osd id
+func (c *Cluster) listOSDDeployments() ([]apps.Deployment, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", osdAppName)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(context.TODO(), listOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list osd deployments")
+	}
+
+	osds := deployments.Items
+	sort.Slice(osds, func(i, j int) bool {
+		a, _ := strconv.Atoi(osds[i].Labels[osdIDLabelKey])
+		b, _ := strconv.Atoi(osds[j].Labels[osdIDLabelKey])
+		return a < b
+	})
+	return osds, nil
+}
+
+func (c *Cluster) scaleOSDDeployments(osds []apps.Deployment, replicas int32) error {
+	for i := range osds {
+		d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(context.TODO(), osds[i].Name, metav1.GetOptions{})
+		if err != nil {
+			return errors.Wrapf(err, "failed to get osd deployment %q", osds[i].Name)
+		}
+		if d.Spec.Replicas != nil && *d.Spec.Replicas == replicas {
+			continue
+		}
+		// The OSDs stopped for the maintenance of their node are started when the maintenance ends
+		if maintenance, ok := d.Labels[osdMaintenanceLabelKey]; ok && replicas > 0 {
+			logger.Infof("not starting osd %q stopped by node maintenance %q", d.Name, maintenance)
+			continue
+		}
+		logger.Infof("scaling the osd %q deployment to replica %d", d.Name, replicas)
+		d.Spec.Replicas = &replicas
+		if _, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Update(context.TODO(), d, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to scale osd deployment %q to %d", d.Name, replicas)
+		}
+	}
+
+	return nil
+}
+
+// createMonStoreRebuildPVC creates the pvc receiving the maps of the osds. The pvc is attached to the node of each
+// osd in turn, so it is created from the mon volumeClaimTemplate that is not bound to a node.
+func (c *Cluster) createMonStoreRebuildPVC() error {
+	template := c.spec.Mon.VolumeClaimTemplate
+	volumeMode := v1.PersistentVolumeFilesystem
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rebuildMonStoreAppName,
+			Namespace: c.Namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     rebuildMonStoreAppName,
+				k8sutil.ClusterAttr: c.Namespace,
+			},
+		},
+		Spec: v1.PersistentVolumeClaimSpec{
+			AccessModes:      []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+			Resources:        template.Spec.Resources,
+			StorageClassName: template.Spec.StorageClassName,
+			VolumeMode:       &volumeMode,
+		},
+	}
+	if err := c.ownerInfo.SetControllerReference(pvc); err != nil {
+		return errors.Wrapf(err, "failed to set owner reference to pvc %q", pvc.Name)
+	}
+
+	_, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Create(context.TODO(), pvc, metav1.CreateOptions{})
+	if err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "failed to create pvc %q", pvc.Name)
+	}
+
+	return nil
+}
+
+// makeCollectOSDMapsJob builds a job running on the pod spec of an osd to add its maps to the mon store. The init
+// containers of the osd activate its data directory before the maps are collected.
+func (c *Cluster) makeCollectOSDMapsJob(d apps.Deployment) (*batch.Job, error) {
+	osdID := d.Labels[osdIDLabelKey]
+	podSpec := *d.Spec.Template.Spec.DeepCopy()
+	var osdContainer *v1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "osd" {
+			osdContainer = &podSpec.Containers[i]
+		}
+	}
+	if osdContainer == nil {
+		return nil, errors.Errorf("failed to find the container of osd %q", osdID)
+	}
+
+	// the osds on pvc in lvm mode are activated by "rook ceph osd start" in their main container launched by tini,
+	// the other osds are activated by the init containers of their pod
+	script := collectOSDMaps
+	if len(osdContainer.Command) == 0 {
+		return nil, errors.Errorf("failed to find the command of osd %q", osdID)
+	}
+	if osdContainer.Command[0] != "ceph-osd" {
+		if path.Base(osdContainer.Command[0]) != "tini" || !hasEnvVar(osdContainer.Env, "ROOK_CV_MODE", "lvm") {
+			return nil, errors.Errorf("osd %q is started with unknown command %q, its maps cannot be collected", osdID, osdContainer.Command[0])
+		}
+		script = collectLVMOSDMaps
+	}
+
+	container := *osdContainer
+	container.Name = rebuildMonStoreContainerName
+	container.Command = []string{
+		"/bin/bash",
+		"-c",
+		fmt.Sprintf("OSD_DATA_PATH=%q\nMON_STORE=%q\n%s", osdDataPathPrefix+osdID, path.Join(rebuildMonStoreMountPath, "store"), script),
+	}
+	container.Args = nil
+	container.VolumeMounts = append(container.VolumeMounts, monStoreRebuildVolumeMount())
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Ports = nil
+
+	podSpec.Containers = []v1.Container{container}
+	podSpec.Volumes = append(podSpec.Volumes, monStoreRebuildVolume())
+	podSpec.RestartPolicy = v1.RestartPolicyOnFailure
+
+	return c.makeRebuildMonStoreJobForPod(fmt.Sprintf("%s-osd-%s", rebuildMonStoreAppName, osdID), osdIDLabelKey, osdID, podSpec)
+}
+
+// makeRebuildMonStoreJob builds a job running on the pod spec of the mon to rebuild the store and replace the store
+// of the mon with it
+func (c *Cluster) makeRebuildMonStoreJob(podSpec v1.PodSpec, mon string) (*batch.Job, error) {
+	monInfo, ok := c.ClusterInfo.Monitors[mon]
+	if !ok {
+		return nil, errors.Errorf("failed to find the endpoint of mon %q", mon)
+	}
+	var monContainer *v1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "mon" {
+			monContainer = podSpec.Containers[i].DeepCopy()
+		}
+	}
+	if monContainer == nil {
+		return nil, errors.Errorf("failed to find the container of mon %q", mon)
+	}
+
+	dataPathMap := config.NewStatefulDaemonDataPathMap(c.spec.DataDirHostPath, dataDirRelativeHostPath(mon), config.MonType, mon, c.Namespace)
+	container := *monContainer
+	container.Name = rebuildMonStoreContainerName
+	container.Command = []string{
+		"/bin/bash",
+		"-c",
+		fmt.Sprintf("MON_ID=%q\nMON_ENDPOINT=%q\nMON_DATA=%q\nMON_STORE=%q\nFSID=%q\n%s",
+			mon, monInfo.Endpoint, dataPathMap.ContainerDataDir, path.Join(rebuildMonStoreMountPath, "store"), c.ClusterInfo.FSID, replaceMonStore),
+		cephMonCommand,
+	}
+	container.VolumeMounts = append(container.VolumeMounts, monStoreRebuildVolumeMount(), keyring.VolumeMount().Admin())
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Ports = nil
+
+	// the log collector sidecar is not needed by the job
+	podSpec.Containers = []v1.Container{container}
+	podSpec.Volumes = append(podSpec.Volumes, monStoreRebuildVolume(), keyring.Volume().Admin())
+	podSpec.ShareProcessNamespace = nil
+	podSpec.RestartPolicy = v1.RestartPolicyOnFailure
+
+	return c.makeRebuildMonStoreJobForPod(rebuildMonStoreAppName, "mon", mon, podSpec)
+}
+
+func (c *Cluster) makeRebuildMonStoreJobForPod(name, daemonLabel, daemonID string, podSpec v1.PodSpec) (*batch.Job, error) {
+	labels := map[string]string{
+		k8sutil.AppAttr:     rebuildMonStoreAppName,
+		k8sutil.ClusterAttr: c.Namespace,
+		daemonLabel:         daemonID,
+	}
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	k8sutil.AddRookVersionLabelToJob(job)
+	if err := c.ownerInfo.SetControllerReference(job); err != nil {
+		return nil, errors.Wrapf(err, "failed to set owner reference to job %q", job.Name)
+	}
+
+	return job, nil
+}
+
+func hasEnvVar(envVars []v1.EnvVar, name, value string) bool {
+	for _, envVar := range envVars {
+		if envVar.Name == name && envVar.Value == value {
+			return true
+		}
+	}
+	return false
+}
+
+func monStoreRebuildVolume() v1.Volume {
+	return v1.Volume{
+		Name: rebuildMonStoreAppName,
+		VolumeSource: v1.VolumeSource{
+			PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: rebuildMonStoreAppName},
+		},
+	}
+}
+
+func monStoreRebuildVolumeMount() v1.VolumeMount {
+	return v1.VolumeMount{Name: rebuildMonStoreAppName, MountPath: rebuildMonStoreMountPath}
+}
+
+// completeMonStoreRebuild removes the rebuild request from the CephCluster so the store is not rebuilt again
+func (c *Cluster) completeMonStoreRebuild() error {
+	c.storeRebuildCluster = ""
+	c.storeRebuildMon = ""
+
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.context.Client.Get(context.TODO(), c.ClusterInfo.NamespacedName(), cephCluster); err != nil {
+		return errors.Wrap(err, "failed to get cluster to remove the rebuild mon store annotation")
+	}
+	if _, ok := cephCluster.Annotations[RebuildMonStoreAnnotation]; !ok {
+		return nil
+	}
+	delete(cephCluster.Annotations, RebuildMonStoreAnnotation)
+	if err := c.context.Client.Update(context.TODO(), cephCluster); err != nil {
+		return errors.Wrap(err, "failed to remove the rebuild mon store annotation")
+	}
+	c.updateRebuildMonStoreCondition("Rebuilt mon store")
+
+	return nil
+}
+
+func (c *Cluster) updateRebuildMonStoreCondition(message string) {
+	logger.Info(message)
+	controller.UpdateCondition(c.context, c.ClusterInfo.NamespacedName(), cephv1.ConditionProgressing, v1.ConditionTrue, cephv1.ClusterProgressingReason, message)
+}
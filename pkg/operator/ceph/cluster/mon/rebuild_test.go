@@ -0,0 +1,207 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func osdDeployment(id int, command string) apps.Deployment {
+	return apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("rook-ceph-osd-%d", id),
+			Namespace: "ns",
+			Labels:    map[string]string{"app": osdAppName, osdIDLabelKey: fmt.Sprintf("%d", id)},
+		},
+		Spec: apps.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					InitContainers: []v1.Container{{Name: "activate"}},
+					Containers: []v1.Container{
+						{
+							Name:          "osd",
+							Image:         "ceph/ceph:v15",
+							Command:       []string{command},
+							Args:          []string{"--foreground", "--id", fmt.Sprintf("%d", id)},
+							LivenessProbe: &v1.Probe{},
+						},
+						{Name: "log-collector"},
+					},
+					Volumes:       []v1.Volume{{Name: "activate-osd"}},
+					RestartPolicy: v1.RestartPolicyAlways,
+				},
+			},
+		},
+	}
+}
+
+func TestMakeCollectOSDMapsJob(t *testing.T) {
+	ownerInfo := cephclient.NewMinimumOwnerInfoWithOwnerRef()
+	c := New(&clusterd.Context{}, "ns", cephv1.ClusterSpec{}, ownerInfo, &sync.Mutex{})
+
+	job, err := c.makeCollectOSDMapsJob(osdDeployment(3, "ceph-osd"))
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-mon-store-rebuild-osd-3", job.Name)
+	assert.Equal(t, "3", job.Spec.Template.Labels[osdIDLabelKey])
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, v1.RestartPolicyOnFailure, spec.RestartPolicy)
+	assert.Equal(t, "activate", spec.InitContainers[0].Name)
+	assert.Equal(t, 2, len(spec.Volumes))
+	assert.Equal(t, rebuildMonStoreAppName, spec.Volumes[1].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, 1, len(spec.Containers))
+	container := spec.Containers[0]
+	assert.Equal(t, rebuildMonStoreContainerName, container.Name)
+	assert.Contains(t, container.Command[2], "OSD_DATA_PATH=\"/var/lib/ceph/osd/ceph-3\"\n")
+	assert.Contains(t, container.Command[2], "--op update-mon-db")
+	assert.Nil(t, container.Args)
+	assert.Nil(t, container.LivenessProbe)
+	assert.Equal(t, rebuildMonStoreMountPath, container.VolumeMounts[0].MountPath)
+
+	// the osds on pvc in lvm mode are activated by the job like their main container does
+	d := osdDeployment(4, "/rook/tini")
+	d.Spec.Template.Spec.Containers[0].Env = []v1.EnvVar{{Name: "ROOK_CV_MODE", Value: "lvm"}}
+	job, err = c.makeCollectOSDMapsJob(d)
+	assert.NoError(t, err)
+	container = job.Spec.Template.Spec.Containers[0]
+	assert.Contains(t, container.Command[2], "ceph-volume lvm activate --no-systemd --bluestore \"$ROOK_OSD_ID\" \"$ROOK_OSD_UUID\"")
+	assert.Contains(t, container.Command[2], "--op update-mon-db")
+
+	// the osds started with an unknown command are not supported
+	_, err = c.makeCollectOSDMapsJob(osdDeployment(5, "/rook/tini"))
+	assert.Error(t, err)
+	_, err = c.makeCollectOSDMapsJob(osdDeployment(6, "/usr/bin/unknown"))
+	assert.Error(t, err)
+}
+
+func TestMakeRebuildMonStoreJob(t *testing.T) {
+	ownerInfo := cephclient.NewMinimumOwnerInfoWithOwnerRef()
+	c := New(&clusterd.Context{}, "ns", cephv1.ClusterSpec{}, ownerInfo, &sync.Mutex{})
+	c.ClusterInfo = cephclient.AdminClusterInfo("ns")
+	c.ClusterInfo.FSID = "fsid"
+	c.ClusterInfo.Monitors = map[string]*cephclient.MonInfo{"b": {Name: "b", Endpoint: "1.2.3.4:6789"}}
+	podSpec := v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init-mon-fs"}},
+		Containers: []v1.Container{
+			{Name: "mon", Command: []string{cephMonCommand}, Args: []string{"--id=b"}},
+			{Name: "log-collector"},
+		},
+	}
+
+	job, err := c.makeRebuildMonStoreJob(podSpec, "b")
+	assert.NoError(t, err)
+	assert.Equal(t, rebuildMonStoreAppName, job.Name)
+	assert.Equal(t, "b", job.Spec.Template.Labels["mon"])
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, podSpec.InitContainers, spec.InitContainers)
+	assert.Equal(t, 2, len(spec.Volumes))
+	assert.Equal(t, 1, len(spec.Containers))
+	container := spec.Containers[0]
+	assert.Contains(t, container.Command[2], "MON_ENDPOINT=\"1.2.3.4:6789\"\n")
+	assert.Contains(t, container.Command[2], "MON_DATA=\"/var/lib/ceph/mon/ceph-b\"\n")
+	assert.Contains(t, container.Command[2], "FSID=\"fsid\"\n")
+	assert.Equal(t, cephMonCommand, container.Command[3])
+	assert.Equal(t, []string{"--id=b"}, container.Args)
+	assert.Equal(t, 2, len(container.VolumeMounts))
+
+	// the endpoint of the mon is required
+	_, err = c.makeRebuildMonStoreJob(podSpec, "a")
+	assert.Error(t, err)
+}
+
+func TestListOSDDeployments(t *testing.T) {
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	for _, id := range []int{10, 2, 1} {
+		d := osdDeployment(id, "ceph-osd")
+		_, err := clientset.AppsV1().Deployments("ns").Create(ctx, &d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+
+	osds, err := c.listOSDDeployments()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(osds))
+	assert.Equal(t, "rook-ceph-osd-1", osds[0].Name)
+	assert.Equal(t, "rook-ceph-osd-2", osds[1].Name)
+	assert.Equal(t, "rook-ceph-osd-10", osds[2].Name)
+
+	// the osds are scaled down and up again
+	assert.NoError(t, c.scaleOSDDeployments(osds, 0))
+	d, err := clientset.AppsV1().Deployments("ns").Get(ctx, "rook-ceph-osd-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), *d.Spec.Replicas)
+	assert.NoError(t, c.scaleOSDDeployments(osds, 1))
+	d, err = clientset.AppsV1().Deployments("ns").Get(ctx, "rook-ceph-osd-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+
+	// the osds are started again after a failed rebuild
+	assert.NoError(t, c.scaleOSDDeployments(osds, 0))
+	c.restartDaemonsAfterFailedRebuild([]string{"a"}, osds)
+	for _, osd := range osds {
+		d, err = clientset.AppsV1().Deployments("ns").Get(ctx, osd.Name, metav1.GetOptions{})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(1), *d.Spec.Replicas)
+	}
+
+	// the osds stopped for a node maintenance are not started
+	assert.NoError(t, c.scaleOSDDeployments(osds, 0))
+	d, err = clientset.AppsV1().Deployments("ns").Get(ctx, "rook-ceph-osd-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	d.Labels[osdMaintenanceLabelKey] = "maintenance-a"
+	_, err = clientset.AppsV1().Deployments("ns").Update(ctx, d, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, c.scaleOSDDeployments(osds, 1))
+	d, err = clientset.AppsV1().Deployments("ns").Get(ctx, "rook-ceph-osd-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), *d.Spec.Replicas)
+	d, err = clientset.AppsV1().Deployments("ns").Get(ctx, "rook-ceph-osd-1", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), *d.Spec.Replicas)
+}
+
+func TestPickStoreRebuildMon(t *testing.T) {
+	c := New(&clusterd.Context{}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	c.ClusterInfo = cephclient.AdminClusterInfo("ns")
+	c.ClusterInfo.Monitors = map[string]*cephclient.MonInfo{"c": {Name: "c"}, "b": {Name: "b"}}
+
+	// the first mon is kept by default
+	mon, err := c.pickStoreRebuildMon()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", mon)
+
+	c.RequestMonStoreRebuild("test", "c")
+	mon, err = c.pickStoreRebuildMon()
+	assert.NoError(t, err)
+	assert.Equal(t, "c", mon)
+
+	c.RequestMonStoreRebuild("test", "z")
+	_, err = c.pickStoreRebuildMon()
+	assert.Error(t, err)
+}
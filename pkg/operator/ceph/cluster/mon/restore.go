@@ -0,0 +1,353 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// RestoreQuorumAnnotation on the CephCluster requests the operator to restore the mon quorum from a single healthy
+	// mon. The value is the name of the healthy mon, or empty to let the operator pick a mon whose pod is running.
+	RestoreQuorumAnnotation = "ceph.rook.io/restore-quorum"
+
+	restoreQuorumAppName       = "rook-ceph-mon-restore-quorum"
+	restoreQuorumContainerName = "restore-quorum"
+	restoreQuorumTimeout       = 15 * time.Minute
+	restoreQuorumRetryDelay    = 5 * time.Second
+)
+
+// rewriteMonmap extracts the monmap of the healthy mon, removes the other mons from it and injects it back. The
+// ceph-mon flags of the mon container are passed as arguments to the script.
+var rewriteMonmap = `
+set -xe
+
+MONMAP=/tmp/monmap
+ceph-mon "$@" --extract-monmap="$MONMAP"
+monmaptool --print "$MONMAP"
+
+for mon in $REMOVED_MONS; do
+  if monmaptool --print "$MONMAP" | grep -q " mon\.${mon}$"; then
+    monmaptool "$MONMAP" --rm "$mon"
+  fi
+done
+
+monmaptool --print "$MONMAP"
+ceph-mon "$@" --inject-monmap="$MONMAP"
+`
+
+// RequestQuorumRestore requests the restore of the mon quorum from a single healthy mon the next time the mons are started
+func (c *Cluster) RequestQuorumRestore(clusterName, healthyMon string) {
+	c.quorumRestoreCluster = clusterName
+	c.quorumRestoreMon = healthyMon
+}
+
+// restoreQuorum forms a new quorum with a single healthy mon when the quorum is lost. The other mons are removed
+// from the monmap of the healthy mon and from the mon endpoints. New mons are started afterwards to join the quorum
+// until the desired mon count is reached. The healthy mon is saved in the restore request before any mon is stopped,
+// so that a failed restore is retried with the same mon during the next reconcile.
+func (c *Cluster) restoreQuorum() error {
+	// the cluster info was just loaded, it needs the name of the CephCluster to report the progress
+	c.ClusterInfo.SetName(c.quorumRestoreCluster)
+	healthyMon, err := c.pickHealthyMon()
+	if err != nil {
+		return err
+	}
+	if c.quorumRestoreMon == "" {
+		if err := c.saveQuorumRestoreMon(healthyMon); err != nil {
+			return err
+		}
+	}
+
+	// the mons removed from the endpoints by a previous attempt may still have resources to remove
+	removedMons, err := c.monsToRemove(healthyMon)
+	if err != nil {
+		return err
+	}
+	if len(removedMons) == 0 {
+		logger.Infof("mon %q is the only mon, no need to restore the quorum", healthyMon)
+		return c.completeQuorumRestore()
+	}
+
+	c.updateRestoreQuorumCondition(fmt.Sprintf("Restoring mon quorum: stopping the mons to remove mons %v from the monmap of mon %q", removedMons, healthyMon))
+	for _, name := range append(removedMons, healthyMon) {
+		if err := c.updateMonDeploymentReplica(name, false); err != nil && !kerrors.IsNotFound(errors.Cause(err)) {
+			return errors.Wrapf(err, "failed to stop mon %q", name)
+		}
+	}
+	if err := c.waitForMonPodToStop(healthyMon); err != nil {
+		return err
+	}
+
+	c.updateRestoreQuorumCondition(fmt.Sprintf("Restoring mon quorum: removing mons %v from the monmap of mon %q", removedMons, healthyMon))
+	if err := c.runRestoreQuorumJob(healthyMon, removedMons); err != nil {
+		return err
+	}
+
+	c.updateRestoreQuorumCondition(fmt.Sprintf("Restoring mon quorum: updating the mon endpoints to mon %q", healthyMon))
+	for _, name := range removedMons {
+		delete(c.ClusterInfo.Monitors, name)
+		delete(c.mapping.Schedule, name)
+		delete(c.monTimeoutList, name)
+	}
+	if err := c.saveMonConfig(); err != nil {
+		return errors.Wrap(err, "failed to save the mon endpoints")
+	}
+
+	c.updateRestoreQuorumCondition(fmt.Sprintf("Restoring mon quorum: starting mon %q", healthyMon))
+	if err := c.updateMonDeploymentReplica(healthyMon, true); err != nil {
+		return errors.Wrapf(err, "failed to start mon %q", healthyMon)
+	}
+	if err := waitForQuorumWithMons(c.context, c.ClusterInfo, []string{healthyMon}, 5, true); err != nil {
+		return errors.Wrapf(err, "failed to wait for mon %q to form a quorum", healthyMon)
+	}
+
+	c.updateRestoreQuorumCondition(fmt.Sprintf("Restoring mon quorum: removing the resources of mons %v", removedMons))
+	for _, name := range removedMons {
+		if err := c.removeMon(name); err != nil {
+			return errors.Wrapf(err, "failed to remove mon %q", name)
+		}
+	}
+
+	logger.Infof("restored the mon quorum with mon %q", healthyMon)
+	return c.completeQuorumRestore()
+}
+
+// monsToRemove returns the mons other than the healthy mon that are in the mon endpoints or still have a deployment
+func (c *Cluster) monsToRemove(healthyMon string) ([]string, error) {
+	mons := map[string]bool{}
+	for name := range c.ClusterInfo.Monitors {
+		mons[name] = true
+	}
+	opts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).List(context.TODO(), opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list mon deployments")
+	}
+	for _, d := range deployments.Items {
+		if name, ok := d.Labels["mon"]; ok {
+			mons[name] = true
+		}
+	}
+
+	removedMons := []string{}
+	for name := range mons {
+		if name != healthyMon {
+			removedMons = append(removedMons, name)
+		}
+	}
+	sort.Strings(removedMons)
+	return removedMons, nil
+}
+
+// saveQuorumRestoreMon records the healthy mon picked by the operator in the restore request of the CephCluster
+func (c *Cluster) saveQuorumRestoreMon(healthyMon string) error {
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.context.Client.Get(context.TODO(), c.ClusterInfo.NamespacedName(), cephCluster); err != nil {
+		return errors.Wrap(err, "failed to get cluster to save the mon to restore the quorum from")
+	}
+	if cephCluster.Annotations == nil {
+		cephCluster.Annotations = map[string]string{}
+	}
+	cephCluster.Annotations[RestoreQuorumAnnotation] = healthyMon
+	if err := c.context.Client.Update(context.TODO(), cephCluster); err != nil {
+		return errors.Wrapf(err, "failed to save mon %q to restore the quorum from", healthyMon)
+	}
+	c.quorumRestoreMon = healthyMon
+
+	return nil
+}
+
+// pickHealthyMon returns the mon requested by the user, or the first mon whose pod is running
+func (c *Cluster) pickHealthyMon() (string, error) {
+	if c.quorumRestoreMon != "" {
+		if _, ok := c.ClusterInfo.Monitors[c.quorumRestoreMon]; !ok {
+			return "", errors.Errorf("cannot restore the mon quorum from unknown mon %q", c.quorumRestoreMon)
+		}
+		return c.quorumRestoreMon, nil
+	}
+
+	names := []string{}
+	for name := range c.ClusterInfo.Monitors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		running, err := k8sutil.PodsRunningWithLabel(c.context.Clientset, c.Namespace, fmt.Sprintf("app=%s,mon=%s", AppName, name))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to check if mon %q is running", name)
+		}
+		if running > 0 {
+			logger.Infof("picked mon %q to restore the mon quorum", name)
+			return name, nil
+		}
+	}
+
+	return "", errors.New("failed to find a running mon to restore the mon quorum")
+}
+
+// waitForMonPodToStop waits for the pod of a mon to be gone so that its store is not locked anymore
+func (c *Cluster) waitForMonPodToStop(name string) error {
+	return c.waitForPodsToStop(fmt.Sprintf("app=%s,mon=%s", AppName, name), fmt.Sprintf("mon %q", name))
+}
+
+// waitForPodsToStop waits for all the pods matching the label selector to be gone
+func (c *Cluster) waitForPodsToStop(labelSelector, description string) error {
+	listOpts := metav1.ListOptions{LabelSelector: labelSelector}
+	retries := int(restoreQuorumTimeout / restoreQuorumRetryDelay)
+	err := util.Retry(retries, restoreQuorumRetryDelay, func() error {
+		pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(context.TODO(), listOpts)
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) != 0 {
+			return errors.Errorf("%d pods of %s are still running", len(pods.Items), description)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for %s to stop", description)
+	}
+
+	return nil
+}
+
+func (c *Cluster) runRestoreQuorumJob(healthyMon string, removedMons []string) error {
+	d, err := c.context.Clientset.AppsV1().Deployments(c.Namespace).Get(context.TODO(), resourceName(healthyMon), metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get mon %q", healthyMon)
+	}
+	job, err := c.makeRestoreQuorumJob(d.Spec.Template.Spec, healthyMon, removedMons)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate restore quorum job")
+	}
+
+	return c.runMonMaintenanceJob(job)
+}
+
+// runMonMaintenanceJob runs a job to completion and deletes it afterwards
+func (c *Cluster) runMonMaintenanceJob(job *batch.Job) error {
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
+		return errors.Wrapf(err, "failed to run job %q", job.Name)
+	}
+	if err := k8sutil.WaitForJobCompletion(c.context.Clientset, job, restoreQuorumTimeout); err != nil {
+		return errors.Wrapf(err, "failed to wait for job %q", job.Name)
+	}
+	if err := k8sutil.DeleteBatchJob(c.context.Clientset, c.Namespace, job.Name, false); err != nil {
+		logger.Warningf("failed to delete job %q. %v", job.Name, err)
+	}
+
+	return nil
+}
+
+// makeRestoreQuorumJob builds a job running on the pod spec of the healthy mon to rewrite its monmap
+func (c *Cluster) makeRestoreQuorumJob(podSpec v1.PodSpec, healthyMon string, removedMons []string) (*batch.Job, error) {
+	var monContainer *v1.Container
+	for i := range podSpec.Containers {
+		if podSpec.Containers[i].Name == "mon" {
+			monContainer = podSpec.Containers[i].DeepCopy()
+		}
+	}
+	if monContainer == nil {
+		return nil, errors.Errorf("failed to find the container of mon %q", healthyMon)
+	}
+
+	container := *monContainer
+	container.Name = restoreQuorumContainerName
+	container.Command = []string{
+		"/bin/bash",
+		"-c",
+		fmt.Sprintf("REMOVED_MONS=%q\n%s", strings.Join(removedMons, " "), rewriteMonmap),
+		cephMonCommand,
+	}
+	container.LivenessProbe = nil
+	container.ReadinessProbe = nil
+	container.StartupProbe = nil
+	container.Ports = nil
+
+	// the log collector sidecar is not needed by the job
+	podSpec.Containers = []v1.Container{container}
+	podSpec.ShareProcessNamespace = nil
+	podSpec.RestartPolicy = v1.RestartPolicyOnFailure
+
+	labels := map[string]string{
+		k8sutil.AppAttr:     restoreQuorumAppName,
+		k8sutil.ClusterAttr: c.Namespace,
+		"mon":               healthyMon,
+	}
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      restoreQuorumAppName,
+			Namespace: c.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: podSpec,
+			},
+		},
+	}
+	k8sutil.AddRookVersionLabelToJob(job)
+	if err := c.ownerInfo.SetControllerReference(job); err != nil {
+		return nil, errors.Wrapf(err, "failed to set owner reference to job %q", job.Name)
+	}
+
+	return job, nil
+}
+
+// completeQuorumRestore removes the restore request from the CephCluster so the quorum is not restored again
+func (c *Cluster) completeQuorumRestore() error {
+	c.quorumRestoreCluster = ""
+	c.quorumRestoreMon = ""
+
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.context.Client.Get(context.TODO(), c.ClusterInfo.NamespacedName(), cephCluster); err != nil {
+		return errors.Wrap(err, "failed to get cluster to remove the restore quorum annotation")
+	}
+	if _, ok := cephCluster.Annotations[RestoreQuorumAnnotation]; !ok {
+		return nil
+	}
+	delete(cephCluster.Annotations, RestoreQuorumAnnotation)
+	if err := c.context.Client.Update(context.TODO(), cephCluster); err != nil {
+		return errors.Wrap(err, "failed to remove the restore quorum annotation")
+	}
+	c.updateRestoreQuorumCondition("Restored mon quorum")
+
+	return nil
+}
+
+func (c *Cluster) updateRestoreQuorumCondition(message string) {
+	logger.Info(message)
+	controller.UpdateCondition(c.context, c.ClusterInfo.NamespacedName(), cephv1.ConditionProgressing, v1.ConditionTrue, cephv1.ClusterProgressingReason, message)
+}
@@ -0,0 +1,181 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMakeRestoreQuorumJob(t *testing.T) {
+	ownerInfo := cephclient.NewMinimumOwnerInfoWithOwnerRef()
+	c := New(&clusterd.Context{}, "ns", cephv1.ClusterSpec{}, ownerInfo, &sync.Mutex{})
+	podSpec := v1.PodSpec{
+		InitContainers: []v1.Container{{Name: "init-mon-fs"}},
+		Containers: []v1.Container{
+			{
+				Name:          "mon",
+				Image:         "ceph/ceph:v15",
+				Command:       []string{cephMonCommand},
+				Args:          []string{"--id=b", "--foreground"},
+				LivenessProbe: &v1.Probe{},
+			},
+			{Name: "log-collector"},
+		},
+		Volumes:      []v1.Volume{{Name: "ceph-daemon-data"}},
+		NodeSelector: map[string]string{v1.LabelHostname: "node0"},
+	}
+
+	job, err := c.makeRestoreQuorumJob(podSpec, "b", []string{"a", "c"})
+	assert.NoError(t, err)
+	assert.Equal(t, restoreQuorumAppName, job.Name)
+	assert.Equal(t, "b", job.Spec.Template.Labels["mon"])
+	assert.Equal(t, restoreQuorumAppName, job.Spec.Template.Labels["app"])
+	spec := job.Spec.Template.Spec
+	assert.Equal(t, v1.RestartPolicyOnFailure, spec.RestartPolicy)
+	assert.Equal(t, podSpec.InitContainers, spec.InitContainers)
+	assert.Equal(t, podSpec.Volumes, spec.Volumes)
+	assert.Equal(t, "node0", spec.NodeSelector[v1.LabelHostname])
+	assert.Equal(t, 1, len(spec.Containers))
+	container := spec.Containers[0]
+	assert.Equal(t, restoreQuorumContainerName, container.Name)
+	assert.Equal(t, "ceph/ceph:v15", container.Image)
+	assert.Contains(t, container.Command[2], "REMOVED_MONS=\"a c\"\n")
+	assert.Equal(t, cephMonCommand, container.Command[3])
+	assert.Equal(t, []string{"--id=b", "--foreground"}, container.Args)
+	assert.Nil(t, container.LivenessProbe)
+
+	// the mon container is required
+	_, err = c.makeRestoreQuorumJob(v1.PodSpec{}, "b", []string{"a"})
+	assert.Error(t, err)
+}
+
+func TestPickHealthyMon(t *testing.T) {
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	c.ClusterInfo = cephclient.AdminClusterInfo("ns")
+	c.ClusterInfo.Monitors = map[string]*cephclient.MonInfo{"a": {Name: "a"}, "b": {Name: "b"}, "c": {Name: "c"}}
+
+	// no mon is running
+	_, err := c.pickHealthyMon()
+	assert.Error(t, err)
+
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-c-xyz", Namespace: "ns", Labels: map[string]string{"app": AppName, "mon": "c"}},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	_, err = clientset.CoreV1().Pods("ns").Create(ctx, pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	mon, err := c.pickHealthyMon()
+	assert.NoError(t, err)
+	assert.Equal(t, "c", mon)
+
+	// the mon requested by the user
+	c.RequestQuorumRestore("test", "b")
+	mon, err = c.pickHealthyMon()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", mon)
+
+	c.RequestQuorumRestore("test", "z")
+	_, err = c.pickHealthyMon()
+	assert.Error(t, err)
+}
+
+func TestRestoreQuorumWithSingleMon(t *testing.T) {
+	ctx := context.TODO()
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "ns",
+			Annotations: map[string]string{RestoreQuorumAnnotation: "a"},
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fakeclient.NewFakeClientWithScheme(s, []runtime.Object{cephCluster}...)
+	context := &clusterd.Context{Client: cl, Clientset: fake.NewSimpleClientset(), RookClientset: rookclient.NewSimpleClientset()}
+	c := New(context, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	c.ClusterInfo = cephclient.AdminClusterInfo("ns")
+	c.ClusterInfo.Monitors = map[string]*cephclient.MonInfo{"a": {Name: "a"}}
+
+	// there is nothing to restore, the request is removed
+	c.RequestQuorumRestore("test", "a")
+	err := c.restoreQuorum()
+	assert.NoError(t, err)
+	assert.Equal(t, "", c.quorumRestoreCluster)
+	updatedCluster := &cephv1.CephCluster{}
+	err = cl.Get(ctx, c.ClusterInfo.NamespacedName(), updatedCluster)
+	assert.NoError(t, err)
+	_, ok := updatedCluster.Annotations[RestoreQuorumAnnotation]
+	assert.False(t, ok)
+}
+
+func TestRestoreQuorumRetry(t *testing.T) {
+	ctx := context.TODO()
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test",
+			Namespace:   "ns",
+			Annotations: map[string]string{RestoreQuorumAnnotation: ""},
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fakeclient.NewFakeClientWithScheme(s, []runtime.Object{cephCluster}...)
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Client: cl, Clientset: clientset}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	c.ClusterInfo = cephclient.AdminClusterInfo("ns")
+	c.ClusterInfo.SetName("test")
+	c.ClusterInfo.Monitors = map[string]*cephclient.MonInfo{"b": {Name: "b"}}
+
+	// the mon picked by the operator is saved in the request
+	c.RequestQuorumRestore("test", "")
+	assert.NoError(t, c.saveQuorumRestoreMon("b"))
+	assert.Equal(t, "b", c.quorumRestoreMon)
+	err := cl.Get(ctx, c.ClusterInfo.NamespacedName(), cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "b", cephCluster.Annotations[RestoreQuorumAnnotation])
+
+	// the mons removed from the endpoints by a previous attempt are still removed if they have a deployment
+	for _, name := range []string{"a", "b"} {
+		d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:      resourceName(name),
+			Namespace: "ns",
+			Labels:    map[string]string{"app": AppName, "mon": name},
+		}}
+		_, err := clientset.AppsV1().Deployments("ns").Create(ctx, d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	removedMons, err := c.monsToRemove("b")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a"}, removedMons)
+}
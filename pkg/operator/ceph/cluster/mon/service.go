@@ -28,6 +28,8 @@ import (
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// createService creates the service of the mon and returns its cluster IP. In a dual-stack cluster, the service has a
+// cluster IP in each IP family and the cluster IP of the second family is set on the mon config.
 func (c *Cluster) createService(mon *monConfig) (string, error) {
 	ctx := context.TODO()
 	svcDef := &v1.Service{
@@ -58,6 +60,18 @@ func (c *Cluster) createService(mon *monConfig) (string, error) {
 	// If deploying Nautilus or newer we need a new port for the monitor service
 	addServicePort(svcDef, "tcp-msgr2", DefaultMsgr2Port)
 
+	// The mons do not listen on the msgr1 port when msgr2 is required
+	if c.spec.Network.RequireMsgr2() {
+		svcDef.Spec.Ports = svcDef.Spec.Ports[1:]
+	}
+
+	// The service has both an IPv4 and an IPv6 cluster IP in a dual-stack cluster
+	if c.spec.Network.IsDualStack() {
+		policy := v1.IPFamilyPolicyRequireDualStack
+		svcDef.Spec.IPFamilyPolicy = &policy
+		svcDef.Spec.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+	}
+
 	// Set the ClusterIP if the service does not exist and we expect a certain cluster IP
 	// For example, in disaster recovery the service might have been deleted accidentally, but we have the
 	// expected endpoint from the mon configmap.
@@ -66,6 +80,10 @@ func (c *Cluster) createService(mon *monConfig) (string, error) {
 		if err != nil && kerrors.IsNotFound(err) {
 			logger.Infof("ensuring the clusterIP for mon %q is %q", mon.DaemonName, mon.PublicIP)
 			svcDef.Spec.ClusterIP = mon.PublicIP
+			if c.spec.Network.IsDualStack() && mon.SecondaryPublicIP != "" {
+				logger.Infof("ensuring the secondary clusterIP for mon %q is %q", mon.DaemonName, mon.SecondaryPublicIP)
+				svcDef.Spec.ClusterIPs = []string{mon.PublicIP, mon.SecondaryPublicIP}
+			}
 		}
 	}
 
@@ -79,6 +97,19 @@ func (c *Cluster) createService(mon *monConfig) (string, error) {
 		return "", nil
 	}
 
+	if c.spec.Network.IsDualStack() {
+		if len(s.Spec.ClusterIPs) < 2 {
+			return "", errors.Errorf("failed to get the cluster IPs of both IP families of the service of mon %q, found %v", mon.DaemonName, s.Spec.ClusterIPs)
+		}
+		mon.SecondaryPublicIP = s.Spec.ClusterIPs[1]
+		logger.Infof("mon %q secondary endpoint is on cluster IP %q", mon.DaemonName, mon.SecondaryPublicIP)
+	}
+
+	if c.spec.Network.RequireMsgr2() {
+		logger.Infof("mon %q endpoint is [v2:%s:%s]", mon.DaemonName, s.Spec.ClusterIP, strconv.Itoa(int(DefaultMsgr2Port)))
+		return s.Spec.ClusterIP, nil
+	}
+
 	// mon endpoint are not actually like, they remain with the mgrs1 format
 	// however it's interesting to show that monitors can be addressed via 2 different ports
 	// in the end the service has msgr1 and msgr2 ports configured so it's not entirely wrong
@@ -26,6 +26,7 @@ import (
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	"github.com/rook/rook/pkg/operator/test"
 	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -54,3 +55,64 @@ func TestCreateService(t *testing.T) {
 	// the clusterIP will now be set to the expected value
 	assert.Equal(t, m.PublicIP, clusterIP)
 }
+
+func TestCreateServiceMsgr2Only(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", cephv1.ClusterSpec{}, &k8sutil.OwnerInfo{}, &sync.Mutex{})
+	m := &monConfig{ResourceName: "rook-ceph-mon-b", DaemonName: "b", Port: DefaultMsgr1Port}
+	_, err := c.createService(m)
+	assert.NoError(t, err)
+	s, err := clientset.CoreV1().Services(c.Namespace).Get(ctx, m.ResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(s.Spec.Ports))
+
+	// the msgr1 port is removed from the existing service
+	c.spec.Network.Connections = &cephv1.ConnectionsSpec{Encryption: &cephv1.EncryptionSpec{Enabled: true}}
+	m.Port = c.monPort()
+	_, err = c.createService(m)
+	assert.NoError(t, err)
+	s, err = clientset.CoreV1().Services(c.Namespace).Get(ctx, m.ResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(s.Spec.Ports))
+	assert.Equal(t, "tcp-msgr2", s.Spec.Ports[0].Name)
+	assert.Equal(t, DefaultMsgr2Port, s.Spec.Ports[0].Port)
+}
+
+func TestCreateServiceDualStack(t *testing.T) {
+	ctx := context.TODO()
+	clientset := test.New(t, 1)
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", cephv1.ClusterSpec{}, &k8sutil.OwnerInfo{}, &sync.Mutex{})
+	c.spec.Network.IPFamily = cephv1.DualStack
+	m := &monConfig{ResourceName: "rook-ceph-mon-b", DaemonName: "b", Port: DefaultMsgr1Port}
+
+	// the mock service has no cluster IP
+	_, err := c.createService(m)
+	assert.Error(t, err)
+
+	// the service has a cluster IP in each family
+	svc, err := clientset.CoreV1().Services(c.Namespace).Get(ctx, m.ResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, v1.IPFamilyPolicyRequireDualStack, *svc.Spec.IPFamilyPolicy)
+	assert.Equal(t, []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}, svc.Spec.IPFamilies)
+	svc.Spec.ClusterIP = "10.0.0.1"
+	svc.Spec.ClusterIPs = []string{"10.0.0.1", "fd00::1"}
+	_, err = clientset.CoreV1().Services(c.Namespace).Update(ctx, svc, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+
+	clusterIP, err := c.createService(m)
+	assert.NoError(t, err)
+	assert.Equal(t, "10.0.0.1", clusterIP)
+	assert.Equal(t, "fd00::1", m.SecondaryPublicIP)
+	m.PublicIP = clusterIP
+	assert.Equal(t, "[fd00::1]:6789", m.monInfo().SecondaryEndpoint)
+
+	// the cluster IPs are restored in disaster recovery
+	err = clientset.CoreV1().Services(c.Namespace).Delete(ctx, m.ResourceName, metav1.DeleteOptions{})
+	assert.NoError(t, err)
+	_, err = c.createService(m)
+	assert.NoError(t, err)
+	svc, err = clientset.CoreV1().Services(c.Namespace).Get(ctx, m.ResourceName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1", "fd00::1"}, svc.Spec.ClusterIPs)
+}
@@ -215,8 +215,8 @@ func (c *Cluster) makeMonPod(monConfig *monConfig, canary bool) (*v1.Pod, error)
 		}
 	}
 
-	if c.spec.IsStretchCluster() {
-		nodeAffinity, err := k8sutil.GenerateNodeAffinity(fmt.Sprintf("%s=%s", StretchFailureDomainLabel(c.spec), monConfig.Zone))
+	if c.spec.ZonesRequired() && monConfig.Zone != "" {
+		nodeAffinity, err := k8sutil.GenerateNodeAffinity(fmt.Sprintf("%s=%s", c.zoneFailureDomainLabel(), monConfig.Zone))
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to generate mon %q node affinity", monConfig.DaemonName)
 		}
@@ -252,7 +252,7 @@ func (c *Cluster) makeMonFSInitContainer(monConfig *monConfig) v1.Container {
 			controller.DaemonFlags(c.ClusterInfo, &c.spec, monConfig.DaemonName),
 			// needed so we can generate an initial monmap
 			// otherwise the mkfs will say: "0  no local addrs match monmap"
-			config.NewFlag("public-addr", monConfig.PublicIP),
+			c.monPublicAddrFlag(monConfig, monConfig.PublicIP),
 			"--mkfs",
 		),
 		Image:           c.spec.CephVersion.Image,
@@ -264,13 +264,22 @@ func (c *Cluster) makeMonFSInitContainer(monConfig *monConfig) v1.Container {
 	}
 }
 
+// monPublicAddrFlag returns the flag of the address the mon advertises. A mon of a dual-stack cluster advertises
+// the addresses of both IP families, with their ports since they are required in an addrvec.
+func (c *Cluster) monPublicAddrFlag(monConfig *monConfig, publicAddr string) string {
+	if monConfig.SecondaryPublicIP != "" {
+		return config.NewFlag("public-addrv", client.MonAddrvec(monConfig.monInfo()))
+	}
+	return config.NewFlag("public-addr", publicAddr)
+}
+
 func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 	podIPEnvVar := "ROOK_POD_IP"
 	publicAddr := monConfig.PublicIP
 
 	// Handle the non-default port for host networking. If host networking is not being used,
 	// the service created elsewhere will handle the non-default port redirection to the default port inside the container.
-	if c.spec.Network.IsHost() && monConfig.Port != DefaultMsgr1Port {
+	if c.spec.Network.IsHost() && monConfig.Port != DefaultMsgr1Port && monConfig.Port != DefaultMsgr2Port {
 		logger.Warningf("Starting mon %s with host networking on a non-default port %d. The mon must be failed over before enabling msgr2.",
 			monConfig.DaemonName, monConfig.Port)
 		publicAddr = fmt.Sprintf("%s:%d", publicAddr, monConfig.Port)
@@ -286,7 +295,7 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 			"--foreground",
 			// If the mon is already in the monmap, when the port is left off of --public-addr,
 			// it will still advertise on the previous port b/c monmap is saved to mon database.
-			config.NewFlag("public-addr", publicAddr),
+			c.monPublicAddrFlag(monConfig, publicAddr),
 			// Set '--setuser-match-path' so that existing directory owned by root won't affect the daemon startup.
 			// For existing data store owned by root, the daemon will continue to run as root
 			//
@@ -328,6 +337,18 @@ func (c *Cluster) makeMonDaemonContainer(monConfig *monConfig) v1.Container {
 	// Add messenger 2 port
 	addContainerPort(container, "tcp-msgr2", 3300)
 
+	if c.spec.Network.RequireMsgr2() {
+		// The mons bind before they read the centralized config, the msgr1 protocol is disabled on the command line
+		container.Args = append(container.Args, config.NewFlag("ms-bind-msgr1", "false"))
+		container.Ports = []v1.ContainerPort{
+			{
+				Name:          "tcp-msgr2",
+				ContainerPort: DefaultMsgr2Port,
+				Protocol:      v1.ProtocolTCP,
+			},
+		}
+	}
+
 	return container
 }
 
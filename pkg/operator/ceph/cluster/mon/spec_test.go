@@ -91,6 +91,40 @@ func testPodSpec(t *testing.T, monID string, pvc bool) {
 		"my-priority-class")
 }
 
+func TestMonDaemonContainerMsgr2Only(t *testing.T) {
+	c := New(&clusterd.Context{}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "rook/rook:myversion")
+	monConfig := testGenMonConfig("a")
+
+	container := c.makeMonDaemonContainer(monConfig)
+	assert.Equal(t, "tcp-msgr1", container.Ports[0].Name)
+	assert.NotContains(t, container.Args, "--ms-bind-msgr1=false")
+
+	c.spec.Network.Connections = &cephv1.ConnectionsSpec{RequireMsgr2: true}
+	container = c.makeMonDaemonContainer(monConfig)
+	assert.Equal(t, 1, len(container.Ports))
+	assert.Equal(t, DefaultMsgr2Port, container.Ports[0].ContainerPort)
+	assert.Contains(t, container.Args, "--ms-bind-msgr1=false")
+}
+
+func TestMonDaemonContainerDualStack(t *testing.T) {
+	c := New(&clusterd.Context{}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	setCommonMonProperties(c, 0, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "rook/rook:myversion")
+	monConfig := testGenMonConfig("a")
+
+	container := c.makeMonDaemonContainer(monConfig)
+	assert.Contains(t, container.Args, "--public-addr=2.4.6.1")
+
+	// the mon advertises the addresses of both IP families
+	c.spec.Network.IPFamily = cephv1.DualStack
+	monConfig.SecondaryPublicIP = "fd00::1"
+	container = c.makeMonDaemonContainer(monConfig)
+	assert.Contains(t, container.Args, "--public-addrv=[v2:2.4.6.1:3300,v1:2.4.6.1:6789,v2:[fd00::1]:3300,v1:[fd00::1]:6789]")
+	assert.Contains(t, container.Args, "--ms-bind-ipv6=true")
+	initContainer := c.makeMonFSInitContainer(monConfig)
+	assert.Contains(t, initContainer.Args, "--public-addrv=[v2:2.4.6.1:3300,v1:2.4.6.1:6789,v2:[fd00::1]:3300,v1:[fd00::1]:6789]")
+}
+
 func TestDeploymentPVCSpec(t *testing.T) {
 	clientset := testop.New(t, 1)
 	ownerInfo := cephclient.NewMinimumOwnerInfoWithOwnerRef()
@@ -0,0 +1,335 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// health checks raised by ceph when the store of a mon is too big or when the disk of a mon is filling up
+	monDiskBigCheck  = "MON_DISK_BIG"
+	monDiskLowCheck  = "MON_DISK_LOW"
+	monDiskCritCheck = "MON_DISK_CRIT"
+	// the store of a mon is not compacted again before this interval even if it is still too big
+	monStoreCompactInterval = time.Hour
+	// the mon PVCs grow by this percentage when a mon is low on disk space
+	monVolumeExpansionPercent = 50
+)
+
+// checkStore compacts the mons whose store is above the threshold of the cluster spec, reports the mons that
+// are low on disk space in the CephCluster status and expands their PVCs if allowed
+func (c *Cluster) checkStore() error {
+	// the orchestration lock is only held to read the cluster settings, a compaction can take several minutes
+	c.acquireOrchestrationLock()
+	clusterInfo := c.ClusterInfo
+	storeSpec := c.spec.Mon.Store
+	external := c.spec.External.Enable
+	c.releaseOrchestrationLock()
+
+	if clusterInfo == nil || !clusterInfo.IsInitialized(false) || external {
+		return nil
+	}
+
+	health, err := cephclient.HealthDetail(c.context, clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to check the disk usage of the mons")
+	}
+
+	if storeSpec.CompactThresholdGB > 0 {
+		sizes := monStoreSizes(health)
+		for _, mon := range sortedMons(sizes) {
+			logger.Debugf("the store of mon %q is %s", mon, resource.NewQuantity(int64(sizes[mon]), resource.BinarySI).String())
+		}
+		if mon := c.nextMonToCompact(sizes, uint64(storeSpec.CompactThresholdGB)<<30); mon != "" {
+			go c.compactMonStore(clusterInfo, mon, sizes[mon])
+		}
+	}
+
+	lowMons := append(monsInHealthCheck(health, monDiskCritCheck), monsInHealthCheck(health, monDiskLowCheck)...)
+	c.updateMonDiskStatus(lowMons)
+
+	if storeSpec.ExpandVolume {
+		for _, mon := range lowMons {
+			if err := c.expandMonVolume(mon); err != nil {
+				logger.Errorf("failed to expand the volume of mon %q. %v", mon, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// nextMonToCompact returns the first mon whose store is above the threshold and was not compacted recently. Only one
+// mon is compacted at a time since a mon does not serve requests while it is compacting.
+func (c *Cluster) nextMonToCompact(sizes map[string]uint64, threshold uint64) string {
+	c.monStoreMutex.Lock()
+	defer c.monStoreMutex.Unlock()
+
+	if c.monStoreCompacting != "" {
+		logger.Debugf("the store of mon %q is still being compacted", c.monStoreCompacting)
+		return ""
+	}
+	for _, mon := range sortedMons(sizes) {
+		if sizes[mon] < threshold {
+			continue
+		}
+		if lastCompaction, ok := c.monStoreCompactions[mon]; ok && time.Since(lastCompaction) < monStoreCompactInterval {
+			logger.Debugf("mon %q store was compacted at %s, not compacting it again yet", mon, lastCompaction)
+			continue
+		}
+		c.monStoreCompacting = mon
+		c.monStoreCompactions[mon] = time.Now()
+		return mon
+	}
+	return ""
+}
+
+// compactMonStore compacts the store of a mon. The command returns when the compaction is completed, so it runs in
+// the background and the health checks of the mons keep running in the meantime.
+func (c *Cluster) compactMonStore(clusterInfo *cephclient.ClusterInfo, mon string, size uint64) {
+	defer func() {
+		c.monStoreMutex.Lock()
+		c.monStoreCompacting = ""
+		c.monStoreMutex.Unlock()
+	}()
+
+	logger.Infof("compacting the store of mon %q since its size %s is above the compaction threshold", mon, resource.NewQuantity(int64(size), resource.BinarySI).String())
+	if err := cephclient.CompactMonStore(c.context, clusterInfo, mon); err != nil {
+		logger.Errorf("failed to compact the store of mon %q. %v", mon, err)
+	}
+}
+
+// updateMonDiskStatus sets the MonDiskLow condition of the CephCluster and raises an event when a mon starts
+// running low on disk space
+func (c *Cluster) updateMonDiskStatus(lowMons []string) {
+	newLowMons := []string{}
+	currentLowMons := map[string]bool{}
+	for _, mon := range lowMons {
+		currentLowMons[mon] = true
+		if !c.monsLowOnDisk[mon] {
+			newLowMons = append(newLowMons, mon)
+		}
+	}
+
+	cephCluster := &cephv1.CephCluster{}
+	err := c.context.Client.Get(context.TODO(), c.ClusterInfo.NamespacedName(), cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve ceph cluster %q to update the mon disk status. %v", c.ClusterInfo.NamespacedName().Name, err)
+		return
+	}
+
+	condition := cephv1.Condition{
+		Type:    cephv1.ConditionMonDiskLow,
+		Status:  v1.ConditionFalse,
+		Reason:  cephv1.MonDiskAvailableReason,
+		Message: "The mons have enough disk space",
+	}
+	if len(lowMons) > 0 {
+		condition.Status = v1.ConditionTrue
+		condition.Reason = cephv1.MonDiskLowReason
+		condition.Message = fmt.Sprintf("Mons %v are low on disk space", lowMons)
+	}
+	if setClusterCondition(cephCluster, condition) {
+		if err := controller.UpdateStatus(c.context.Client, cephCluster); err != nil {
+			logger.Errorf("failed to update cluster %q mon disk status. %v", c.ClusterInfo.NamespacedName().Name, err)
+			return
+		}
+	}
+
+	for _, mon := range newLowMons {
+		message := fmt.Sprintf("mon %q is low on disk space", mon)
+		logger.Warning(message)
+		c.recordClusterEvent(cephCluster, v1.EventTypeWarning, string(cephv1.MonDiskLowReason), message)
+	}
+	c.monsLowOnDisk = currentLowMons
+}
+
+// setClusterCondition replaces the condition of the same type in the cluster status without changing the phase of
+// the cluster. It returns false if the status does not need to be updated. A condition that is not true is only
+// added to the status to clear the same condition.
+func setClusterCondition(cephCluster *cephv1.CephCluster, condition cephv1.Condition) bool {
+	now := metav1.NewTime(time.Now())
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+	found := false
+	conditions := []cephv1.Condition{}
+	for _, existing := range cephCluster.Status.Conditions {
+		if existing.Type != condition.Type {
+			conditions = append(conditions, existing)
+			continue
+		}
+		if existing.Status == condition.Status && existing.Message == condition.Message {
+			return false
+		}
+		found = true
+	}
+	if !found && condition.Status != v1.ConditionTrue {
+		return false
+	}
+	cephCluster.Status.Conditions = append(conditions, condition)
+	return true
+}
+
+// recordClusterEvent creates a kubernetes event on the CephCluster
+func (c *Cluster) recordClusterEvent(cephCluster *cephv1.CephCluster, eventType, reason, message string) {
+	now := metav1.NewTime(time.Now())
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: fmt.Sprintf("%s-", cephCluster.Name),
+			Namespace:    cephCluster.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion: cephv1.SchemeGroupVersion.String(),
+			Kind:       "CephCluster",
+			Name:       cephCluster.Name,
+			Namespace:  cephCluster.Namespace,
+			UID:        cephCluster.UID,
+		},
+		Type:           eventType,
+		Reason:         reason,
+		Message:        message,
+		Source:         v1.EventSource{Component: "rook-ceph-operator"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+	}
+	if _, err := c.context.Clientset.CoreV1().Events(cephCluster.Namespace).Create(context.TODO(), event, metav1.CreateOptions{}); err != nil {
+		logger.Errorf("failed to create event %q on cluster %q. %v", reason, cephCluster.Name, err)
+	}
+}
+
+// expandMonVolume requests a bigger PVC for the mon if its storage class allows volume expansion
+func (c *Cluster) expandMonVolume(mon string) error {
+	ctx := context.TODO()
+	pvc, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Get(ctx, resourceName(mon), metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debugf("mon %q does not run on a pvc, its volume cannot be expanded", mon)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get the pvc of mon %q", mon)
+	}
+
+	if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+		logger.Infof("not expanding the pvc %q of mon %q since it has no storage class", pvc.Name, mon)
+		return nil
+	}
+	storageClass, err := c.context.Clientset.StorageV1().StorageClasses().Get(ctx, *pvc.Spec.StorageClassName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get storage class %q", *pvc.Spec.StorageClassName)
+	}
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		logger.Infof("not expanding the pvc %q of mon %q since storage class %q does not allow volume expansion", pvc.Name, mon, storageClass.Name)
+		return nil
+	}
+
+	// wait for the previous expansion to complete before requesting more space
+	requested := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+	capacity := pvc.Status.Capacity[v1.ResourceStorage]
+	if capacity.Cmp(requested) < 0 {
+		logger.Infof("waiting for the pvc %q of mon %q to be expanded to %s", pvc.Name, mon, requested.String())
+		return nil
+	}
+
+	size := resource.NewQuantity(capacity.Value()*(100+monVolumeExpansionPercent)/100, capacity.Format)
+	logger.Infof("expanding the pvc %q of mon %q from %s to %s", pvc.Name, mon, capacity.String(), size.String())
+	pvc.Spec.Resources.Requests[v1.ResourceStorage] = *size
+	if _, err := c.context.Clientset.CoreV1().PersistentVolumeClaims(c.Namespace).Update(ctx, pvc, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to expand pvc %q", pvc.Name)
+	}
+
+	return nil
+}
+
+// monsInHealthCheck returns the names of the mons reported by a health check. The detail messages of the mon disk
+// checks start with the name of the mon, for example "mon.a has 25% avail".
+func monsInHealthCheck(health cephclient.HealthStatus, check string) []string {
+	mons := []string{}
+	for _, detail := range health.Checks[check].Detail {
+		fields := strings.Fields(detail.Message)
+		if len(fields) == 0 || !strings.HasPrefix(fields[0], "mon.") {
+			continue
+		}
+		mons = append(mons, strings.TrimPrefix(fields[0], "mon."))
+	}
+	sort.Strings(mons)
+	return mons
+}
+
+// monStoreSizes returns the size in bytes of the store of the mons reported by the MON_DISK_BIG health check. Ceph
+// does not report the size of the mon stores anywhere else, the detail messages of the check are for example
+// "mon.a is 16 GiB >= mon_data_size_warn (15 GiB)".
+func monStoreSizes(health cephclient.HealthStatus) map[string]uint64 {
+	sizes := map[string]uint64{}
+	for _, detail := range health.Checks[monDiskBigCheck].Detail {
+		fields := strings.Fields(detail.Message)
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "mon.") || fields[1] != "is" {
+			continue
+		}
+		size, err := parseCephBytes(fields[2], fields[3])
+		if err != nil {
+			logger.Debugf("failed to parse the store size of %q. %v", fields[0], err)
+			continue
+		}
+		sizes[strings.TrimPrefix(fields[0], "mon.")] = size
+	}
+	return sizes
+}
+
+// parseCephBytes converts a size printed by ceph with a binary unit, for example "16 GiB", to bytes
+func parseCephBytes(value, unit string) (uint64, error) {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse size %q", value)
+	}
+	for i, u := range units {
+		if u == unit {
+			return uint64(number * float64(uint64(1)<<(10*uint(i)))), nil
+		}
+	}
+	return 0, errors.Errorf("unknown unit %q", unit)
+}
+
+// sortedMons returns the names of the mons in alphabetical order
+func sortedMons(sizes map[string]uint64) []string {
+	mons := []string{}
+	for mon := range sizes {
+		mons = append(mons, mon)
+	}
+	sort.Strings(mons)
+	return mons
+}
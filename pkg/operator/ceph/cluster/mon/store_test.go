@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMonsInHealthCheck(t *testing.T) {
+	health := cephclient.HealthStatus{
+		Checks: map[string]cephclient.CheckMessage{
+			monDiskLowCheck: {
+				Severity: "HEALTH_WARN",
+				Detail: []cephclient.Summary{
+					{Message: "mon.c has 25% avail"},
+					{Message: "mon.a has 28% avail"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, []string{"a", "c"}, monsInHealthCheck(health, monDiskLowCheck))
+	assert.Equal(t, []string{}, monsInHealthCheck(health, monDiskBigCheck))
+}
+
+func TestMonStoreSizes(t *testing.T) {
+	health := cephclient.HealthStatus{
+		Checks: map[string]cephclient.CheckMessage{
+			monDiskBigCheck: {
+				Severity: "HEALTH_WARN",
+				Detail: []cephclient.Summary{
+					{Message: "mon.a is 16 GiB >= mon_data_size_warn (15 GiB)"},
+					{Message: "mon.b is 15.5 GiB >= mon_data_size_warn (15 GiB)"},
+					{Message: "mon.c is big"},
+				},
+			},
+		},
+	}
+	assert.Equal(t, map[string]uint64{"a": 16 << 30, "b": 31 << 29}, monStoreSizes(health))
+	assert.Equal(t, map[string]uint64{}, monStoreSizes(cephclient.HealthStatus{}))
+
+	size, err := parseCephBytes("512", "MiB")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(512<<20), size)
+	_, err = parseCephBytes("512", "MB")
+	assert.Error(t, err)
+}
+
+func TestCompactMonStores(t *testing.T) {
+	compacted := []string{}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outputFile string, args ...string) (string, error) {
+			if args[0] == "tell" && args[2] == "compact" {
+				compacted = append(compacted, args[1])
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+	c := New(&clusterd.Context{Executor: executor}, "ns", cephv1.ClusterSpec{}, nil, nil)
+	clusterInfo := cephclient.AdminClusterInfo("ns")
+	threshold := uint64(10 << 30)
+	sizes := map[string]uint64{"a": 12 << 30, "b": 11 << 30, "c": 9 << 30}
+
+	// one mon is compacted at a time
+	assert.Equal(t, "a", c.nextMonToCompact(sizes, threshold))
+	assert.Equal(t, "", c.nextMonToCompact(sizes, threshold))
+	c.compactMonStore(clusterInfo, "a", sizes["a"])
+	assert.Equal(t, []string{"mon.a"}, compacted)
+	assert.Equal(t, "b", c.nextMonToCompact(sizes, threshold))
+	c.compactMonStore(clusterInfo, "b", sizes["b"])
+	assert.Equal(t, []string{"mon.a", "mon.b"}, compacted)
+
+	// the mons below the threshold are not compacted, and the mons are not compacted again until the interval has passed
+	assert.Equal(t, "", c.nextMonToCompact(sizes, threshold))
+	c.monStoreCompactions["a"] = time.Now().Add(-monStoreCompactInterval)
+	assert.Equal(t, "a", c.nextMonToCompact(sizes, threshold))
+}
+
+func TestUpdateMonDiskStatus(t *testing.T) {
+	ctx := context.TODO()
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"}}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fakeclient.NewFakeClientWithScheme(s, []runtime.Object{cephCluster}...)
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Client: cl, Clientset: clientset}, "ns", cephv1.ClusterSpec{}, nil, nil)
+	c.ClusterInfo = cephclient.AdminClusterInfo("ns")
+	c.ClusterInfo.SetName("test")
+
+	getCondition := func() *cephv1.Condition {
+		err := cl.Get(ctx, c.ClusterInfo.NamespacedName(), cephCluster)
+		assert.NoError(t, err)
+		for _, condition := range cephCluster.Status.Conditions {
+			if condition.Type == cephv1.ConditionMonDiskLow {
+				return &condition
+			}
+		}
+		return nil
+	}
+
+	// no condition is added while the mons have enough space
+	c.updateMonDiskStatus([]string{})
+	assert.Nil(t, getCondition())
+
+	// a mon is low on space
+	c.updateMonDiskStatus([]string{"a"})
+	condition := getCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, cephv1.MonDiskLowReason, condition.Reason)
+	events, err := clientset.CoreV1().Events("ns").List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+	assert.Equal(t, "CephCluster", events.Items[0].InvolvedObject.Kind)
+
+	// the event is not raised again for the same mon
+	c.updateMonDiskStatus([]string{"a"})
+	events, err = clientset.CoreV1().Events("ns").List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(events.Items))
+
+	// the condition is cleared when the mons have enough space again
+	c.updateMonDiskStatus([]string{})
+	condition = getCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, cephv1.MonDiskAvailableReason, condition.Reason)
+}
+
+func TestExpandMonVolume(t *testing.T) {
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", cephv1.ClusterSpec{}, nil, nil)
+
+	// mons on the host path have no volume to expand
+	assert.NoError(t, c.expandMonVolume("a"))
+
+	storageClassName := "gp2"
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a", Namespace: "ns"},
+		Spec: v1.PersistentVolumeClaimSpec{
+			StorageClassName: &storageClassName,
+			Resources: v1.ResourceRequirements{
+				Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+			},
+		},
+		Status: v1.PersistentVolumeClaimStatus{
+			Capacity: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+		},
+	}
+	_, err := clientset.CoreV1().PersistentVolumeClaims("ns").Create(ctx, pvc, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	allowExpansion := false
+	storageClass := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: storageClassName}, AllowVolumeExpansion: &allowExpansion}
+	_, err = clientset.StorageV1().StorageClasses().Create(ctx, storageClass, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	requestedSize := func() string {
+		pvc, err := clientset.CoreV1().PersistentVolumeClaims("ns").Get(ctx, "rook-ceph-mon-a", metav1.GetOptions{})
+		assert.NoError(t, err)
+		size := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+		return size.String()
+	}
+
+	// the storage class does not allow expansion
+	assert.NoError(t, c.expandMonVolume("a"))
+	assert.Equal(t, "10Gi", requestedSize())
+
+	// the pvc is expanded
+	allowExpansion = true
+	_, err = clientset.StorageV1().StorageClasses().Update(ctx, storageClass, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, c.expandMonVolume("a"))
+	assert.Equal(t, "15Gi", requestedSize())
+
+	// the pvc is not expanded again until the previous expansion is completed
+	assert.NoError(t, c.expandMonVolume("a"))
+	assert.Equal(t, "15Gi", requestedSize())
+}
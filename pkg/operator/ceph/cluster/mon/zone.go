@@ -0,0 +1,147 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// zoneFailureDomainLabel returns the node label of the zones the mons are spread across
+func (c *Cluster) zoneFailureDomainLabel() string {
+	if c.spec.IsStretchCluster() {
+		return StretchFailureDomainLabel(c.spec)
+	}
+	if c.spec.Mon.FailureDomainLabel != "" {
+		return c.spec.Mon.FailureDomainLabel
+	}
+	// The default topology label is for a zone
+	return v1.LabelZoneFailureDomainStable
+}
+
+// findBalancedZone returns the zone with the fewest mons among the zones that have a node available for a mon.
+// When a zone is lost, its mons are failed over to the other zones until the zone has a node available again.
+func (c *Cluster) findBalancedZone(mons []*monConfig) (string, error) {
+	zones, err := c.availableMonZones()
+	if err != nil {
+		return "", err
+	}
+	if len(zones) == 0 {
+		return "", errors.Errorf("no node is available for a mon in any zone with label %q", c.zoneFailureDomainLabel())
+	}
+
+	zoneCount := map[string]int{}
+	for _, m := range mons {
+		zone, err := c.monZone(m)
+		if err != nil {
+			return "", err
+		}
+		if zone == "" {
+			logger.Debugf("zone of mon %q is unknown, not counting it in the mon zones", m.DaemonName)
+			continue
+		}
+		zoneCount[zone]++
+	}
+
+	// the zones are ordered, the first zone with the fewest mons is picked
+	bestZone := zones[0]
+	for _, zone := range zones[1:] {
+		if zoneCount[zone] < zoneCount[bestZone] {
+			bestZone = zone
+		}
+	}
+	logger.Infof("zone %q has the fewest mons (%d) among the available zones %v", bestZone, zoneCount[bestZone], zones)
+	return bestZone, nil
+}
+
+// availableMonZones returns the zones that have at least one node ready to run a mon. The zones are the ones listed
+// in the mon spec in the same order, or all the values of the failure domain label on the nodes in alphabetical order.
+func (c *Cluster) availableMonZones() ([]string, error) {
+	nodes, err := c.context.Clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list nodes to find the mon zones")
+	}
+
+	label := c.zoneFailureDomainLabel()
+	placement := cephv1.GetMonPlacement(c.spec.Placement)
+	available := map[string]bool{}
+	for _, node := range nodes.Items {
+		zone, ok := node.Labels[label]
+		if !ok || zone == "" {
+			continue
+		}
+		valid, err := k8sutil.ValidNode(node, placement)
+		if err != nil {
+			logger.Warningf("failed to check if node %q is valid for a mon. %v", node.Name, err)
+			continue
+		}
+		if valid {
+			available[zone] = true
+		}
+	}
+
+	zones := []string{}
+	if len(c.spec.Mon.Zones) > 0 {
+		for _, zone := range c.spec.Mon.Zones {
+			if available[zone.Name] {
+				zones = append(zones, zone.Name)
+			} else {
+				logger.Warningf("no node is available for a mon in zone %q", zone.Name)
+			}
+		}
+		return zones, nil
+	}
+
+	for zone := range available {
+		zones = append(zones, zone)
+	}
+	sort.Strings(zones)
+	return zones, nil
+}
+
+// monZone returns the zone of a mon. The mons that were created before the zones were configured have no zone
+// in the mapping, their zone is the one of the node running their pod.
+func (c *Cluster) monZone(m *monConfig) (string, error) {
+	if m.Zone != "" {
+		return m.Zone, nil
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s,mon=%s", AppName, m.DaemonName)}
+	pods, err := c.context.Clientset.CoreV1().Pods(c.Namespace).List(context.TODO(), listOpts)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list the pods of mon %q", m.DaemonName)
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" {
+			continue
+		}
+		node, err := c.context.Clientset.CoreV1().Nodes().Get(context.TODO(), pod.Spec.NodeName, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to get node %q of mon %q", pod.Spec.NodeName, m.DaemonName)
+		}
+		return node.Labels[c.zoneFailureDomainLabel()], nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,216 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mon
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	clienttest "github.com/rook/rook/pkg/daemon/ceph/client/test"
+	testopk8s "github.com/rook/rook/pkg/operator/k8sutil/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/tevino/abool"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// addZoneNode creates a node in the zone, the node is ready unless it is lost
+func addZoneNode(t *testing.T, clientset kubernetes.Interface, name, zone string, ready bool) {
+	status := v1.ConditionTrue
+	if !ready {
+		status = v1.ConditionFalse
+	}
+	node := &v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{v1.LabelZoneFailureDomainStable: zone, v1.LabelHostname: name},
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: status}},
+			Addresses:  []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "10.0.0.1"}},
+		},
+	}
+	_, err := clientset.CoreV1().Nodes().Create(context.TODO(), node, metav1.CreateOptions{})
+	assert.NoError(t, err)
+}
+
+func setNodeReady(t *testing.T, clientset kubernetes.Interface, name string, ready bool) {
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	node.Status.Conditions[0].Status = v1.ConditionTrue
+	if !ready {
+		node.Status.Conditions[0].Status = v1.ConditionFalse
+	}
+	_, err = clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+}
+
+func TestFindBalancedZone(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	for _, zone := range []string{"a", "b", "c"} {
+		addZoneNode(t, clientset, "node-"+zone+"1", zone, true)
+		addZoneNode(t, clientset, "node-"+zone+"2", zone, true)
+	}
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	c.spec.Mon.Zones = []cephv1.MonZoneSpec{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+
+	// the new mons are spread across the zones
+	mons := []*monConfig{}
+	for i := 0; i < 3; i++ {
+		zone, err := c.findAvailableZone(mons)
+		assert.NoError(t, err)
+		mons = append(mons, &monConfig{DaemonName: fmt.Sprintf("m%d", i), Zone: zone})
+	}
+	assert.Equal(t, "a", mons[0].Zone)
+	assert.Equal(t, "b", mons[1].Zone)
+	assert.Equal(t, "c", mons[2].Zone)
+
+	// node loss: the mon of zone b is failed over to the other node of the zone
+	setNodeReady(t, clientset, "node-b1", false)
+	zone, err := c.findAvailableZone([]*monConfig{mons[0], mons[2]})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", zone)
+
+	// zone loss: the mon of zone b is failed over to another zone
+	setNodeReady(t, clientset, "node-b2", false)
+	zone, err = c.findAvailableZone([]*monConfig{mons[0], mons[2]})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", zone)
+
+	// the mon of the lost zone was failed over to zone a, the next failover of zone a goes to zone c
+	zone, err = c.findAvailableZone([]*monConfig{mons[0], mons[2], {DaemonName: "m3", Zone: "a"}, {DaemonName: "m4", Zone: "c"}, {DaemonName: "m5", Zone: "a"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "c", zone)
+
+	// the zones are discovered from the failure domain label when they are not listed
+	c.spec.Mon.Zones = nil
+	c.spec.Mon.FailureDomainLabel = v1.LabelZoneFailureDomainStable
+	zone, err = c.findAvailableZone([]*monConfig{mons[0]})
+	assert.NoError(t, err)
+	assert.Equal(t, "c", zone)
+
+	// no zone is available
+	c.spec.Mon.FailureDomainLabel = "unknown-label"
+	_, err = c.findAvailableZone(mons)
+	assert.Error(t, err)
+
+	// the zones are not used by default
+	c.spec.Mon.FailureDomainLabel = ""
+	zone, err = c.findAvailableZone(mons)
+	assert.NoError(t, err)
+	assert.Equal(t, "", zone)
+}
+
+func TestMonZone(t *testing.T) {
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	addZoneNode(t, clientset, "node-a1", "a", true)
+	c := New(&clusterd.Context{Clientset: clientset}, "ns", cephv1.ClusterSpec{}, nil, &sync.Mutex{})
+	c.spec.Mon.FailureDomainLabel = v1.LabelZoneFailureDomainStable
+
+	// the zone of the mapping
+	zone, err := c.monZone(&monConfig{DaemonName: "a", Zone: "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", zone)
+
+	// the mon has no pod
+	zone, err = c.monZone(&monConfig{DaemonName: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "", zone)
+
+	// the zone of the node of the mon pod
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon-a-xyz", Namespace: "ns", Labels: map[string]string{"app": AppName, "mon": "a"}},
+		Spec:       v1.PodSpec{NodeName: "node-a1"},
+	}
+	_, err = clientset.CoreV1().Pods("ns").Create(ctx, pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	zone, err = c.monZone(&monConfig{DaemonName: "a"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", zone)
+}
+
+func TestFailoverMonAcrossZones(t *testing.T) {
+	ctx := context.TODO()
+	updateDeploymentAndWait, _ = testopk8s.UpdateDeploymentAndWaitStub()
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command string, outFileArg string, args ...string) (string, error) {
+			return clienttest.MonInQuorumResponse(), nil
+		},
+	}
+	clientset := fake.NewSimpleClientset()
+	for _, zone := range []string{"a", "b", "c"} {
+		addZoneNode(t, clientset, "node-"+zone+"1", zone, true)
+	}
+	configDir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(configDir)
+	context := &clusterd.Context{
+		Clientset:                  clientset,
+		ConfigDir:                  configDir,
+		Executor:                   executor,
+		RequestCancelOrchestration: abool.New(),
+	}
+	ownerInfo := cephclient.NewMinimumOwnerInfoWithOwnerRef()
+	c := New(context, "ns", cephv1.ClusterSpec{}, ownerInfo, &sync.Mutex{})
+	setCommonMonProperties(c, 3, cephv1.MonSpec{Count: 3, AllowMultiplePerNode: true}, "myversion")
+	c.spec.Mon.Zones = []cephv1.MonZoneSpec{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	c.waitForStart = false
+	c.maxMonID = 2
+	for _, name := range []string{"a", "b", "c"} {
+		c.mapping.Schedule[name] = &MonScheduleInfo{Name: "node-" + name + "1", Zone: name}
+	}
+
+	// the scheduler places the canary on the first ready node of the zone of the mon
+	oldWaitForMonitorScheduling := waitForMonitorScheduling
+	defer func() { waitForMonitorScheduling = oldWaitForMonitorScheduling }()
+	waitForMonitorScheduling = func(c *Cluster, d *apps.Deployment) (SchedulingResult, error) {
+		zone := d.Spec.Template.Labels["stretch-zone"]
+		node, err := clientset.CoreV1().Nodes().Get(ctx, "node-"+zone+"1", metav1.GetOptions{})
+		return SchedulingResult{Node: node}, err
+	}
+
+	// zone b is lost, its mon is failed over to zone a
+	setNodeReady(t, clientset, "node-b1", false)
+	err := c.failoverMon("b")
+	assert.NoError(t, err)
+	_, ok := c.ClusterInfo.Monitors["b"]
+	assert.False(t, ok)
+	assert.Equal(t, "a", c.mapping.Schedule["d"].Zone)
+	d, err := clientset.AppsV1().Deployments("ns").Get(ctx, "rook-ceph-mon-d", metav1.GetOptions{})
+	assert.NoError(t, err)
+	terms := d.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	assert.Equal(t, v1.LabelZoneFailureDomainStable, terms[0].MatchExpressions[0].Key)
+	assert.Equal(t, []string{"a"}, terms[0].MatchExpressions[0].Values)
+
+	// zone b is back and the mon of zone c is lost with its node, it is failed over to zone b
+	setNodeReady(t, clientset, "node-b1", true)
+	setNodeReady(t, clientset, "node-c1", false)
+	err = c.failoverMon("c")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", c.mapping.Schedule["e"].Zone)
+}
@@ -26,7 +26,7 @@ import (
 )
 
 var (
-	monitorDaemonList = []string{"mon", "osd", "status"}
+	monitorDaemonList = []string{"mon", "osd", "status", "keyrotation"}
 )
 
 func (c *ClusterController) configureCephMonitoring(cluster *cluster, clusterInfo *cephclient.ClusterInfo) {
@@ -103,6 +103,9 @@ func isMonitoringDisabled(daemon string, clusterSpec *cephv1.ClusterSpec) bool {
 
 	case "status":
 		return clusterSpec.HealthCheck.DaemonHealth.Status.Disabled
+
+	case "keyrotation":
+		return !clusterSpec.Security.KeyManagementService.IsKeyRotationEnabled()
 	}
 
 	return false
@@ -126,5 +129,12 @@ func (c *ClusterController) startMonitoringCheck(cluster *cluster, clusterInfo *
 		cephChecker := newCephStatusChecker(c.context, clusterInfo, cluster.Spec)
 		logger.Infof("enabling ceph %s monitoring goroutine for cluster %q", daemon, cluster.Namespace)
 		go cephChecker.checkCephStatus(cluster.monitoringChannels[daemon].stopChan)
+
+	case "keyrotation":
+		if !cluster.Spec.External.Enable {
+			keyRotation := osd.NewKeyRotationMonitor(c.context, clusterInfo, c.rookImage)
+			logger.Infof("enabling osd encryption key rotation goroutine for cluster %q", cluster.Namespace)
+			go keyRotation.Start(cluster.monitoringChannels[daemon].stopChan)
+		}
 	}
 }
@@ -35,6 +35,8 @@ func TestIsMonitoringDisabled(t *testing.T) {
 	}{
 		{"isDisabled", args{"mon", &cephv1.ClusterSpec{}}, false},
 		{"isEnabled", args{"mon", &cephv1.ClusterSpec{HealthCheck: cephv1.CephClusterHealthCheckSpec{DaemonHealth: cephv1.DaemonHealthSpec{Monitor: cephv1.HealthCheckSpec{Disabled: true}}}}}, true},
+		{"keyRotationDisabled", args{"keyrotation", &cephv1.ClusterSpec{}}, true},
+		{"keyRotationEnabled", args{"keyrotation", &cephv1.ClusterSpec{Security: cephv1.SecuritySpec{KeyManagementService: cephv1.KeyManagementServiceSpec{KeyRotation: &cephv1.KeyRotationSpec{Enabled: true}}}}}, false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,83 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/config"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// validateNetwork checks the network attachment definitions of the multus networks before the daemons are rolled
+// out, and reports the result in the NetworkValidated condition of the CephCluster
+func (c *ClusterController) validateNetwork(cluster *cluster) error {
+	condition := cephv1.Condition{
+		Type:    cephv1.ConditionNetworkValidated,
+		Status:  v1.ConditionTrue,
+		Reason:  cephv1.NetworkValidReason,
+		Message: "The network attachment definitions are valid",
+	}
+	err := config.ValidateMultusNetworks(c.context, cluster.Namespace, cluster.Spec.Network.Selectors)
+	if err != nil {
+		condition.Status = v1.ConditionFalse
+		condition.Reason = cephv1.NetworkInvalidReason
+		condition.Message = err.Error()
+	}
+	c.updateNetworkValidatedCondition(cluster, condition)
+	return errors.Wrap(err, "failed to validate the network attachment definitions")
+}
+
+// updateNetworkValidatedCondition replaces the NetworkValidated condition of the CephCluster without changing the
+// phase of the cluster
+func (c *ClusterController) updateNetworkValidatedCondition(cluster *cluster, condition cephv1.Condition) {
+	cephCluster := &cephv1.CephCluster{}
+	err := c.client.Get(context.TODO(), cluster.namespacedName, cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve ceph cluster %q to update the network validation status. %v", cluster.namespacedName.Name, err)
+		return
+	}
+
+	now := metav1.NewTime(time.Now())
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+	conditions := []cephv1.Condition{}
+	for _, existing := range cephCluster.Status.Conditions {
+		if existing.Type != condition.Type {
+			conditions = append(conditions, existing)
+			continue
+		}
+		if existing.Status == condition.Status {
+			// the status did not change since the last validation
+			condition.LastTransitionTime = existing.LastTransitionTime
+		}
+	}
+	cephCluster.Status.Conditions = append(conditions, condition)
+	if err := opcontroller.UpdateStatus(c.client, cephCluster); err != nil {
+		logger.Errorf("failed to update cluster %q network validation status. %v", cluster.namespacedName.Name, err)
+	}
+}
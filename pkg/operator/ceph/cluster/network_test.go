@@ -0,0 +1,94 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cluster
+
+import (
+	"context"
+	"testing"
+
+	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
+	fakenetclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/fake"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookv1 "github.com/rook/rook/pkg/apis/rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	fakeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestValidateNetwork(t *testing.T) {
+	ctx := context.TODO()
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"},
+		Spec: cephv1.ClusterSpec{
+			Network: cephv1.NetworkSpec{
+				NetworkSpec: rookv1.NetworkSpec{Provider: "multus", Selectors: map[string]string{"public": "public-net"}},
+			},
+		},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fakeclient.NewFakeClientWithScheme(s, []runtime.Object{cephCluster}...)
+	c := &ClusterController{
+		context: &clusterd.Context{
+			NetworkClient: fakenetclient.NewSimpleClientset().K8sCniCncfIoV1(),
+		},
+		client: cl,
+	}
+	clust := &cluster{Namespace: "ns", Spec: &cephCluster.Spec, namespacedName: types.NamespacedName{Namespace: "ns", Name: "test"}}
+
+	networkCondition := func() *cephv1.Condition {
+		updated := &cephv1.CephCluster{}
+		err := cl.Get(ctx, clust.namespacedName, updated)
+		assert.NoError(t, err)
+		for _, condition := range updated.Status.Conditions {
+			if condition.Type == cephv1.ConditionNetworkValidated {
+				return &condition
+			}
+		}
+		return nil
+	}
+
+	// the network attachment definition does not exist
+	err := c.validateNetwork(clust)
+	assert.Error(t, err)
+	condition := networkCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionFalse, condition.Status)
+	assert.Equal(t, cephv1.NetworkInvalidReason, condition.Reason)
+	assert.Contains(t, condition.Message, "public-net")
+
+	// the network is valid once the network attachment definition is created
+	nad := &networkv1.NetworkAttachmentDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "public-net", Namespace: "ns"},
+		Spec: networkv1.NetworkAttachmentDefinitionSpec{
+			Config: `{"cniVersion": "0.3.0", "type": "macvlan", "ipam": {"type": "host-local", "subnet": "192.168.0.0/24"}}`,
+		},
+	}
+	_, err = c.context.NetworkClient.NetworkAttachmentDefinitions("ns").Create(ctx, nad, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	err = c.validateNetwork(clust)
+	assert.NoError(t, err)
+	condition = networkCondition()
+	assert.NotNil(t, condition)
+	assert.Equal(t, v1.ConditionTrue, condition.Status)
+	assert.Equal(t, cephv1.NetworkValidReason, condition.Reason)
+}
@@ -0,0 +1,541 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	blueFSMigrationAppName       = "rook-ceph-osd-bluefs-migration"
+	blueFSMigrationNameFmt       = "rook-ceph-osd-bluefs-migration-%s"
+	blueFSMigrationContainerName = "bluefs-migration"
+	blueFSMigrationTimeout       = 30 * time.Minute
+	blueFSMigrationRetryDelay    = 10 * time.Second
+)
+
+var (
+	// addBlueFSDevices is idempotent so that an interrupted migration can be resumed
+	// The new devices are labeled and linked by ceph-bluestore-tool, then the bluefs data is moved from the main block
+	// to the new metadata device. The links are replaced with a copy of the devices like the OSD deployment does.
+	addBlueFSDevices = `
+set -xe
+
+OSD_PATH=%s
+METADATA_DEV=%s
+WAL_DEV=%s
+
+add_device() {
+	local name=$1 dev=$2 cmd=$3
+	if [ -z "$dev" ]; then
+		return
+	fi
+	rm -f "$OSD_PATH/$name"
+	if ceph-bluestore-tool show-label --dev "$dev"; then
+		echo "$dev was already added to the osd"
+		ln -s "$dev" "$OSD_PATH/$name"
+	else
+		ceph-bluestore-tool "$cmd" --path "$OSD_PATH" --dev-target "$dev"
+	fi
+}
+
+add_device block.db "$METADATA_DEV" bluefs-bdev-new-db
+add_device block.wal "$WAL_DEV" bluefs-bdev-new-wal
+
+if [ -n "$METADATA_DEV" ]; then
+	ceph-bluestore-tool bluefs-bdev-migrate --path "$OSD_PATH" --devs-source "$OSD_PATH/block" --dev-target "$OSD_PATH/block.db"
+fi
+
+for name in block.db block.wal; do
+	if [ -L "$OSD_PATH/$name" ]; then
+		cp --archive --dereference --remove-destination --verbose "$(readlink "$OSD_PATH/$name")" "$OSD_PATH/$name"
+	fi
+done
+`
+
+	// addNodeBlueFSDB is idempotent so that an interrupted migration can be resumed
+	// A logical volume is carved out of the metadata device of the node for the OSD, ceph-volume adds it to the OSD
+	// and tags the logical volumes so that the OSD is activated with its new block.db
+	addNodeBlueFSDB = `
+set -xe
+
+OSD_ID=%s
+OSD_FSID=%s
+METADATA_DEV=%s
+DB_SIZE_MB=%d
+DB_LV=osd-db-$OSD_FSID
+
+export DM_DISABLE_UDEV=1
+
+DB_VG=$(pvs --noheadings -o vg_name "$METADATA_DEV" 2>/dev/null | tr -d ' ' || true)
+if [ -z "$DB_VG" ]; then
+	DB_VG=ceph-db-$(uuidgen)
+	vgcreate "$DB_VG" "$METADATA_DEV"
+fi
+
+if ! lvs "$DB_VG/$DB_LV"; then
+	lvcreate --yes --name "$DB_LV" --size "${DB_SIZE_MB}m" "$DB_VG"
+fi
+
+if ! lvs --noheadings -o lv_tags | grep "ceph.osd_fsid=$OSD_FSID" | grep -q "ceph.db_uuid="; then
+	ceph-volume lvm new-db --osd-id "$OSD_ID" --osd-fsid "$OSD_FSID" --target "$DB_VG/$DB_LV"
+fi
+ceph-volume lvm migrate --osd-id "$OSD_ID" --osd-fsid "$OSD_FSID" --from data --target "$DB_VG/$DB_LV"
+`
+)
+
+var (
+	// blueFSMigrationsRunning are the namespaces of the clusters whose OSDs are migrated in the background
+	blueFSMigrationsRunning = map[string]bool{}
+	blueFSMigrationsMutex   sync.Mutex
+)
+
+// blueFSMigration is an OSD whose device set or node declares a metadata or wal device the OSD was not created with
+type blueFSMigration struct {
+	osdProps   osdProperties
+	deployment *apps.Deployment
+	osds       []OSDInfo
+}
+
+// name is the PVC of the OSD, or the OSD itself when it runs on a device of a node
+func (m blueFSMigration) name() string {
+	if m.osdProps.onPVC() {
+		return m.osdProps.pvc.ClaimName
+	}
+	return fmt.Sprintf("osd-%d", m.osds[0].ID)
+}
+
+// needsBlueFSMigration returns whether a metadata or wal PVC must be added to an existing OSD before its deployment
+// is updated, the OSD would not start with a block.db or block.wal that bluefs does not know about
+func needsBlueFSMigration(d *apps.Deployment, osdProps osdProperties) bool {
+	newMetadata := osdProps.onPVCWithMetadata() && !hasInitContainer(d, blockPVCMetadataMapperInitContainer)
+	newWal := osdProps.onPVCWithWal() && !hasInitContainer(d, blockPVCWalMapperInitContainer)
+	return newMetadata || newWal
+}
+
+// nodeOSDNeedsBlueFSMigration returns whether the metadata device of the node must be added to an existing OSD.
+// The deployment of an OSD on a node does not change with its metadata device, Ceph reports whether the OSD has one.
+func (c *Cluster) nodeOSDNeedsBlueFSMigration(osdProps osdProperties, osd OSDInfo) bool {
+	if osdProps.onPVC() || osdProps.metadataDevice == "" {
+		return false
+	}
+	metadata, err := client.GetOSDMetadata(c.context, c.clusterInfo, osd.ID)
+	if err != nil {
+		logger.Debugf("failed to get the metadata of osd %d, not checking its metadata device. %v", osd.ID, err)
+		return false
+	}
+	return metadata.BlueFSDedicatedDB == "0"
+}
+
+// blueFSMigrationSkipReason returns why the new devices cannot be added to the OSD, or an empty string if they can
+func (c *Cluster) blueFSMigrationSkipReason(m blueFSMigration) string {
+	if m.osdProps.onPVC() {
+		// The OSDs in lvm mode are activated by ceph-volume and the new devices of an encrypted OSD would have to be
+		// encrypted first
+		if m.osdProps.encrypted {
+			return "encrypted osds on pvc are not supported"
+		}
+		for _, osd := range m.osds {
+			if osd.CVMode != "raw" {
+				return "osds on pvc in lvm mode are not supported"
+			}
+		}
+		return ""
+	}
+
+	// The metadata device of a node is split in logical volumes by ceph-volume, the OSDs in raw mode have no tags
+	// ceph-volume could record their new block.db in
+	if !c.clusterInfo.CephVersion.IsAtLeastPacific() {
+		return "ceph-volume can only add a metadata device to an existing osd since pacific"
+	}
+	if m.osdProps.storeConfig.DatabaseSizeMB == 0 {
+		return "the databaseSizeMB of the node must be set to add a metadata device to an existing osd"
+	}
+	if m.osdProps.storeConfig.EncryptedDevice || m.osdProps.storeConfig.Encrypted {
+		return "encrypted osds on nodes are not supported"
+	}
+	for _, osd := range m.osds {
+		if osd.CVMode == "raw" {
+			return "osds on nodes in raw mode are not supported"
+		}
+		if osd.EncryptedDeviceUUID != "" {
+			return "encrypted osds on nodes are not supported"
+		}
+	}
+	return ""
+}
+
+// withoutNewBlueFSDevices removes the metadata and wal PVCs the OSD deployment does not have yet from the OSD
+// properties, the OSD keeps running with its current layout until the new devices are added to it
+func withoutNewBlueFSDevices(d *apps.Deployment, osdProps osdProperties) osdProperties {
+	if osdProps.onPVCWithMetadata() && !hasInitContainer(d, blockPVCMetadataMapperInitContainer) {
+		osdProps.metadataPVC = v1.PersistentVolumeClaimVolumeSource{}
+	}
+	if osdProps.onPVCWithWal() && !hasInitContainer(d, blockPVCWalMapperInitContainer) {
+		osdProps.walPVC = v1.PersistentVolumeClaimVolumeSource{}
+	}
+	return osdProps
+}
+
+func hasInitContainer(d *apps.Deployment, name string) bool {
+	for _, container := range d.Spec.Template.Spec.InitContainers {
+		if container.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// groupBlueFSMigrations groups the migrations by CRUSH host, the smallest failure domain of the pools.
+// The failure domains are returned in the order they are migrated.
+func groupBlueFSMigrations(migrations []blueFSMigration) ([]string, map[string][]blueFSMigration) {
+	groups := map[string][]blueFSMigration{}
+	for _, m := range migrations {
+		labels := m.deployment.Spec.Template.Labels
+		failureDomain := labels[fmt.Sprintf(TopologyLocationLabel, "host")]
+		if failureDomain == "" {
+			failureDomain = labels[FailureDomainKey]
+		}
+		groups[failureDomain] = append(groups[failureDomain], m)
+	}
+
+	failureDomains := []string{}
+	for failureDomain := range groups {
+		failureDomains = append(failureDomains, failureDomain)
+	}
+	sort.Strings(failureDomains)
+
+	return failureDomains, groups
+}
+
+// startBlueFSMigrations adds the new metadata and wal devices to the OSDs in the background, the migration of an
+// OSD takes as long as moving its RocksDB. The migrations left over are started again by the next orchestration.
+func (c *Cluster) startBlueFSMigrations(migrations []blueFSMigration) {
+	blueFSMigrationsMutex.Lock()
+	defer blueFSMigrationsMutex.Unlock()
+	if blueFSMigrationsRunning[c.clusterInfo.Namespace] {
+		logger.Infof("adding metadata and wal devices to the osds is still in progress")
+		return
+	}
+	blueFSMigrationsRunning[c.clusterInfo.Namespace] = true
+
+	go func() {
+		c.migrateBlueFS(migrations)
+
+		blueFSMigrationsMutex.Lock()
+		defer blueFSMigrationsMutex.Unlock()
+		delete(blueFSMigrationsRunning, c.clusterInfo.Namespace)
+	}()
+}
+
+// migrateBlueFS adds the new metadata and wal devices to the OSDs one failure domain at a time. Before moving on to
+// a failure domain, the PGs must be clean again so that the data stays available while the OSDs are stopped.
+func (c *Cluster) migrateBlueFS(migrations []blueFSMigration) {
+	retries := int(c.clusterInfo.OsdUpgradeTimeout / blueFSMigrationRetryDelay)
+	failureDomains, groups := groupBlueFSMigrations(migrations)
+	for _, failureDomain := range failureDomains {
+		if err := controller.CheckForCancelledOrchestration(c.context); err != nil {
+			logger.Infof("stopping to add metadata and wal devices to the osds. %v", err)
+			return
+		}
+
+		err := util.Retry(retries, blueFSMigrationRetryDelay, func() error {
+			return client.IsClusterCleanError(c.context, c.clusterInfo)
+		})
+		if err != nil {
+			logger.Errorf("failed to add metadata and wal devices to the osds of failure domain %q, the pgs are not clean. %v", failureDomain, err)
+			return
+		}
+
+		logger.Infof("adding metadata and wal devices to the osds of failure domain %q", failureDomain)
+		for _, m := range groups[failureDomain] {
+			if err := c.migrateOSDBlueFS(m); err != nil {
+				logger.Errorf("failed to add metadata and wal devices to %q. %v", m.name(), err)
+				return
+			}
+		}
+	}
+}
+
+// migrateOSDBlueFS stops the OSD, runs the migration job and starts the OSD again with its new devices.
+// If the job fails the OSD stays stopped, the job is run again by the next orchestration. The OSDs stopped for the
+// maintenance of their node are migrated by the first orchestration after the maintenance.
+func (c *Cluster) migrateOSDBlueFS(m blueFSMigration) error {
+	job, err := c.makeBlueFSMigrationJob(m)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate bluefs migration job")
+	}
+
+	maintenance, err := c.osdMaintenance(m.deployment.Name)
+	if err != nil {
+		return err
+	}
+	if maintenance != "" {
+		logger.Infof("osd %s of %q is stopped by node maintenance %q, adding its metadata and wal devices after the maintenance", m.deployment.Labels[OsdIdLabelKey], m.name(), maintenance)
+		return nil
+	}
+
+	logger.Infof("stopping osd %s of %q to add its metadata and wal devices", m.deployment.Labels[OsdIdLabelKey], m.name())
+	if err := c.stopOSDDeployment(m.deployment.Name, m.deployment.Labels[OsdIdLabelKey]); err != nil {
+		return err
+	}
+
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
+		return errors.Wrapf(err, "failed to run bluefs migration job %q", job.Name)
+	}
+	if err := k8sutil.WaitForJobCompletion(c.context.Clientset, job, blueFSMigrationTimeout); err != nil {
+		return errors.Wrapf(err, "failed to wait for bluefs migration job %q", job.Name)
+	}
+	if err := k8sutil.DeleteBatchJob(c.context.Clientset, c.clusterInfo.Namespace, job.Name, false); err != nil {
+		logger.Warningf("failed to delete bluefs migration job %q. %v", job.Name, err)
+	}
+
+	// The deployment is updated with the new devices and scaled up again, unless a maintenance of the node started
+	// in the meantime. The OSD then starts with its new devices when the maintenance ends.
+	maintenance, err = c.osdMaintenance(m.deployment.Name)
+	if err != nil {
+		return err
+	}
+	config := c.newProvisionConfig()
+	for _, osd := range m.osds {
+		dp, err := c.makeDeployment(m.osdProps, osd, config)
+		if err != nil {
+			return errors.Wrapf(err, "failed to generate deployment of osd %d", osd.ID)
+		}
+		if maintenance != "" {
+			logger.Infof("not starting osd %d stopped by node maintenance %q", osd.ID, maintenance)
+			replicas := int32(0)
+			dp.Spec.Replicas = &replicas
+			dp.Labels[MaintenanceLabelKey] = maintenance
+			if _, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Update(context.TODO(), dp, metav1.UpdateOptions{}); err != nil {
+				return errors.Wrapf(err, "failed to update osd deployment %q", dp.Name)
+			}
+			continue
+		}
+		if err := updateDeploymentAndWait(c.context, c.clusterInfo, dp, opconfig.OsdType, strconv.Itoa(osd.ID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+			return errors.Wrapf(err, "failed to start osd %d", osd.ID)
+		}
+	}
+	logger.Infof("added metadata and wal devices to osd %s of %q", m.deployment.Labels[OsdIdLabelKey], m.name())
+
+	return nil
+}
+
+// stopOSDDeployment scales down the OSD deployment and waits for its pod to be gone
+func (c *Cluster) stopOSDDeployment(name, osdID string) error {
+	ctx := context.TODO()
+	d, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get osd deployment %q", name)
+	}
+	replicas := int32(0)
+	d.Spec.Replicas = &replicas
+	if _, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrapf(err, "failed to scale down osd deployment %q", d.Name)
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", OsdIdLabelKey, osdID)}
+	retries := int(blueFSMigrationTimeout / blueFSMigrationRetryDelay)
+	err = util.Retry(retries, blueFSMigrationRetryDelay, func() error {
+		pods, err := c.context.Clientset.CoreV1().Pods(c.clusterInfo.Namespace).List(ctx, listOpts)
+		if err != nil {
+			return err
+		}
+		if len(pods.Items) != 0 {
+			return errors.Errorf("%d pods of osd deployment %q are still running", len(pods.Items), d.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to wait for osd deployment %q to stop", d.Name)
+	}
+
+	return nil
+}
+
+// makeBlueFSMigrationJob builds a job activating the OSD like its deployment does and adding the new devices to it
+func (c *Cluster) makeBlueFSMigrationJob(m blueFSMigration) (*batch.Job, error) {
+	podSpec := m.deployment.Spec.Template.Spec
+	osdID, err := strconv.Atoi(m.deployment.Labels[OsdIdLabelKey])
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse the id of osd deployment %q", m.deployment.Name)
+	}
+
+	var initContainers []v1.Container
+	var migrationContainer v1.Container
+	var newVolumes []v1.Volume
+	if m.osdProps.onPVC() {
+		initContainers, migrationContainer, newVolumes, err = c.pvcBlueFSMigrationContainers(m, osdID)
+	} else {
+		migrationContainer, err = c.nodeBlueFSMigrationContainer(m)
+	}
+	if err != nil {
+		return nil, err
+	}
+	volumes := append(volumesForContainers(podSpec.Volumes, append(initContainers, migrationContainer)), newVolumes...)
+
+	labels := map[string]string{
+		k8sutil.AppAttr:     blueFSMigrationAppName,
+		k8sutil.ClusterAttr: c.clusterInfo.Namespace,
+	}
+	if m.osdProps.onPVC() {
+		labels[OSDOverPVCLabelKey] = m.osdProps.pvc.ClaimName
+	}
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k8sutil.TruncateNodeName(blueFSMigrationNameFmt, m.name()),
+			Namespace: c.clusterInfo.Namespace,
+			Labels:    labels,
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: v1.PodSpec{
+					InitContainers:     initContainers,
+					Containers:         []v1.Container{migrationContainer},
+					Volumes:            volumes,
+					RestartPolicy:      v1.RestartPolicyOnFailure,
+					ServiceAccountName: podSpec.ServiceAccountName,
+					NodeSelector:       podSpec.NodeSelector,
+					Affinity:           podSpec.Affinity,
+					Tolerations:        podSpec.Tolerations,
+					SchedulerName:      podSpec.SchedulerName,
+					PriorityClassName:  podSpec.PriorityClassName,
+					HostNetwork:        podSpec.HostNetwork,
+				},
+			},
+		},
+	}
+
+	k8sutil.AddRookVersionLabelToJob(job)
+	err = c.clusterInfo.OwnerInfo.SetControllerReference(job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// pvcBlueFSMigrationContainers copies the block of the OSD on PVC and primes the OSD dir like the OSD deployment does,
+// the new metadata and wal PVCs are mounted in the migration container
+func (c *Cluster) pvcBlueFSMigrationContainers(m blueFSMigration, osdID int) ([]v1.Container, v1.Container, []v1.Volume, error) {
+	podSpec := m.deployment.Spec.Template.Spec
+	osdDataPath := activateOSDMountPath + strconv.Itoa(osdID)
+	pvcName := m.osdProps.pvc.ClaimName
+
+	// Only the block copy and the osd dir priming are needed before the new devices are added
+	initContainers := []v1.Container{}
+	for _, container := range podSpec.InitContainers {
+		if container.Name == blockPVCMapperInitContainer || container.Name == activatePVCOSDInitContainer {
+			initContainers = append(initContainers, *container.DeepCopy())
+		}
+	}
+	if len(initContainers) != 2 {
+		return nil, v1.Container{}, nil, errors.Errorf("osd deployment %q is not an osd on pvc in raw mode", m.deployment.Name)
+	}
+
+	// The block devices are not mounted, their volumes are added with the ones of the new devices
+	newVolumes := []v1.Volume{{
+		Name:         pvcName,
+		VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &m.osdProps.pvc},
+	}}
+	volumeDevices := []v1.VolumeDevice{}
+	var metadataDevice, walDevice string
+	if m.osdProps.onPVCWithMetadata() && !hasInitContainer(m.deployment, blockPVCMetadataMapperInitContainer) {
+		metadataDevice = fmt.Sprintf("/%s", m.osdProps.metadataPVC.ClaimName)
+		volumeDevices = append(volumeDevices, v1.VolumeDevice{Name: m.osdProps.metadataPVC.ClaimName, DevicePath: metadataDevice})
+		newVolumes = append(newVolumes, v1.Volume{
+			Name:         m.osdProps.metadataPVC.ClaimName,
+			VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &m.osdProps.metadataPVC},
+		})
+	}
+	if m.osdProps.onPVCWithWal() && !hasInitContainer(m.deployment, blockPVCWalMapperInitContainer) {
+		walDevice = fmt.Sprintf("/%s", m.osdProps.walPVC.ClaimName)
+		volumeDevices = append(volumeDevices, v1.VolumeDevice{Name: m.osdProps.walPVC.ClaimName, DevicePath: walDevice})
+		newVolumes = append(newVolumes, v1.Volume{
+			Name:         m.osdProps.walPVC.ClaimName,
+			VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &m.osdProps.walPVC},
+		})
+	}
+
+	migrationContainer := v1.Container{
+		Name:  blueFSMigrationContainerName,
+		Image: c.spec.CephVersion.Image,
+		Command: []string{
+			"/bin/bash",
+			"-c",
+			fmt.Sprintf(addBlueFSDevices, osdDataPath, metadataDevice, walDevice),
+		},
+		VolumeDevices:   volumeDevices,
+		VolumeMounts:    []v1.VolumeMount{getPvcOSDBridgeMountActivate(osdDataPath, pvcName)},
+		SecurityContext: PrivilegedContext(),
+		Resources:       m.osdProps.resources,
+	}
+
+	return initContainers, migrationContainer, newVolumes, nil
+}
+
+// nodeBlueFSMigrationContainer runs ceph-volume with the devices of the node and the config of the OSD, like the
+// activate init container of the OSD deployment
+func (c *Cluster) nodeBlueFSMigrationContainer(m blueFSMigration) (v1.Container, error) {
+	var activateContainer *v1.Container
+	for i, container := range m.deployment.Spec.Template.Spec.InitContainers {
+		if container.Name == activatePVCOSDInitContainer {
+			activateContainer = m.deployment.Spec.Template.Spec.InitContainers[i].DeepCopy()
+		}
+	}
+	if activateContainer == nil {
+		return v1.Container{}, errors.Errorf("osd deployment %q is not an osd on a node", m.deployment.Name)
+	}
+
+	osd := m.osds[0]
+	metadataDevice := m.osdProps.metadataDevice
+	if !strings.HasPrefix(metadataDevice, "/dev") {
+		metadataDevice = path.Join("/dev", metadataDevice)
+	}
+
+	activateContainer.Name = blueFSMigrationContainerName
+	activateContainer.Command = []string{
+		"/bin/bash",
+		"-c",
+		fmt.Sprintf(addNodeBlueFSDB, strconv.Itoa(osd.ID), osd.UUID, metadataDevice, m.osdProps.storeConfig.DatabaseSizeMB),
+	}
+	activateContainer.Args = nil
+
+	return *activateContainer, nil
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestBlueFSMigration(t *testing.T) {
+	clusterInfo := &cephclient.ClusterInfo{
+		Namespace:   "ns",
+		CephVersion: cephver.Nautilus,
+	}
+	clusterInfo.SetName("test")
+	clusterInfo.OwnerInfo = cephclient.NewMinimumOwnerInfo(t)
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}
+	spec := cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v15"}}
+	c := New(context, clusterInfo, spec, "rook/rook:myversion")
+	config := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.clusterInfo.Namespace, "/var/lib/rook"),
+	}
+
+	// The osd was created without metadata device
+	osdProps := osdProperties{
+		crushHostname: "data-0",
+		pvc:           v1.PersistentVolumeClaimVolumeSource{ClaimName: "data-0"},
+	}
+	osds := []OSDInfo{{ID: 0, CVMode: "raw", Location: "root=default host=data-0"}}
+	d, err := c.makeDeployment(osdProps, osds[0], config)
+	assert.NoError(t, err)
+	assert.False(t, needsBlueFSMigration(d, osdProps))
+
+	// A metadata device is declared in the device set
+	osdProps.metadataPVC = v1.PersistentVolumeClaimVolumeSource{ClaimName: "metadata-0"}
+	assert.True(t, needsBlueFSMigration(d, osdProps))
+	m := blueFSMigration{osdProps: osdProps, deployment: d, osds: osds}
+	assert.Equal(t, "", c.blueFSMigrationSkipReason(m))
+
+	// The osd keeps running without the metadata device until it is added
+	assert.Equal(t, "", withoutNewBlueFSDevices(d, osdProps).metadataPVC.ClaimName)
+
+	job, err := c.makeBlueFSMigrationJob(m)
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-osd-bluefs-migration-data-0", job.Name)
+	assert.Equal(t, "data-0", job.Labels[OSDOverPVCLabelKey])
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, 2, len(podSpec.InitContainers))
+	assert.Equal(t, blockPVCMapperInitContainer, podSpec.InitContainers[0].Name)
+	assert.Equal(t, activatePVCOSDInitContainer, podSpec.InitContainers[1].Name)
+	container := podSpec.Containers[0]
+	assert.Contains(t, container.Command[2], "OSD_PATH=/var/lib/ceph/osd/ceph-0\nMETADATA_DEV=/metadata-0\nWAL_DEV=\n")
+	assert.Equal(t, []v1.VolumeDevice{{Name: "metadata-0", DevicePath: "/metadata-0"}}, container.VolumeDevices)
+	volumes := map[string]bool{}
+	for _, volume := range podSpec.Volumes {
+		volumes[volume.Name] = true
+	}
+	assert.True(t, volumes["data-0"])
+	assert.True(t, volumes["data-0-bridge"])
+	assert.True(t, volumes["metadata-0"])
+
+	// The osd already runs with its metadata device
+	d, err = c.makeDeployment(osdProps, osds[0], config)
+	assert.NoError(t, err)
+	assert.False(t, needsBlueFSMigration(d, osdProps))
+	assert.Equal(t, "metadata-0", withoutNewBlueFSDevices(d, osdProps).metadataPVC.ClaimName)
+
+	// Encrypted osds and osds in lvm mode are not migrated
+	m.osdProps.encrypted = true
+	assert.Equal(t, "encrypted osds on pvc are not supported", c.blueFSMigrationSkipReason(m))
+	m.osdProps.encrypted = false
+	m.osds = []OSDInfo{{ID: 0, CVMode: "lvm"}}
+	assert.Equal(t, "osds on pvc in lvm mode are not supported", c.blueFSMigrationSkipReason(m))
+}
+
+func TestNodeBlueFSMigration(t *testing.T) {
+	clusterInfo := &cephclient.ClusterInfo{
+		Namespace:   "ns",
+		CephVersion: cephver.Pacific,
+	}
+	clusterInfo.SetName("test")
+	clusterInfo.OwnerInfo = cephclient.NewMinimumOwnerInfo(t)
+	executor := &exectest.MockExecutor{}
+	executor.MockExecuteCommandWithOutputFile = func(command, outputFile string, args ...string) (string, error) {
+		if args[0] == "osd" && args[1] == "metadata" {
+			if args[2] == "0" {
+				return `{"id":0,"bluefs_dedicated_db":"0"}`, nil
+			}
+			return `{"id":1,"bluefs_dedicated_db":"1"}`, nil
+		}
+		return "", errors.Errorf("unexpected ceph command %q", args)
+	}
+	context := &clusterd.Context{Clientset: fake.NewSimpleClientset(), ConfigDir: "/var/lib/rook", Executor: executor}
+	spec := cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v16"}}
+	c := New(context, clusterInfo, spec, "rook/rook:myversion")
+	config := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.clusterInfo.Namespace, "/var/lib/rook"),
+	}
+
+	osdProps := osdProperties{
+		crushHostname: "node1",
+		storeConfig:   osdconfig.StoreConfig{DatabaseSizeMB: 20480},
+	}
+	osd := OSDInfo{ID: 0, UUID: "osd-uuid", CVMode: "lvm", BlockPath: "/dev/ceph-vg/osd-block", Location: "root=default host=node1"}
+
+	// No metadata device is declared on the node
+	assert.False(t, c.nodeOSDNeedsBlueFSMigration(osdProps, osd))
+
+	// osd.0 has no metadata device yet, osd.1 already has one
+	osdProps.metadataDevice = "nvme0n1"
+	assert.True(t, c.nodeOSDNeedsBlueFSMigration(osdProps, osd))
+	assert.False(t, c.nodeOSDNeedsBlueFSMigration(osdProps, OSDInfo{ID: 1, CVMode: "lvm"}))
+
+	d, err := c.makeDeployment(osdProps, osd, config)
+	assert.NoError(t, err)
+	m := blueFSMigration{osdProps: osdProps, deployment: d, osds: []OSDInfo{osd}}
+	assert.Equal(t, "", c.blueFSMigrationSkipReason(m))
+
+	job, err := c.makeBlueFSMigrationJob(m)
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-osd-bluefs-migration-osd-0", job.Name)
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, 0, len(podSpec.InitContainers))
+	container := podSpec.Containers[0]
+	assert.Equal(t, blueFSMigrationContainerName, container.Name)
+	assert.Contains(t, container.Command[2], "OSD_ID=0\nOSD_FSID=osd-uuid\nMETADATA_DEV=/dev/nvme0n1\nDB_SIZE_MB=20480\n")
+	volumes := map[string]bool{}
+	for _, volume := range podSpec.Volumes {
+		volumes[volume.Name] = true
+	}
+	assert.True(t, volumes["devices"])
+
+	// The osds in raw mode, the encrypted osds and the osds without a database size are not migrated
+	m.osds = []OSDInfo{{ID: 0, CVMode: "raw"}}
+	assert.Equal(t, "osds on nodes in raw mode are not supported", c.blueFSMigrationSkipReason(m))
+	m.osds = []OSDInfo{osd}
+	m.osdProps.storeConfig.Encrypted = true
+	assert.Equal(t, "encrypted osds on nodes are not supported", c.blueFSMigrationSkipReason(m))
+	m.osdProps.storeConfig = osdconfig.StoreConfig{}
+	assert.Equal(t, "the databaseSizeMB of the node must be set to add a metadata device to an existing osd", c.blueFSMigrationSkipReason(m))
+}
+
+func TestGroupBlueFSMigrations(t *testing.T) {
+	migration := func(host, failureDomain string) blueFSMigration {
+		labels := map[string]string{FailureDomainKey: failureDomain}
+		if host != "" {
+			labels["topology-location-host"] = host
+		}
+		d := &apps.Deployment{Spec: apps.DeploymentSpec{Template: v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}}}}
+		return blueFSMigration{deployment: d}
+	}
+
+	failureDomains, groups := groupBlueFSMigrations([]blueFSMigration{
+		migration("node-b", "set1-data-0"),
+		migration("node-a", "set1-data-1"),
+		migration("node-b", "set1-data-2"),
+		migration("", "set1-data-3"),
+	})
+	assert.Equal(t, []string{"node-a", "node-b", "set1-data-3"}, failureDomains)
+	assert.Equal(t, 1, len(groups["node-a"]))
+	assert.Equal(t, 2, len(groups["node-b"]))
+	assert.Equal(t, 1, len(groups["set1-data-3"]))
+}
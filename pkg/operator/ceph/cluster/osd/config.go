@@ -25,11 +25,14 @@ import (
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mgr"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	"github.com/rook/rook/pkg/operator/k8sutil"
 	v1 "k8s.io/api/core/v1"
 )
 
 const (
 	dmCryptKeySize = 128
+	// the key of the encrypted OSDs on the devices of a node is named after the node
+	hostEncryptionKeyNameFmt = "host-%s"
 )
 
 // PrivilegedContext returns a privileged Pod security context
@@ -64,6 +67,21 @@ func encryptionDMPath(pvcName, blockType string) string {
 	return path.Join("/dev/mapper", encryptionDMName(pvcName, blockType))
 }
 
+// HostEncryptionKeyName returns the name of the key used to encrypt the OSDs on the devices of a node
+func HostEncryptionKeyName(nodeName string) string {
+	return k8sutil.TruncateNodeName(hostEncryptionKeyNameFmt, nodeName)
+}
+
+// HostEncryptionDMName returns the device mapper name of an encrypted device of a node from its LUKS UUID
+func HostEncryptionDMName(luksUUID string) string {
+	return encryptionDMName(luksUUID, DmcryptBlockType)
+}
+
+// hostEncryptedDevicePath returns the path of the underlying encrypted device from its LUKS UUID
+func hostEncryptedDevicePath(luksUUID string) string {
+	return path.Join("/dev/disk/by-uuid", luksUUID)
+}
+
 func encryptionBlockDestinationCopy(mountPath, blockType string) string {
 	return path.Join(mountPath, blockType) + "-tmp"
 }
@@ -50,6 +50,8 @@ type StoreConfig struct {
 	EncryptedDevice bool   `json:"encryptedDevice,omitempty"`
 	MetadataDevice  string `json:"metadataDevice,omitempty"`
 	DeviceClass     string `json:"deviceClass,omitempty"`
+	// Encrypted is set from the storage selection, unlike EncryptedDevice the key is held by the KMS of the cluster
+	Encrypted bool `json:"encrypted,omitempty"`
 }
 
 // NewStoreConfig returns a StoreConfig with proper defaults set.
@@ -58,6 +58,14 @@ func TestEncryptionDMName(t *testing.T) {
 	assert.Equal(t, "set1-data-0-6rqdn-block-dmcrypt", encryptionDMName("set1-data-0-6rqdn", DmcryptBlockType))
 }
 
+func TestHostEncryptionNames(t *testing.T) {
+	assert.Equal(t, "host-node1", HostEncryptionKeyName("node1"))
+	// The name is hashed when too long for a label
+	assert.True(t, len(HostEncryptionKeyName("a-very-long-node-name-that-does-not-fit-in-a-kubernetes-label-value")) <= 63)
+	assert.Equal(t, "43e9efed-0676-4731-b75a-a4c42ece1bb1-block-dmcrypt", HostEncryptionDMName("43e9efed-0676-4731-b75a-a4c42ece1bb1"))
+	assert.Equal(t, "/dev/disk/by-uuid/43e9efed-0676-4731-b75a-a4c42ece1bb1", hostEncryptedDevicePath("43e9efed-0676-4731-b75a-a4c42ece1bb1"))
+}
+
 func TestClusterIsCephVolumeRAwModeSupported(t *testing.T) {
 	type fields struct {
 		context      *clusterd.Context
@@ -33,7 +33,11 @@ const (
 	osdsPerDeviceEnvVarName   = "ROOK_OSDS_PER_DEVICE"
 	// EncryptedDeviceEnvVarName is used in the pod spec to indicate whether the OSD is encrypted or not
 	EncryptedDeviceEnvVarName = "ROOK_ENCRYPTED_DEVICE"
-	PVCNameEnvVarName         = "ROOK_PVC_NAME"
+	// EncryptedEnvVarName indicates whether Rook encrypts the OSDs on the devices of a node with a key held by the KMS
+	EncryptedEnvVarName = "ROOK_ENCRYPTED"
+	PVCNameEnvVarName   = "ROOK_PVC_NAME"
+	// KeyNameEnvVarName is the name of the encryption key of the OSDs on the devices of a node
+	KeyNameEnvVarName = "ROOK_KEY_NAME"
 	// CephVolumeEncryptedKeyEnvVarName is the env variable used by ceph-volume to encrypt the OSD (raw mode)
 	// Hardcoded in ceph-volume do NOT touch
 	CephVolumeEncryptedKeyEnvVarName = "CEPH_VOLUME_DMCRYPT_SECRET"
@@ -42,6 +46,10 @@ const (
 	// PVCBackedOSDVarName indicates whether the OSD is on PVC ("true") or not ("false")
 	PVCBackedOSDVarName                 = "ROOK_PVC_BACKED_OSD"
 	blockPathVarName                    = "ROOK_BLOCK_PATH"
+	encryptedDeviceUUIDVarName          = "ROOK_ENCRYPTED_DEVICE_UUID"
+	deviceSerialVarName                 = "ROOK_DEVICE_SERIAL"
+	devicePathVarName                   = "ROOK_DEVICE_PATH"
+	replaceOSDsVarName                  = "ROOK_REPLACE_OSDS"
 	cvModeVarName                       = "ROOK_CV_MODE"
 	lvBackedPVVarName                   = "ROOK_LV_BACKED_PV"
 	CrushDeviceClassVarName             = "ROOK_OSD_CRUSH_DEVICE_CLASS"
@@ -104,6 +112,10 @@ func (c *Cluster) getConfigEnvVars(osdProps osdProperties, dataDir string) []v1.
 		envVars = append(envVars, v1.EnvVar{Name: EncryptedDeviceEnvVarName, Value: "true"})
 	}
 
+	if osdProps.storeConfig.Encrypted {
+		envVars = append(envVars, v1.EnvVar{Name: EncryptedEnvVarName, Value: "true"})
+	}
+
 	return envVars
 }
 
@@ -162,13 +174,37 @@ func pvcNameEnvVar(pvcName string) v1.EnvVar {
 	return v1.EnvVar{Name: PVCNameEnvVarName, Value: pvcName}
 }
 
+// encryptionKeyNameEnvVar tells the rook binary which key to fetch from the KMS
+func encryptionKeyNameEnvVar(osdProps osdProperties) v1.EnvVar {
+	if osdProps.onPVC() {
+		return pvcNameEnvVar(osdProps.pvc.ClaimName)
+	}
+	return v1.EnvVar{Name: KeyNameEnvVarName, Value: osdProps.encryptionKeyName()}
+}
+
+func encryptedDeviceUUIDEnvVar(luksUUID string) v1.EnvVar {
+	return v1.EnvVar{Name: encryptedDeviceUUIDVarName, Value: luksUUID}
+}
+
+func deviceSerialEnvVar(serial string) v1.EnvVar {
+	return v1.EnvVar{Name: deviceSerialVarName, Value: serial}
+}
+
+func devicePathEnvVar(devicePath string) v1.EnvVar {
+	return v1.EnvVar{Name: devicePathVarName, Value: devicePath}
+}
+
+func replaceOSDsEnvVar(replaceOSDs string) v1.EnvVar {
+	return v1.EnvVar{Name: replaceOSDsVarName, Value: replaceOSDs}
+}
+
 func cephVolumeRawEncryptedEnvVarFromSecret(osdProps osdProperties) v1.EnvVar {
 	return v1.EnvVar{
 		Name: CephVolumeEncryptedKeyEnvVarName,
 		ValueFrom: &v1.EnvVarSource{
 			SecretKeyRef: &v1.SecretKeySelector{
 				LocalObjectReference: v1.LocalObjectReference{
-					Name: kms.GenerateOSDEncryptionSecretName(osdProps.pvc.ClaimName),
+					Name: kms.GenerateOSDEncryptionSecretName(osdProps.encryptionKeyName()),
 				},
 				Key: kms.OsdEncryptionSecretNameKeyName,
 			},
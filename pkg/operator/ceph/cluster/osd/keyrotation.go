@@ -0,0 +1,494 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	keyRotationAppName       = "rook-ceph-osd-key-rotation"
+	keyRotationNameFmt       = "rook-ceph-osd-key-rotation-%s"
+	keyRotationContainerName = "key-rotation"
+	keyRotationVolumeName    = "osd-new-encryption-key"
+	keyRotationKeyDir        = "/etc/rook-key-rotation"
+	keyRotationTimeout       = 15 * time.Minute
+	keyRotationPhaseAdd      = "add"
+	keyRotationPhaseRemove   = "remove"
+)
+
+var (
+	defaultKeyRotationCheckInterval = 1 * time.Hour
+
+	// rotateEncryptionKey is idempotent so that an interrupted rotation can be resumed, the block is opened with
+	// either the current or the new key. In the add phase the new key is added next to the current one. The remove
+	// phase runs once the new key is stored in the KMS, the key slots other than the one of the new key are removed.
+	rotateEncryptionKey = `
+set -e
+
+ROTATION_PHASE=%s
+KEY_FILE_PATH=%s
+NEW_KEY_FILE_PATH=%s
+
+for BLOCK_PATH in %s; do
+	if [ ! -b "$BLOCK_PATH" ]; then
+		continue
+	fi
+
+	if [ "$ROTATION_PHASE" = "add" ]; then
+		if cryptsetup luksOpen --test-passphrase --key-file "$NEW_KEY_FILE_PATH" "$BLOCK_PATH"; then
+			echo "New key already added to $BLOCK_PATH"
+		else
+			echo "Adding new key to $BLOCK_PATH"
+			cryptsetup luksAddKey --key-file "$KEY_FILE_PATH" "$BLOCK_PATH" "$NEW_KEY_FILE_PATH"
+		fi
+		continue
+	fi
+
+	NEW_SLOT=$(cryptsetup luksOpen --test-passphrase --verbose --key-file "$NEW_KEY_FILE_PATH" "$BLOCK_PATH" | sed -n 's/^Key slot \([0-9]*\) unlocked.*/\1/p')
+	if [ -z "$NEW_SLOT" ]; then
+		echo "The new key does not open $BLOCK_PATH, not removing any key"
+		exit 1
+	fi
+	for SLOT in $(cryptsetup luksDump "$BLOCK_PATH" | sed -n -e 's/^Key Slot \([0-9]*\): ENABLED$/\1/p' -e 's/^  \([0-9]*\): luks2$/\1/p'); do
+		if [ "$SLOT" != "$NEW_SLOT" ]; then
+			echo "Removing previous key slot $SLOT from $BLOCK_PATH"
+			cryptsetup luksKillSlot --batch-mode --key-file "$NEW_KEY_FILE_PATH" "$BLOCK_PATH" "$SLOT"
+		fi
+	done
+done
+`
+)
+
+// KeyRotationMonitor rotates the encryption keys of the OSDs on PVC on schedule
+type KeyRotationMonitor struct {
+	context     *clusterd.Context
+	clusterInfo *client.ClusterInfo
+	rookVersion string
+	interval    time.Duration
+}
+
+// NewKeyRotationMonitor instantiates the OSD encryption key rotation
+func NewKeyRotationMonitor(context *clusterd.Context, clusterInfo *client.ClusterInfo, rookVersion string) *KeyRotationMonitor {
+	return &KeyRotationMonitor{
+		context:     context,
+		clusterInfo: clusterInfo,
+		rookVersion: rookVersion,
+		interval:    defaultKeyRotationCheckInterval,
+	}
+}
+
+// Start checks at set intervals whether the OSD encryption keys must be rotated
+func (m *KeyRotationMonitor) Start(stopCh chan struct{}) {
+	for {
+		select {
+		case <-time.After(m.interval):
+			logger.Debug("checking whether the osd encryption keys must be rotated")
+			m.checkKeyRotation()
+
+		case <-stopCh:
+			logger.Infof("stopping osd encryption key rotation in namespace %q", m.clusterInfo.Namespace)
+			return
+		}
+	}
+}
+
+func (m *KeyRotationMonitor) checkKeyRotation() {
+	// The spec is read on every check so that schedule changes are picked up
+	cephCluster := &cephv1.CephCluster{}
+	err := m.context.Client.Get(context.TODO(), m.clusterInfo.NamespacedName(), cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve ceph cluster %q to rotate osd encryption keys. %v", m.clusterInfo.NamespacedName().Name, err)
+		return
+	}
+
+	kmsSpec := cephCluster.Spec.Security.KeyManagementService
+	if !kmsSpec.IsKeyRotationEnabled() {
+		return
+	}
+	interval, err := keyRotationInterval(kmsSpec.KeyRotation.Schedule)
+	if err != nil {
+		logger.Errorf("failed to rotate osd encryption keys. %v", err)
+		return
+	}
+	status := cephCluster.Status.KeyRotation
+	if !isKeyRotationDue(status, interval, time.Now()) {
+		return
+	}
+
+	logger.Infof("rotating the osd encryption keys in namespace %q", m.clusterInfo.Namespace)
+	newStatus := &cephv1.KeyRotationStatus{}
+	if status != nil {
+		newStatus.LastRotationTime = status.LastRotationTime
+	}
+	c := New(m.context, m.clusterInfo, cephCluster.Spec, m.rookVersion)
+	if err := c.RotateEncryptionKeys(); err != nil {
+		logger.Errorf("failed to rotate osd encryption keys. %v", err)
+		newStatus.Message = err.Error()
+	} else {
+		now := metav1.Now()
+		newStatus.LastRotationTime = &now
+		logger.Infof("successfully rotated the osd encryption keys in namespace %q", m.clusterInfo.Namespace)
+	}
+
+	cephCluster.Status.KeyRotation = newStatus
+	if err := opcontroller.UpdateStatus(m.context.Client, cephCluster); err != nil {
+		logger.Errorf("failed to update cluster %q key rotation status. %v", m.clusterInfo.NamespacedName().Name, err)
+	}
+}
+
+// keyRotationInterval returns the interval between two rotations
+func keyRotationInterval(schedule string) (time.Duration, error) {
+	switch schedule {
+	case "", "@weekly":
+		return 7 * 24 * time.Hour, nil
+	case "@daily":
+		return 24 * time.Hour, nil
+	case "@monthly":
+		return 30 * 24 * time.Hour, nil
+	}
+
+	interval, err := time.ParseDuration(schedule)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to parse key rotation schedule %q", schedule)
+	}
+	if interval <= 0 {
+		return 0, errors.Errorf("invalid key rotation schedule %q, it must be positive", schedule)
+	}
+
+	return interval, nil
+}
+
+// isKeyRotationDue determines whether the keys must be rotated, they are rotated right away the first time
+func isKeyRotationDue(status *cephv1.KeyRotationStatus, interval time.Duration, now time.Time) bool {
+	if status == nil || status.LastRotationTime == nil {
+		return true
+	}
+
+	return !now.Before(status.LastRotationTime.Add(interval))
+}
+
+// RotateEncryptionKeys replaces the encryption key of every encrypted OSD on PVC
+// The OSDs keep running, only the LUKS key slots and the key stored in the KMS are changed. The OSDs stopped for the
+// maintenance of their node are skipped and an error is returned so the rotation is tried again.
+func (c *Cluster) RotateEncryptionKeys() error {
+	deployments, err := c.getExistingOSDDeploymentsOnPVCs()
+	if err != nil {
+		return err
+	}
+
+	kmsConfig := kms.NewConfig(c.context, &c.spec, c.clusterInfo)
+	if c.spec.Security.KeyManagementService.IsTokenAuthEnabled() {
+		err := kms.SetTokenToEnvVar(c.context, c.spec.Security.KeyManagementService.TokenSecretName, kmsConfig.Provider, c.clusterInfo.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch kms token secret %q", c.spec.Security.KeyManagementService.TokenSecretName)
+		}
+	}
+
+	inMaintenance := []string{}
+	for pvcName, d := range deployments {
+		if !isEncryptedOSDDeployment(d) {
+			continue
+		}
+		if maintenance, ok := d.Labels[MaintenanceLabelKey]; ok {
+			logger.Infof("not rotating encryption key of osd %s on pvc %q stopped by node maintenance %q", d.Labels[OsdIdLabelKey], pvcName, maintenance)
+			inMaintenance = append(inMaintenance, d.Labels[OsdIdLabelKey])
+			continue
+		}
+		if err := c.rotateEncryptionKey(kmsConfig, pvcName, d); err != nil {
+			return errors.Wrapf(err, "failed to rotate encryption key of osd on pvc %q", pvcName)
+		}
+		logger.Infof("rotated encryption key of osd %s on pvc %q", d.Labels[OsdIdLabelKey], pvcName)
+	}
+
+	if len(inMaintenance) > 0 {
+		sort.Strings(inMaintenance)
+		return errors.Errorf("failed to rotate encryption keys of osds %v stopped by node maintenance", inMaintenance)
+	}
+	return nil
+}
+
+func (c *Cluster) rotateEncryptionKey(kmsConfig *kms.Config, pvcName string, d *apps.Deployment) error {
+	// The new key is kept in a Secret until the rotation is completed so that an interrupted rotation can be resumed
+	newKey, err := c.getOrCreateNewEncryptionKey(pvcName)
+	if err != nil {
+		return err
+	}
+
+	// The new key is added next to the current one, the block can be opened with either of them
+	if err := c.runKeyRotationJob(pvcName, d, keyRotationPhaseAdd); err != nil {
+		return err
+	}
+
+	// The new key is stored before the previous one is removed so that the KMS always holds a key opening the block
+	if err := kmsConfig.UpdateSecret(pvcName, newKey); err != nil {
+		return errors.Wrap(err, "failed to store the new encryption key")
+	}
+
+	if err := c.runKeyRotationJob(pvcName, d, keyRotationPhaseRemove); err != nil {
+		return err
+	}
+
+	err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Delete(context.TODO(), keyRotationName(pvcName), metav1.DeleteOptions{})
+	if err != nil && !kerrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete key rotation secret %q", keyRotationName(pvcName))
+	}
+
+	return nil
+}
+
+// runKeyRotationJob runs a phase of the key rotation and waits for it to complete
+func (c *Cluster) runKeyRotationJob(pvcName string, d *apps.Deployment, phase string) error {
+	job, err := c.makeKeyRotationJob(pvcName, d, phase)
+	if err != nil {
+		return errors.Wrapf(err, "failed to generate key rotation job for the %s phase", phase)
+	}
+	if err := k8sutil.RunReplaceableJob(c.context.Clientset, job, true); err != nil {
+		return errors.Wrapf(err, "failed to run key rotation job %q", job.Name)
+	}
+	if err := k8sutil.WaitForJobCompletion(c.context.Clientset, job, keyRotationTimeout); err != nil {
+		return errors.Wrapf(err, "failed to wait for key rotation job %q in the %s phase", job.Name, phase)
+	}
+	if err := k8sutil.DeleteBatchJob(c.context.Clientset, c.clusterInfo.Namespace, job.Name, false); err != nil {
+		logger.Warningf("failed to delete key rotation job %q. %v", job.Name, err)
+	}
+
+	return nil
+}
+
+// getOrCreateNewEncryptionKey returns the key of an interrupted rotation or generates a new one
+func (c *Cluster) getOrCreateNewEncryptionKey(pvcName string) (string, error) {
+	ctx := context.TODO()
+	name := keyRotationName(pvcName)
+	s, err := c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Get(ctx, name, metav1.GetOptions{})
+	if err == nil {
+		if key := s.Data[kms.OsdEncryptionSecretNameKeyName]; len(key) != 0 {
+			logger.Infof("resuming the interrupted key rotation of pvc %q", pvcName)
+			return string(key), nil
+		}
+	} else if !kerrors.IsNotFound(err) {
+		return "", errors.Wrapf(err, "failed to get key rotation secret %q", name)
+	}
+
+	key, err := generateDmCryptKey()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to generate dmcrypt key for pvc %q", pvcName)
+	}
+
+	s = &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: c.clusterInfo.Namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:    keyRotationAppName,
+				OSDOverPVCLabelKey: pvcName,
+			},
+		},
+		Data: map[string][]byte{kms.OsdEncryptionSecretNameKeyName: []byte(key)},
+		Type: k8sutil.RookType,
+	}
+	err = c.clusterInfo.OwnerInfo.SetControllerReference(s)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to set owner reference to key rotation secret %q", name)
+	}
+	_, err = c.context.Clientset.CoreV1().Secrets(c.clusterInfo.Namespace).Create(ctx, s, metav1.CreateOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create key rotation secret %q", name)
+	}
+
+	return key, nil
+}
+
+// makeKeyRotationJob builds a job running a phase of the rotation next to the OSD pod, the current key is fetched like
+// the OSD deployment does
+func (c *Cluster) makeKeyRotationJob(pvcName string, d *apps.Deployment, phase string) (*batch.Job, error) {
+	ctx := context.TODO()
+	podSpec := d.Spec.Template.Spec
+
+	var getKEKContainer, openContainer *v1.Container
+	for i := range podSpec.InitContainers {
+		switch podSpec.InitContainers[i].Name {
+		case blockEncryptionKMSGetKEKInitContainer:
+			getKEKContainer = &podSpec.InitContainers[i]
+		case blockEncryptionOpenInitContainer:
+			openContainer = &podSpec.InitContainers[i]
+		}
+	}
+	if openContainer == nil {
+		return nil, errors.Errorf("osd deployment %q is not encrypted", d.Name)
+	}
+
+	// The LUKS header is rewritten on the block copied in the OSD data dir, it must run on the node of the OSD
+	pods, err := c.context.Clientset.CoreV1().Pods(c.clusterInfo.Namespace).List(ctx, metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", OsdIdLabelKey, d.Labels[OsdIdLabelKey])})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list pods of osd deployment %q", d.Name)
+	}
+	var nodeName string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == v1.PodRunning && pod.Spec.NodeName != "" {
+			nodeName = pod.Spec.NodeName
+			break
+		}
+	}
+	if nodeName == "" {
+		return nil, errors.Errorf("no running pod found for osd deployment %q", d.Name)
+	}
+
+	// The bridge mount of the open container is the OSD data dir
+	var mountPath string
+	for _, m := range openContainer.VolumeMounts {
+		if m.Name == fmt.Sprintf("%s-bridge", pvcName) {
+			mountPath = m.MountPath
+		}
+	}
+	if mountPath == "" {
+		return nil, errors.Errorf("failed to find the data dir of osd deployment %q", d.Name)
+	}
+	blocks := []string{
+		encryptionBlockDestinationCopy(mountPath, bluestoreBlockName),
+		encryptionBlockDestinationCopy(mountPath, bluestoreMetadataName),
+		encryptionBlockDestinationCopy(mountPath, bluestoreWalName),
+	}
+
+	var m int32 = 0400
+	newKeyVolume := v1.Volume{
+		Name: keyRotationVolumeName,
+		VolumeSource: v1.VolumeSource{
+			Secret: &v1.SecretVolumeSource{
+				SecretName:  keyRotationName(pvcName),
+				Items:       []v1.KeyToPath{{Key: kms.OsdEncryptionSecretNameKeyName, Path: encryptionKeyFileName}},
+				DefaultMode: &m,
+			},
+		},
+	}
+	newKeyMount := v1.VolumeMount{Name: keyRotationVolumeName, ReadOnly: true, MountPath: keyRotationKeyDir}
+
+	rotateContainer := v1.Container{
+		Name:  keyRotationContainerName,
+		Image: c.spec.CephVersion.Image,
+		Command: []string{
+			"/bin/bash",
+			"-c",
+			fmt.Sprintf(rotateEncryptionKey, phase, encryptionKeyPath(), path.Join(keyRotationKeyDir, encryptionKeyFileName), strings.Join(blocks, " ")),
+		},
+		VolumeMounts:    append(append([]v1.VolumeMount{}, openContainer.VolumeMounts...), newKeyMount),
+		SecurityContext: PrivilegedContext(),
+		Resources:       openContainer.Resources,
+	}
+
+	initContainers := []v1.Container{}
+	if getKEKContainer != nil {
+		initContainers = append(initContainers, *getKEKContainer.DeepCopy())
+	}
+	volumes := append(volumesForContainers(podSpec.Volumes, append(initContainers, rotateContainer)), newKeyVolume)
+
+	job := &batch.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      keyRotationName(pvcName),
+			Namespace: c.clusterInfo.Namespace,
+			Labels: map[string]string{
+				k8sutil.AppAttr:     keyRotationAppName,
+				k8sutil.ClusterAttr: c.clusterInfo.Namespace,
+				OSDOverPVCLabelKey:  pvcName,
+			},
+		},
+		Spec: batch.JobSpec{
+			Template: v1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						k8sutil.AppAttr:     keyRotationAppName,
+						k8sutil.ClusterAttr: c.clusterInfo.Namespace,
+						OSDOverPVCLabelKey:  pvcName,
+					},
+				},
+				Spec: v1.PodSpec{
+					NodeName:           nodeName,
+					InitContainers:     initContainers,
+					Containers:         []v1.Container{rotateContainer},
+					Volumes:            volumes,
+					RestartPolicy:      v1.RestartPolicyOnFailure,
+					ServiceAccountName: podSpec.ServiceAccountName,
+					Tolerations:        podSpec.Tolerations,
+					PriorityClassName:  podSpec.PriorityClassName,
+				},
+			},
+		},
+	}
+
+	k8sutil.AddRookVersionLabelToJob(job)
+	err = c.clusterInfo.OwnerInfo.SetControllerReference(job)
+	if err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// volumesForContainers returns the volumes mounted by the containers
+func volumesForContainers(volumes []v1.Volume, containers []v1.Container) []v1.Volume {
+	mounted := map[string]bool{}
+	for _, container := range containers {
+		for _, m := range container.VolumeMounts {
+			mounted[m.Name] = true
+		}
+	}
+
+	result := []v1.Volume{}
+	for _, volume := range volumes {
+		if mounted[volume.Name] {
+			result = append(result, volume)
+		}
+	}
+
+	return result
+}
+
+func isEncryptedOSDDeployment(d *apps.Deployment) bool {
+	for _, container := range d.Spec.Template.Spec.InitContainers {
+		if container.Name == blockEncryptionOpenInitContainer {
+			return true
+		}
+	}
+
+	return false
+}
+
+func keyRotationName(pvcName string) string {
+	return k8sutil.TruncateNodeName(keyRotationNameFmt, pvcName)
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestKeyRotationInterval(t *testing.T) {
+	interval, err := keyRotationInterval("")
+	assert.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, interval)
+
+	interval, err = keyRotationInterval("@daily")
+	assert.NoError(t, err)
+	assert.Equal(t, 24*time.Hour, interval)
+
+	interval, err = keyRotationInterval("@monthly")
+	assert.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, interval)
+
+	interval, err = keyRotationInterval("12h")
+	assert.NoError(t, err)
+	assert.Equal(t, 12*time.Hour, interval)
+
+	_, err = keyRotationInterval("@yearly")
+	assert.Error(t, err)
+	_, err = keyRotationInterval("-1h")
+	assert.Error(t, err)
+}
+
+func TestIsKeyRotationDue(t *testing.T) {
+	now := time.Now()
+	assert.True(t, isKeyRotationDue(nil, time.Hour, now))
+	assert.True(t, isKeyRotationDue(&cephv1.KeyRotationStatus{}, time.Hour, now))
+
+	last := metav1.NewTime(now.Add(-30 * time.Minute))
+	assert.False(t, isKeyRotationDue(&cephv1.KeyRotationStatus{LastRotationTime: &last}, time.Hour, now))
+	assert.True(t, isKeyRotationDue(&cephv1.KeyRotationStatus{LastRotationTime: &last}, 30*time.Minute, now))
+}
+
+func TestMakeKeyRotationJob(t *testing.T) {
+	ctx := context.TODO()
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephclient.ClusterInfo{
+		Namespace:   "ns",
+		CephVersion: cephver.Nautilus,
+	}
+	clusterInfo.SetName("test")
+	clusterInfo.OwnerInfo = cephclient.NewMinimumOwnerInfo(t)
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}
+	spec := cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v15"}}
+	c := New(context, clusterInfo, spec, "rook/rook:myversion")
+
+	osdProp := osdProperties{
+		crushHostname: "node1",
+		pvc:           v1.PersistentVolumeClaimVolumeSource{ClaimName: "mypvc"},
+		encrypted:     true,
+	}
+	dataPathMap := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.clusterInfo.Namespace, "/var/lib/rook"),
+	}
+	d, err := c.makeDeployment(osdProp, OSDInfo{ID: 0, CVMode: "raw"}, dataPathMap)
+	assert.NoError(t, err)
+	assert.True(t, isEncryptedOSDDeployment(d))
+
+	// The OSD must be running to find its node
+	_, err = c.makeKeyRotationJob("mypvc", d, keyRotationPhaseAdd)
+	assert.Error(t, err)
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0-abc", Namespace: "ns", Labels: map[string]string{OsdIdLabelKey: "0"}},
+		Spec:       v1.PodSpec{NodeName: "node1"},
+		Status:     v1.PodStatus{Phase: v1.PodRunning},
+	}
+	_, err = clientset.CoreV1().Pods("ns").Create(ctx, pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	job, err := c.makeKeyRotationJob("mypvc", d, keyRotationPhaseAdd)
+	assert.NoError(t, err)
+	assert.Equal(t, "rook-ceph-osd-key-rotation-mypvc", job.Name)
+	assert.Equal(t, "mypvc", job.Labels[OSDOverPVCLabelKey])
+	assert.Equal(t, "node1", job.Spec.Template.Spec.NodeName)
+	assert.Equal(t, 0, len(job.Spec.Template.Spec.InitContainers))
+	assert.Equal(t, 1, len(job.Spec.Template.Spec.Containers))
+	container := job.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "ceph/ceph:v15", container.Image)
+	assert.Contains(t, container.Command[2], "ROTATION_PHASE=add")
+	assert.Contains(t, container.Command[2], "KEY_FILE_PATH=/etc/ceph/luks_key")
+	assert.Contains(t, container.Command[2], "NEW_KEY_FILE_PATH=/etc/rook-key-rotation/luks_key")
+	assert.Contains(t, container.Command[2], "/var/lib/ceph/osd/ceph-0/block-tmp /var/lib/ceph/osd/ceph-0/block.db-tmp /var/lib/ceph/osd/ceph-0/block.wal-tmp")
+	assert.True(t, *container.SecurityContext.Privileged)
+
+	// Only the volumes needed by the job are kept
+	volumes := map[string]bool{}
+	for _, volume := range job.Spec.Template.Spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	assert.Equal(t, map[string]bool{"mypvc-bridge": true, "dev-mapper": true, "osd-encryption-key": true, "osd-new-encryption-key": true}, volumes)
+
+	// Not encrypted
+	osdProp.encrypted = false
+	d, err = c.makeDeployment(osdProp, OSDInfo{ID: 0, CVMode: "raw"}, dataPathMap)
+	assert.NoError(t, err)
+	assert.False(t, isEncryptedOSDDeployment(d))
+	_, err = c.makeKeyRotationJob("mypvc", d, keyRotationPhaseAdd)
+	assert.Error(t, err)
+}
+
+func TestGetOrCreateNewEncryptionKey(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := cephclient.AdminClusterInfo("ns")
+	clusterInfo.OwnerInfo = cephclient.NewMinimumOwnerInfo(t)
+	c := New(&clusterd.Context{Clientset: clientset}, clusterInfo, cephv1.ClusterSpec{}, "rook/rook:myversion")
+
+	key, err := c.getOrCreateNewEncryptionKey("mypvc")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, key)
+
+	// An interrupted rotation reuses the same key
+	sameKey, err := c.getOrCreateNewEncryptionKey("mypvc")
+	assert.NoError(t, err)
+	assert.Equal(t, key, sameKey)
+
+	otherKey, err := c.getOrCreateNewEncryptionKey("otherpvc")
+	assert.NoError(t, err)
+	assert.NotEqual(t, key, otherKey)
+}
@@ -35,6 +35,9 @@ const (
 	OSDOverPVCLabelKey = "ceph.rook.io/pvc"
 	// TopologyLocationLabel is the crush location label added to OSD deployments
 	TopologyLocationLabel = "topology-location-%s"
+	// MaintenanceLabelKey is set on the deployments of the OSDs stopped by a CephNodeMaintenance, its value is the
+	// name of the CephNodeMaintenance
+	MaintenanceLabelKey = "ceph.rook.io/maintenance"
 )
 
 func makeStorageClassDeviceSetPVCLabel(storageClassDeviceSetName, pvcStorageClassDeviceSetPVCId string, setIndex int) map[string]string {
@@ -38,7 +38,6 @@ import (
 	kms "github.com/rook/rook/pkg/daemon/ceph/osd/kms"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
 	osdconfig "github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
-	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	"github.com/rook/rook/pkg/operator/ceph/controller"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	cephver "github.com/rook/rook/pkg/operator/ceph/version"
@@ -116,6 +115,12 @@ type OSDInfo struct {
 	Store         string `json:"store"`
 	// Ensure the OSD daemon has affinity with the same topology from the OSD prepare pod
 	TopologyAffinity string `json:"topologyAffinity"`
+	// EncryptedDeviceUUID is the LUKS UUID of the device of a node encrypted by Rook, the OSD block sits on top of it
+	EncryptedDeviceUUID string `json:"encrypted-device-uuid,omitempty"`
+	// DeviceSerial is the serial of the device of the node the OSD was created on
+	DeviceSerial string `json:"device-serial,omitempty"`
+	// DevicePath is the by-path link of the device of the node the OSD was created on, it follows the slot of the device
+	DevicePath string `json:"device-path,omitempty"`
 }
 
 // OrchestrationStatus represents the status of an OSD orchestration
@@ -124,6 +129,8 @@ type OrchestrationStatus struct {
 	Status       string    `json:"status"`
 	PvcBackedOSD bool      `json:"pvc-backed-osd"`
 	Message      string    `json:"message"`
+	// SkippedBlueFSMigration is set when the new metadata and wal devices of the OSD on PVC cannot be added to it
+	SkippedBlueFSMigration bool `json:"skipped-bluefs-migration,omitempty"`
 }
 
 type osdProperties struct {
@@ -152,6 +159,31 @@ func (osdProps osdProperties) onPVC() bool {
 	return osdProps.pvc.ClaimName != ""
 }
 
+// onHostEncrypted returns whether Rook encrypts the OSDs on the devices of the node
+func (osdProps osdProperties) onHostEncrypted() bool {
+	if osdProps.onPVC() {
+		return false
+	}
+	if osdProps.storeConfig.Encrypted {
+		return true
+	}
+	for _, device := range osdProps.devices {
+		if device.Encrypted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encryptionKeyName returns the name of the key of the OSD in the KMS
+func (osdProps osdProperties) encryptionKeyName() string {
+	if osdProps.onPVC() {
+		return osdProps.pvc.ClaimName
+	}
+	return HostEncryptionKeyName(osdProps.crushHostname)
+}
+
 func (osdProps osdProperties) onPVCWithMetadata() bool {
 	return osdProps.metadataPVC.ClaimName != ""
 }
@@ -180,6 +212,12 @@ func (c *Cluster) Start() error {
 	}
 	logger.Infof("start running osds in namespace %s", c.clusterInfo.Namespace)
 
+	// The OSDs stopped for the maintenance of their node are not updated until the maintenance ends
+	config.osdsInMaintenance, err = c.osdsInMaintenance()
+	if err != nil {
+		return err
+	}
+
 	if !c.spec.Storage.UseAllNodes && len(c.spec.Storage.Nodes) == 0 && len(c.spec.Storage.VolumeSources) == 0 && len(c.spec.Storage.StorageClassDeviceSets) == 0 {
 		logger.Warningf("useAllNodes is set to false and no nodes, storageClassDevicesets or volumeSources are specified, no OSD pods are going to be created")
 	}
@@ -191,6 +229,14 @@ func (c *Cluster) Start() error {
 	}
 	logger.Infof("wait timeout for healthy OSDs during upgrade or restart is %q", c.clusterInfo.OsdUpgradeTimeout)
 
+	// Make sure every OSD key is wrapped by the current master key before the OSD pods need them
+	if c.spec.Security.KeyManagementService.IsMasterKeyEnabled() {
+		err := kms.NewConfig(c.context, &c.spec, c.clusterInfo).RewrapKeys()
+		if err != nil {
+			return errors.Wrap(err, "failed to wrap osd encryption keys with the master key")
+		}
+	}
+
 	// start the jobs to provision the OSD devices
 	logger.Info("start provisioning the osds on PVCs, if needed")
 	c.startProvisioningOverPVCs(config)
@@ -200,6 +246,13 @@ func (c *Cluster) Start() error {
 			len(config.errorMessages), c.clusterInfo.Namespace, strings.Join(config.errorMessages, "\n"))
 	}
 
+	// The OSDs are destroyed before the prepare jobs run so that the new devices get the same OSD IDs
+	if c.spec.ReplaceSwappedOSDs {
+		if err := c.replaceSwappedOSDs(); err != nil {
+			logger.Errorf("failed to replace the osds of swapped devices. %v", err)
+		}
+	}
+
 	logger.Info("start provisioning the osds on nodes, if needed")
 	c.startProvisioningOverNodes(config)
 
@@ -208,6 +261,21 @@ func (c *Cluster) Start() error {
 			len(config.errorMessages), c.clusterInfo.Namespace, strings.Join(config.errorMessages, "\n"))
 	}
 
+	if len(config.blueFSMigrations) > 0 {
+		logger.Infof("adding metadata and wal devices to %d existing osds", len(config.blueFSMigrations))
+		c.startBlueFSMigrations(config.blueFSMigrations)
+	}
+
+	// The existing OSDs are restarted once all the new OSDs are created
+	if len(config.plannedUpdates) > 0 {
+		logger.Info("updating the existing osds one failure domain at a time")
+		c.updateOSDsInParallel(config)
+		if len(config.errorMessages) > 0 {
+			return errors.Errorf("%d failures encountered while updating osds in namespace %q. %v",
+				len(config.errorMessages), c.clusterInfo.Namespace, strings.Join(config.errorMessages, "\n"))
+		}
+	}
+
 	// The following block is used to apply any command(s) required by an upgrade
 	// The block below handles the upgrade from Mimic to Nautilus.
 	// This should only run before Octopus
@@ -303,32 +371,9 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 			}
 
 			// create encryption Kubernetes Secret if the PVC is encrypted
-			key, err := generateDmCryptKey()
+			err := c.storeEncryptionKey(osdProps.pvc.ClaimName)
 			if err != nil {
-				errMsg := fmt.Sprintf("failed to generate dmcrypt key for osd claim %q. %v", osdProps.pvc.ClaimName, err)
-				config.addError(errMsg)
-				continue
-			}
-
-			// Initialize the KMS code
-			kmsConfig := kms.NewConfig(c.context, &c.spec, c.clusterInfo)
-
-			// We could set an env var in the Operator or a global var instead of the API call?
-			// Hopefully, the API is cheap and we can always retrieve the token if it has changed...
-			if c.spec.Security.KeyManagementService.IsTokenAuthEnabled() {
-				err := kms.SetTokenToEnvVar(c.context, c.spec.Security.KeyManagementService.TokenSecretName, kmsConfig.Provider, c.clusterInfo.Namespace)
-				if err != nil {
-					errMsg := fmt.Sprintf("failed to fetch kms token secret %q. %v", c.spec.Security.KeyManagementService.TokenSecretName, err)
-					config.addError(errMsg)
-					continue
-				}
-			}
-
-			// Generate and store the encrypted key in whatever KMS is configured
-			err = kmsConfig.PutSecret(osdProps.pvc.ClaimName, key)
-			if err != nil {
-				errMsg := fmt.Sprintf("failed to store secret. %v", err)
-				config.addError(errMsg)
+				config.addError("failed to create encryption key for osd claim %q. %v", osdProps.pvc.ClaimName, err)
 				continue
 			}
 		}
@@ -343,6 +388,18 @@ func (c *Cluster) startProvisioningOverPVCs(config *provisionConfig) {
 			}
 			// Update the orchestration status of this pvc to the completed state
 			status := OrchestrationStatus{OSDs: osds, Status: OrchestrationStatusAlreadyExists, PvcBackedOSD: true}
+			if needsBlueFSMigration(osdDeployment, osdProps) {
+				m := blueFSMigration{osdProps: osdProps, deployment: osdDeployment, osds: osds}
+				reason := c.blueFSMigrationSkipReason(m)
+				if reason == "" {
+					// The deployment is only updated after the new metadata and wal devices are added to the OSD
+					config.blueFSMigrations = append(config.blueFSMigrations, m)
+					continue
+				}
+				logger.Warningf("skipped adding the metadata and wal devices to the osd on pvc %q, %s. the osd keeps its current devices", osdProps.crushHostname, reason)
+				status.SkippedBlueFSMigration = true
+				status.Message = fmt.Sprintf("skipped adding the metadata and wal devices, %s", reason)
+			}
 			c.updateOSDStatus(osdProps.crushHostname, status)
 			continue
 		}
@@ -457,6 +514,7 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 
 		// create the job that prepares osds on the node
 		storeConfig := osdconfig.ToStoreConfig(n.Config)
+		storeConfig.Encrypted = n.Selection.GetEncrypted()
 		metadataDevice := osdconfig.MetadataDevice(n.Config)
 		osdProps := osdProperties{
 			crushHostname:  n.Name,
@@ -466,6 +524,19 @@ func (c *Cluster) startProvisioningOverNodes(config *provisionConfig) {
 			storeConfig:    storeConfig,
 			metadataDevice: metadataDevice,
 		}
+
+		// The OSDs on the devices of a node share the same key
+		if osdProps.onHostEncrypted() {
+			err := c.storeEncryptionKey(osdProps.encryptionKeyName())
+			if err != nil {
+				message := fmt.Sprintf("failed to create encryption key for node %q. %v", n.Name, err)
+				config.addError(message)
+				status := OrchestrationStatus{Status: OrchestrationStatusCompleted, Message: message}
+				c.updateOSDStatus(n.Name, status)
+				continue
+			}
+		}
+
 		job, err := c.makeJob(osdProps, config)
 		if err != nil {
 			message := fmt.Sprintf("failed to create prepare job node %q. %v", n.Name, err)
@@ -500,6 +571,35 @@ func (c *Cluster) runJob(job *batch.Job, nodeName string, config *provisionConfi
 	return true
 }
 
+// storeEncryptionKey generates an encryption key and stores it in the KMS of the cluster
+// An existing key is never overwritten
+func (c *Cluster) storeEncryptionKey(keyName string) error {
+	key, err := generateDmCryptKey()
+	if err != nil {
+		return errors.Wrap(err, "failed to generate dmcrypt key")
+	}
+
+	// Initialize the KMS code
+	kmsConfig := kms.NewConfig(c.context, &c.spec, c.clusterInfo)
+
+	// We could set an env var in the Operator or a global var instead of the API call?
+	// Hopefully, the API is cheap and we can always retrieve the token if it has changed...
+	if c.spec.Security.KeyManagementService.IsTokenAuthEnabled() {
+		err := kms.SetTokenToEnvVar(c.context, c.spec.Security.KeyManagementService.TokenSecretName, kmsConfig.Provider, c.clusterInfo.Namespace)
+		if err != nil {
+			return errors.Wrapf(err, "failed to fetch kms token secret %q", c.spec.Security.KeyManagementService.TokenSecretName)
+		}
+	}
+
+	// Generate and store the encrypted key in whatever KMS is configured
+	err = kmsConfig.PutSecret(keyName, key)
+	if err != nil {
+		return errors.Wrap(err, "failed to store secret")
+	}
+
+	return nil
+}
+
 func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig, configMap *v1.ConfigMap, status *OrchestrationStatus) {
 	ctx := context.TODO()
 	osds := status.OSDs
@@ -510,6 +610,16 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		return
 	}
 
+	// The OSD keeps running with its current devices when the new ones cannot be added to it
+	if status.SkippedBlueFSMigration && len(osds) > 0 {
+		d, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Get(ctx, fmt.Sprintf(osdAppNameFmt, osds[0].ID), metav1.GetOptions{})
+		if err != nil {
+			config.addError("failed to get the deployment of the osd on pvc %q. %v", pvcName, err)
+			return
+		}
+		osdProps = withoutNewBlueFSDevices(d, osdProps)
+	}
+
 	// start osds
 	for _, osd := range osds {
 		logger.Debugf("start osd %v", osd)
@@ -533,9 +643,7 @@ func (c *Cluster) startOSDDaemonsOnPVC(pvcName string, config *provisionConfig,
 		if createErr != nil {
 			if kerrors.IsAlreadyExists(createErr) {
 				logger.Infof("deployment for osd %d already exists. updating if needed", osd.ID)
-				if err = updateDeploymentAndWait(c.context, c.clusterInfo, dp, opconfig.OsdType, strconv.Itoa(osd.ID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-					logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
-				}
+				c.updateOSDDeployment(dp, osd.ID, config)
 			} else {
 				// we failed to create job, update the orchestration status for this pvc
 				logger.Warningf("failed to create osd deployment for pvc %q, osd %v. %v", osdProps.pvc.ClaimName, osd, createErr)
@@ -559,6 +667,7 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		return
 	}
 	storeConfig := osdconfig.ToStoreConfig(n.Config)
+	storeConfig.Encrypted = n.Selection.GetEncrypted()
 	metadataDevice := osdconfig.MetadataDevice(n.Config)
 
 	osdProps := osdProperties{
@@ -594,10 +703,18 @@ func (c *Cluster) startOSDDaemonsOnNode(nodeName string, config *provisionConfig
 		_, createErr := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Create(ctx, dp, metav1.CreateOptions{})
 		if createErr != nil {
 			if kerrors.IsAlreadyExists(createErr) {
-				logger.Debugf("deployment for osd %d already exists. updating if needed", osd.ID)
-				if err = updateDeploymentAndWait(c.context, c.clusterInfo, dp, opconfig.OsdType, strconv.Itoa(osd.ID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
-					logger.Errorf("failed to update osd deployment %d. %v", osd.ID, err)
+				if c.nodeOSDNeedsBlueFSMigration(osdProps, osd) {
+					m := blueFSMigration{osdProps: osdProps, deployment: dp, osds: []OSDInfo{osd}}
+					reason := c.blueFSMigrationSkipReason(m)
+					if reason == "" {
+						// The OSD is left alone until the metadata device of the node is added to it
+						config.blueFSMigrations = append(config.blueFSMigrations, m)
+						continue
+					}
+					logger.Warningf("skipped adding the metadata device %q to osd %d, %s. the osd keeps its current devices", osdProps.metadataDevice, osd.ID, reason)
 				}
+				logger.Debugf("deployment for osd %d already exists. updating if needed", osd.ID)
+				c.updateOSDDeployment(dp, osd.ID, config)
 			} else {
 				// we failed to create job, update the orchestration status for this pvc
 				logger.Warningf("failed to create osd deployment for node %q, osd %+v. %v", n.Name, osd, createErr)
@@ -756,6 +873,15 @@ func (c *Cluster) getOSDInfo(d *apps.Deployment) ([]OSDInfo, error) {
 		if envVar.Name == osdWalDeviceEnvVarName {
 			osd.WalPath = envVar.Value
 		}
+		if envVar.Name == encryptedDeviceUUIDVarName {
+			osd.EncryptedDeviceUUID = envVar.Value
+		}
+		if envVar.Name == deviceSerialVarName {
+			osd.DeviceSerial = envVar.Value
+		}
+		if envVar.Name == devicePathVarName {
+			osd.DevicePath = envVar.Value
+		}
 	}
 
 	// If CVMode is empty, this likely means we upgraded Rook
@@ -63,6 +63,24 @@ func TestOSDProperties(t *testing.T) {
 	}
 }
 
+func TestOSDPropertiesHostEncrypted(t *testing.T) {
+	osdProps := osdProperties{crushHostname: "node1", devices: []rookv1.Device{{Name: "sda"}}}
+	assert.False(t, osdProps.onHostEncrypted())
+	assert.Equal(t, "host-node1", osdProps.encryptionKeyName())
+
+	osdProps.devices = append(osdProps.devices, rookv1.Device{Name: "sdb", Encrypted: true})
+	assert.True(t, osdProps.onHostEncrypted())
+
+	osdProps.devices = nil
+	osdProps.storeConfig.Encrypted = true
+	assert.True(t, osdProps.onHostEncrypted())
+
+	// OSDs on PVC are encrypted by ceph-volume with the key of the PVC
+	osdProps.pvc = v1.PersistentVolumeClaimVolumeSource{ClaimName: "pvc1"}
+	assert.False(t, osdProps.onHostEncrypted())
+	assert.Equal(t, "pvc1", osdProps.encryptionKeyName())
+}
+
 func TestStart(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	clusterInfo := &cephclient.ClusterInfo{
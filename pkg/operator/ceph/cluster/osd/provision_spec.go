@@ -118,15 +118,28 @@ func (c *Cluster) provisionPodTemplateSpec(osdProps osdProperties, restart v1.Re
 	if osdProps.onPVC() {
 		// Create volume config for PVCs
 		volumes = append(volumes, getPVCOSDVolumes(&osdProps, c.spec.DataDirHostPath, c.clusterInfo.Namespace, true)...)
-		if osdProps.encrypted {
-			// If a KMS is configured we populate
-			if c.spec.Security.KeyManagementService.IsEnabled() {
-				kmsProvider := kms.GetParam(c.spec.Security.KeyManagementService.ConnectionDetails, kms.Provider)
-				if kmsProvider == secrets.TypeVault {
-					volumeTLS, _ := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
-					volumes = append(volumes, volumeTLS)
+	}
+
+	// The encrypted OSDs on PVC and on the devices of a node fetch their key the same way
+	if osdProps.encrypted || osdProps.onHostEncrypted() {
+		// If a KMS is configured we populate
+		if c.spec.Security.KeyManagementService.IsEnabled() {
+			kmsProvider := kms.GetParam(c.spec.Security.KeyManagementService.ConnectionDetails, kms.Provider)
+			if kmsProvider == secrets.TypeVault {
+				volumeTLS, _ := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+				volumes = append(volumes, volumeTLS)
+				if kms.VaultKubernetesAuthEnabled(c.spec.Security.KeyManagementService.ConnectionDetails) {
+					volumeToken, _ := kms.VaultServiceAccountTokenVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+					volumes = append(volumes, volumeToken)
 				}
 			}
+			if kmsProvider == kms.TypeKMIP {
+				volumeTLS, _ := kms.KMIPVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+				volumes = append(volumes, volumeTLS)
+			}
+		} else if c.spec.Security.KeyManagementService.IsMasterKeyEnabled() {
+			masterKeyVolumes, _ := kms.MasterKeyVolumeAndMount(c.spec.Security.KeyManagementService)
+			volumes = append(volumes, masterKeyVolumes...)
 		}
 	}
 
@@ -187,7 +200,7 @@ func (c *Cluster) provisionPodTemplateSpec(osdProps osdProperties, restart v1.Re
 
 	// ceph-volume --dmcrypt uses cryptsetup that synchronizes with udev on
 	// host through semaphore
-	podSpec.HostIPC = osdProps.storeConfig.EncryptedDevice || osdProps.encrypted
+	podSpec.HostIPC = osdProps.storeConfig.EncryptedDevice || osdProps.encrypted || osdProps.onHostEncrypted()
 
 	return &v1.PodTemplateSpec{
 		ObjectMeta: podMeta,
@@ -213,6 +226,7 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 				ID:          id,
 				StoreConfig: config.ToStoreConfig(device.Config),
 			}
+			cd.StoreConfig.Encrypted = device.Encrypted
 			configuredDevices = append(configuredDevices, cd)
 		}
 		marshalledDevices, err := json.Marshal(configuredDevices)
@@ -234,6 +248,21 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 		envVars = append(envVars, metadataDeviceEnvVar(osdProps.metadataDevice))
 	}
 
+	// The destroyed OSDs of the node are re-created on their new devices with the same IDs
+	if c.spec.ReplaceSwappedOSDs && !osdProps.onPVC() {
+		replaceOSDs, err := c.osdReplacements(osdProps.crushHostname)
+		if err != nil {
+			return v1.Container{}, errors.Wrapf(err, "failed to get the osds to replace on node %q", osdProps.crushHostname)
+		}
+		if len(replaceOSDs) > 0 {
+			marshalledReplacements, err := json.Marshal(replaceOSDs)
+			if err != nil {
+				return v1.Container{}, errors.Wrapf(err, "failed to JSON marshal the osds to replace on node %q", osdProps.crushHostname)
+			}
+			envVars = append(envVars, replaceOSDsEnvVar(string(marshalledReplacements)))
+		}
+	}
+
 	volumeMounts := append(controller.CephVolumeMounts(provisionConfig.DataPathMap, true), []v1.VolumeMount{
 		{Name: "devices", MountPath: "/dev"},
 		{Name: "udev", MountPath: "/run/udev"},
@@ -279,19 +308,41 @@ func (c *Cluster) provisionOSDContainer(osdProps osdProperties, copyBinariesMoun
 		envVars = append(envVars, pvcBackedOSDEnvVar("true"))
 		envVars = append(envVars, encryptedDeviceEnvVar(osdProps.encrypted))
 		envVars = append(envVars, pvcNameEnvVar(osdProps.pvc.ClaimName))
+	}
 
-		if osdProps.encrypted {
-			// If a KMS is configured we populate
-			if c.spec.Security.KeyManagementService.IsEnabled() {
-				kmsProvider := kms.GetParam(c.spec.Security.KeyManagementService.ConnectionDetails, kms.Provider)
-				if kmsProvider == secrets.TypeVault {
-					_, volumeMountsTLS := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
-					volumeMounts = append(volumeMounts, volumeMountsTLS)
-					envVars = append(envVars, kms.VaultConfigToEnvVar(c.spec)...)
+	// The encrypted OSDs on PVC and on the devices of a node fetch their key the same way
+	if osdProps.encrypted || osdProps.onHostEncrypted() {
+		keyName := osdProps.encryptionKeyName()
+		if osdProps.onHostEncrypted() {
+			envVars = append(envVars, encryptionKeyNameEnvVar(osdProps))
+		}
+		// If a KMS is configured we populate
+		if c.spec.Security.KeyManagementService.IsEnabled() {
+			kmsProvider := kms.GetParam(c.spec.Security.KeyManagementService.ConnectionDetails, kms.Provider)
+			if kmsProvider == secrets.TypeVault {
+				_, volumeMountsTLS := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+				volumeMounts = append(volumeMounts, volumeMountsTLS)
+				envVars = append(envVars, kms.VaultConfigToEnvVar(c.spec)...)
+				if kms.VaultKubernetesAuthEnabled(c.spec.Security.KeyManagementService.ConnectionDetails) {
+					_, volumeMountToken := kms.VaultServiceAccountTokenVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+					volumeMounts = append(volumeMounts, volumeMountToken)
+				}
+				if kms.VaultTransitEnabled(c.spec.Security.KeyManagementService.ConnectionDetails) {
+					envVars = append(envVars, kms.VaultTransitCiphertextEnvVar(keyName))
 				}
-			} else {
-				envVars = append(envVars, cephVolumeRawEncryptedEnvVarFromSecret(osdProps))
 			}
+			if kmsProvider == kms.TypeKMIP {
+				_, volumeMountsTLS := kms.KMIPVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+				volumeMounts = append(volumeMounts, volumeMountsTLS)
+				envVars = append(envVars, kms.KMIPConfigToEnvVar(c.spec, keyName)...)
+			}
+		} else if c.spec.Security.KeyManagementService.IsMasterKeyEnabled() {
+			// The key is unwrapped by the provisioner before calling ceph-volume
+			_, masterKeyMounts := kms.MasterKeyVolumeAndMount(c.spec.Security.KeyManagementService)
+			volumeMounts = append(volumeMounts, masterKeyMounts...)
+			envVars = append(envVars, kms.MasterKeyConfigToEnvVar(keyName)...)
+		} else {
+			envVars = append(envVars, cephVolumeRawEncryptedEnvVarFromSecret(osdProps))
 		}
 	}
 
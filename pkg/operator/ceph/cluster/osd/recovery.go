@@ -0,0 +1,190 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	mclockProfileOption          = "osd_mclock_profile"
+	mclockRecoveryLimitOption    = "osd_mclock_scheduler_background_recovery_lim"
+	mclockOverrideRecoveryOption = "osd_mclock_override_recovery_settings"
+	maxBackfillsOption           = "osd_max_backfills"
+	recoveryMaxActiveOption      = "osd_recovery_max_active"
+	recoverySleepOption          = "osd_recovery_sleep"
+	recoveryConfigWho            = "osd"
+	mclockCustomProfile          = "custom"
+)
+
+var (
+	// mclockRecoveryVersion is the first version whose mClock scheduler caps the recovery with a share of the OSD
+	// capacity and honors the recovery limits when the override is set
+	mclockRecoveryVersion = cephver.CephVersion{Major: 17, Minor: 2, Extra: 6}
+)
+
+// recoveryOptions returns the centralized config options of the OSDs for the recovery spec. The options with
+// an empty value are not set in the spec and must be removed from the config to restore the Ceph defaults.
+func recoveryOptions(spec cephv1.RecoverySpec, cephVersion cephver.CephVersion) ([]opconfig.Option, error) {
+	limits := cephv1.RecoveryLimitsSpec{}
+	if spec.Limits != nil {
+		limits = *spec.Limits
+	}
+
+	intValue := func(v int) string {
+		if v == 0 {
+			return ""
+		}
+		return strconv.Itoa(v)
+	}
+	// The recovery sleep is in seconds
+	sleep := ""
+	if limits.SleepMilliseconds > 0 {
+		sleep = strconv.FormatFloat(float64(limits.SleepMilliseconds)/1000, 'f', -1, 64)
+	}
+
+	profile := string(spec.Profile)
+	// The bandwidth cap is a share of the OSD capacity, only honored with the custom profile of the mClock scheduler
+	bandwidth := ""
+	if limits.MaxBandwidthPercent > 0 {
+		if profile != "" {
+			return nil, errors.Errorf("the recovery profile %q cannot be set with a bandwidth limit", profile)
+		}
+		if !cephVersion.IsAtLeast(mclockRecoveryVersion) {
+			logger.Warningf("the recovery bandwidth limit requires ceph %q or newer, not applying it", mclockRecoveryVersion.String())
+		} else {
+			profile = mclockCustomProfile
+			bandwidth = strconv.FormatFloat(float64(limits.MaxBandwidthPercent)/100, 'f', -1, 64)
+		}
+	}
+
+	// The mClock scheduler ignores the backfill and recovery limits unless they override the profile
+	override := ""
+	if (limits.MaxBackfills > 0 || limits.MaxActive > 0) && cephVersion.IsAtLeast(mclockRecoveryVersion) {
+		override = "true"
+	}
+
+	return []opconfig.Option{
+		{Who: recoveryConfigWho, Option: mclockProfileOption, Value: profile},
+		{Who: recoveryConfigWho, Option: mclockRecoveryLimitOption, Value: bandwidth},
+		{Who: recoveryConfigWho, Option: mclockOverrideRecoveryOption, Value: override},
+		{Who: recoveryConfigWho, Option: maxBackfillsOption, Value: intValue(limits.MaxBackfills)},
+		{Who: recoveryConfigWho, Option: recoveryMaxActiveOption, Value: intValue(limits.MaxActive)},
+		{Who: recoveryConfigWho, Option: recoverySleepOption, Value: sleep},
+	}, nil
+}
+
+// applyRecoveryOptions sets the recovery options of the OSDs in the centralized config and removes the ones that
+// are not set in the spec anymore. Only the options previously set by the operator are removed, so the options
+// set by an admin are preserved. The options set by the operator are returned, even on failure.
+func applyRecoveryOptions(monStore *opconfig.MonStore, options []opconfig.Option, previous []string) ([]string, error) {
+	applied := map[string]bool{}
+	for _, option := range previous {
+		applied[option] = true
+	}
+	setOptions := func() []string {
+		var result []string
+		for _, option := range options {
+			if applied[option.Option] {
+				result = append(result, option.Option)
+			}
+		}
+		return result
+	}
+
+	for _, option := range options {
+		if option.Value == "" {
+			if !applied[option.Option] {
+				continue
+			}
+			if err := monStore.Delete(option.Who, option.Option); err != nil {
+				return setOptions(), errors.Wrapf(err, "failed to remove recovery option %q", option.Option)
+			}
+			delete(applied, option.Option)
+			continue
+		}
+		if err := monStore.Set(option.Who, option.Option, option.Value); err != nil {
+			return setOptions(), errors.Wrapf(err, "failed to set recovery option %q to %q", option.Option, option.Value)
+		}
+		applied[option.Option] = true
+	}
+	return setOptions(), nil
+}
+
+// ConfigureRecovery applies the recovery profile and limits of the cluster spec to the OSDs and reports the
+// active profile in the cluster status
+func (c *Cluster) ConfigureRecovery() error {
+	options, err := recoveryOptions(c.spec.Recovery, c.clusterInfo.CephVersion)
+	if err != nil {
+		return errors.Wrap(err, "failed to generate the recovery options")
+	}
+
+	cephCluster := &cephv1.CephCluster{}
+	if err := c.context.Client.Get(context.TODO(), c.clusterInfo.NamespacedName(), cephCluster); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return nil
+		}
+		return errors.Wrapf(err, "failed to retrieve ceph cluster %q to configure the recovery", c.clusterInfo.NamespacedName().Name)
+	}
+	previous := []string{}
+	if cephCluster.Status.Recovery != nil {
+		previous = cephCluster.Status.Recovery.Options
+	}
+
+	monStore := opconfig.GetMonStore(c.context, c.clusterInfo)
+	applied, applyErr := applyRecoveryOptions(monStore, options, previous)
+	status := &cephv1.RecoveryStatus{Options: applied}
+	if applyErr == nil {
+		// The profile is only known to the OSDs running with the mClock scheduler
+		profile, err := monStore.Get(recoveryConfigWho, mclockProfileOption)
+		if err != nil {
+			logger.Debugf("failed to get the mclock profile of the osds, not reporting it. %v", err)
+		} else {
+			status.Profile = profile
+		}
+	} else if cephCluster.Status.Recovery != nil {
+		status.Profile = cephCluster.Status.Recovery.Profile
+	}
+	c.updateRecoveryStatus(cephCluster, status)
+	if applyErr != nil {
+		return errors.Wrap(applyErr, "failed to apply the recovery options")
+	}
+	return nil
+}
+
+// updateRecoveryStatus reports the recovery settings active on the OSDs in the cluster status
+func (c *Cluster) updateRecoveryStatus(cephCluster *cephv1.CephCluster, status *cephv1.RecoveryStatus) {
+	if reflect.DeepEqual(cephCluster.Status.Recovery, status) {
+		return
+	}
+	cephCluster.Status.Recovery = status
+	if err := opcontroller.UpdateStatus(c.context.Client, cephCluster); err != nil {
+		logger.Errorf("failed to update cluster %q recovery status. %v", c.clusterInfo.NamespacedName().Name, err)
+		return
+	}
+	logger.Infof("osd recovery profile is %q", status.Profile)
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	cephver "github.com/rook/rook/pkg/operator/ceph/version"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRecoveryOptions(t *testing.T) {
+	quincy := cephver.CephVersion{Major: 17, Minor: 2, Extra: 6}
+
+	// nothing set, all the options are removed
+	options, err := recoveryOptions(cephv1.RecoverySpec{}, quincy)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, len(options))
+	for _, option := range options {
+		assert.Equal(t, "osd", option.Who)
+		assert.Equal(t, "", option.Value)
+	}
+
+	options, err = recoveryOptions(cephv1.RecoverySpec{
+		Profile: cephv1.RecoveryProfileHighClientOps,
+		Limits:  &cephv1.RecoveryLimitsSpec{MaxBackfills: 1, SleepMilliseconds: 100},
+	}, quincy)
+	assert.NoError(t, err)
+	assert.Equal(t, []opconfig.Option{
+		{Who: "osd", Option: "osd_mclock_profile", Value: "high_client_ops"},
+		{Who: "osd", Option: "osd_mclock_scheduler_background_recovery_lim", Value: ""},
+		{Who: "osd", Option: "osd_mclock_override_recovery_settings", Value: "true"},
+		{Who: "osd", Option: "osd_max_backfills", Value: "1"},
+		{Who: "osd", Option: "osd_recovery_max_active", Value: ""},
+		{Who: "osd", Option: "osd_recovery_sleep", Value: "0.1"},
+	}, options)
+
+	// the override does not exist in older versions
+	options, err = recoveryOptions(cephv1.RecoverySpec{
+		Limits: &cephv1.RecoveryLimitsSpec{MaxActive: 2},
+	}, cephver.Pacific)
+	assert.NoError(t, err)
+	assert.Equal(t, "", options[2].Value)
+	assert.Equal(t, "2", options[4].Value)
+
+	// the bandwidth limit switches to the custom profile
+	options, err = recoveryOptions(cephv1.RecoverySpec{
+		Limits: &cephv1.RecoveryLimitsSpec{MaxBandwidthPercent: 30},
+	}, quincy)
+	assert.NoError(t, err)
+	assert.Equal(t, "custom", options[0].Value)
+	assert.Equal(t, "0.3", options[1].Value)
+
+	// the bandwidth limit is not applied on older versions
+	options, err = recoveryOptions(cephv1.RecoverySpec{
+		Limits: &cephv1.RecoveryLimitsSpec{MaxBandwidthPercent: 30},
+	}, cephver.Pacific)
+	assert.NoError(t, err)
+	assert.Equal(t, "", options[0].Value)
+	assert.Equal(t, "", options[1].Value)
+
+	// the bandwidth limit conflicts with a profile
+	_, err = recoveryOptions(cephv1.RecoverySpec{
+		Profile: cephv1.RecoveryProfileBalanced,
+		Limits:  &cephv1.RecoveryLimitsSpec{MaxBandwidthPercent: 30},
+	}, quincy)
+	assert.Error(t, err)
+}
+
+func TestConfigureRecovery(t *testing.T) {
+	ctx := context.TODO()
+	clusterInfo := cephclient.AdminClusterInfo("ns")
+	clusterInfo.SetName("test")
+	clusterInfo.CephVersion = cephver.Pacific
+	cephCluster := &cephv1.CephCluster{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "ns"}}
+	// an admin set the recovery sleep, the operator previously set the max active
+	cephCluster.Status.Recovery = &cephv1.RecoveryStatus{Options: []string{"osd_recovery_max_active"}}
+	cl := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects([]runtime.Object{cephCluster}...).Build()
+
+	commands := []string{}
+	getErr := false
+	setErr := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			commands = append(commands, strings.Join(args[:4], " "))
+			if args[1] == "get" {
+				if getErr {
+					return "", errors.New("unrecognized option")
+				}
+				return "high_client_ops", nil
+			}
+			if args[1] == "set" && setErr {
+				return "", errors.New("failed to set")
+			}
+			return "", nil
+		},
+	}
+	context := &clusterd.Context{Executor: executor, Client: cl}
+	spec := cephv1.ClusterSpec{Recovery: cephv1.RecoverySpec{
+		Profile: cephv1.RecoveryProfileHighClientOps,
+		Limits:  &cephv1.RecoveryLimitsSpec{MaxBackfills: 1},
+	}}
+	c := New(context, clusterInfo, spec, "myversion")
+
+	err := c.ConfigureRecovery()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"config set osd osd_mclock_profile",
+		"config set osd osd_max_backfills",
+		"config rm osd osd_recovery_max_active",
+		"config get osd osd_mclock_profile",
+	}, commands)
+	cephCluster = &cephv1.CephCluster{}
+	err = cl.Get(ctx, clusterInfo.NamespacedName(), cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "high_client_ops", cephCluster.Status.Recovery.Profile)
+	assert.Equal(t, []string{"osd_mclock_profile", "osd_max_backfills"}, cephCluster.Status.Recovery.Options)
+
+	// the options set by the operator are recorded even when applying the spec fails
+	commands = []string{}
+	setErr = true
+	c.spec.Recovery = cephv1.RecoverySpec{Limits: &cephv1.RecoveryLimitsSpec{SleepMilliseconds: 100}}
+	err = c.ConfigureRecovery()
+	assert.Error(t, err)
+	assert.Equal(t, []string{
+		"config rm osd osd_mclock_profile",
+		"config rm osd osd_max_backfills",
+		"config set osd osd_recovery_sleep",
+	}, commands)
+	cephCluster = &cephv1.CephCluster{}
+	err = cl.Get(ctx, clusterInfo.NamespacedName(), cephCluster)
+	assert.NoError(t, err)
+	assert.Nil(t, cephCluster.Status.Recovery.Options)
+
+	// only the options set by the operator are removed, the profile is not reported when unknown to the osds
+	commands = []string{}
+	setErr = false
+	getErr = true
+	c.spec.Recovery = cephv1.RecoverySpec{}
+	err = c.ConfigureRecovery()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"config get osd osd_mclock_profile"}, commands)
+	cephCluster = &cephv1.CephCluster{}
+	err = cl.Get(ctx, clusterInfo.NamespacedName(), cephCluster)
+	assert.NoError(t, err)
+	assert.Equal(t, "", cephCluster.Status.Recovery.Profile)
+}
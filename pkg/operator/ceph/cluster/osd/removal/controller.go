@@ -0,0 +1,198 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package removal drains and removes the OSDs listed in a CephOSDRemoval
+package removal
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-osd-removal-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephOSDRemovalKind = reflect.TypeOf(cephv1.CephOSDRemoval{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephOSDRemovalKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// The progress of the removal is checked again after this interval until all the OSDs are removed
+var waitForRequeueIfRemovalInProgress = reconcile.Result{Requeue: true, RequeueAfter: 30 * time.Second}
+
+// ReconcileOSDRemoval reconciles a CephOSDRemoval object
+type ReconcileOSDRemoval struct {
+	client      client.Client
+	scheme      *runtime.Scheme
+	context     *clusterd.Context
+	clusterInfo *cephclient.ClusterInfo
+}
+
+// Add creates a new CephOSDRemoval Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	if err := cephv1.AddToScheme(mgr.GetScheme()); err != nil {
+		panic(err)
+	}
+
+	return &ReconcileOSDRemoval{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephOSDRemoval CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephOSDRemoval{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephOSDRemoval object and makes changes based on the state read
+// and what is in the CephOSDRemoval.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileOSDRemoval) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileOSDRemoval) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephOSDRemoval instance
+	osdRemoval := &cephv1.CephOSDRemoval{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, osdRemoval)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephOSDRemoval resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get CephOSDRemoval")
+	}
+
+	// Nothing to do once the CR is deleted, the OSDs already marked out remain out
+	if !osdRemoval.GetDeletionTimestamp().IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	// The CR was just created, initializing status fields
+	if osdRemoval.Status == nil {
+		updateStatus(r.client, request.NamespacedName, &cephv1.OSDRemovalStatus{Phase: cephv1.ConditionProgressing})
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, _, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, request.NamespacedName.Namespace)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	status, err := r.removeOSDs(osdRemoval)
+	if err != nil {
+		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+			logger.Info("skipping reconcile since operator is still initializing")
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+		}
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to remove osds of %q", osdRemoval.Name)
+	}
+	updateStatus(r.client, request.NamespacedName, status)
+
+	if status.Phase == cephv1.ConditionProgressing {
+		logger.Debugf("removal of osds of %q in progress. %s", osdRemoval.Name, status.Message)
+		return waitForRequeueIfRemovalInProgress, nil
+	}
+
+	// Return and do not requeue
+	logger.Infof("done removing osds of %q. %s", osdRemoval.Name, status.Message)
+	return reconcile.Result{}, nil
+}
+
+// updateStatus updates an object with a given status
+func updateStatus(client client.Client, name types.NamespacedName, status *cephv1.OSDRemovalStatus) {
+	osdRemoval := &cephv1.CephOSDRemoval{}
+	if err := client.Get(context.TODO(), name, osdRemoval); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephOSDRemoval resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve osd removal %q to update status to %q. %v", name, status.Phase, err)
+		return
+	}
+
+	osdRemoval.Status = status
+	if err := opcontroller.UpdateStatus(client, osdRemoval); err != nil {
+		logger.Errorf("failed to set osd removal %q status to %q. %v", name, status.Phase, err)
+		return
+	}
+	logger.Debugf("osd removal %q status updated to %q", name, status.Phase)
+}
@@ -0,0 +1,301 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package removal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+type fakeOSD struct {
+	host string
+	up   int
+	in   int
+	pgs  int
+}
+
+// fakeOSDMap answers the ceph commands used to remove OSDs
+type fakeOSDMap map[int]*fakeOSD
+
+func (m fakeOSDMap) ids() []int {
+	ids := []int{}
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (m fakeOSDMap) executor() *exectest.MockExecutor {
+	return &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "status" {
+				return `{"fsid":"c47cac40-9bee-4d52-823b-ccd803ba5bfe","health":{"checks":{},"status":"HEALTH_OK"},"pgmap":{"num_pgs":100,"pgs_by_state":[{"state_name":"active+clean","count":100}]}}`, nil
+			}
+			if args[0] != "osd" {
+				return "", nil
+			}
+			switch args[1] {
+			case "dump":
+				osds := []map[string]int{}
+				for _, id := range m.ids() {
+					osds = append(osds, map[string]int{"osd": id, "up": m[id].up, "in": m[id].in})
+				}
+				out, _ := json.Marshal(map[string]interface{}{"osds": osds})
+				return string(out), nil
+			case "df":
+				nodes := []map[string]int{}
+				for _, id := range m.ids() {
+					nodes = append(nodes, map[string]int{"id": id, "pgs": m[id].pgs})
+				}
+				out, _ := json.Marshal(map[string]interface{}{"nodes": nodes})
+				return string(out), nil
+			case "tree":
+				hosts := map[string][]int{}
+				for _, id := range m.ids() {
+					hosts[m[id].host] = append(hosts[m[id].host], id)
+				}
+				nodes := []map[string]interface{}{}
+				for host, children := range hosts {
+					nodes = append(nodes, map[string]interface{}{"name": host, "type": "host", "children": children})
+				}
+				out, _ := json.Marshal(map[string]interface{}{"nodes": nodes})
+				return string(out), nil
+			case "find":
+				id, _ := strconv.Atoi(args[2])
+				return fmt.Sprintf(`{"osd":%d,"crush_location":{"host":%q}}`, id, m[id].host), nil
+			case "out":
+				id, _ := strconv.Atoi(args[2])
+				m[id].in = 0
+				return "", nil
+			case "safe-to-destroy":
+				id, _ := strconv.Atoi(args[2])
+				if m[id].up == 0 && m[id].pgs == 0 {
+					return fmt.Sprintf(`{"safe_to_destroy":[%d]}`, id), nil
+				}
+				return `{"safe_to_destroy":[]}`, nil
+			case "purge":
+				var id int
+				_, _ = fmt.Sscanf(args[2], "osd.%d", &id)
+				delete(m, id)
+				return "", nil
+			}
+			return "", nil
+		},
+	}
+}
+
+func TestCephOSDRemovalController(t *testing.T) {
+	ctx := context.TODO()
+	namespace := "rook-ceph"
+
+	osdRemoval := &cephv1.CephOSDRemoval{
+		ObjectMeta: metav1.ObjectMeta{Name: "remove", Namespace: namespace},
+		Spec:       cephv1.OSDRemovalSpec{OSDs: []int{0}, Hosts: []string{"node2"}},
+		TypeMeta:   controllerTypeMeta,
+	}
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Status: cephv1.ClusterStatus{
+			Phase:      k8sutil.ReadyStatus,
+			CephStatus: &cephv1.CephStatus{Health: "HEALTH_OK"},
+		},
+	}
+	object := []runtime.Object{osdRemoval, cephCluster}
+
+	osds := fakeOSDMap{
+		0: {host: "node1", up: 1, in: 1, pgs: 10},
+		1: {host: "node2", up: 0, in: 1, pgs: 5},
+		2: {host: "node3", up: 1, in: 1, pgs: 10},
+	}
+	clientset := test.New(t, 3)
+	c := &clusterd.Context{
+		Executor:      osds.executor(),
+		RookClientset: rookclient.NewSimpleClientset(),
+		Clientset:     clientset,
+	}
+
+	// Mock clusterInfo
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: namespace},
+		Data: map[string][]byte{
+			"fsid":         []byte("fsid"),
+			"mon-secret":   []byte("monsecret"),
+			"admin-secret": []byte("adminsecret"),
+		},
+		Type: k8sutil.RookType,
+	}
+	_, err := clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// osd.0 runs on a node, osd.1 runs on a PVC
+	for _, d := range []*apps.Deployment{
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0", Namespace: namespace, Labels: map[string]string{osd.OsdIdLabelKey: "0"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1", Namespace: namespace, Labels: map[string]string{osd.OsdIdLabelKey: "1", osd.OSDOverPVCLabelKey: "pvc1"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-2", Namespace: namespace, Labels: map[string]string{osd.OsdIdLabelKey: "2"}}},
+	} {
+		_, err = clientset.AppsV1().Deployments(namespace).Create(ctx, d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: namespace}}
+	_, err = clientset.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephOSDRemoval{})
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
+	r := &ReconcileOSDRemoval{client: cl, scheme: s, context: c}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "remove", Namespace: namespace}}
+
+	// The OSDs are marked out and drain
+	res, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	err = cl.Get(ctx, req.NamespacedName, osdRemoval)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.ConditionProgressing, osdRemoval.Status.Phase)
+	assert.Equal(t, 2, len(osdRemoval.Status.OSDs))
+	assert.Equal(t, cephv1.OSDRemovalProgress{ID: 0, Host: "node1", Phase: cephv1.OSDRemovalDraining, PGsRemaining: 10, Message: "waiting for 10 placement groups to move to other osds"}, osdRemoval.Status.OSDs[0])
+	assert.Equal(t, cephv1.OSDRemovalProgress{ID: 1, Host: "node2", Phase: cephv1.OSDRemovalDraining, PGsRemaining: 5, Message: "waiting for osd.1 to be safe to destroy"}, osdRemoval.Status.OSDs[1])
+	assert.Equal(t, 0, osds[0].in)
+	assert.Equal(t, 0, osds[1].in)
+	assert.Equal(t, 1, osds[2].in)
+
+	// The drained OSD that is still up is stopped, the one already down is purged
+	osds[0].pgs = 0
+	osds[1].pgs = 0
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	err = cl.Get(ctx, req.NamespacedName, osdRemoval)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.OSDRemovalRemoving, osdRemoval.Status.OSDs[0].Phase)
+	assert.Equal(t, cephv1.OSDRemovalRemoved, osdRemoval.Status.OSDs[1].Phase)
+	assert.Equal(t, "1/2 osds removed", osdRemoval.Status.Message)
+	_, err = clientset.AppsV1().Deployments(namespace).Get(ctx, "rook-ceph-osd-0", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.AppsV1().Deployments(namespace).Get(ctx, "rook-ceph-osd-1", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, "pvc1", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, ok := osds[1]
+	assert.False(t, ok)
+
+	// The stopped OSD is purged
+	osds[0].up = 0
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	err = cl.Get(ctx, req.NamespacedName, osdRemoval)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.ConditionReady, osdRemoval.Status.Phase)
+	assert.Equal(t, "2/2 osds removed", osdRemoval.Status.Message)
+	assert.Equal(t, []int{2}, osds.ids())
+	_, err = clientset.AppsV1().Deployments(namespace).Get(ctx, "rook-ceph-osd-2", metav1.GetOptions{})
+	assert.NoError(t, err)
+}
+
+func TestPreservePVC(t *testing.T) {
+	ctx := context.TODO()
+	namespace := "rook-ceph"
+	clientset := test.New(t, 3)
+	r := &ReconcileOSDRemoval{context: &clusterd.Context{Clientset: clientset}, clusterInfo: cephclient.AdminClusterInfo(namespace)}
+
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-1", Namespace: namespace, Labels: map[string]string{osd.OsdIdLabelKey: "1", osd.OSDOverPVCLabelKey: "pvc1"}}}
+	_, err := clientset.AppsV1().Deployments(namespace).Create(ctx, d, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	pvc := &v1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: "pvc1", Namespace: namespace}}
+	_, err = clientset.CoreV1().PersistentVolumeClaims(namespace).Create(ctx, pvc, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	err = r.removeOSDResources(1, true)
+	assert.NoError(t, err)
+	_, err = clientset.AppsV1().Deployments(namespace).Get(ctx, "rook-ceph-osd-1", metav1.GetOptions{})
+	assert.True(t, kerrors.IsNotFound(err))
+	_, err = clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, "pvc1", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	// Nothing left to remove
+	err = r.removeOSDResources(1, true)
+	assert.NoError(t, err)
+}
+
+func TestOSDsToRemove(t *testing.T) {
+	var osdTree cephclient.OsdTree
+	err := json.Unmarshal([]byte(`{"nodes":[{"id":-2,"name":"node1","type":"host","children":[0,1]},{"id":-3,"name":"node2-example-com","type":"host","children":[2]},{"id":0,"name":"osd.0","type":"osd"}]}`), &osdTree)
+	assert.NoError(t, err)
+
+	osds, hostsNotFound := osdsToRemove(cephv1.OSDRemovalSpec{OSDs: []int{1, 5}, Hosts: []string{"node2.example.com", "node4"}}, nil, osdTree)
+	assert.Equal(t, []cephv1.OSDRemovalProgress{
+		{ID: 1, Phase: cephv1.OSDRemovalPending},
+		{ID: 5, Phase: cephv1.OSDRemovalPending},
+		{ID: 2, Host: "node2-example-com", Phase: cephv1.OSDRemovalPending},
+	}, osds)
+	assert.Equal(t, []string{"node4"}, hostsNotFound)
+
+	// The OSDs tracked in the status are kept even once gone from the CRUSH map
+	status := &cephv1.OSDRemovalStatus{OSDs: []cephv1.OSDRemovalProgress{{ID: 3, Host: "node4", Phase: cephv1.OSDRemovalRemoved}}}
+	osds, hostsNotFound = osdsToRemove(cephv1.OSDRemovalSpec{OSDs: []int{0}, Hosts: []string{"node4"}}, status, osdTree)
+	assert.Equal(t, []cephv1.OSDRemovalProgress{
+		{ID: 3, Host: "node4", Phase: cephv1.OSDRemovalRemoved},
+		{ID: 0, Phase: cephv1.OSDRemovalPending},
+	}, osds)
+	assert.Equal(t, 0, len(hostsNotFound))
+}
+
+func TestRemovalStatus(t *testing.T) {
+	status := removalStatus([]cephv1.OSDRemovalProgress{{ID: 0, Phase: cephv1.OSDRemovalRemoved}, {ID: 1, Phase: cephv1.OSDRemovalDraining}}, nil)
+	assert.Equal(t, cephv1.ConditionProgressing, status.Phase)
+	assert.Equal(t, "1/2 osds removed", status.Message)
+
+	status = removalStatus([]cephv1.OSDRemovalProgress{{ID: 0, Phase: cephv1.OSDRemovalRemoved}, {ID: 1, Phase: cephv1.OSDRemovalFailed}}, nil)
+	assert.Equal(t, cephv1.ConditionFailure, status.Phase)
+
+	status = removalStatus([]cephv1.OSDRemovalProgress{{ID: 0, Phase: cephv1.OSDRemovalRemoved}}, []string{"node4"})
+	assert.Equal(t, cephv1.ConditionFailure, status.Phase)
+	assert.Equal(t, "1/1 osds removed, crush hosts not found: node4", status.Message)
+
+	status = removalStatus([]cephv1.OSDRemovalProgress{}, nil)
+	assert.Equal(t, cephv1.ConditionFailure, status.Phase)
+
+	status = removalStatus([]cephv1.OSDRemovalProgress{{ID: 0, Phase: cephv1.OSDRemovalRemoved}}, nil)
+	assert.Equal(t, cephv1.ConditionReady, status.Phase)
+}
@@ -0,0 +1,287 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package removal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	upStatus = 1
+	inStatus = 1
+)
+
+// removeOSDs moves the removal of each OSD one step forward and returns the new status of the CephOSDRemoval
+func (r *ReconcileOSDRemoval) removeOSDs(osdRemoval *cephv1.CephOSDRemoval) (*cephv1.OSDRemovalStatus, error) {
+	osdDump, err := cephclient.GetOSDDump(r.context, r.clusterInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get osd dump")
+	}
+	osdUsage, err := cephclient.GetOSDUsage(r.context, r.clusterInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get osd usage")
+	}
+	osdTree, err := cephclient.HostTree(r.context, r.clusterInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get osd tree")
+	}
+
+	osds, hostsNotFound := osdsToRemove(osdRemoval.Spec, osdRemoval.Status, osdTree)
+	for i := range osds {
+		if osds[i].Phase == cephv1.OSDRemovalRemoved || osds[i].Phase == cephv1.OSDRemovalFailed {
+			continue
+		}
+		r.removeOSD(&osds[i], osdRemoval.Spec.PreservePVC, osdDump, osdUsage)
+	}
+
+	return removalStatus(osds, hostsNotFound), nil
+}
+
+// osdsToRemove returns the OSDs already tracked in the status followed by the new ones from the spec
+// and the hosts of the spec that are not found in the CRUSH map
+func osdsToRemove(spec cephv1.OSDRemovalSpec, status *cephv1.OSDRemovalStatus, osdTree cephclient.OsdTree) ([]cephv1.OSDRemovalProgress, []string) {
+	osds := []cephv1.OSDRemovalProgress{}
+	tracked := map[int]bool{}
+	if status != nil {
+		for _, p := range status.OSDs {
+			osds = append(osds, p)
+			tracked[p.ID] = true
+		}
+	}
+
+	for _, id := range spec.OSDs {
+		if !tracked[id] {
+			osds = append(osds, cephv1.OSDRemovalProgress{ID: id, Phase: cephv1.OSDRemovalPending})
+			tracked[id] = true
+		}
+	}
+
+	hostsNotFound := []string{}
+	for _, host := range spec.Hosts {
+		found := false
+		for _, node := range osdTree.Nodes {
+			if node.Type != "host" || !cephclient.IsNormalizedCrushNameEqual(host, node.Name) {
+				continue
+			}
+			found = true
+			for _, id := range node.Children {
+				if !tracked[id] {
+					osds = append(osds, cephv1.OSDRemovalProgress{ID: id, Host: node.Name, Phase: cephv1.OSDRemovalPending})
+					tracked[id] = true
+				}
+			}
+		}
+		// The bucket of the host goes away with its last OSD
+		for _, p := range osds {
+			if cephclient.IsNormalizedCrushNameEqual(host, p.Host) {
+				found = true
+			}
+		}
+		if !found {
+			hostsNotFound = append(hostsNotFound, host)
+		}
+	}
+
+	return osds, hostsNotFound
+}
+
+// removeOSD marks the OSD out, stops it once its placement groups moved to other OSDs and purges it once safe to destroy
+func (r *ReconcileOSDRemoval) removeOSD(p *cephv1.OSDRemovalProgress, preservePVC bool, osdDump *cephclient.OSDDump, osdUsage *cephclient.OSDUsage) {
+	up, in, err := osdDump.StatusByID(int64(p.ID))
+	if err != nil {
+		if p.Phase == cephv1.OSDRemovalPending {
+			p.Phase = cephv1.OSDRemovalFailed
+			p.Message = fmt.Sprintf("osd.%d does not exist", p.ID)
+			return
+		}
+		// The OSD was purged since the last check
+		p.Phase = cephv1.OSDRemovalRemoved
+		p.PGsRemaining = 0
+		p.Message = ""
+		return
+	}
+
+	if p.Host == "" {
+		p.Host, err = cephclient.GetCrushHostName(r.context, r.clusterInfo, p.ID)
+		if err != nil {
+			logger.Warningf("failed to get the crush host of osd.%d. %v", p.ID, err)
+		}
+	}
+
+	if in == inStatus {
+		logger.Infof("marking osd.%d out", p.ID)
+		if _, err := cephclient.OSDOut(r.context, r.clusterInfo, p.ID); err != nil {
+			p.Message = fmt.Sprintf("failed to mark osd.%d out. %v", p.ID, err)
+			return
+		}
+	}
+	if p.Phase == cephv1.OSDRemovalPending {
+		p.Phase = cephv1.OSDRemovalDraining
+	}
+	p.PGsRemaining = pgsOnOSD(osdUsage, p.ID)
+
+	if up == upStatus {
+		if p.PGsRemaining > 0 {
+			p.Message = fmt.Sprintf("waiting for %d placement groups to move to other osds", p.PGsRemaining)
+			return
+		}
+		// The OSD holds no data anymore so it can be stopped
+		if err := r.removeOSDResources(p.ID, preservePVC); err != nil {
+			p.Message = err.Error()
+			return
+		}
+		p.Phase = cephv1.OSDRemovalRemoving
+		p.Message = fmt.Sprintf("waiting for osd.%d to stop", p.ID)
+		return
+	}
+
+	safeToDestroy, err := cephclient.OsdSafeToDestroy(r.context, r.clusterInfo, p.ID)
+	if err != nil {
+		p.Message = err.Error()
+		return
+	}
+	if !safeToDestroy {
+		p.Message = fmt.Sprintf("waiting for osd.%d to be safe to destroy", p.ID)
+		return
+	}
+
+	// The OSD may have been down from the start so its deployment is still there
+	if err := r.removeOSDResources(p.ID, preservePVC); err != nil {
+		p.Message = err.Error()
+		return
+	}
+	logger.Infof("purging osd.%d", p.ID)
+	if err := cephclient.PurgeOSD(r.context, r.clusterInfo, p.ID); err != nil {
+		p.Message = err.Error()
+		return
+	}
+	// Errors can be ignored if there are other OSDs on the same host
+	if p.Host != "" {
+		if err := cephclient.RemoveCrushBucket(r.context, r.clusterInfo, p.Host); err != nil {
+			logger.Debugf("did not remove crush host %q. %v", p.Host, err)
+		}
+	}
+
+	p.Phase = cephv1.OSDRemovalRemoved
+	p.PGsRemaining = 0
+	p.Message = ""
+	logger.Infof("removed osd.%d", p.ID)
+}
+
+// removeOSDResources removes the deployment of the OSD and, if it runs on PVC, its prepare jobs and its PVC
+func (r *ReconcileOSDRemoval) removeOSDResources(osdID int, preservePVC bool) error {
+	ctx := context.TODO()
+	namespace := r.clusterInfo.Namespace
+	label := fmt.Sprintf("%s=%d", osd.OsdIdLabelKey, osdID)
+	deployments, err := k8sutil.GetDeployments(r.context.Clientset, namespace, label)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get the deployment of osd.%d", osdID)
+	}
+
+	for _, deployment := range deployments.Items {
+		logger.Infof("removing the deployment %q of osd.%d", deployment.Name, osdID)
+		if err := k8sutil.DeleteDeployment(r.context.Clientset, namespace, deployment.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete the deployment %q of osd.%d", deployment.Name, osdID)
+		}
+
+		pvcName, ok := deployment.GetLabels()[osd.OSDOverPVCLabelKey]
+		if !ok {
+			continue
+		}
+		labelSelector := fmt.Sprintf("%s=%s", osd.OSDOverPVCLabelKey, pvcName)
+		prepareJobs, err := r.context.Clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+		if err != nil {
+			return errors.Wrapf(err, "failed to list the prepare jobs of osd.%d", osdID)
+		}
+		for _, prepareJob := range prepareJobs.Items {
+			logger.Infof("removing the prepare job %q of osd.%d", prepareJob.Name, osdID)
+			if err := k8sutil.DeleteBatchJob(r.context.Clientset, namespace, prepareJob.Name, false); err != nil {
+				return errors.Wrapf(err, "failed to delete the prepare job %q of osd.%d", prepareJob.Name, osdID)
+			}
+		}
+
+		if preservePVC {
+			logger.Infof("preserving the pvc %q of osd.%d", pvcName, osdID)
+			continue
+		}
+		logger.Infof("removing the pvc %q of osd.%d", pvcName, osdID)
+		err = r.context.Clientset.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, pvcName, metav1.DeleteOptions{})
+		if err != nil && !kerrors.IsNotFound(err) {
+			return errors.Wrapf(err, "failed to delete the pvc %q of osd.%d", pvcName, osdID)
+		}
+	}
+
+	return nil
+}
+
+// pgsOnOSD returns the number of placement groups mapped to the OSD
+func pgsOnOSD(osdUsage *cephclient.OSDUsage, osdID int) int {
+	for _, node := range osdUsage.OSDNodes {
+		if node.ID != osdID {
+			continue
+		}
+		pgs, err := node.Pgs.Int64()
+		if err != nil {
+			return 0
+		}
+		return int(pgs)
+	}
+
+	return 0
+}
+
+// removalStatus summarizes the progress of the removal of each OSD
+func removalStatus(osds []cephv1.OSDRemovalProgress, hostsNotFound []string) *cephv1.OSDRemovalStatus {
+	removed, failed := 0, 0
+	for _, p := range osds {
+		switch p.Phase {
+		case cephv1.OSDRemovalRemoved:
+			removed++
+		case cephv1.OSDRemovalFailed:
+			failed++
+		}
+	}
+
+	status := &cephv1.OSDRemovalStatus{
+		OSDs:    osds,
+		Message: fmt.Sprintf("%d/%d osds removed", removed, len(osds)),
+	}
+	if len(hostsNotFound) != 0 {
+		status.Message = fmt.Sprintf("%s, crush hosts not found: %s", status.Message, strings.Join(hostsNotFound, ", "))
+	}
+
+	switch {
+	case removed+failed < len(osds):
+		status.Phase = cephv1.ConditionProgressing
+	case failed != 0 || len(hostsNotFound) != 0 || len(osds) == 0:
+		status.Phase = cephv1.ConditionFailure
+	default:
+		status.Phase = cephv1.ConditionReady
+	}
+
+	return status
+}
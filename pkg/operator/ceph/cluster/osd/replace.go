@@ -0,0 +1,285 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	discoverDaemon "github.com/rook/rook/pkg/daemon/discover"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	osdReplacementsMapName = "rook-ceph-osd-%s-replacements"
+)
+
+// osdReplacement is a down OSD and the blank device that replaces its device
+type osdReplacement struct {
+	deployment apps.Deployment
+	// device is the path of the new device the OSD is re-created on
+	device string
+}
+
+// replaceSwappedOSDs destroys the OSDs whose device was swapped with a new one. The OSD ID and CRUSH location of a
+// destroyed OSD are kept, the prepare job of the node re-creates the OSD on the new device with the same ID.
+func (c *Cluster) replaceSwappedOSDs() error {
+	nodeDevices, err := c.discoveredDevices()
+	if err != nil {
+		return errors.Wrap(err, "failed to get the devices discovered on the nodes")
+	}
+	if len(nodeDevices) == 0 {
+		logger.Debug("no devices discovered on the nodes, the discovery daemon may be disabled")
+		return nil
+	}
+
+	deployments, err := k8sutil.GetDeployments(c.context.Clientset, c.clusterInfo.Namespace, fmt.Sprintf("%s=%s", k8sutil.AppAttr, AppName))
+	if err != nil {
+		return errors.Wrap(err, "failed to get osd deployments")
+	}
+
+	osdDump, err := client.GetOSDDump(c.context, c.clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to get osd dump")
+	}
+
+	for host := range nodeDevices {
+		if err := c.clearCompletedOSDReplacements(host, deployments.Items, osdDump); err != nil {
+			logger.Warningf("failed to clear the completed osd replacements of node %q. %v", host, err)
+		}
+	}
+
+	for _, replacement := range swappedOSDDeployments(deployments.Items, osdDump, nodeDevices) {
+		d := replacement.deployment
+		id, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the id of osd deployment %q", d.Name)
+		}
+
+		safe, err := client.OsdSafeToDestroy(c.context, c.clusterInfo, id)
+		if err != nil {
+			return errors.Wrapf(err, "failed to check if osd.%d is safe to destroy", id)
+		}
+		if !safe {
+			logger.Infof("the device of osd.%d was swapped but the osd is not safe to destroy yet, waiting for its data to be recovered", id)
+			continue
+		}
+
+		// The new device is recorded first so the prepare job of the node only re-creates the OSD on it
+		host := d.Spec.Template.Spec.NodeSelector[v1.LabelHostname]
+		storeName := k8sutil.TruncateNodeName(osdReplacementsMapName, host)
+		if err := c.kv.SetValue(storeName, strconv.Itoa(id), replacement.device); err != nil {
+			return errors.Wrapf(err, "failed to record the new device of osd.%d", id)
+		}
+
+		logger.Infof("the device of osd.%d was swapped with %q, destroying the osd so it is re-created on the new device", id, replacement.device)
+		if err := client.DestroyOSD(c.context, c.clusterInfo, id); err != nil {
+			return err
+		}
+		if err := k8sutil.DeleteDeployment(c.context.Clientset, d.Namespace, d.Name); err != nil {
+			return errors.Wrapf(err, "failed to delete the deployment of destroyed osd.%d", id)
+		}
+	}
+
+	return nil
+}
+
+// osdReplacements returns the IDs of the destroyed OSDs of the node by the path of their new device
+func (c *Cluster) osdReplacements(host string) (map[string]int, error) {
+	replacements, err := c.kv.GetStore(k8sutil.TruncateNodeName(osdReplacementsMapName, host))
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	replaceOSDs := map[string]int{}
+	for osdID, device := range replacements {
+		id, err := strconv.Atoi(osdID)
+		if err != nil {
+			logger.Warningf("skipping the replacement of unknown osd %q. %v", osdID, err)
+			continue
+		}
+		replaceOSDs[device] = id
+	}
+	return replaceOSDs, nil
+}
+
+// clearCompletedOSDReplacements removes the replacements of the node once all the destroyed OSDs were re-created or
+// purged
+func (c *Cluster) clearCompletedOSDReplacements(host string, deployments []apps.Deployment, osdDump *client.OSDDump) error {
+	replaceOSDs, err := c.osdReplacements(host)
+	if err != nil || len(replaceOSDs) == 0 {
+		return err
+	}
+
+	osdDeployments := map[string]bool{}
+	for _, d := range deployments {
+		osdDeployments[d.Labels[OsdIdLabelKey]] = true
+	}
+	for _, id := range replaceOSDs {
+		if _, _, err := osdDump.StatusByID(int64(id)); err != nil {
+			continue
+		}
+		if !osdDeployments[strconv.Itoa(id)] {
+			return nil
+		}
+	}
+
+	logger.Infof("the destroyed osds of node %q were re-created", host)
+	return c.kv.ClearStore(k8sutil.TruncateNodeName(osdReplacementsMapName, host))
+}
+
+// swappedOSDDeployments returns the down OSDs whose device was swapped, with the blank device that replaces it. A
+// device only replaces the device of an OSD if it is in the same slot, or if it is the same device once wiped.
+func swappedOSDDeployments(deployments []apps.Deployment, osdDump *client.OSDDump, nodeDevices map[string][]sys.LocalDisk) []osdReplacement {
+	// The devices of the running OSDs are never blank, but keep them out in case the discovery is late
+	serialsInUse := map[string]bool{}
+	for _, d := range deployments {
+		if serial := deploymentEnvVar(d, deviceSerialVarName); serial != "" {
+			serialsInUse[serial] = true
+		}
+	}
+
+	swapped := []osdReplacement{}
+	for _, d := range deployments {
+		serial := deploymentEnvVar(d, deviceSerialVarName)
+		devicePath := deploymentEnvVar(d, devicePathVarName)
+		if serial == "" || d.Labels[OSDOverPVCLabelKey] != "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(d.Labels[OsdIdLabelKey])
+		if err != nil {
+			continue
+		}
+		up, _, err := osdDump.StatusByID(int64(id))
+		if err != nil || up == upStatus {
+			continue
+		}
+
+		devices, ok := nodeDevices[d.Spec.Template.Spec.NodeSelector[v1.LabelHostname]]
+		if !ok {
+			continue
+		}
+		var newDevice *sys.LocalDisk
+		deviceFound := false
+		for i, device := range devices {
+			if device.Type != sys.DiskType {
+				continue
+			}
+			if device.Serial == serial {
+				if !device.Empty {
+					deviceFound = true
+					continue
+				}
+				// the device of the osd was wiped
+				newDevice = &devices[i]
+				continue
+			}
+			if devicePath != "" && device.Empty && !serialsInUse[device.Serial] && hasDevLink(device, devicePath) {
+				newDevice = &devices[i]
+			}
+		}
+		if deviceFound {
+			logger.Debugf("osd.%d is down but its device %q is still on the node", id, serial)
+			continue
+		}
+		if newDevice == nil {
+			logger.Infof("osd.%d is down and its device %q is gone, waiting for a new device in its slot %q to replace it", id, serial, devicePath)
+			continue
+		}
+
+		swapped = append(swapped, osdReplacement{deployment: d, device: localDevicePath(*newDevice)})
+	}
+
+	return swapped
+}
+
+func deploymentEnvVar(d apps.Deployment, name string) string {
+	for _, envVar := range d.Spec.Template.Spec.Containers[0].Env {
+		if envVar.Name == name {
+			return envVar.Value
+		}
+	}
+	return ""
+}
+
+func hasDevLink(device sys.LocalDisk, devLink string) bool {
+	for _, link := range strings.Fields(device.DevLinks) {
+		if link == devLink {
+			return true
+		}
+	}
+	return false
+}
+
+// localDevicePath returns the by-path link of the device, or its name under /dev when it has none
+func localDevicePath(device sys.LocalDisk) string {
+	for _, link := range strings.Fields(device.DevLinks) {
+		if strings.HasPrefix(link, "/dev/disk/by-path/") {
+			return link
+		}
+	}
+	return path.Join("/dev", device.Name)
+}
+
+// discoveredDevices returns the devices of each node by hostname, as reported by the discovery daemon
+func (c *Cluster) discoveredDevices() (map[string][]sys.LocalDisk, error) {
+	ctx := context.TODO()
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s", k8sutil.AppAttr, discoverDaemon.AppName)}
+	cms, err := c.context.Clientset.CoreV1().ConfigMaps(os.Getenv(k8sutil.PodNamespaceEnvVar)).List(ctx, listOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list device configmaps")
+	}
+
+	nodeDevices := map[string][]sys.LocalDisk{}
+	for _, cm := range cms.Items {
+		nodeName := cm.Labels[discoverDaemon.NodeAttr]
+		deviceJSON := cm.Data[discoverDaemon.LocalDiskCMData]
+		if nodeName == "" || deviceJSON == "" {
+			continue
+		}
+		var devices []sys.LocalDisk
+		if err := json.Unmarshal([]byte(deviceJSON), &devices); err != nil {
+			logger.Warningf("failed to unmarshal the devices of node %q. %v", nodeName, err)
+			continue
+		}
+
+		hostname, err := k8sutil.GetNodeHostName(c.context.Clientset, nodeName)
+		if err != nil {
+			logger.Warningf("failed to get the hostname of node %q. %v", nodeName, err)
+			continue
+		}
+		nodeDevices[hostname] = devices
+	}
+
+	return nodeDevices, nil
+}
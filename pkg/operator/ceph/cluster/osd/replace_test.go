@@ -0,0 +1,149 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util/sys"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func osdDeploymentOnDevice(id, node, serial, devicePath string) apps.Deployment {
+	return apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-" + id, Labels: map[string]string{OsdIdLabelKey: id}},
+		Spec: apps.DeploymentSpec{
+			Template: v1.PodTemplateSpec{
+				Spec: v1.PodSpec{
+					NodeSelector: map[string]string{v1.LabelHostname: node},
+					Containers:   []v1.Container{{Env: []v1.EnvVar{deviceSerialEnvVar(serial), devicePathEnvVar(devicePath)}}},
+				},
+			},
+		},
+	}
+}
+
+func TestSwappedOSDDeployments(t *testing.T) {
+	osdDump := &client.OSDDump{}
+	err := json.Unmarshal([]byte(`{"osds":[{"osd":0,"up":1,"in":1},{"osd":1,"up":0,"in":0},{"osd":2,"up":0,"in":1}]}`), osdDump)
+	assert.NoError(t, err)
+
+	deployments := []apps.Deployment{
+		osdDeploymentOnDevice("0", "node-a", "serial-0", "/dev/disk/by-path/pci-0"),
+		osdDeploymentOnDevice("1", "node-a", "serial-1", "/dev/disk/by-path/pci-1"),
+		osdDeploymentOnDevice("2", "node-b", "serial-2", "/dev/disk/by-path/pci-2"),
+	}
+	osd0Disk := sys.LocalDisk{Name: "sda", Type: sys.DiskType, Serial: "serial-0", DevLinks: "/dev/disk/by-path/pci-0"}
+	osd1Disk := sys.LocalDisk{Name: "sdb", Type: sys.DiskType, Serial: "serial-1", DevLinks: "/dev/disk/by-path/pci-1"}
+	newDiskInSlot := sys.LocalDisk{Name: "sdb", Type: sys.DiskType, Serial: "serial-new", Empty: true, DevLinks: "/dev/disk/by-id/wwn-new /dev/disk/by-path/pci-1"}
+	newDiskElsewhere := sys.LocalDisk{Name: "sdc", Type: sys.DiskType, Serial: "serial-other", Empty: true, DevLinks: "/dev/disk/by-path/pci-3"}
+
+	// the device of the down osd is still on the node
+	nodeDevices := map[string][]sys.LocalDisk{
+		"node-a": {osd0Disk, osd1Disk, newDiskElsewhere},
+	}
+	assert.Equal(t, 0, len(swappedOSDDeployments(deployments, osdDump, nodeDevices)))
+
+	// the device of the down osd is gone but no new device showed up yet
+	nodeDevices = map[string][]sys.LocalDisk{
+		"node-a": {osd0Disk},
+	}
+	assert.Equal(t, 0, len(swappedOSDDeployments(deployments, osdDump, nodeDevices)))
+
+	// a blank device in another slot never replaces the device of the osd
+	nodeDevices = map[string][]sys.LocalDisk{
+		"node-a": {osd0Disk, newDiskElsewhere},
+	}
+	assert.Equal(t, 0, len(swappedOSDDeployments(deployments, osdDump, nodeDevices)))
+
+	// the device of the down osd was swapped in the same slot, the osd of the node without discovered devices is left alone
+	nodeDevices = map[string][]sys.LocalDisk{
+		"node-a": {osd0Disk, newDiskElsewhere, newDiskInSlot},
+	}
+	swapped := swappedOSDDeployments(deployments, osdDump, nodeDevices)
+	assert.Equal(t, 1, len(swapped))
+	assert.Equal(t, "rook-ceph-osd-1", swapped[0].deployment.Name)
+	assert.Equal(t, "/dev/disk/by-path/pci-1", swapped[0].device)
+
+	// the device of the down osd was wiped
+	wipedDisk := osd1Disk
+	wipedDisk.Empty = true
+	wipedDisk.DevLinks = ""
+	nodeDevices = map[string][]sys.LocalDisk{
+		"node-a": {osd0Disk, wipedDisk},
+	}
+	swapped = swappedOSDDeployments(deployments, osdDump, nodeDevices)
+	assert.Equal(t, 1, len(swapped))
+	assert.Equal(t, "/dev/sdb", swapped[0].device)
+
+	// the osd is up so its device was not swapped
+	nodeDevices = map[string][]sys.LocalDisk{
+		"node-a": {{Name: "sda", Type: sys.DiskType, Serial: "serial-new", Empty: true, DevLinks: "/dev/disk/by-path/pci-0"}, osd1Disk},
+	}
+	assert.Equal(t, 0, len(swappedOSDDeployments(deployments, osdDump, nodeDevices)))
+
+	// the osds created before the device serial was recorded are ignored
+	deployments[1].Spec.Template.Spec.Containers[0].Env = nil
+	nodeDevices = map[string][]sys.LocalDisk{
+		"node-a": {osd0Disk, newDiskInSlot},
+	}
+	assert.Equal(t, 0, len(swappedOSDDeployments(deployments, osdDump, nodeDevices)))
+}
+
+func TestOSDReplacements(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := client.AdminClusterInfo("ns")
+	clusterInfo.OwnerInfo = client.NewMinimumOwnerInfo(t)
+	c := &Cluster{kv: k8sutil.NewConfigMapKVStore("ns", clientset, clusterInfo.OwnerInfo)}
+
+	// no osd to replace on the node
+	replaceOSDs, err := c.osdReplacements("node-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(replaceOSDs))
+
+	storeName := k8sutil.TruncateNodeName(osdReplacementsMapName, "node-a")
+	assert.NoError(t, c.kv.SetValue(storeName, "1", "/dev/disk/by-path/pci-1"))
+	assert.NoError(t, c.kv.SetValue(storeName, "3", "/dev/sdc"))
+	replaceOSDs, err = c.osdReplacements("node-a")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"/dev/disk/by-path/pci-1": 1, "/dev/sdc": 3}, replaceOSDs)
+
+	osdDump := &client.OSDDump{}
+	err = json.Unmarshal([]byte(`{"osds":[{"osd":0,"up":1,"in":1},{"osd":1,"up":0,"in":1}]}`), osdDump)
+	assert.NoError(t, err)
+
+	// osd.1 was not re-created yet
+	deployments := []apps.Deployment{osdDeploymentOnDevice("0", "node-a", "serial-0", "")}
+	assert.NoError(t, c.clearCompletedOSDReplacements("node-a", deployments, osdDump))
+	replaceOSDs, err = c.osdReplacements("node-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(replaceOSDs))
+
+	// osd.1 was re-created and osd.3 was purged
+	deployments = append(deployments, osdDeploymentOnDevice("1", "node-a", "serial-new", ""))
+	assert.NoError(t, c.clearCompletedOSDReplacements("node-a", deployments, osdDump))
+	replaceOSDs, err = c.osdReplacements("node-a")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(replaceOSDs))
+}
@@ -47,6 +47,7 @@ const (
 	blockEncryptionOpenInitContainer              = "encryption-open"
 	blockEncryptionOpenMetadataInitContainer      = "encryption-open-metadata"
 	blockEncryptionOpenWalInitContainer           = "encryption-open-wal"
+	blockEncryptionOpenHostInitContainer          = "encryption-open-host"
 	blockPVCMapperEncryptionInitContainer         = "blkdevmapper-encryption"
 	blockPVCMapperEncryptionMetadataInitContainer = "blkdevmapper-metadata-encryption"
 	blockPVCMapperEncryptionWalInitContainer      = "blkdevmapper-wal-encryption"
@@ -151,6 +152,10 @@ if [ -b "$DM_PATH" ]; then
 else
 	open_encrypted_block
 fi
+`
+	activateHostEncryptedVolumeGroup = `
+# The logical volume of the osd sits on top of the encrypted device
+vgchange --activate y %s
 `
 	// #nosec G101 no leak just variable names
 	getKEKFromVaultWithToken = `
@@ -315,15 +320,15 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		volumes = append(volumes, getPVCOSDVolumes(&osdProps, c.context.ConfigDir, c.clusterInfo.Namespace, false)...)
 		// If encrypted let's add the secret key mount path
 		if osdProps.encrypted && osd.CVMode == "raw" {
-			encryptedVol, _ := c.getEncryptionVolume(osdProps)
-			volumes = append(volumes, encryptedVol)
-			if c.spec.Security.KeyManagementService.IsEnabled() {
-				encryptedVol, _ := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
-				volumes = append(volumes, encryptedVol)
-			}
+			volumes = append(volumes, c.getEncryptionVolumes(osdProps)...)
 		}
 	}
 
+	// If the OSD sits on a device of the node encrypted by Rook let's add the secret key mount path too
+	if !osdProps.onPVC() && osd.EncryptedDeviceUUID != "" {
+		volumes = append(volumes, c.getEncryptionVolumes(osdProps)...)
+	}
+
 	if len(volumes) == 0 {
 		return nil, errors.New("empty volumes")
 	}
@@ -446,7 +451,7 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	args = append(args, opconfig.LoggingFlags()...)
 	args = append(args, osdOnSDNFlag(c.spec.Network)...)
 
-	if c.spec.Network.IPFamily == cephv1.IPv6 {
+	if c.spec.Network.BindIPv6() {
 		args = append(args, opconfig.NewFlag("ms-bind-ipv6", "true"))
 	}
 
@@ -467,6 +472,18 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 		envVars = append(envVars, cvModeEnvVariable(osd.CVMode))
 	}
 
+	if !osdProps.onPVC() && osd.EncryptedDeviceUUID != "" {
+		envVars = append(envVars, encryptedDeviceUUIDEnvVar(osd.EncryptedDeviceUUID))
+	}
+
+	// Keep track of the device so the OSD can be replaced when the device is swapped
+	if !osdProps.onPVC() && osd.DeviceSerial != "" {
+		envVars = append(envVars, deviceSerialEnvVar(osd.DeviceSerial))
+	}
+	if !osdProps.onPVC() && osd.DevicePath != "" {
+		envVars = append(envVars, devicePathEnvVar(osd.DevicePath))
+	}
+
 	// We cannot go un-privileged until we have a bindmount for logs and crash
 	// OpenShift requires privileged containers for that
 	// If we remove those OSD on PVC with raw mode won't need to be privileged
@@ -481,7 +498,7 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 	}
 
 	// needed for luksOpen synchronization when devices are encrypted and the osd is prepared with LVM
-	hostIPC := osdProps.storeConfig.EncryptedDevice || osdProps.encrypted
+	hostIPC := osdProps.storeConfig.EncryptedDevice || osdProps.encrypted || osd.EncryptedDeviceUUID != ""
 
 	initContainers := make([]v1.Container, 0, 4)
 	if doConfigInit {
@@ -528,6 +545,10 @@ func (c *Cluster) makeDeployment(osdProps osdProperties, osd OSDInfo, provisionC
 
 	}
 	if doActivateOSDInit {
+		// Open the encrypted device of the node before ceph-volume looks for the osd on top of it
+		if osd.EncryptedDeviceUUID != "" {
+			initContainers = append(initContainers, c.getHostEncryptionOpenInitContainers(osdProps, osd)...)
+		}
 		initContainers = append(initContainers, *activateOSDContainer)
 	}
 
@@ -855,14 +876,92 @@ func (c *Cluster) generateVaultGetKEK(osdProps osdProperties) v1.Container {
 		Command: []string{
 			"/bin/bash",
 			"-c",
-			fmt.Sprintf(getKEKFromVaultWithToken, kms.GenerateOSDEncryptionSecretName(osdProps.pvc.ClaimName), encryptionKeyPath()),
+			fmt.Sprintf(getKEKFromVaultWithToken, kms.GenerateOSDEncryptionSecretName(osdProps.encryptionKeyName()), encryptionKeyPath()),
 		},
 		Env:       kms.VaultConfigToEnvVar(c.spec),
 		Resources: osdProps.resources,
 	}
 }
 
-func (c *Cluster) getPVCEncryptionOpenInitContainerActivate(mountPath string, osdProps osdProperties) []v1.Container {
+func (c *Cluster) generateVaultRookGetKEK(osdProps osdProperties) v1.Container {
+	connectionDetails := c.spec.Security.KeyManagementService.ConnectionDetails
+	env := append(kms.VaultConfigToEnvVar(c.spec), encryptionKeyNameEnvVar(osdProps), k8sutil.NamespaceEnvVar())
+	if kms.VaultTransitEnabled(connectionDetails) {
+		env = append(env, kms.VaultTransitCiphertextEnvVar(osdProps.encryptionKeyName()))
+	}
+
+	// Volume mount to store the encrypted key and the Vault TLS config
+	_, volMount := c.getEncryptionVolume(osdProps)
+	_, vaultVolMount := kms.VaultVolumeAndMount(connectionDetails)
+	volumeMounts := []v1.VolumeMount{volMount, vaultVolMount}
+	if kms.VaultKubernetesAuthEnabled(connectionDetails) {
+		_, tokenVolMount := kms.VaultServiceAccountTokenVolumeAndMount(connectionDetails)
+		volumeMounts = append(volumeMounts, tokenVolMount)
+	}
+
+	return v1.Container{
+		Name:            blockEncryptionKMSGetKEKInitContainer,
+		Image:           c.rookVersion,
+		Args:            []string{"ceph", "osd", "encryption-key", "--key-path", encryptionKeyPath()},
+		Env:             env,
+		VolumeMounts:    volumeMounts,
+		SecurityContext: PrivilegedContext(),
+		Resources:       osdProps.resources,
+	}
+}
+
+func (c *Cluster) generateKMIPGetKEK(osdProps osdProperties) v1.Container {
+	return v1.Container{
+		Name:            blockEncryptionKMSGetKEKInitContainer,
+		Image:           c.rookVersion,
+		Args:            []string{"ceph", "osd", "encryption-key", "--key-path", encryptionKeyPath()},
+		Env:             append(kms.KMIPConfigToEnvVar(c.spec, osdProps.encryptionKeyName()), encryptionKeyNameEnvVar(osdProps), k8sutil.NamespaceEnvVar()),
+		SecurityContext: PrivilegedContext(),
+		Resources:       osdProps.resources,
+	}
+}
+
+func (c *Cluster) generateMasterKeyUnwrapKEK(osdProps osdProperties) v1.Container {
+	_, masterKeyMounts := kms.MasterKeyVolumeAndMount(c.spec.Security.KeyManagementService)
+	_, volMount := c.getEncryptionVolume(osdProps)
+	return v1.Container{
+		Name:            blockEncryptionKMSGetKEKInitContainer,
+		Image:           c.rookVersion,
+		Args:            []string{"ceph", "osd", "encryption-key", "--key-path", encryptionKeyPath()},
+		Env:             append(kms.MasterKeyConfigToEnvVar(osdProps.encryptionKeyName()), encryptionKeyNameEnvVar(osdProps), k8sutil.NamespaceEnvVar()),
+		VolumeMounts:    append(masterKeyMounts, volMount),
+		SecurityContext: PrivilegedContext(),
+		Resources:       osdProps.resources,
+	}
+}
+
+// getEncryptionVolumes returns the volumes needed to fetch the encryption key of the OSD
+func (c *Cluster) getEncryptionVolumes(osdProps osdProperties) []v1.Volume {
+	encryptedVol, _ := c.getEncryptionVolume(osdProps)
+	volumes := []v1.Volume{encryptedVol}
+	if c.spec.Security.KeyManagementService.IsEnabled() {
+		if kms.GetParam(c.spec.Security.KeyManagementService.ConnectionDetails, kms.Provider) == kms.TypeKMIP {
+			encryptedVol, _ := kms.KMIPVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+			volumes = append(volumes, encryptedVol)
+		} else {
+			encryptedVol, _ := kms.VaultVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+			volumes = append(volumes, encryptedVol)
+			if kms.VaultKubernetesAuthEnabled(c.spec.Security.KeyManagementService.ConnectionDetails) {
+				tokenVol, _ := kms.VaultServiceAccountTokenVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+				volumes = append(volumes, tokenVol)
+			}
+		}
+	} else if c.spec.Security.KeyManagementService.IsMasterKeyEnabled() {
+		masterKeyVolumes, _ := kms.MasterKeyVolumeAndMount(c.spec.Security.KeyManagementService)
+		volumes = append(volumes, masterKeyVolumes...)
+	}
+
+	return volumes
+}
+
+// getEncryptionKEKInitContainers returns the containers writing the key of the OSD at encryptionKeyPath()
+// Without KMS the key is mounted from the Kubernetes Secret so there is nothing to do
+func (c *Cluster) getEncryptionKEKInitContainers(osdProps osdProperties) []v1.Container {
 	containers := []v1.Container{}
 
 	// If a KMS is enabled we need to add an init container to fetch the KEK
@@ -870,7 +969,10 @@ func (c *Cluster) getPVCEncryptionOpenInitContainerActivate(mountPath string, os
 		kmsProvider := kms.GetParam(c.spec.Security.KeyManagementService.ConnectionDetails, kms.Provider)
 		// Get Vault KEK from KMS container
 		if kmsProvider == secrets.TypeVault {
-			if c.spec.Security.KeyManagementService.IsTokenAuthEnabled() {
+			if kms.VaultTransitEnabled(c.spec.Security.KeyManagementService.ConnectionDetails) || kms.VaultKubernetesAuthEnabled(c.spec.Security.KeyManagementService.ConnectionDetails) {
+				// The transit engine and the kubernetes auth method are handled by the rook binary
+				containers = append(containers, c.generateVaultRookGetKEK(osdProps))
+			} else if c.spec.Security.KeyManagementService.IsTokenAuthEnabled() {
 				getKEKFromKMSContainer := c.generateVaultGetKEK(osdProps)
 
 				// Volume mount to store the encrypted key
@@ -885,8 +987,31 @@ func (c *Cluster) getPVCEncryptionOpenInitContainerActivate(mountPath string, os
 				containers = append(containers, getKEKFromKMSContainer)
 			}
 		}
+		// Get KMIP KEK with the rook binary since the ceph image has no KMIP client
+		if kmsProvider == kms.TypeKMIP {
+			getKEKFromKMSContainer := c.generateKMIPGetKEK(osdProps)
+
+			// Volume mount to store the encrypted key
+			_, volMount := c.getEncryptionVolume(osdProps)
+			getKEKFromKMSContainer.VolumeMounts = append(getKEKFromKMSContainer.VolumeMounts, volMount)
+
+			// Mount the KMIP client certificate
+			_, kmipVolMount := kms.KMIPVolumeAndMount(c.spec.Security.KeyManagementService.ConnectionDetails)
+			getKEKFromKMSContainer.VolumeMounts = append(getKEKFromKMSContainer.VolumeMounts, kmipVolMount)
+
+			containers = append(containers, getKEKFromKMSContainer)
+		}
+	} else if c.spec.Security.KeyManagementService.IsMasterKeyEnabled() {
+		// Unwrap the key stored in the Kubernetes Secret with the master key
+		containers = append(containers, c.generateMasterKeyUnwrapKEK(osdProps))
 	}
 
+	return containers
+}
+
+func (c *Cluster) getPVCEncryptionOpenInitContainerActivate(mountPath string, osdProps osdProperties) []v1.Container {
+	containers := c.getEncryptionKEKInitContainers(osdProps)
+
 	// Main block container
 	blockContainer := c.generateEncryptionOpenBlockContainer(osdProps.resources, blockEncryptionOpenInitContainer, osdProps.pvc.ClaimName, osdProps.pvc.ClaimName, DmcryptBlockType, bluestoreBlockName, mountPath)
 	_, volMount := c.getEncryptionVolume(osdProps)
@@ -914,6 +1039,34 @@ func (c *Cluster) getPVCEncryptionOpenInitContainerActivate(mountPath string, os
 	return containers
 }
 
+// getHostEncryptionOpenInitContainers opens the device of the node encrypted by Rook so the osd on top of it can be activated
+func (c *Cluster) getHostEncryptionOpenInitContainers(osdProps osdProperties, osd OSDInfo) []v1.Container {
+	containers := c.getEncryptionKEKInitContainers(osdProps)
+
+	script := fmt.Sprintf(openEncryptedBlock, encryptionKeyPath(), hostEncryptedDevicePath(osd.EncryptedDeviceUUID), HostEncryptionDMName(osd.EncryptedDeviceUUID), encryptionDMPath(osd.EncryptedDeviceUUID, DmcryptBlockType))
+	// With lvm mode the volume group sits on top of the encrypted device so it must be activated once opened
+	if osd.CVMode == "lvm" {
+		script += fmt.Sprintf(activateHostEncryptedVolumeGroup, path.Base(path.Dir(osd.BlockPath)))
+	}
+
+	_, volMount := c.getEncryptionVolume(osdProps)
+	containers = append(containers, v1.Container{
+		Name:  blockEncryptionOpenHostInitContainer,
+		Image: c.spec.CephVersion.Image,
+		Command: []string{
+			"/bin/bash",
+			"-c",
+			script,
+		},
+		Env:             cephVolumeEnvVar(),
+		VolumeMounts:    []v1.VolumeMount{{Name: "devices", MountPath: "/dev"}, volMount},
+		SecurityContext: PrivilegedContext(),
+		Resources:       osdProps.resources,
+	})
+
+	return containers
+}
+
 func (c *Cluster) generateEncryptionCopyBlockContainer(resources v1.ResourceRequirements, containerName, pvcName, mountPath, volumeMountPVCName, blockName, blockType string) v1.Container {
 	return v1.Container{
 		Name:  containerName,
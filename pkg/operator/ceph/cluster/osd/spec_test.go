@@ -25,6 +25,7 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/daemon/ceph/client"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/daemon/ceph/osd/kms"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/config"
 	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
 	operatortest "github.com/rook/rook/pkg/operator/ceph/test"
@@ -396,6 +397,23 @@ func testPodDevices(t *testing.T, dataDir, deviceName string, allDevices bool) {
 	assert.NotNil(t, deployment)
 	assert.Equal(t, 10, len(deployment.Spec.Template.Spec.Volumes), deployment.Spec.Template.Spec.Volumes)                                     // One more than the encryption with k8s for the kek get init container
 	assert.Equal(t, 3, len(deployment.Spec.Template.Spec.Volumes[7].VolumeSource.Projected.Sources), deployment.Spec.Template.Spec.Volumes[0]) // 3 more since we have the tls secrets
+
+	// Test with encrypted OSD on PVC with RAW with Vault transit and kubernetes auth
+	c.spec.Security.KeyManagementService.ConnectionDetails = map[string]string{"KMS_PROVIDER": "vault", "VAULT_SECRET_ENGINE": "transit", "VAULT_AUTH_METHOD": "kubernetes", "VAULT_AUTH_KUBERNETES_ROLE": "rook-ceph-osd"}
+	c.spec.Security.KeyManagementService.TokenSecretName = ""
+	deployment, err = c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.Nil(t, err)
+	assert.NotNil(t, deployment)
+	assert.Equal(t, 9, len(deployment.Spec.Template.Spec.InitContainers), deployment.Spec.Template.Spec.InitContainers)
+	getKEKCont := deployment.Spec.Template.Spec.InitContainers[1]
+	assert.Equal(t, "encryption-kms-get-kek", getKEKCont.Name)
+	assert.Equal(t, "rook/rook:myversion", getKEKCont.Image)
+	assert.Contains(t, getKEKCont.Env, v1.EnvVar{Name: "VAULT_AUTH_KUBERNETES_TOKEN_PATH", Value: "/var/run/secrets/rook-vault/token"})
+	assert.Contains(t, getKEKCont.Env, kms.VaultTransitCiphertextEnvVar("mypvc"))
+	assert.Equal(t, 3, len(getKEKCont.VolumeMounts), getKEKCont.VolumeMounts)
+	assert.Equal(t, 11, len(deployment.Spec.Template.Spec.Volumes), deployment.Spec.Template.Spec.Volumes) // One more for the projected service account token
+	assert.Equal(t, "vault-service-account-token", deployment.Spec.Template.Spec.Volumes[8].Name)
+	assert.NotNil(t, deployment.Spec.Template.Spec.Volumes[8].VolumeSource.Projected.Sources[0].ServiceAccountToken)
 	osdProp.encrypted = false
 
 	// Test tune Fast settings when OSD on PVC
@@ -642,6 +660,80 @@ func TestClusterGetPVCEncryptionOpenInitContainerActivate(t *testing.T) {
 	assert.Equal(t, 3, len(containers))
 }
 
+func TestHostEncryptedOSD(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clusterInfo := &cephclient.ClusterInfo{
+		Namespace:   "ns",
+		CephVersion: cephver.Nautilus,
+	}
+	clusterInfo.SetName("test")
+	clusterInfo.OwnerInfo = cephclient.NewMinimumOwnerInfo(t)
+	context := &clusterd.Context{Clientset: clientset, ConfigDir: "/var/lib/rook", Executor: &exectest.MockExecutor{}}
+	spec := cephv1.ClusterSpec{CephVersion: cephv1.CephVersionSpec{Image: "ceph/ceph:v15"}}
+	c := New(context, clusterInfo, spec, "rook/rook:myversion")
+
+	osdProp := osdProperties{
+		crushHostname: "node1",
+		devices:       []rookv1.Device{{Name: "sdb", Encrypted: true}},
+	}
+	dataPathMap := &provisionConfig{
+		DataPathMap: opconfig.NewDatalessDaemonDataPathMap(c.clusterInfo.Namespace, "/var/lib/rook"),
+	}
+
+	// The prepare pod receives the key of the node
+	podTemplate, err := c.provisionPodTemplateSpec(osdProp, v1.RestartPolicyAlways, dataPathMap)
+	assert.NoError(t, err)
+	assert.True(t, podTemplate.Spec.HostIPC)
+	envs := map[string]v1.EnvVar{}
+	for _, env := range podTemplate.Spec.Containers[0].Env {
+		envs[env.Name] = env
+	}
+	assert.Equal(t, "host-node1", envs[KeyNameEnvVarName].Value)
+	assert.Equal(t, "rook-ceph-osd-encryption-key-host-node1", envs[CephVolumeEncryptedKeyEnvVarName].ValueFrom.SecretKeyRef.Name)
+	assert.Contains(t, envs[dataDevicesEnvVar("").Name].Value, `"encrypted":true`)
+
+	// Raw mode
+	osd := OSDInfo{ID: 0, UUID: "osd-uuid", CVMode: "raw", BlockPath: "/dev/mapper/43e9efed-0676-4731-b75a-a4c42ece1bb1-block-dmcrypt", EncryptedDeviceUUID: "43e9efed-0676-4731-b75a-a4c42ece1bb1"}
+	d, err := c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.NoError(t, err)
+	assert.True(t, d.Spec.Template.Spec.HostIPC)
+	initContainers := d.Spec.Template.Spec.InitContainers
+	assert.Equal(t, blockEncryptionOpenHostInitContainer, initContainers[0].Name)
+	assert.Contains(t, initContainers[0].Command[2], "BLOCK_PATH=/dev/disk/by-uuid/43e9efed-0676-4731-b75a-a4c42ece1bb1")
+	assert.Contains(t, initContainers[0].Command[2], "DM_NAME=43e9efed-0676-4731-b75a-a4c42ece1bb1-block-dmcrypt")
+	assert.NotContains(t, initContainers[0].Command[2], "vgchange")
+	assert.Equal(t, "activate", initContainers[1].Name)
+	volumes := map[string]bool{}
+	for _, volume := range d.Spec.Template.Spec.Volumes {
+		volumes[volume.Name] = true
+	}
+	assert.True(t, volumes[osdEncryptionVolName])
+
+	// LVM mode
+	osd.CVMode = "lvm"
+	osd.BlockPath = "/dev/ceph-0b8e1f8b-0bd5-4d1a-9d17-b0a0ff2a5cc8/osd-block-9f3a5e4c-2a2a-4b3a-9f0e-8c0c0e0d5c1b"
+	d, err = c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.NoError(t, err)
+	assert.Contains(t, d.Spec.Template.Spec.InitContainers[0].Command[2], "vgchange --activate y ceph-0b8e1f8b-0bd5-4d1a-9d17-b0a0ff2a5cc8")
+
+	// The KEK is fetched from the KMS first
+	c.spec.Security.KeyManagementService.ConnectionDetails = map[string]string{"KMS_PROVIDER": "kmip"}
+	d, err = c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.NoError(t, err)
+	initContainers = d.Spec.Template.Spec.InitContainers
+	assert.Equal(t, blockEncryptionKMSGetKEKInitContainer, initContainers[0].Name)
+	assert.Contains(t, initContainers[0].Env, v1.EnvVar{Name: KeyNameEnvVarName, Value: "host-node1"})
+	assert.Equal(t, blockEncryptionOpenHostInitContainer, initContainers[1].Name)
+
+	// Not encrypted
+	c.spec.Security.KeyManagementService.ConnectionDetails = nil
+	osd.EncryptedDeviceUUID = ""
+	d, err = c.makeDeployment(osdProp, osd, dataPathMap)
+	assert.NoError(t, err)
+	assert.False(t, d.Spec.Template.Spec.HostIPC)
+	assert.Equal(t, "activate", d.Spec.Template.Spec.InitContainers[0].Name)
+}
+
 func TestClusterGetPVCEncryptionInitContainerActivate(t *testing.T) {
 	c := New(&clusterd.Context{}, &cephclient.ClusterInfo{OwnerInfo: &k8sutil.OwnerInfo{}}, cephv1.ClusterSpec{}, "rook/rook:myversion")
 	osdProperties := osdProperties{
@@ -60,8 +60,13 @@ var (
 )
 
 type provisionConfig struct {
-	errorMessages []string
-	DataPathMap   *config.DataPathMap // location to store data in container
+	errorMessages  []string
+	DataPathMap    *config.DataPathMap // location to store data in container
+	plannedUpdates []osdUpdate         // updates of existing OSDs restarted in parallel
+	// OSDs the new metadata and wal devices are added to in the background
+	blueFSMigrations []blueFSMigration
+	// OSD deployments stopped for a node maintenance and the name of their maintenance
+	osdsInMaintenance map[string]string
 }
 
 func (c *Cluster) newProvisionConfig() *provisionConfig {
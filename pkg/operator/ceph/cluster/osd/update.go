@@ -0,0 +1,287 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/banzaicloud/k8s-objectmatcher/patch"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/daemon/ceph/client"
+	opconfig "github.com/rook/rook/pkg/operator/ceph/config"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/util"
+	apps "k8s.io/api/apps/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	defaultOSDUpdateFailureDomain = "host"
+	osdUpdateRetryDelay           = 10 * time.Second
+)
+
+// osdUpdate is an OSD deployment whose spec changed
+type osdUpdate struct {
+	osdID    int
+	current  *apps.Deployment
+	modified *apps.Deployment
+}
+
+// osdUpdateBatch is a set of OSDs of the same failure domain restarted at once
+type osdUpdateBatch struct {
+	failureDomain string
+	updates       []osdUpdate
+}
+
+// updateOSDDeployment updates the deployment of an existing OSD right away, or plans it for the parallel update
+// of the OSDs when it is enabled
+func (c *Cluster) updateOSDDeployment(dp *apps.Deployment, osdID int, config *provisionConfig) {
+	// The OSDs stopped for the maintenance of their node are left alone until the maintenance ends
+	if maintenance, ok := config.osdsInMaintenance[dp.Name]; ok {
+		logger.Infof("osd %d is stopped by node maintenance %q, not updating it", osdID, maintenance)
+		return
+	}
+
+	if c.spec.OSDUpdateStrategy.Parallel {
+		config.plannedUpdates = append(config.plannedUpdates, osdUpdate{osdID: osdID, modified: dp})
+		return
+	}
+
+	if err := updateDeploymentAndWait(c.context, c.clusterInfo, dp, opconfig.OsdType, strconv.Itoa(osdID), c.spec.SkipUpgradeChecks, c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy); err != nil {
+		logger.Errorf("failed to update osd deployment %d. %v", osdID, err)
+	}
+}
+
+// osdsInMaintenance returns the OSD deployments stopped for the maintenance of their node, with the name of the
+// CephNodeMaintenance that stopped them
+func (c *Cluster) osdsInMaintenance() (map[string]string, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("%s=%s,%s", k8sutil.AppAttr, AppName, MaintenanceLabelKey)}
+	deployments, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).List(context.TODO(), listOpts)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list the osd deployments in maintenance")
+	}
+
+	osds := map[string]string{}
+	for _, d := range deployments.Items {
+		osds[d.Name] = d.Labels[MaintenanceLabelKey]
+	}
+	return osds, nil
+}
+
+// osdMaintenance returns the name of the CephNodeMaintenance that stopped the OSD deployment, or an empty string if
+// the OSD is not stopped for the maintenance of its node
+func (c *Cluster) osdMaintenance(name string) (string, error) {
+	d, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get osd deployment %q", name)
+	}
+	return d.Labels[MaintenanceLabelKey], nil
+}
+
+// updateOSDsInParallel restarts the planned OSD updates one failure domain at a time. All the OSDs of a failure domain,
+// up to the maximum set in the spec, are restarted at once after the PGs are active+clean and the OSDs are ok to stop.
+func (c *Cluster) updateOSDsInParallel(config *provisionConfig) {
+	updates, err := c.changedOSDDeployments(config.plannedUpdates)
+	config.plannedUpdates = nil
+	if err != nil {
+		config.addError("failed to plan the osd updates. %v", err)
+		return
+	}
+	if len(updates) == 0 {
+		return
+	}
+
+	strategy := c.spec.OSDUpdateStrategy
+	failureDomain := strategy.FailureDomain
+	if failureDomain == "" {
+		failureDomain = defaultOSDUpdateFailureDomain
+	}
+	batches := planOSDUpdates(updates, failureDomain, strategy.MaxConcurrent)
+	logger.Infof("updating %d osds in %d batches by %s", len(updates), len(batches), failureDomain)
+
+	status := &cephv1.OSDUpdateStatus{TotalOSDs: len(updates)}
+	for _, batch := range batches {
+		// Check whether we need to cancel the orchestration
+		if err := opcontroller.CheckForCancelledOrchestration(c.context); err != nil {
+			config.addError("%s", err.Error())
+			return
+		}
+
+		status.FailureDomain = batch.failureDomain
+		status.Message = ""
+		c.updateOSDUpdateStatus(status)
+
+		err := c.waitForOSDsOkToStop(batch)
+		if err == nil {
+			err = c.restartOSDDeployments(batch.updates)
+		}
+		if err != nil {
+			status.Message = err.Error()
+			c.updateOSDUpdateStatus(status)
+			config.addError("failed to update the osds of %s %q. %v", failureDomain, batch.failureDomain, err)
+			return
+		}
+
+		status.UpdatedOSDs += len(batch.updates)
+		logger.Infof("updated %d/%d osds", status.UpdatedOSDs, status.TotalOSDs)
+	}
+
+	status.FailureDomain = ""
+	c.updateOSDUpdateStatus(status)
+}
+
+// changedOSDDeployments returns the planned updates whose deployment spec actually changed
+func (c *Cluster) changedOSDDeployments(planned []osdUpdate) ([]osdUpdate, error) {
+	ctx := context.TODO()
+	changed := []osdUpdate{}
+	for _, u := range planned {
+		current, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Get(ctx, u.modified.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get deployment %q", u.modified.Name)
+		}
+
+		patchResult, err := patch.DefaultPatchMaker.Calculate(current, u.modified)
+		if err != nil {
+			logger.Warningf("failed to calculate diff between current deployment %q and newly generated one. Assuming it changed. %v", current.Name, err)
+		} else if patchResult.IsEmpty() {
+			logger.Debugf("deployment %q did not change, nothing to update", current.Name)
+			continue
+		}
+
+		u.current = current
+		changed = append(changed, u)
+	}
+
+	return changed, nil
+}
+
+// planOSDUpdates groups the OSD updates by failure domain and splits the groups in batches of at most maxConcurrent OSDs.
+// The OSDs without the topology label of the failure domain are updated one at a time.
+func planOSDUpdates(updates []osdUpdate, failureDomain string, maxConcurrent int) []osdUpdateBatch {
+	topologyLabel := fmt.Sprintf(TopologyLocationLabel, failureDomain)
+	groups := map[string][]osdUpdate{}
+	for _, u := range updates {
+		name := u.modified.Spec.Template.Labels[topologyLabel]
+		if name == "" && failureDomain == defaultOSDUpdateFailureDomain {
+			name = u.modified.Spec.Template.Labels[FailureDomainKey]
+		}
+		if name == "" {
+			name = u.modified.Name
+		}
+		groups[name] = append(groups[name], u)
+	}
+
+	names := []string{}
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	batches := []osdUpdateBatch{}
+	for _, name := range names {
+		group := groups[name]
+		sort.Slice(group, func(i, j int) bool { return group[i].osdID < group[j].osdID })
+		size := len(group)
+		if maxConcurrent > 0 && maxConcurrent < size {
+			size = maxConcurrent
+		}
+		for start := 0; start < len(group); start += size {
+			end := start + size
+			if end > len(group) {
+				end = len(group)
+			}
+			batches = append(batches, osdUpdateBatch{failureDomain: name, updates: group[start:end]})
+		}
+	}
+
+	return batches
+}
+
+// waitForOSDsOkToStop waits for the PGs to be active+clean and for Ceph to allow stopping all the OSDs of the batch
+func (c *Cluster) waitForOSDsOkToStop(batch osdUpdateBatch) error {
+	if c.spec.SkipUpgradeChecks {
+		logger.Warningf("not checking if the osds of %q can be stopped because skipUpgradeChecks is true", batch.failureDomain)
+		return nil
+	}
+
+	osdIDs := []int{}
+	for _, u := range batch.updates {
+		osdIDs = append(osdIDs, u.osdID)
+	}
+	retries := int(c.clusterInfo.OsdUpgradeTimeout / osdUpdateRetryDelay)
+	err := util.Retry(retries, osdUpdateRetryDelay, func() error {
+		if err := client.IsClusterCleanError(c.context, c.clusterInfo); err != nil {
+			return errors.Wrap(err, "pgs are not active+clean")
+		}
+		return client.OSDsOkToStop(c.context, c.clusterInfo, osdIDs)
+	})
+	if err != nil {
+		if c.spec.ContinueUpgradeAfterChecksEvenIfNotHealthy {
+			logger.Infof("osds %v are not ok to stop but 'continueUpgradeAfterChecksEvenIfNotHealthy' is true, so proceeding to stop them. %v", osdIDs, err)
+			return nil
+		}
+		return errors.Wrapf(err, "failed to check if osds %v can be stopped", osdIDs)
+	}
+
+	return nil
+}
+
+// restartOSDDeployments updates all the deployments at once and waits for them to be running again
+func (c *Cluster) restartOSDDeployments(updates []osdUpdate) error {
+	ctx := context.TODO()
+	for _, u := range updates {
+		if err := patch.DefaultAnnotator.SetLastAppliedAnnotation(u.modified); err != nil {
+			return errors.Wrapf(err, "failed to set hash annotation on deployment %q", u.modified.Name)
+		}
+		if _, err := c.context.Clientset.AppsV1().Deployments(c.clusterInfo.Namespace).Update(ctx, u.modified, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to update deployment %q", u.modified.Name)
+		}
+	}
+
+	for _, u := range updates {
+		if err := k8sutil.WaitForDeploymentToStart(c.context, u.current); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Cluster) updateOSDUpdateStatus(status *cephv1.OSDUpdateStatus) {
+	cephCluster := &cephv1.CephCluster{}
+	err := c.context.Client.Get(context.TODO(), c.clusterInfo.NamespacedName(), cephCluster)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephCluster resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Errorf("failed to retrieve ceph cluster %q to update the osd update status. %v", c.clusterInfo.NamespacedName().Name, err)
+		return
+	}
+
+	cephCluster.Status.OSDUpdate = status
+	if err := opcontroller.UpdateStatus(c.context.Client, cephCluster); err != nil {
+		logger.Errorf("failed to update cluster %q osd update status. %v", c.clusterInfo.NamespacedName().Name, err)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package osd
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func osdUpdateOnHost(id int, host string) osdUpdate {
+	labels := map[string]string{}
+	if host != "" {
+		labels["topology-location-host"] = host
+		labels["topology-location-zone"] = "zone-" + host[len(host)-1:]
+	}
+	d := &apps.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("rook-ceph-osd-%d", id)},
+		Spec:       apps.DeploymentSpec{Template: v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}}},
+	}
+	return osdUpdate{osdID: id, modified: d}
+}
+
+func batchOSDIDs(batch osdUpdateBatch) []int {
+	ids := []int{}
+	for _, u := range batch.updates {
+		ids = append(ids, u.osdID)
+	}
+	return ids
+}
+
+func TestPlanOSDUpdates(t *testing.T) {
+	updates := []osdUpdate{
+		osdUpdateOnHost(4, "node-b"),
+		osdUpdateOnHost(0, "node-a"),
+		osdUpdateOnHost(3, "node-a"),
+		osdUpdateOnHost(1, "node-a"),
+		osdUpdateOnHost(2, "node-b"),
+	}
+
+	// all the osds of a host at once
+	batches := planOSDUpdates(updates, "host", 0)
+	assert.Equal(t, 2, len(batches))
+	assert.Equal(t, "node-a", batches[0].failureDomain)
+	assert.Equal(t, []int{0, 1, 3}, batchOSDIDs(batches[0]))
+	assert.Equal(t, "node-b", batches[1].failureDomain)
+	assert.Equal(t, []int{2, 4}, batchOSDIDs(batches[1]))
+
+	// concurrency is capped
+	batches = planOSDUpdates(updates, "host", 2)
+	assert.Equal(t, 3, len(batches))
+	assert.Equal(t, []int{0, 1}, batchOSDIDs(batches[0]))
+	assert.Equal(t, []int{3}, batchOSDIDs(batches[1]))
+	assert.Equal(t, "node-a", batches[1].failureDomain)
+	assert.Equal(t, []int{2, 4}, batchOSDIDs(batches[2]))
+
+	// larger failure domain
+	batches = planOSDUpdates(updates, "zone", 0)
+	assert.Equal(t, 2, len(batches))
+	assert.Equal(t, "zone-a", batches[0].failureDomain)
+
+	// the osds without topology label are updated one at a time
+	updates = append(updates, osdUpdateOnHost(5, ""), osdUpdateOnHost(6, ""))
+	batches = planOSDUpdates(updates, "zone", 0)
+	assert.Equal(t, 4, len(batches))
+	assert.Equal(t, []int{5}, batchOSDIDs(batches[0]))
+	assert.Equal(t, []int{6}, batchOSDIDs(batches[1]))
+}
+
+func TestUpdateOSDDeploymentParallel(t *testing.T) {
+	updated := 0
+	oldUpdateDeploymentAndWait := updateDeploymentAndWait
+	defer func() { updateDeploymentAndWait = oldUpdateDeploymentAndWait }()
+	updateDeploymentAndWait = func(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, deployment *apps.Deployment, daemonType, daemonName string, skipUpgradeChecks, continueUpgradeAfterChecksEvenIfNotHealthy bool) error {
+		updated++
+		return nil
+	}
+
+	d := &apps.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0", Namespace: "ns"}}
+	clientset := fake.NewSimpleClientset(d.DeepCopy())
+	c := New(&clusterd.Context{Clientset: clientset}, cephclient.AdminClusterInfo("ns"), cephv1.ClusterSpec{}, "rook/rook:myversion")
+	config := c.newProvisionConfig()
+
+	// one osd at a time by default
+	c.updateOSDDeployment(d, 0, config)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, 0, len(config.plannedUpdates))
+
+	// the update is planned to restart the osds of a failure domain together
+	c.spec.OSDUpdateStrategy.Parallel = true
+	c.updateOSDDeployment(d, 0, config)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, 1, len(config.plannedUpdates))
+	assert.Equal(t, 0, config.plannedUpdates[0].osdID)
+
+	// the osds stopped for a node maintenance are not updated
+	current := d.DeepCopy()
+	current.Labels = map[string]string{k8sutil.AppAttr: AppName, MaintenanceLabelKey: "maintenance-a"}
+	_, err := clientset.AppsV1().Deployments("ns").Update(context.TODO(), current, metav1.UpdateOptions{})
+	assert.NoError(t, err)
+	config.osdsInMaintenance, err = c.osdsInMaintenance()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"rook-ceph-osd-0": "maintenance-a"}, config.osdsInMaintenance)
+	maintenance, err := c.osdMaintenance("rook-ceph-osd-0")
+	assert.NoError(t, err)
+	assert.Equal(t, "maintenance-a", maintenance)
+	c.updateOSDDeployment(d, 0, config)
+	assert.Equal(t, 1, updated)
+	assert.Equal(t, 1, len(config.plannedUpdates))
+}
@@ -196,10 +196,14 @@ func (c *Cluster) getEncryptionVolume(osdProps osdProperties) (v1.Volume, v1.Vol
 	var isKMS bool
 	if len(c.spec.Security.KeyManagementService.ConnectionDetails) != 0 {
 		provider := kms.GetParam(c.spec.Security.KeyManagementService.ConnectionDetails, kms.Provider)
-		if provider == secrets.TypeVault {
+		if provider == secrets.TypeVault || provider == kms.TypeKMIP {
 			isKMS = true
 		}
 	}
+	// The wrapped key must be unwrapped before being written in the volume
+	if c.spec.Security.KeyManagementService.IsMasterKeyEnabled() {
+		isKMS = true
+	}
 
 	// Generate volume
 	var m int32 = 0400
@@ -207,7 +211,7 @@ func (c *Cluster) getEncryptionVolume(osdProps osdProperties) (v1.Volume, v1.Vol
 		Name: osdEncryptionVolName,
 		VolumeSource: v1.VolumeSource{
 			Secret: &v1.SecretVolumeSource{
-				SecretName: kms.GenerateOSDEncryptionSecretName(osdProps.pvc.ClaimName),
+				SecretName: kms.GenerateOSDEncryptionSecretName(osdProps.encryptionKeyName()),
 				Items: []v1.KeyToPath{
 					{
 						Key:  kms.OsdEncryptionSecretNameKeyName,
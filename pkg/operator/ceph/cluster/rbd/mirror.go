@@ -38,6 +38,8 @@ import (
 const (
 	// AppName is the ceph rbd mirror  application name
 	AppName = "rook-ceph-rbd-mirror"
+	// CephRBDMirrorLabel is the label of the rbd-mirror pods with the name of their CephRBDMirror
+	CephRBDMirrorLabel = "ceph_rbd_mirror"
 	// minimum amount of memory in MB to run the pod
 	cephRbdMirrorPodMinimumMemory uint64 = 512
 )
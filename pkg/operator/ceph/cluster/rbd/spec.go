@@ -79,6 +79,14 @@ func (r *ReconcileCephRBDMirror) makeDeployment(daemonConfig *daemonConfig, rbdM
 		podSpec.Spec.Volumes[0].VolumeSource.Projected.Sources = append(podSpec.Spec.Volumes[0].VolumeSource.Projected.Sources, volProjection...)
 	}
 
+	// The selector of the existing deployments cannot be changed, the name of the CephRBDMirror is only added to the
+	// labels of the pods
+	selector := map[string]string{}
+	for key, value := range podSpec.Labels {
+		selector[key] = value
+	}
+	podSpec.Labels[CephRBDMirrorLabel] = rbdMirror.Name
+
 	replicas := int32(rbdMirror.Spec.Count)
 	d := &apps.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -89,7 +97,7 @@ func (r *ReconcileCephRBDMirror) makeDeployment(daemonConfig *daemonConfig, rbdM
 		},
 		Spec: apps.DeploymentSpec{
 			Selector: &metav1.LabelSelector{
-				MatchLabels: podSpec.Labels,
+				MatchLabels: selector,
 			},
 			Template: podSpec,
 			Replicas: &replicas,
@@ -95,6 +95,11 @@ func TestPodSpec(t *testing.T) {
 	test.AssertLabelsContainCephRequirements(t, d.ObjectMeta.Labels,
 		config.RbdMirrorType, "a", AppName, "ns")
 
+	// The pods are labeled with the name of the CephRBDMirror, the selector is not
+	assert.Equal(t, "a", d.Spec.Template.Labels[CephRBDMirrorLabel])
+	_, ok := d.Spec.Selector.MatchLabels[CephRBDMirrorLabel]
+	assert.False(t, ok)
+
 	podTemplate := test.NewPodTemplateSpecTester(t, &d.Spec.Template)
 	podTemplate.RunFullSuite(config.RbdMirrorType, "a", AppName, "ns", "ceph/ceph:myceph",
 		"200", "100", "600", "300", /* resources */
@@ -21,11 +21,13 @@ import (
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/crash"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd/removal"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/clusterdisruption"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/controllerconfig"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/machinedisruption"
 	"github.com/rook/rook/pkg/operator/ceph/disruption/machinelabel"
+	"github.com/rook/rook/pkg/operator/ceph/disruption/nodemaintenance"
 	"github.com/rook/rook/pkg/operator/ceph/file"
 	"github.com/rook/rook/pkg/operator/ceph/file/mirror"
 	"github.com/rook/rook/pkg/operator/ceph/nfs"
@@ -35,6 +37,7 @@ import (
 	"github.com/rook/rook/pkg/operator/ceph/object/zone"
 	"github.com/rook/rook/pkg/operator/ceph/object/zonegroup"
 	"github.com/rook/rook/pkg/operator/ceph/pool"
+	"github.com/rook/rook/pkg/operator/ceph/pool/radosnamespace"
 
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
@@ -69,6 +72,9 @@ var AddToManagerFuncs = []func(manager.Manager, *clusterd.Context) error{
 	rbd.Add,
 	client.Add,
 	mirror.Add,
+	removal.Add,
+	nodemaintenance.Add,
+	radosnamespace.Add,
 }
 
 // AddToManager adds all the registered controllers to the passed manager.
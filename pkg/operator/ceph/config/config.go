@@ -22,6 +22,7 @@ package config
 import (
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 
 	"github.com/coreos/pkg/capnslog"
@@ -143,6 +144,34 @@ func SetDefaultConfigs(
 		return errors.Wrapf(err, "failed to apply legacy config overrides")
 	}
 
+	// Ceph warns when the store of a mon grows above the size at which the operator compacts it
+	if clusterSpec.Mon.Store.CompactThresholdGB > 0 {
+		sizeWarn := strconv.FormatUint(uint64(clusterSpec.Mon.Store.CompactThresholdGB)<<30, 10)
+		if err := monStore.Set("mon", "mon_data_size_warn", sizeWarn); err != nil {
+			return errors.Wrap(err, "failed to apply mon store size warning")
+		}
+	} else {
+		if err := monStore.Delete("mon", "mon_data_size_warn"); err != nil {
+			return errors.Wrap(err, "failed to reset mon store size warning")
+		}
+	}
+
+	// Bind the daemons to the addresses of the IP families of the cluster
+	if err := monStore.SetAll(ipFamilySettings(clusterSpec.Network)...); err != nil {
+		return errors.Wrap(err, "failed to apply ip family settings")
+	}
+
+	// Require the msgr2 protocol and encrypt the connections if needed
+	connectionsOptions, resetConnectionsOptions := connectionsSettings(clusterSpec.Network)
+	if err := monStore.SetAll(connectionsOptions...); err != nil {
+		return errors.Wrap(err, "failed to apply connections settings")
+	}
+	for _, option := range resetConnectionsOptions {
+		if err := monStore.Delete(option.Who, option.Option); err != nil {
+			return errors.Wrapf(err, "failed to reset connections setting %q", option.Option)
+		}
+	}
+
 	// Apply Multus if needed
 	if clusterSpec.Network.IsMultus() {
 		logger.Info("configuring ceph network(s) with multus")
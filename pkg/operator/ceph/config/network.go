@@ -20,9 +20,12 @@ package config
 import (
 	"context"
 	"fmt"
+	"net"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
@@ -45,8 +48,43 @@ var (
 	NetworkSelectors = []string{PublicNetworkSelectorKeyName, ClusterNetworkSelectorKeyName}
 )
 
+// connectionsSettings returns the settings requested by the connections of the cluster network, and the settings the
+// connections do not request anymore that are reset to the Ceph defaults. Nothing is set when the cluster network has
+// no connections settings.
+func connectionsSettings(network cephv1.NetworkSpec) ([]Option, []Option) {
+	settings := []Option{}
+	resetSettings := []Option{}
+	if network.Connections == nil {
+		return settings, resetSettings
+	}
+
+	if network.RequireMsgr2() {
+		settings = append(settings, configOverride("global", "ms_bind_msgr1", "false"))
+	} else {
+		resetSettings = append(resetSettings, configOverride("global", "ms_bind_msgr1", ""))
+	}
+
+	// The secure mode of the msgr2 protocol encrypts the data on the wire
+	for _, option := range []string{"ms_cluster_mode", "ms_service_mode", "ms_client_mode"} {
+		if network.IsEncryptionEnabled() {
+			settings = append(settings, configOverride("global", option, "secure"))
+		} else {
+			resetSettings = append(resetSettings, configOverride("global", option, ""))
+		}
+	}
+	return settings, resetSettings
+}
+
+// ipFamilySettings returns the IP families the daemons bind to, both the IPv4 and the IPv6 addresses in dual stack
+func ipFamilySettings(network cephv1.NetworkSpec) []Option {
+	bindIPv4 := network.IPFamily != cephv1.IPv6
+	return []Option{
+		configOverride("global", "ms_bind_ipv4", strconv.FormatBool(bindIPv4)),
+		configOverride("global", "ms_bind_ipv6", strconv.FormatBool(network.BindIPv6())),
+	}
+}
+
 func generateNetworkSettings(clusterdContext *clusterd.Context, namespace string, networkSelectors map[string]string) ([]Option, error) {
-	ctx := context.TODO()
 	cephNetworks := []Option{}
 
 	for _, selectorKey := range NetworkSelectors {
@@ -57,23 +95,9 @@ func generateNetworkSettings(clusterdContext *clusterd.Context, namespace string
 			continue
 		}
 
-		multusNamespace, nad := GetMultusNamespace(networkSelectors[selectorKey])
-		if multusNamespace == "" {
-			multusNamespace = namespace
-		}
-		// Get network attachment definition
-		netDefinition, err := clusterdContext.NetworkClient.NetworkAttachmentDefinitions(multusNamespace).Get(ctx, nad, metav1.GetOptions{})
-		if err != nil {
-			if kerrors.IsNotFound(err) {
-				return []Option{}, errors.Wrapf(err, "specified network attachment definition %q in namespace %q for selector %q does not exist", nad, namespace, selectorKey)
-			}
-			return []Option{}, errors.Wrapf(err, "failed to fetch network attachment definition for selector %q", selectorKey)
-		}
-
-		// Get network attachment definition configuration
-		netConfig, err := k8sutil.GetNetworkAttachmentConfig(*netDefinition)
+		netConfig, err := getNetworkAttachmentConfig(clusterdContext, namespace, selectorKey, networkSelectors[selectorKey])
 		if err != nil {
-			return []Option{}, errors.Wrapf(err, "failed to get network attachment definition configuration for selector %q", selectorKey)
+			return []Option{}, err
 		}
 
 		networkRange := getNetworkRange(netConfig)
@@ -87,6 +111,89 @@ func generateNetworkSettings(clusterdContext *clusterd.Context, namespace string
 	return cephNetworks, nil
 }
 
+// ValidateMultusNetworks checks the network attachment definitions of the multus network selectors before the daemons
+// are rolled out. Each definition must exist and have an IPAM with a usable range, and the ranges of the public and
+// cluster networks must not overlap.
+func ValidateMultusNetworks(clusterdContext *clusterd.Context, namespace string, networkSelectors map[string]string) error {
+	subnets := map[string][]*net.IPNet{}
+	for _, selectorKey := range NetworkSelectors {
+		selector, ok := networkSelectors[selectorKey]
+		if !ok {
+			continue
+		}
+		netConfig, err := getNetworkAttachmentConfig(clusterdContext, namespace, selectorKey, selector)
+		if err != nil {
+			return err
+		}
+		if netConfig.Ipam.Type == "" {
+			return errors.Errorf("network attachment definition %q for selector %q has no IPAM", selector, selectorKey)
+		}
+		networkRange := getNetworkRange(netConfig)
+		if networkRange == "" {
+			return errors.Errorf("network attachment definition %q for selector %q has no range for IPAM type %q", selector, selectorKey, netConfig.Ipam.Type)
+		}
+		subnets[selectorKey], err = parseNetworkRange(networkRange)
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse the range of network attachment definition %q for selector %q", selector, selectorKey)
+		}
+	}
+
+	// The public and cluster networks can be the same network, otherwise their ranges must be separate
+	public, cluster := networkSelectors[PublicNetworkSelectorKeyName], networkSelectors[ClusterNetworkSelectorKeyName]
+	if public == "" || cluster == "" || public == cluster {
+		return nil
+	}
+	for _, publicSubnet := range subnets[PublicNetworkSelectorKeyName] {
+		for _, clusterSubnet := range subnets[ClusterNetworkSelectorKeyName] {
+			if publicSubnet.Contains(clusterSubnet.IP) || clusterSubnet.Contains(publicSubnet.IP) {
+				return errors.Errorf("public network range %q overlaps with cluster network range %q", publicSubnet, clusterSubnet)
+			}
+		}
+	}
+	return nil
+}
+
+// getNetworkAttachmentConfig returns the configuration of the network attachment definition of a network selector
+func getNetworkAttachmentConfig(clusterdContext *clusterd.Context, namespace, selectorKey, selector string) (k8sutil.NetworkAttachmentConfig, error) {
+	multusNamespace, nad := GetMultusNamespace(selector)
+	if multusNamespace == "" {
+		multusNamespace = namespace
+	}
+	// Get network attachment definition
+	netDefinition, err := clusterdContext.NetworkClient.NetworkAttachmentDefinitions(multusNamespace).Get(context.TODO(), nad, metav1.GetOptions{})
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			return k8sutil.NetworkAttachmentConfig{}, errors.Wrapf(err, "specified network attachment definition %q in namespace %q for selector %q does not exist", nad, multusNamespace, selectorKey)
+		}
+		return k8sutil.NetworkAttachmentConfig{}, errors.Wrapf(err, "failed to fetch network attachment definition for selector %q", selectorKey)
+	}
+
+	// Get network attachment definition configuration
+	netConfig, err := k8sutil.GetNetworkAttachmentConfig(*netDefinition)
+	if err != nil {
+		return k8sutil.NetworkAttachmentConfig{}, errors.Wrapf(err, "failed to get network attachment definition configuration for selector %q", selectorKey)
+	}
+	return netConfig, nil
+}
+
+// parseNetworkRange parses the comma-separated subnets of a network range. The whereabouts ranges can be limited to
+// a start and end address like "192.168.0.10-192.168.0.20/24", the subnet is the one of the start address.
+func parseNetworkRange(networkRange string) ([]*net.IPNet, error) {
+	subnets := []*net.IPNet{}
+	for _, subnet := range strings.Split(networkRange, ",") {
+		subnet = strings.TrimSpace(subnet)
+		if i := strings.Index(subnet, "-"); i >= 0 && strings.Contains(subnet, "/") {
+			subnet = subnet[:i] + subnet[strings.LastIndex(subnet, "/"):]
+		}
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid subnet %q", subnet)
+		}
+		subnets = append(subnets, ipNet)
+	}
+	return subnets, nil
+}
+
 func GetMultusNamespace(nad string) (string, string) {
 	tmp := strings.Split(nad, "/")
 	if len(tmp) == 2 {
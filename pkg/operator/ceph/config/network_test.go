@@ -23,6 +23,7 @@ import (
 
 	networkv1 "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/apis/k8s.cni.cncf.io/v1"
 	fakenetclient "github.com/k8snetworkplumbingwg/network-attachment-definition-client/pkg/client/clientset/versioned/fake"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	"github.com/rook/rook/pkg/clusterd"
 	"github.com/rook/rook/pkg/operator/k8sutil"
 	testop "github.com/rook/rook/pkg/operator/test"
@@ -316,3 +317,153 @@ func TestGetMultusNamespace(t *testing.T) {
 	assert.Empty(t, namespace)
 	assert.Equal(t, "public-nad", nad)
 }
+
+func TestConnectionsSettings(t *testing.T) {
+	// nothing is set without connections settings
+	settings, resetSettings := connectionsSettings(cephv1.NetworkSpec{})
+	assert.Equal(t, 0, len(settings))
+	assert.Equal(t, 0, len(resetSettings))
+
+	// the ceph defaults are restored
+	settings, resetSettings = connectionsSettings(cephv1.NetworkSpec{Connections: &cephv1.ConnectionsSpec{}})
+	assert.Equal(t, 0, len(settings))
+	assert.Equal(t, 4, len(resetSettings))
+	assert.Equal(t, "ms_bind_msgr1", resetSettings[0].Option)
+	assert.Equal(t, "ms_cluster_mode", resetSettings[1].Option)
+
+	// msgr2 only
+	settings, resetSettings = connectionsSettings(cephv1.NetworkSpec{Connections: &cephv1.ConnectionsSpec{RequireMsgr2: true}})
+	assert.Equal(t, []Option{{Who: "global", Option: "ms_bind_msgr1", Value: "false"}}, settings)
+	assert.Equal(t, 3, len(resetSettings))
+
+	// encrypted
+	settings, resetSettings = connectionsSettings(cephv1.NetworkSpec{Connections: &cephv1.ConnectionsSpec{Encryption: &cephv1.EncryptionSpec{Enabled: true}}})
+	assert.Equal(t, 4, len(settings))
+	assert.Equal(t, 0, len(resetSettings))
+	assert.Equal(t, "false", settings[0].Value)
+	for _, setting := range settings[1:] {
+		assert.Equal(t, "secure", setting.Value)
+	}
+}
+
+
+func TestIPFamilySettings(t *testing.T) {
+	settings := ipFamilySettings(cephv1.NetworkSpec{})
+	assert.Equal(t, []Option{{Who: "global", Option: "ms_bind_ipv4", Value: "true"}, {Who: "global", Option: "ms_bind_ipv6", Value: "false"}}, settings)
+
+	settings = ipFamilySettings(cephv1.NetworkSpec{IPFamily: cephv1.IPv6})
+	assert.Equal(t, "false", settings[0].Value)
+	assert.Equal(t, "true", settings[1].Value)
+
+	settings = ipFamilySettings(cephv1.NetworkSpec{IPFamily: cephv1.DualStack})
+	assert.Equal(t, "true", settings[0].Value)
+	assert.Equal(t, "true", settings[1].Value)
+}
+
+func TestValidateMultusNetworks(t *testing.T) {
+	ns := "rook-ceph"
+	nad := func(name, namespace, ipam string) *networkv1.NetworkAttachmentDefinition {
+		return &networkv1.NetworkAttachmentDefinition{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: networkv1.NetworkAttachmentDefinitionSpec{
+				Config: fmt.Sprintf(`{"cniVersion": "0.3.0", "type": "macvlan", "master": "eth2", "mode": "bridge"%s}`, ipam),
+			},
+		}
+	}
+	tests := []struct {
+		name      string
+		nads      []*networkv1.NetworkAttachmentDefinition
+		selectors map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "public network only",
+			nads:      []*networkv1.NetworkAttachmentDefinition{nad("public", ns, `, "ipam": {"type": "host-local", "subnet": "192.168.0.0/24"}`)},
+			selectors: map[string]string{"public": "public"},
+		},
+		{
+			name: "separate public and cluster networks",
+			nads: []*networkv1.NetworkAttachmentDefinition{
+				nad("public", ns, `, "ipam": {"type": "host-local", "subnet": "192.168.0.0/24"}`),
+				nad("cluster", ns, `, "ipam": {"type": "whereabouts", "range": "192.168.1.0/24"}`),
+			},
+			selectors: map[string]string{"public": "public", "cluster": "cluster"},
+		},
+		{
+			name: "same network for public and cluster",
+			nads: []*networkv1.NetworkAttachmentDefinition{
+				nad("public", ns, `, "ipam": {"type": "host-local", "subnet": "192.168.0.0/24"}`),
+			},
+			selectors: map[string]string{"public": "public", "cluster": "public"},
+		},
+		{
+			name: "network in another namespace",
+			nads: []*networkv1.NetworkAttachmentDefinition{
+				nad("public", "multus", `, "ipam": {"type": "static", "addresses": [{"address": "10.0.0.10/16"}]}`),
+			},
+			selectors: map[string]string{"public": "multus/public"},
+		},
+		{
+			name: "whereabouts range with a start and an end",
+			nads: []*networkv1.NetworkAttachmentDefinition{
+				nad("public", ns, `, "ipam": {"type": "whereabouts", "range": "192.168.0.10-192.168.0.20/24"}`),
+				nad("cluster", ns, `, "ipam": {"type": "whereabouts", "range": "192.168.1.10-192.168.1.20/24"}`),
+			},
+			selectors: map[string]string{"public": "public", "cluster": "cluster"},
+		},
+		{
+			name:      "network does not exist",
+			nads:      []*networkv1.NetworkAttachmentDefinition{nad("public", ns, `, "ipam": {"type": "host-local", "subnet": "192.168.0.0/24"}`)},
+			selectors: map[string]string{"public": "public", "cluster": "cluster"},
+			wantErr:   true,
+		},
+		{
+			name:      "network in the wrong namespace",
+			nads:      []*networkv1.NetworkAttachmentDefinition{nad("public", ns, `, "ipam": {"type": "host-local", "subnet": "192.168.0.0/24"}`)},
+			selectors: map[string]string{"public": "multus/public"},
+			wantErr:   true,
+		},
+		{
+			name:      "no IPAM",
+			nads:      []*networkv1.NetworkAttachmentDefinition{nad("public", ns, "")},
+			selectors: map[string]string{"public": "public"},
+			wantErr:   true,
+		},
+		{
+			name:      "IPAM without a range",
+			nads:      []*networkv1.NetworkAttachmentDefinition{nad("public", ns, `, "ipam": {"type": "dhcp"}`)},
+			selectors: map[string]string{"public": "public"},
+			wantErr:   true,
+		},
+		{
+			name:      "invalid range",
+			nads:      []*networkv1.NetworkAttachmentDefinition{nad("public", ns, `, "ipam": {"type": "host-local", "subnet": "192.168.0.0"}`)},
+			selectors: map[string]string{"public": "public"},
+			wantErr:   true,
+		},
+		{
+			name: "overlapping public and cluster networks",
+			nads: []*networkv1.NetworkAttachmentDefinition{
+				nad("public", ns, `, "ipam": {"type": "host-local", "subnet": "192.168.0.0/16"}`),
+				nad("cluster", ns, `, "ipam": {"type": "host-local", "ranges": [[{"subnet": "10.0.0.0/24"}], [{"subnet": "192.168.1.0/24"}]]}`),
+			},
+			selectors: map[string]string{"public": "public", "cluster": "cluster"},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &clusterd.Context{NetworkClient: fakenetclient.NewSimpleClientset().K8sCniCncfIoV1()}
+			for _, n := range tt.nads {
+				_, err := ctx.NetworkClient.NetworkAttachmentDefinitions(n.Namespace).Create(context.TODO(), n, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			err := ValidateMultusNetworks(ctx, ns, tt.selectors)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -52,7 +52,9 @@ func UpdateClusterCondition(c *clusterd.Context, cluster *cephv1.CephCluster, na
 		// The transient conditions are not persisted. However, if the currently requested condition is not expected to
 		// reset the transient conditions, they are retained. For example, if the operator is checking for ceph health
 		// in the middle of the reconcile, the progress condition should not be reset by the status check update.
-		if preserveAllConditions || condition.Reason == cephv1.ClusterCreatedReason || condition.Reason == cephv1.ClusterConnectedReason {
+		// The result of the network validation is kept until the next validation.
+		if preserveAllConditions || condition.Reason == cephv1.ClusterCreatedReason || condition.Reason == cephv1.ClusterConnectedReason ||
+			condition.Type == cephv1.ConditionNetworkValidated {
 			if conditionType != condition.Type {
 				conditions = append(conditions, condition)
 				continue
@@ -313,7 +313,7 @@ func DaemonFlags(cluster *cephclient.ClusterInfo, spec *cephv1.ClusterSpec, daem
 		config.NewFlag("setgroup", "ceph"),
 	)
 
-	if spec.Network.IPFamily == cephv1.IPv6 {
+	if spec.Network.BindIPv6() {
 		flags = append(flags, config.NewFlag("ms-bind-ipv6", "true"))
 	}
 
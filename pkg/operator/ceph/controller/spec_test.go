@@ -192,6 +192,22 @@ func TestDaemonFlags(t *testing.T) {
 				"--mon-cluster-log-to-stderr=true", "--log-stderr-prefix=debug ", "--default-log-to-file=false", "--default-mon-cluster-log-to-file=false",
 				"--mon-host=$(ROOK_CEPH_MON_HOST)", "--mon-initial-members=$(ROOK_CEPH_MON_INITIAL_MEMBERS)", "--id=daemon-id", "--setuser=ceph", "--setgroup=ceph"},
 		},
+		{
+			label: "case 3: dual stack",
+			clusterInfo: &client.ClusterInfo{
+				FSID: "id",
+			},
+			clusterSpec: &cephv1.ClusterSpec{
+				Network: cephv1.NetworkSpec{
+					IPFamily: "DualStack",
+				},
+			},
+			daemonID: "daemon-id",
+			expected: []string{"--fsid=id", "--keyring=/etc/ceph/keyring-store/keyring", "--log-to-stderr=true", "--err-to-stderr=true",
+				"--mon-cluster-log-to-stderr=true", "--log-stderr-prefix=debug ", "--default-log-to-file=false", "--default-mon-cluster-log-to-file=false",
+				"--mon-host=$(ROOK_CEPH_MON_HOST)", "--mon-initial-members=$(ROOK_CEPH_MON_INITIAL_MEMBERS)", "--id=daemon-id", "--setuser=ceph", "--setgroup=ceph",
+				"--ms-bind-ipv6=true"},
+		},
 	}
 
 	for _, tc := range testcases {
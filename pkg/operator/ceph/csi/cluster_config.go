@@ -37,8 +37,12 @@ var (
 )
 
 type csiClusterConfigEntry struct {
-	ClusterID string   `json:"clusterID"`
-	Monitors  []string `json:"monitors"`
+	ClusterID      string   `json:"clusterID"`
+	Monitors       []string `json:"monitors"`
+	RadosNamespace string   `json:"radosNamespace,omitempty"`
+	// Namespace is the namespace of the CephCluster of a RADOS namespace entry, the monitors of the entry are updated
+	// with the ones of the cluster. It is ignored by ceph-csi.
+	Namespace string `json:"namespace,omitempty"`
 }
 
 type csiClusterConfig []csiClusterConfigEntry
@@ -51,9 +55,7 @@ func FormatCsiClusterConfig(
 	cc := make(csiClusterConfig, 1)
 	cc[0].ClusterID = clusterKey
 	cc[0].Monitors = []string{}
-	for _, m := range mons {
-		cc[0].Monitors = append(cc[0].Monitors, m.Endpoint)
-	}
+	cc[0].Monitors = monEndpoints(mons)
 
 	ccJson, err := json.Marshal(cc)
 	if err != nil {
@@ -79,10 +81,13 @@ func formatCsiClusterConfig(cc csiClusterConfig) (string, error) {
 	return string(ccJson), nil
 }
 
+// monEndpoints returns the endpoints of the mons. When the cluster requires msgr2, the endpoints are on the msgr2
+// port 3300 and the kernel clients need the ms_mode option in the map and mount options of the storage classes. In a
+// dual-stack cluster, the mons have an endpoint in each IP family so the clients of both families can connect.
 func monEndpoints(mons map[string]*cephclient.MonInfo) []string {
 	endpoints := make([]string, 0)
 	for _, m := range mons {
-		endpoints = append(endpoints, m.Endpoint)
+		endpoints = append(endpoints, m.Endpoints()...)
 	}
 	return endpoints
 }
@@ -107,7 +112,10 @@ func UpdateCsiClusterConfig(
 			centry.Monitors = monEndpoints(mons)
 			found = true
 			cc[i] = centry
-			break
+		} else if centry.Namespace == clusterKey {
+			// the RADOS namespaces of the cluster use the same mons
+			centry.Monitors = monEndpoints(mons)
+			cc[i] = centry
 		}
 	}
 	if !found {
@@ -118,6 +126,54 @@ func UpdateCsiClusterConfig(
 	return formatCsiClusterConfig(cc)
 }
 
+// UpdateCsiRadosNamespaceConfig returns a json-formatted string containing
+// the csi cluster config with the entry of a RADOS namespace. The entry is
+// identified by clusterID and uses the mons of the cluster in clusterNamespace.
+func UpdateCsiRadosNamespaceConfig(
+	curr, clusterID, clusterNamespace, radosNamespace string, mons map[string]*cephclient.MonInfo) (string, error) {
+
+	cc, err := parseCsiClusterConfig(curr)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse current csi cluster config")
+	}
+
+	centry := csiClusterConfigEntry{
+		ClusterID:      clusterID,
+		Monitors:       monEndpoints(mons),
+		RadosNamespace: radosNamespace,
+		Namespace:      clusterNamespace,
+	}
+	found := false
+	for i := range cc {
+		if cc[i].ClusterID == clusterID {
+			cc[i] = centry
+			found = true
+			break
+		}
+	}
+	if !found {
+		cc = append(cc, centry)
+	}
+	return formatCsiClusterConfig(cc)
+}
+
+// RemoveCsiClusterConfigEntry returns a json-formatted string containing
+// the csi cluster config without the entry of clusterID.
+func RemoveCsiClusterConfigEntry(curr, clusterID string) (string, error) {
+	cc, err := parseCsiClusterConfig(curr)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to parse current csi cluster config")
+	}
+
+	newcc := make(csiClusterConfig, 0, len(cc))
+	for _, centry := range cc {
+		if centry.ClusterID != clusterID {
+			newcc = append(newcc, centry)
+		}
+	}
+	return formatCsiClusterConfig(newcc)
+}
+
 // CreateCsiConfigMap creates an empty config map that will be later used
 // to provide cluster configuration to ceph-csi. If a config map already
 // exists, it will return it.
@@ -158,13 +214,47 @@ func CreateCsiConfigMap(namespace string, clientset kubernetes.Interface, ownerI
 func SaveClusterConfig(
 	clientset kubernetes.Interface, clusterNamespace string,
 	clusterInfo *cephclient.ClusterInfo, l sync.Locker) error {
-	ctx := context.TODO()
-
 	if !CSIEnabled() {
 		return nil
 	}
 	l.Lock()
 	defer l.Unlock()
+	return updateCsiConfigMap(clientset, func(currData string) (string, error) {
+		return UpdateCsiClusterConfig(currData, clusterNamespace, clusterInfo.Monitors)
+	})
+}
+
+// SaveRadosNamespaceConfig adds the entry of a RADOS namespace to the config
+// map of ceph-csi. The clusterID is the value a storage class uses to target
+// the RADOS namespace. The config map is not locked, a concurrent update of
+// the config map fails on a conflict and must be retried.
+func SaveRadosNamespaceConfig(
+	clientset kubernetes.Interface, clusterID, clusterNamespace, radosNamespace string,
+	clusterInfo *cephclient.ClusterInfo) error {
+
+	if !CSIEnabled() {
+		return nil
+	}
+	return updateCsiConfigMap(clientset, func(currData string) (string, error) {
+		return UpdateCsiRadosNamespaceConfig(currData, clusterID, clusterNamespace, radosNamespace, clusterInfo.Monitors)
+	})
+}
+
+// RemoveRadosNamespaceConfig removes the entry of a RADOS namespace from the
+// config map of ceph-csi.
+func RemoveRadosNamespaceConfig(clientset kubernetes.Interface, clusterID string) error {
+	if !CSIEnabled() {
+		return nil
+	}
+	return updateCsiConfigMap(clientset, func(currData string) (string, error) {
+		return RemoveCsiClusterConfigEntry(currData, clusterID)
+	})
+}
+
+// updateCsiConfigMap updates the contents of the config map of ceph-csi
+func updateCsiConfigMap(clientset kubernetes.Interface, update func(currData string) (string, error)) error {
+	ctx := context.TODO()
+
 	// csi is deployed into the same namespace as the operator
 	csiNamespace := os.Getenv(k8sutil.PodNamespaceEnvVar)
 	if csiNamespace == "" {
@@ -179,16 +269,18 @@ func SaveClusterConfig(
 		return errors.Wrap(err, "failed to fetch current csi config map")
 	}
 
-	// update ConfigMap contents for current cluster
+	// update ConfigMap contents
 	currData := configMap.Data[ConfigKey]
 	if currData == "" {
 		currData = "[]"
 	}
-	newData, err := UpdateCsiClusterConfig(
-		currData, clusterNamespace, clusterInfo.Monitors)
+	newData, err := update(currData)
 	if err != nil {
 		return errors.Wrap(err, "failed to update csi config map data")
 	}
+	if configMap.Data == nil {
+		configMap.Data = map[string]string{}
+	}
 	configMap.Data[ConfigKey] = newData
 
 	// update ConfigMap with new contents
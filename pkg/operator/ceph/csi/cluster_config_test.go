@@ -83,7 +83,58 @@ func TestUpdateCsiClusterConfig(t *testing.T) {
 	assert.Contains(t, cc[1].Monitors, "20.1.1.2:5000")
 	assert.Equal(t, len(cc[1].Monitors), 2)
 
+	// the mons of a dual-stack cluster have an endpoint in each IP family
+	mons2["flim"].SecondaryEndpoint = "[fd00::1]:5000"
+	s, err = UpdateCsiClusterConfig(s, "beta", mons2)
+	assert.NoError(t, err)
+	cc, err = parseCsiClusterConfig(s)
+	assert.NoError(t, err)
+	assert.Contains(t, cc[1].Monitors, "20.1.1.1:5000")
+	assert.Contains(t, cc[1].Monitors, "[fd00::1]:5000")
+	assert.Equal(t, len(cc[1].Monitors), 3)
+
 	// does it return error on garbage input?
 	_, err = UpdateCsiClusterConfig("qqq", "beta", mons2)
 	assert.Error(t, err)
 }
+
+func TestUpdateCsiRadosNamespaceConfig(t *testing.T) {
+	mons := map[string]*cephclient.MonInfo{
+		"foo": {Name: "foo", Endpoint: "1.2.3.4:5000"},
+	}
+	s, err := UpdateCsiClusterConfig("[]", "rook-ceph", mons)
+	assert.NoError(t, err)
+
+	// add a rados namespace of the cluster
+	s, err = UpdateCsiRadosNamespaceConfig(s, "abc123", "rook-ceph", "team-a", mons)
+	assert.NoError(t, err)
+	assert.Equal(t, s,
+		`[{"clusterID":"rook-ceph","monitors":["1.2.3.4:5000"]},{"clusterID":"abc123","monitors":["1.2.3.4:5000"],"radosNamespace":"team-a","namespace":"rook-ceph"}]`)
+
+	// the mons of the rados namespace are updated with the ones of the cluster
+	mons["bar"] = &cephclient.MonInfo{Name: "bar", Endpoint: "10.11.12.13:5000"}
+	s, err = UpdateCsiClusterConfig(s, "rook-ceph", mons)
+	assert.NoError(t, err)
+	cc, err := parseCsiClusterConfig(s)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cc), 2)
+	assert.Equal(t, cc[1].ClusterID, "abc123")
+	assert.Equal(t, cc[1].RadosNamespace, "team-a")
+	assert.Contains(t, cc[1].Monitors, "10.11.12.13:5000")
+	assert.Equal(t, len(cc[1].Monitors), 2)
+
+	// updating the rados namespace does not add a new entry
+	s, err = UpdateCsiRadosNamespaceConfig(s, "abc123", "rook-ceph", "team-a", mons)
+	assert.NoError(t, err)
+	cc, err = parseCsiClusterConfig(s)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cc), 2)
+
+	// remove the rados namespace
+	s, err = RemoveCsiClusterConfigEntry(s, "abc123")
+	assert.NoError(t, err)
+	cc, err = parseCsiClusterConfig(s)
+	assert.NoError(t, err)
+	assert.Equal(t, len(cc), 1)
+	assert.Equal(t, cc[0].ClusterID, "rook-ceph")
+}
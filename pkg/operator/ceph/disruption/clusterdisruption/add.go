@@ -160,5 +160,23 @@ func Add(mgr manager.Manager, context *controllerconfig.Context) error {
 		return err
 	}
 
+	// Watch for CephNFSes and enqueue the CephCluster in the namespace
+	err = c.Watch(&source.Kind{Type: &cephv1.CephNFS{}}, enqueueByNamespace)
+	if err != nil {
+		return err
+	}
+
+	// Watch for CephRBDMirrors and enqueue the CephCluster in the namespace
+	err = c.Watch(&source.Kind{Type: &cephv1.CephRBDMirror{}}, enqueueByNamespace)
+	if err != nil {
+		return err
+	}
+
+	// Watch for CephNodeMaintenances and enqueue the CephCluster in the namespace
+	err = c.Watch(&source.Kind{Type: &cephv1.CephNodeMaintenance{}}, enqueueByNamespace)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,74 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterdisruption
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/rbd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Setting naive minAvailable for NFS at: n - 1
+// getting n from the cephnfs.spec.server.active
+func (r *ReconcileClusterDisruption) reconcileCephNFS(namespace string) error {
+	cephNFSList := &cephv1.CephNFSList{}
+	err := r.client.List(context.TODO(), cephNFSList, client.InNamespace(namespace))
+	if err != nil {
+		return errors.Wrapf(err, "could not list the CephNFSes in namespace %q", namespace)
+	}
+
+	for i := range cephNFSList.Items {
+		nfs := &cephNFSList.Items[i]
+		pdbName := fmt.Sprintf("rook-ceph-nfs-%s", nfs.Name)
+		selector := map[string]string{"ceph_nfs": nfs.Name}
+
+		minAvailable := int32(nfs.Spec.Server.Active - 1)
+		err := r.reconcileDaemonPDB(nfs, "CephNFS", pdbName, selector, minAvailable)
+		if err != nil {
+			return errors.Wrapf(err, "could not reconcile cephnfs pdb %q", pdbName)
+		}
+	}
+	return nil
+}
+
+// Setting naive minAvailable for rbd-mirror at: n - 1
+// getting n from the cephrbdmirror.spec.count
+func (r *ReconcileClusterDisruption) reconcileCephRBDMirror(namespace string) error {
+	cephRBDMirrorList := &cephv1.CephRBDMirrorList{}
+	err := r.client.List(context.TODO(), cephRBDMirrorList, client.InNamespace(namespace))
+	if err != nil {
+		return errors.Wrapf(err, "could not list the CephRBDMirrors in namespace %q", namespace)
+	}
+
+	for i := range cephRBDMirrorList.Items {
+		rbdMirror := &cephRBDMirrorList.Items[i]
+		pdbName := fmt.Sprintf("%s-%s", rbd.AppName, rbdMirror.Name)
+		selector := map[string]string{k8sutil.AppAttr: rbd.AppName, rbd.CephRBDMirrorLabel: rbdMirror.Name}
+
+		minAvailable := int32(rbdMirror.Spec.Count - 1)
+		err := r.reconcileDaemonPDB(rbdMirror, "CephRBDMirror", pdbName, selector, minAvailable)
+		if err != nil {
+			return errors.Wrapf(err, "could not reconcile cephrbdmirror pdb %q", pdbName)
+		}
+	}
+	return nil
+}
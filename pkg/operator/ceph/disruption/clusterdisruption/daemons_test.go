@@ -0,0 +1,128 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package clusterdisruption
+
+import (
+	"context"
+	"testing"
+
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/stretchr/testify/assert"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func getPDB(t *testing.T, r *ReconcileClusterDisruption, name string) *policyv1beta1.PodDisruptionBudget {
+	pdb := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "rook-ceph"}, pdb)
+	if kerrors.IsNotFound(err) {
+		return nil
+	}
+	assert.NoError(t, err)
+	return pdb
+}
+
+func TestReconcileCephNFSPDB(t *testing.T) {
+	nfs := &cephv1.CephNFS{
+		ObjectMeta: metav1.ObjectMeta{Name: "nfs1", Namespace: "rook-ceph", UID: "nfs-uid"},
+		Spec:       cephv1.NFSGaneshaSpec{Server: cephv1.GaneshaServerSpec{Active: 3}},
+	}
+	r := createFakeReconcileClusterDisruption(t, nfs)
+
+	err := r.reconcileCephNFS("rook-ceph")
+	assert.NoError(t, err)
+	pdb := getPDB(t, r, "rook-ceph-nfs-nfs1")
+	assert.NotNil(t, pdb)
+	assert.Equal(t, 2, pdb.Spec.MinAvailable.IntValue())
+	assert.Equal(t, map[string]string{"ceph_nfs": "nfs1"}, pdb.Spec.Selector.MatchLabels)
+	assert.Equal(t, "CephNFS", pdb.OwnerReferences[0].Kind)
+	assert.Equal(t, "ceph.rook.io/v1", pdb.OwnerReferences[0].APIVersion)
+	assert.Equal(t, types.UID("nfs-uid"), pdb.OwnerReferences[0].UID)
+
+	// the budget scales with the number of servers
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: "nfs1", Namespace: "rook-ceph"}, nfs)
+	assert.NoError(t, err)
+	nfs.Spec.Server.Active = 2
+	err = r.client.Update(context.TODO(), nfs)
+	assert.NoError(t, err)
+	err = r.reconcileCephNFS("rook-ceph")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, getPDB(t, r, "rook-ceph-nfs-nfs1").Spec.MinAvailable.IntValue())
+
+	// a single server has no redundancy to protect
+	err = r.client.Get(context.TODO(), types.NamespacedName{Name: "nfs1", Namespace: "rook-ceph"}, nfs)
+	assert.NoError(t, err)
+	nfs.Spec.Server.Active = 1
+	err = r.client.Update(context.TODO(), nfs)
+	assert.NoError(t, err)
+	err = r.reconcileCephNFS("rook-ceph")
+	assert.NoError(t, err)
+	assert.Nil(t, getPDB(t, r, "rook-ceph-nfs-nfs1"))
+}
+
+func TestReconcileCephRBDMirrorPDB(t *testing.T) {
+	rbdMirror := &cephv1.CephRBDMirror{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-rbd-mirror", Namespace: "rook-ceph"},
+		Spec:       cephv1.RBDMirroringSpec{Count: 2},
+	}
+	r := createFakeReconcileClusterDisruption(t, rbdMirror)
+
+	err := r.reconcileCephRBDMirror("rook-ceph")
+	assert.NoError(t, err)
+	pdb := getPDB(t, r, "rook-ceph-rbd-mirror-my-rbd-mirror")
+	assert.NotNil(t, pdb)
+	assert.Equal(t, 1, pdb.Spec.MinAvailable.IntValue())
+	assert.Equal(t, map[string]string{"app": "rook-ceph-rbd-mirror", "ceph_rbd_mirror": "my-rbd-mirror"}, pdb.Spec.Selector.MatchLabels)
+	assert.Equal(t, "CephRBDMirror", pdb.OwnerReferences[0].Kind)
+}
+
+func TestReconcileDaemonPDBs(t *testing.T) {
+	r := createFakeReconcileClusterDisruption(t)
+
+	// rgw: n - 1, two instances are enough for a budget
+	objectStores := &cephv1.CephObjectStoreList{Items: []cephv1.CephObjectStore{
+		{ObjectMeta: metav1.ObjectMeta{Name: "store1", Namespace: "rook-ceph"}, Spec: cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Instances: 2}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "store2", Namespace: "rook-ceph"}, Spec: cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Instances: 1}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "store3", Namespace: "rook-ceph"}, Spec: cephv1.ObjectStoreSpec{Gateway: cephv1.GatewaySpec{Instances: 4}}},
+	}}
+	err := r.reconcileCephObjectStore(objectStores)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, getPDB(t, r, "rook-ceph-rgw-store1").Spec.MinAvailable.IntValue())
+	assert.Nil(t, getPDB(t, r, "rook-ceph-rgw-store2"))
+	assert.Equal(t, 3, getPDB(t, r, "rook-ceph-rgw-store3").Spec.MinAvailable.IntValue())
+	assert.Equal(t, "CephObjectStore", getPDB(t, r, "rook-ceph-rgw-store3").OwnerReferences[0].Kind)
+
+	// mds: the standbys of active standby count in the budget
+	filesystems := &cephv1.CephFilesystemList{Items: []cephv1.CephFilesystem{
+		{ObjectMeta: metav1.ObjectMeta{Name: "fs1", Namespace: "rook-ceph"}, Spec: cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "fs2", Namespace: "rook-ceph"}, Spec: cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 1, ActiveStandby: true}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "fs3", Namespace: "rook-ceph"}, Spec: cephv1.FilesystemSpec{MetadataServer: cephv1.MetadataServerSpec{ActiveCount: 2, ActiveStandby: true}}},
+	}}
+	err = r.reconcileCephFilesystem(filesystems)
+	assert.NoError(t, err)
+	assert.Nil(t, getPDB(t, r, "rook-ceph-mds-fs1"))
+	assert.Equal(t, 1, getPDB(t, r, "rook-ceph-mds-fs2").Spec.MinAvailable.IntValue())
+	assert.Equal(t, 2, getPDB(t, r, "rook-ceph-mds-fs3").Spec.MinAvailable.IntValue())
+
+	// the budget is removed when the instances are scaled down to one
+	objectStores.Items[0].Spec.Gateway.Instances = 1
+	err = r.reconcileCephObjectStore(objectStores)
+	assert.NoError(t, err)
+	assert.Nil(t, getPDB(t, r, "rook-ceph-rgw-store1"))
+}
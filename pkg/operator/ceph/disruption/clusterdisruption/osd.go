@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	cephClient "github.com/rook/rook/pkg/daemon/ceph/client"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
@@ -334,7 +335,15 @@ func (r *ReconcileClusterDisruption) updateNoout(clusterInfo *cephclient.Cluster
 	if err != nil {
 		return errors.Wrapf(err, "failed to get osddump for reconciling maintenance noout in namespace %s", clusterInfo.Namespace)
 	}
+	hostsInMaintenance, err := r.hostsInMaintenance(clusterInfo.Namespace)
+	if err != nil {
+		return err
+	}
 	for _, failureDomainName := range allFailureDomains {
+		if hostsInMaintenance.Has(failureDomainName) {
+			logger.Debugf("noout of %q is managed by its node maintenance", failureDomainName)
+			continue
+		}
 		drainingFailureDomainTimeStampKey := fmt.Sprintf("%s-noout-last-set-at", failureDomainName)
 		if drainingFailureDomain == failureDomainName {
 
@@ -373,6 +382,26 @@ func (r *ReconcileClusterDisruption) updateNoout(clusterInfo *cephclient.Cluster
 	return nil
 }
 
+// hostsInMaintenance returns the CRUSH hosts whose OSDs are stopped by a CephNodeMaintenance. Their noout flag is
+// set until the maintenance ends, regardless of the maintenance timeout of the drains.
+func (r *ReconcileClusterDisruption) hostsInMaintenance(namespace string) (sets.String, error) {
+	maintenances := &cephv1.CephNodeMaintenanceList{}
+	if err := r.client.List(context.TODO(), maintenances, client.InNamespace(namespace)); err != nil {
+		return nil, errors.Wrapf(err, "failed to list the node maintenances in namespace %q", namespace)
+	}
+
+	hosts := sets.NewString()
+	for _, m := range maintenances.Items {
+		if m.Status == nil || m.Status.Host == "" {
+			continue
+		}
+		if m.Status.Phase == cephv1.NodeMaintenanceStopping || m.Status.Phase == cephv1.NodeMaintenanceActive {
+			hosts.Insert(m.Status.Host)
+		}
+	}
+	return hosts, nil
+}
+
 func (r *ReconcileClusterDisruption) getOSDFailureDomains(clusterInfo *cephClient.ClusterInfo, request reconcile.Request, poolFailureDomain string) ([]string, []string, error) {
 	osdDeploymentList := &appsv1.DeploymentList{}
 	namespaceListOpts := client.InNamespace(request.Namespace)
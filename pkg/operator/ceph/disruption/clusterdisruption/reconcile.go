@@ -168,6 +168,18 @@ func (r *ReconcileClusterDisruption) reconcile(request reconcile.Request) (recon
 		return reconcile.Result{}, err
 	}
 
+	// reconcile the pdbs for nfs servers
+	err = r.reconcileCephNFS(request.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// reconcile the pdbs for rbd mirrors
+	err = r.reconcileCephRBDMirror(request.Namespace)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
 	// no pools, no need to reconcile OSD PDB
 	if poolCount < 1 {
 		return reconcile.Result{}, nil
@@ -21,9 +21,12 @@ import (
 
 	"github.com/pkg/errors"
 
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 func (r *ReconcileClusterDisruption) createStaticPDB(pdb *policyv1beta1.PodDisruptionBudget) error {
@@ -53,3 +56,53 @@ func (r *ReconcileClusterDisruption) reconcileStaticPDB(request types.Namespaced
 	}
 	return nil
 }
+
+func (r *ReconcileClusterDisruption) deleteStaticPDB(request types.NamespacedName) error {
+	existingPDB := &policyv1beta1.PodDisruptionBudget{}
+	err := r.client.Get(context.TODO(), request, existingPDB)
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get pdb %q", request.Name)
+	}
+
+	logger.Infof("deleting pdb %q since its daemons have no redundancy", request.Name)
+	err = r.client.Delete(context.TODO(), existingPDB)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return errors.Wrapf(err, "failed to delete pdb %q", request.Name)
+	}
+	return nil
+}
+
+// reconcileDaemonPDB reconciles the pdb of the daemons of a ceph CR. The pdb is owned by the CR so it is garbage
+// collected with it, and it is deleted when minAvailable is below 1 since there is no redundancy to protect.
+func (r *ReconcileClusterDisruption) reconcileDaemonPDB(owner metav1.Object, kind, pdbName string, selector map[string]string, minAvailable int32) error {
+	request := types.NamespacedName{Name: pdbName, Namespace: owner.GetNamespace()}
+	if minAvailable < 1 {
+		return r.deleteStaticPDB(request)
+	}
+
+	// The type meta of the listed CRs is not set, so the owner reference is built from the kind
+	blockOwnerDeletion := false
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pdbName,
+			Namespace: owner.GetNamespace(),
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion:         cephv1.SchemeGroupVersion.String(),
+					Kind:               kind,
+					Name:               owner.GetName(),
+					UID:                owner.GetUID(),
+					BlockOwnerDeletion: &blockOwnerDeletion,
+				},
+			},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			Selector:     &metav1.LabelSelector{MatchLabels: selector},
+			MinAvailable: &intstr.IntOrString{IntVal: minAvailable},
+		},
+	}
+	return r.reconcileStaticPDB(request, pdb)
+}
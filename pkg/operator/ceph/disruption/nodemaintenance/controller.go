@@ -0,0 +1,244 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemaintenance
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-node-maintenance-controller"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephNodeMaintenanceKind = reflect.TypeOf(cephv1.CephNodeMaintenance{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephNodeMaintenanceKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// The OSDs are stopped one at a time, the next OSD is stopped after this interval
+var waitForRequeueIfStopping = reconcile.Result{Requeue: true, RequeueAfter: 30 * time.Second}
+
+// ReconcileNodeMaintenance reconciles a CephNodeMaintenance object
+type ReconcileNodeMaintenance struct {
+	client      client.Client
+	scheme      *runtime.Scheme
+	context     *clusterd.Context
+	clusterInfo *cephclient.ClusterInfo
+}
+
+// Add creates a new CephNodeMaintenance Controller and adds it to the Manager. The Manager will set fields on the
+// Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	if err := cephv1.AddToScheme(mgr.GetScheme()); err != nil {
+		panic(err)
+	}
+
+	return &ReconcileNodeMaintenance{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephNodeMaintenance CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephNodeMaintenance{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephNodeMaintenance object and makes changes based on the state read
+// and what is in the CephNodeMaintenance.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileNodeMaintenance) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileNodeMaintenance) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephNodeMaintenance instance
+	maintenance := &cephv1.CephNodeMaintenance{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, maintenance)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephNodeMaintenance resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return reconcile.Result{}, errors.Wrap(err, "failed to get CephNodeMaintenance")
+	}
+
+	// Set a finalizer so the OSDs are started again before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.client, maintenance)
+	if err != nil {
+		return reconcile.Result{}, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// There is nothing to restore once the CephCluster is gone
+		if !maintenance.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			err = opcontroller.RemoveFinalizer(r.client, maintenance)
+			if err != nil {
+				return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to remove finalizer")
+			}
+			return reconcile.Result{}, nil
+		}
+		logger.Debugf("CephCluster resource not ready in namespace %q, retrying in %q.", request.NamespacedName.Namespace, reconcileResponse.RequeueAfter.String())
+		return reconcileResponse, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, request.NamespacedName.Namespace)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	// DELETE: the maintenance ends when the CR is deleted
+	if !maintenance.GetDeletionTimestamp().IsZero() {
+		if err := r.endMaintenance(maintenance); err != nil {
+			return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to end node maintenance %q", maintenance.Name)
+		}
+
+		err = opcontroller.RemoveFinalizer(r.client, maintenance)
+		if err != nil {
+			return reconcile.Result{}, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, nil
+	}
+
+	// The maintenance also ends when it expires, the CR is kept to report it
+	if isExpired(maintenance.Spec, time.Now()) {
+		if maintenance.Status != nil && maintenance.Status.Phase == cephv1.NodeMaintenanceExpired {
+			return reconcile.Result{}, nil
+		}
+		if err := r.endMaintenance(maintenance); err != nil {
+			return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to end expired node maintenance %q", maintenance.Name)
+		}
+		status := &cephv1.NodeMaintenanceStatus{Phase: cephv1.NodeMaintenanceExpired, Message: "the maintenance expired and the osds were started"}
+		if maintenance.Status != nil {
+			status.Host = maintenance.Status.Host
+		}
+		updateStatus(r.client, request.NamespacedName, status)
+		logger.Infof("node maintenance %q expired", maintenance.Name)
+		return reconcile.Result{}, nil
+	}
+
+	status, err := r.startMaintenance(maintenance)
+	if err != nil {
+		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+			logger.Info("skipping reconcile since operator is still initializing")
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+		}
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to start node maintenance %q", maintenance.Name)
+	}
+	updateStatus(r.client, request.NamespacedName, status)
+
+	switch status.Phase {
+	case cephv1.NodeMaintenanceStopping:
+		logger.Debugf("stopping the osds of node maintenance %q. %s", maintenance.Name, status.Message)
+		return waitForRequeueIfStopping, nil
+	case cephv1.NodeMaintenanceFailed:
+		logger.Errorf("failed to start node maintenance %q. %s", maintenance.Name, status.Message)
+		return reconcile.Result{}, nil
+	}
+
+	// Check again when the maintenance expires
+	if maintenance.Spec.Until != nil {
+		return reconcile.Result{Requeue: true, RequeueAfter: time.Until(maintenance.Spec.Until.Time)}, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// isExpired returns whether the expiry of the maintenance passed
+func isExpired(spec cephv1.NodeMaintenanceSpec, now time.Time) bool {
+	return spec.Until != nil && !now.Before(spec.Until.Time)
+}
+
+// updateStatus updates an object with a given status
+func updateStatus(client client.Client, name types.NamespacedName, status *cephv1.NodeMaintenanceStatus) {
+	maintenance := &cephv1.CephNodeMaintenance{}
+	if err := client.Get(context.TODO(), name, maintenance); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephNodeMaintenance resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve node maintenance %q to update status to %q. %v", name, status.Phase, err)
+		return
+	}
+
+	maintenance.Status = status
+	if err := opcontroller.UpdateStatus(client, maintenance); err != nil {
+		logger.Errorf("failed to set node maintenance %q status to %q. %v", name, status.Phase, err)
+		return
+	}
+	logger.Debugf("node maintenance %q status updated to %q", name, status.Phase)
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemaintenance
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	apps "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeCeph answers the ceph commands used to stop the OSDs of a host
+type fakeCeph struct {
+	nooutHosts  map[string]bool
+	okToStop    map[string]bool
+	norebalance bool
+}
+
+func (f *fakeCeph) executor() *exectest.MockExecutor {
+	return &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] != "osd" {
+				return "", nil
+			}
+			switch args[1] {
+			case "dump":
+				flags := map[string][]string{}
+				for host := range f.nooutHosts {
+					flags[host] = []string{"noout"}
+				}
+				clusterFlags := ""
+				if f.norebalance {
+					clusterFlags = "norebalance"
+				}
+				out, _ := json.Marshal(map[string]interface{}{"flags": clusterFlags, "crush_node_flags": flags})
+				return string(out), nil
+			case "set":
+				f.norebalance = true
+			case "unset":
+				f.norebalance = false
+			case "set-group":
+				f.nooutHosts[args[3]] = true
+			case "unset-group":
+				delete(f.nooutHosts, args[3])
+			case "ok-to-stop":
+				if !f.okToStop[args[2]] {
+					return "", errors.New("mock osd not ok to stop")
+				}
+			}
+			return "", nil
+		},
+	}
+}
+
+func osdReplicas(t *testing.T, clientset kubernetes.Interface, namespace, name string) (int32, string) {
+	d, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	assert.NoError(t, err)
+	return *d.Spec.Replicas, d.Labels[osd.MaintenanceLabelKey]
+}
+
+func TestCephNodeMaintenanceController(t *testing.T) {
+	ctx := context.TODO()
+	namespace := "rook-ceph"
+
+	maintenance := &cephv1.CephNodeMaintenance{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade", Namespace: namespace},
+		Spec:       cephv1.NodeMaintenanceSpec{Node: "node1.example.com"},
+		TypeMeta:   controllerTypeMeta,
+	}
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Status: cephv1.ClusterStatus{
+			Phase:      k8sutil.ReadyStatus,
+			CephStatus: &cephv1.CephStatus{Health: "HEALTH_OK"},
+		},
+	}
+	object := []runtime.Object{maintenance, cephCluster}
+
+	ceph := &fakeCeph{nooutHosts: map[string]bool{}, okToStop: map[string]bool{"0": true}}
+	clientset := test.New(t, 3)
+	c := &clusterd.Context{
+		Executor:      ceph.executor(),
+		RookClientset: rookclient.NewSimpleClientset(),
+		Clientset:     clientset,
+	}
+
+	// Mock clusterInfo
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: namespace},
+		Data: map[string][]byte{
+			"fsid":         []byte("fsid"),
+			"mon-secret":   []byte("monsecret"),
+			"admin-secret": []byte("adminsecret"),
+		},
+		Type: k8sutil.RookType,
+	}
+	_, err := clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// osd.0 and osd.1 are on host node1, osd.2 is on host node2
+	replicas := int32(1)
+	for _, o := range []struct{ id, host string }{{"0", "node1"}, {"1", "node1"}, {"2", "node2"}} {
+		labels := map[string]string{"app": osd.AppName, osd.OsdIdLabelKey: o.id, hostLocationLabel: o.host}
+		d := &apps.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-" + o.id, Namespace: namespace, Labels: labels},
+			Spec:       apps.DeploymentSpec{Replicas: &replicas},
+		}
+		_, err = clientset.AppsV1().Deployments(namespace).Create(ctx, d, metav1.CreateOptions{})
+		assert.NoError(t, err)
+	}
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-osd-0-xyz", Namespace: namespace, Labels: map[string]string{"app": osd.AppName, hostLocationLabel: "node1"}},
+		Spec:       v1.PodSpec{NodeName: "node1.example.com"},
+	}
+	_, err = clientset.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephNodeMaintenance{}, &cephv1.CephNodeMaintenanceList{})
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
+	r := &ReconcileNodeMaintenance{client: cl, scheme: s, context: c}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "upgrade", Namespace: namespace}}
+
+	// noout is set on the host of the node and its first OSD is stopped
+	res, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	err = cl.Get(ctx, req.NamespacedName, maintenance)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.NodeMaintenanceStopping, maintenance.Status.Phase)
+	assert.Equal(t, "node1", maintenance.Status.Host)
+	assert.Equal(t, map[string]bool{"node1": true}, ceph.nooutHosts)
+	assert.True(t, ceph.norebalance)
+	assert.True(t, maintenance.Status.NoRebalance)
+	count, label := osdReplicas(t, clientset, namespace, "rook-ceph-osd-0")
+	assert.Equal(t, int32(0), count)
+	assert.Equal(t, "upgrade", label)
+
+	// The next OSD is not stopped until it is ok to stop
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	err = cl.Get(ctx, req.NamespacedName, maintenance)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.NodeMaintenanceStopping, maintenance.Status.Phase)
+	assert.Equal(t, "waiting for osd 1 to be ok to stop", maintenance.Status.Message)
+	count, _ = osdReplicas(t, clientset, namespace, "rook-ceph-osd-1")
+	assert.Equal(t, int32(1), count)
+
+	// All the OSDs of the host are stopped, the OSDs of the other hosts keep running
+	ceph.okToStop["1"] = true
+	_, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	err = cl.Get(ctx, req.NamespacedName, maintenance)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.NodeMaintenanceActive, maintenance.Status.Phase)
+	assert.Equal(t, []int{0, 1}, maintenance.Status.StoppedOSDs)
+	count, label = osdReplicas(t, clientset, namespace, "rook-ceph-osd-2")
+	assert.Equal(t, int32(1), count)
+	assert.Equal(t, "", label)
+
+	// The OSDs are started and noout is unset when the maintenance expires
+	maintenance.Spec.Until = &metav1.Time{Time: time.Now().Add(-time.Minute)}
+	err = cl.Update(ctx, maintenance)
+	assert.NoError(t, err)
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	err = cl.Get(ctx, req.NamespacedName, maintenance)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.NodeMaintenanceExpired, maintenance.Status.Phase)
+	assert.Equal(t, 0, len(ceph.nooutHosts))
+	assert.False(t, ceph.norebalance)
+	for _, name := range []string{"rook-ceph-osd-0", "rook-ceph-osd-1"} {
+		count, label = osdReplicas(t, clientset, namespace, name)
+		assert.Equal(t, int32(1), count)
+		assert.Equal(t, "", label)
+	}
+}
+
+func TestNoRebalance(t *testing.T) {
+	namespace := "rook-ceph"
+	held := &cephv1.CephNodeMaintenance{
+		ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: namespace},
+		Status:     &cephv1.NodeMaintenanceStatus{Phase: cephv1.NodeMaintenanceActive, Host: "node2", NoRebalance: true},
+	}
+	maintenance := &cephv1.CephNodeMaintenance{
+		ObjectMeta: metav1.ObjectMeta{Name: "upgrade", Namespace: namespace},
+		Status:     &cephv1.NodeMaintenanceStatus{Phase: cephv1.NodeMaintenanceActive, Host: "node1", NoRebalance: true},
+	}
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephNodeMaintenance{}, &cephv1.CephNodeMaintenanceList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(held, maintenance).Build()
+	ceph := &fakeCeph{nooutHosts: map[string]bool{"node1": true, "node2": true}, norebalance: true}
+	c := &clusterd.Context{Clientset: test.New(t, 3), Executor: ceph.executor()}
+	r := &ReconcileNodeMaintenance{client: cl, scheme: s, context: c, clusterInfo: cephclient.AdminClusterInfo(namespace)}
+
+	// norebalance is kept while another maintenance holds it
+	err := r.endMaintenance(maintenance)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"node2": true}, ceph.nooutHosts)
+	assert.True(t, ceph.norebalance)
+
+	// norebalance is unset by the last maintenance holding it
+	err = cl.Delete(context.TODO(), maintenance)
+	assert.NoError(t, err)
+	err = r.endMaintenance(held)
+	assert.NoError(t, err)
+	assert.False(t, ceph.norebalance)
+
+	// norebalance set by an admin is not held by the maintenance
+	ceph.norebalance = true
+	held.Status.Phase = cephv1.NodeMaintenanceExpired
+	err = cl.Update(context.TODO(), held)
+	assert.NoError(t, err)
+	osdDump := &cephclient.OSDDump{Flags: "norebalance"}
+	noRebalance, err := r.setNoRebalance(&cephv1.CephNodeMaintenance{ObjectMeta: metav1.ObjectMeta{Name: "new", Namespace: namespace}}, osdDump)
+	assert.NoError(t, err)
+	assert.False(t, noRebalance)
+}
+
+func TestMaintenanceValidation(t *testing.T) {
+	namespace := "rook-ceph"
+	ceph := &fakeCeph{nooutHosts: map[string]bool{}}
+	r := &ReconcileNodeMaintenance{context: &clusterd.Context{Clientset: test.New(t, 3), Executor: ceph.executor()}}
+
+	// neither the node nor the host is set
+	maintenance := &cephv1.CephNodeMaintenance{ObjectMeta: metav1.ObjectMeta{Name: "m", Namespace: namespace}}
+	status, err := r.startMaintenance(maintenance)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.NodeMaintenanceFailed, status.Phase)
+
+	// no OSD runs on the node
+	maintenance.Spec.Node = "node3"
+	status, err = r.startMaintenance(maintenance)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.NodeMaintenanceFailed, status.Phase)
+	assert.Equal(t, `no osd found on node "node3"`, status.Message)
+	assert.Equal(t, 0, len(ceph.nooutHosts))
+}
+
+func TestIsExpired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, isExpired(cephv1.NodeMaintenanceSpec{}, now))
+	assert.False(t, isExpired(cephv1.NodeMaintenanceSpec{Until: &metav1.Time{Time: now.Add(time.Hour)}}, now))
+	assert.True(t, isExpired(cephv1.NodeMaintenanceSpec{Until: &metav1.Time{Time: now}}, now))
+}
@@ -0,0 +1,23 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+/*
+Package nodemaintenance implements the controller for the maintenance of a node. The OSDs of the node or CRUSH host
+of a CephNodeMaintenance are stopped one at a time with the noout flag set on the host so their data is not
+rebalanced, and they are started again when the CephNodeMaintenance is deleted or expires.
+*/
+
+package nodemaintenance
@@ -0,0 +1,247 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodemaintenance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/osd"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// the flag set on the CRUSH host during the maintenance so the data of its OSDs is not rebalanced
+	nooutFlag = "noout"
+	// the flag set on the cluster during the maintenance so the data is not moved while the OSDs are stopped,
+	// ceph cannot set it on a single CRUSH host
+	norebalanceFlag = "norebalance"
+)
+
+var (
+	hostLocationLabel = fmt.Sprintf(osd.TopologyLocationLabel, "host")
+)
+
+// startMaintenance stops the next OSD of the host of the maintenance and returns the resulting status. A single
+// OSD is stopped at each call, and only if ceph reports that it is ok to stop it.
+func (r *ReconcileNodeMaintenance) startMaintenance(maintenance *cephv1.CephNodeMaintenance) (*cephv1.NodeMaintenanceStatus, error) {
+	if maintenance.Spec.Node == "" && maintenance.Spec.Host == "" {
+		return failedStatus("", "either the node or the host of the maintenance must be set"), nil
+	}
+	host, err := r.maintenanceHost(maintenance)
+	if err != nil {
+		return nil, err
+	}
+	if host == "" {
+		return failedStatus("", "no osd found on node %q", maintenance.Spec.Node), nil
+	}
+
+	deployments, err := r.osdDeployments(hostLocationLabel + "=" + host)
+	if err != nil {
+		return nil, err
+	}
+	if len(deployments) == 0 {
+		return failedStatus(host, "no osd found on host %q", host), nil
+	}
+
+	osdDump, err := cephclient.GetOSDDump(r.context, r.clusterInfo)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get osd dump")
+	}
+	if _, err := osdDump.UpdateFlagOnCrushUnit(r.context, r.clusterInfo, true, host, nooutFlag); err != nil {
+		return nil, errors.Wrapf(err, "failed to set %q on host %q", nooutFlag, host)
+	}
+
+	noRebalance, err := r.setNoRebalance(maintenance, osdDump)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &cephv1.NodeMaintenanceStatus{Phase: cephv1.NodeMaintenanceStopping, Host: host, NoRebalance: noRebalance}
+	for i := range deployments {
+		d := &deployments[i]
+		osdID, err := strconv.Atoi(d.Labels[osd.OsdIdLabelKey])
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get the id of osd deployment %q", d.Name)
+		}
+
+		if d.Labels[osd.MaintenanceLabelKey] == maintenance.Name && d.Spec.Replicas != nil && *d.Spec.Replicas == 0 {
+			if d.Status.Replicas > 0 {
+				status.Message = fmt.Sprintf("waiting for osd %d to stop", osdID)
+				return status, nil
+			}
+			status.StoppedOSDs = append(status.StoppedOSDs, osdID)
+			continue
+		}
+
+		if err := cephclient.OSDsOkToStop(r.context, r.clusterInfo, []int{osdID}); err != nil {
+			logger.Infof("osd %d of host %q is not ok to stop yet. %v", osdID, host, err)
+			status.Message = fmt.Sprintf("waiting for osd %d to be ok to stop", osdID)
+			return status, nil
+		}
+
+		logger.Infof("stopping osd %d of host %q for node maintenance %q", osdID, host, maintenance.Name)
+		if d.Labels == nil {
+			d.Labels = map[string]string{}
+		}
+		d.Labels[osd.MaintenanceLabelKey] = maintenance.Name
+		replicas := int32(0)
+		d.Spec.Replicas = &replicas
+		if _, err := r.context.Clientset.AppsV1().Deployments(d.Namespace).Update(context.TODO(), d, metav1.UpdateOptions{}); err != nil {
+			return nil, errors.Wrapf(err, "failed to stop osd %d", osdID)
+		}
+		status.Message = fmt.Sprintf("stopping osd %d", osdID)
+		return status, nil
+	}
+
+	logger.Infof("all the osds %v of host %q are stopped for node maintenance %q", status.StoppedOSDs, host, maintenance.Name)
+	status.Phase = cephv1.NodeMaintenanceActive
+	status.Message = fmt.Sprintf("%d osds are stopped", len(status.StoppedOSDs))
+	return status, nil
+}
+
+// endMaintenance starts the OSDs stopped by the maintenance, unsets noout on their host and unsets norebalance if
+// no other maintenance holds it
+func (r *ReconcileNodeMaintenance) endMaintenance(maintenance *cephv1.CephNodeMaintenance) error {
+	deployments, err := r.osdDeployments(fmt.Sprintf("%s=%s", osd.MaintenanceLabelKey, maintenance.Name))
+	if err != nil {
+		return err
+	}
+
+	for i := range deployments {
+		d := &deployments[i]
+		logger.Infof("starting osd %s stopped by node maintenance %q", d.Labels[osd.OsdIdLabelKey], maintenance.Name)
+		delete(d.Labels, osd.MaintenanceLabelKey)
+		replicas := int32(1)
+		d.Spec.Replicas = &replicas
+		if _, err := r.context.Clientset.AppsV1().Deployments(d.Namespace).Update(context.TODO(), d, metav1.UpdateOptions{}); err != nil {
+			return errors.Wrapf(err, "failed to start osd %s", d.Labels[osd.OsdIdLabelKey])
+		}
+	}
+
+	if maintenance.Status == nil || maintenance.Status.Host == "" {
+		return nil
+	}
+	osdDump, err := cephclient.GetOSDDump(r.context, r.clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to get osd dump")
+	}
+	if _, err := osdDump.UpdateFlagOnCrushUnit(r.context, r.clusterInfo, false, maintenance.Status.Host, nooutFlag); err != nil {
+		return errors.Wrapf(err, "failed to unset %q on host %q", nooutFlag, maintenance.Status.Host)
+	}
+
+	if !maintenance.Status.NoRebalance || !osdDump.IsFlagSet(norebalanceFlag) {
+		return nil
+	}
+	held, err := r.noRebalanceHeldByOthers(maintenance)
+	if err != nil {
+		return err
+	}
+	if held {
+		logger.Infof("not unsetting %q, it is held by another node maintenance", norebalanceFlag)
+		return nil
+	}
+	if err := cephclient.UnsetFlag(r.context, r.clusterInfo, norebalanceFlag); err != nil {
+		return errors.Wrapf(err, "failed to unset %q", norebalanceFlag)
+	}
+	return nil
+}
+
+// setNoRebalance sets norebalance on the cluster and returns whether the flag is held by the maintenance. A flag
+// already set by an admin is not held by the maintenance, so it is not unset when the maintenance ends.
+func (r *ReconcileNodeMaintenance) setNoRebalance(maintenance *cephv1.CephNodeMaintenance, osdDump *cephclient.OSDDump) (bool, error) {
+	if maintenance.Status != nil && maintenance.Status.NoRebalance {
+		return true, nil
+	}
+	if osdDump.IsFlagSet(norebalanceFlag) {
+		return r.noRebalanceHeldByOthers(maintenance)
+	}
+	if err := cephclient.SetFlag(r.context, r.clusterInfo, norebalanceFlag); err != nil {
+		return false, errors.Wrapf(err, "failed to set %q", norebalanceFlag)
+	}
+	return true, nil
+}
+
+// noRebalanceHeldByOthers returns whether another maintenance in progress holds the norebalance flag
+func (r *ReconcileNodeMaintenance) noRebalanceHeldByOthers(maintenance *cephv1.CephNodeMaintenance) (bool, error) {
+	maintenances := &cephv1.CephNodeMaintenanceList{}
+	if err := r.client.List(context.TODO(), maintenances, client.InNamespace(maintenance.Namespace)); err != nil {
+		return false, errors.Wrap(err, "failed to list node maintenances")
+	}
+	for _, m := range maintenances.Items {
+		if m.Name == maintenance.Name || !m.GetDeletionTimestamp().IsZero() || m.Status == nil || !m.Status.NoRebalance {
+			continue
+		}
+		if m.Status.Phase == cephv1.NodeMaintenanceStopping || m.Status.Phase == cephv1.NodeMaintenanceActive {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// maintenanceHost returns the CRUSH host of the maintenance. When only the node is set, the host is the one of the
+// OSDs running on the node. An empty host is returned if no OSD runs on the node.
+func (r *ReconcileNodeMaintenance) maintenanceHost(maintenance *cephv1.CephNodeMaintenance) (string, error) {
+	// the host is kept in the status so the maintenance does not move when the OSD pods are stopped
+	if maintenance.Status != nil && maintenance.Status.Host != "" {
+		return maintenance.Status.Host, nil
+	}
+	if maintenance.Spec.Host != "" {
+		return maintenance.Spec.Host, nil
+	}
+
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s", osd.AppName)}
+	pods, err := r.context.Clientset.CoreV1().Pods(maintenance.Namespace).List(context.TODO(), listOpts)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list osd pods")
+	}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == maintenance.Spec.Node && pod.Labels[hostLocationLabel] != "" {
+			return pod.Labels[hostLocationLabel], nil
+		}
+	}
+	return "", nil
+}
+
+// osdDeployments returns the OSD deployments matching the selector sorted by OSD ID
+func (r *ReconcileNodeMaintenance) osdDeployments(selector string) ([]appsv1.Deployment, error) {
+	listOpts := metav1.ListOptions{LabelSelector: fmt.Sprintf("app=%s,%s", osd.AppName, selector)}
+	deployments, err := r.context.Clientset.AppsV1().Deployments(r.clusterInfo.Namespace).List(context.TODO(), listOpts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list osd deployments with selector %q", listOpts.LabelSelector)
+	}
+
+	items := deployments.Items
+	sort.Slice(items, func(i, j int) bool {
+		a, _ := strconv.Atoi(items[i].Labels[osd.OsdIdLabelKey])
+		b, _ := strconv.Atoi(items[j].Labels[osd.OsdIdLabelKey])
+		return a < b
+	})
+	return items, nil
+}
+
+// failedStatus returns the status of a maintenance that cannot start
+func failedStatus(host, format string, args ...interface{}) *cephv1.NodeMaintenanceStatus {
+	return &cephv1.NodeMaintenanceStatus{Phase: cephv1.NodeMaintenanceFailed, Host: host, Message: fmt.Sprintf(format, args...)}
+}
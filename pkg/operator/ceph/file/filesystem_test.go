@@ -92,6 +92,14 @@ func isBasePoolOperation(fsName, command string, args []string) bool {
 	} else if reflect.DeepEqual(args[0:4], []string{"fs", "add_data_pool", fsName, fsName + "-data0"}) {
 		return true
 	}
+	for _, pool := range []string{fsName + "-metadata", fsName + "-data0", fsName + "-data1"} {
+		// the autoscaler settings absent from the spec are reset
+		if reflect.DeepEqual(args[0:6], []string{"osd", "pool", "set", pool, "pg_num_min", "0"}) ||
+			reflect.DeepEqual(args[0:6], []string{"osd", "pool", "set", pool, "target_size_bytes", "0"}) ||
+			reflect.DeepEqual(args[0:6], []string{"osd", "pool", "set", pool, "bulk", "false"}) {
+			return true
+		}
+	}
 	return false
 }
 
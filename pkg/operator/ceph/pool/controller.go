@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 
 	"github.com/coreos/pkg/capnslog"
 	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
@@ -67,11 +68,15 @@ type ReconcileCephBlockPool struct {
 	context           *clusterd.Context
 	clusterInfo       *cephclient.ClusterInfo
 	blockPoolChannels map[string]*blockPoolHealth
+	// the copies of the data of the images migrated by the conversions of the pools
+	imageExecutions     map[string]*imageExecution
+	imageExecutionsLock sync.Mutex
 }
 
 type blockPoolHealth struct {
-	stopChan          chan struct{}
-	monitoringRunning bool
+	stopChan               chan struct{}
+	monitoringRunning      bool
+	usageMonitoringRunning bool
 }
 
 // Add creates a new CephBlockPool Controller and adds it to the Manager. The Manager will set fields on the Controller
@@ -235,17 +240,28 @@ func (r *ReconcileCephBlockPool) reconcile(request reconcile.Request) (reconcile
 	r.clusterInfo.CephVersion = *cephVersion
 
 	// If the CephCluster has enabled the "pg_autoscaler" module and is running Nautilus
-	// we force the pg_autoscale_mode to "on"
+	// we force the pg_autoscale_mode to "on" unless the pool sets its own mode
 	_, propertyExists := cephBlockPool.Spec.Parameters[cephclient.PgAutoscaleModeProperty]
 	if mgr.IsModuleInSpec(cephCluster.Spec.Mgr.Modules, mgr.PgautoscalerModuleName) &&
 		!cephVersion.IsAtLeastOctopus() &&
-		!propertyExists {
+		!propertyExists && cephBlockPool.Spec.Autoscaler.Mode == "" {
 		if len(cephBlockPool.Spec.Parameters) == 0 {
 			cephBlockPool.Spec.Parameters = make(map[string]string)
 		}
 		cephBlockPool.Spec.Parameters[cephclient.PgAutoscaleModeProperty] = cephclient.PgAutoscaleModeOn
 	}
 
+	// CONVERT: the type of the pool was changed between replicated and erasure coded
+	reconcileResponse, err = r.reconcileConversion(&cephCluster.Spec, cephBlockPool)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure, nil)
+		return reconcileResponse, errors.Wrapf(err, "failed to convert pool %q.", cephBlockPool.GetName())
+	}
+	if reconcileResponse.Requeue {
+		updateStatus(r.client, request.NamespacedName, cephv1.ConditionProgressing, nil)
+		return reconcileResponse, nil
+	}
+
 	// CREATE/UPDATE
 	reconcileResponse, err = r.reconcileCreatePool(clusterInfo, &cephCluster.Spec, cephBlockPool)
 	if err != nil {
@@ -290,6 +306,25 @@ func (r *ReconcileCephBlockPool) reconcile(request reconcile.Request) (reconcile
 		updateStatus(r.client, request.NamespacedName, cephv1.ConditionReady, nil)
 	}
 
+	// Run the goroutine to update the usage of the pool
+	if !cephBlockPool.Spec.StatusCheck.Usage.Disabled && !r.blockPoolChannels[cephBlockPool.Name].usageMonitoringRunning {
+		checker := newUsageChecker(r.context, r.client, r.clusterInfo, request.NamespacedName, &cephBlockPool.Spec)
+		go checker.checkUsage(r.blockPoolChannels[cephBlockPool.Name].stopChan)
+		r.blockPoolChannels[cephBlockPool.Name].usageMonitoringRunning = true
+	}
+
+	// Report the placement group counts of the pool, they are refreshed at each reconcile
+	r.updatePGAutoscalerStatus(request.NamespacedName)
+
+	// Report the settings of the erasure code profile which differ from the spec
+	if cephBlockPool.Spec.IsErasureCoded() {
+		ecPoolName := cephBlockPool.Name
+		if dataPoolName := convertedDataPool(cephBlockPool); dataPoolName != "" {
+			ecPoolName = dataPoolName
+		}
+		r.updateErasureCodeProfileStatus(request.NamespacedName, ecPoolName, cephBlockPool.Spec)
+	}
+
 	// Return and do not requeue
 	logger.Debug("done reconciling")
 	return reconcile.Result{}, nil
@@ -307,6 +342,15 @@ func (r *ReconcileCephBlockPool) reconcileCreatePool(clusterInfo *cephclient.Clu
 
 // Create the pool
 func createPool(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, clusterSpec *cephv1.ClusterSpec, p *cephv1.CephBlockPool) error {
+	// the erasure coded settings of a converted pool apply to its data pool, the pool stays replicated
+	if dataPoolName := convertedDataPool(p); dataPoolName != "" {
+		logger.Infof("updating data pool %q of pool %q in namespace %q", dataPoolName, p.Name, p.Namespace)
+		if err := cephclient.CreatePoolWithProfile(context, clusterInfo, clusterSpec, dataPoolName, p.Spec, poolApplicationNameRBD); err != nil {
+			return errors.Wrapf(err, "failed to update data pool %q", dataPoolName)
+		}
+		return nil
+	}
+
 	// create the pool
 	logger.Infof("creating pool %q in namespace %q", p.Name, p.Namespace)
 	if err := cephclient.CreatePoolWithProfile(context, clusterInfo, clusterSpec, p.Name, p.Spec, poolApplicationNameRBD); err != nil {
@@ -318,17 +362,30 @@ func createPool(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo,
 
 // Delete the pool
 func deletePool(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, p *cephv1.CephBlockPool) error {
+	poolNames := []string{p.Name}
+	// the data pool of a converted pool is deleted after the pool, once the pool has no images
+	if p.Status != nil && p.Status.Conversion != nil && p.Status.Conversion.DataPool != "" {
+		poolNames = append(poolNames, p.Status.Conversion.DataPool)
+	}
+
+	return deletePools(context, clusterInfo, poolNames...)
+}
+
+// deletePools deletes the pools in order, the pools which don't exist are skipped
+func deletePools(context *clusterd.Context, clusterInfo *cephclient.ClusterInfo, poolNames ...string) error {
 	pools, err := cephclient.ListPoolSummaries(context, clusterInfo)
 	if err != nil {
 		return errors.Wrap(err, "failed to list pools")
 	}
 
 	// Only delete the pool if it exists...
-	for _, pool := range pools {
-		if pool.Name == p.Name {
-			err := cephclient.DeletePool(context, clusterInfo, p.Name)
-			if err != nil {
-				return errors.Wrapf(err, "failed to delete pool %q", p.Name)
+	for _, poolName := range poolNames {
+		for _, pool := range pools {
+			if pool.Name == poolName {
+				err := cephclient.DeletePool(context, clusterInfo, poolName)
+				if err != nil {
+					return errors.Wrapf(err, "failed to delete pool %q", poolName)
+				}
 			}
 		}
 	}
@@ -73,13 +73,16 @@ func TestCreatePool(t *testing.T) {
 
 func TestDeletePool(t *testing.T) {
 	failOnDelete := false
+	deleted := []string{}
 	clusterInfo := &cephclient.ClusterInfo{Namespace: "myns"}
 	executor := &exectest.MockExecutor{
 		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
 			if command == "ceph" && args[1] == "lspools" {
-				return `[{"poolnum":1,"poolname":"mypool"}]`, nil
+				return `[{"poolnum":1,"poolname":"mypool"},{"poolnum":2,"poolname":"mypool-data"}]`, nil
 			} else if command == "ceph" && args[1] == "pool" && args[2] == "get" {
 				return `{"pool": "mypool","pool_id": 1,"size":1}`, nil
+			} else if command == "ceph" && args[1] == "pool" && args[2] == "delete" {
+				deleted = append(deleted, args[3])
 			}
 
 			return "", nil
@@ -110,6 +113,14 @@ func TestDeletePool(t *testing.T) {
 	p := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
 	err := deletePool(context, clusterInfo, p)
 	assert.Nil(t, err)
+	assert.Equal(t, []string{"mypool"}, deleted)
+
+	// the data pool of a converted pool is deleted after the pool
+	deleted = []string{}
+	p.Status = &cephv1.CephBlockPoolStatus{Conversion: &cephv1.PoolConversionStatus{DataPool: "mypool-data"}}
+	err = deletePool(context, clusterInfo, p)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"mypool", "mypool-data"}, deleted)
 
 	// succeed even if the pool doesn't exist
 	p = &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "otherpool", Namespace: clusterInfo.Namespace}}
@@ -0,0 +1,390 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+var (
+	// waitForRequeueIfImageBlocked is the delay before retrying the migration of the images in use or failing to migrate
+	waitForRequeueIfImageBlocked = reconcile.Result{Requeue: true, RequeueAfter: time.Minute}
+	// waitForRequeueIfImageExecuting is the delay before checking again the copy of the data of an image
+	waitForRequeueIfImageExecuting = reconcile.Result{Requeue: true, RequeueAfter: 30 * time.Second}
+)
+
+// imageExecution is the copy of the data of an image running in the background, "rbd migration execute" copies the
+// whole image and would block the reconcile of the pools for as long
+type imageExecution struct {
+	done bool
+	err  error
+}
+
+// conversionDataPoolName is the name of the erasure coded pool created to convert a replicated pool
+func conversionDataPoolName(poolName string) string {
+	return fmt.Sprintf("%s-data", poolName)
+}
+
+// convertedDataPool returns the data pool of a pool converted to erasure coded, the erasure coded settings of its
+// spec apply to the data pool. It is empty if the pool was not converted.
+func convertedDataPool(cephBlockPool *cephv1.CephBlockPool) string {
+	if !cephBlockPool.Spec.IsErasureCoded() || cephBlockPool.Status == nil || cephBlockPool.Status.Conversion == nil {
+		return ""
+	}
+	return cephBlockPool.Status.Conversion.DataPool
+}
+
+// reconcileConversion converts a pool between replicated and erasure coded when the type of its spec is changed.
+// Ceph cannot change the type of a pool and rbd cannot store the header of an image in an erasure coded pool, so a
+// replicated pool is converted with an erasure coded data pool "<pool>-data" owned by the CephBlockPool. The data of
+// the images is migrated live to the data pool while their headers stay in the pool, and the data pool is set as the
+// default data pool of the pool so that the new images, for instance from a storage class with "pool: <pool>", store
+// their data in it too. A converted pool is converted back to replicated by migrating the data of its images back to
+// the pool and deleting the data pool. The conversion is in progress as long as the returned result requeues.
+func (r *ReconcileCephBlockPool) reconcileConversion(clusterSpec *cephv1.ClusterSpec, cephBlockPool *cephv1.CephBlockPool) (reconcile.Result, error) {
+	conversion := &cephv1.PoolConversionStatus{}
+	if cephBlockPool.Status != nil && cephBlockPool.Status.Conversion != nil {
+		conversion = cephBlockPool.Status.Conversion.DeepCopy()
+	}
+
+	if cephBlockPool.Spec.IsErasureCoded() {
+		return r.convertToErasureCoded(clusterSpec, cephBlockPool, conversion)
+	}
+	return r.convertToReplicated(cephBlockPool, conversion)
+}
+
+// convertToErasureCoded migrates the data of the images of a replicated pool to its erasure coded data pool
+func (r *ReconcileCephBlockPool) convertToErasureCoded(clusterSpec *cephv1.ClusterSpec, cephBlockPool *cephv1.CephBlockPool, conversion *cephv1.PoolConversionStatus) (reconcile.Result, error) {
+	poolName := cephBlockPool.Name
+	if conversion.Target == cephv1.PoolConversionToErasureCoded && conversion.Phase == cephv1.PoolConversionCompleted {
+		return reconcile.Result{}, nil
+	}
+
+	if conversion.DataPool == "" {
+		pools, err := cephclient.ListPoolSummaries(r.context, r.clusterInfo)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to list pools")
+		}
+		poolExists := map[string]bool{}
+		for _, pool := range pools {
+			poolExists[pool.Name] = true
+		}
+		if !poolExists[poolName] {
+			return reconcile.Result{}, nil
+		}
+		details, err := cephclient.GetPoolDetails(r.context, r.clusterInfo, poolName)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to get pool %q details", poolName)
+		}
+		if details.ErasureCodeProfile != "" {
+			// the pool is already erasure coded
+			return reconcile.Result{}, nil
+		}
+
+		if cephBlockPool.Spec.Mirroring.Enabled {
+			return opcontroller.ImmediateRetryResult, errors.Errorf("the mirrored pool %q cannot be converted to an erasure coded pool", poolName)
+		}
+		dataPoolName := conversionDataPoolName(poolName)
+		if poolExists[dataPoolName] {
+			return opcontroller.ImmediateRetryResult, errors.Errorf("failed to convert pool %q, pool %q already exists", poolName, dataPoolName)
+		}
+
+		// the data pool is recorded before it is created so that it is deleted with the pool
+		logger.Infof("converting replicated pool %q to an erasure coded pool", poolName)
+		conversion = &cephv1.PoolConversionStatus{
+			Phase:    cephv1.PoolConversionMigrating,
+			Target:   cephv1.PoolConversionToErasureCoded,
+			DataPool: dataPoolName,
+		}
+		if err := r.updateConversionStatus(cephBlockPool, conversion); err != nil {
+			return opcontroller.ImmediateRetryResult, err
+		}
+	} else if conversion.Target != cephv1.PoolConversionToErasureCoded {
+		// the pool was being converted back to replicated
+		logger.Infof("converting pool %q to an erasure coded pool again", poolName)
+		conversion.Phase = cephv1.PoolConversionMigrating
+		conversion.Target = cephv1.PoolConversionToErasureCoded
+		conversion.Images = nil
+	}
+
+	if err := cephclient.CreatePoolWithProfile(r.context, r.clusterInfo, clusterSpec, conversion.DataPool, cephBlockPool.Spec, poolApplicationNameRBD); err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to create erasure coded pool %q", conversion.DataPool)
+	}
+	// the new images store their data in the data pool, they don't need to be migrated
+	if err := cephclient.SetDefaultDataPool(r.context, r.clusterInfo, poolName, conversion.DataPool); err != nil {
+		return opcontroller.ImmediateRetryResult, err
+	}
+
+	res, err := r.migrateImages(cephBlockPool, conversion, conversion.DataPool)
+	if err != nil || res.Requeue {
+		return res, err
+	}
+
+	logger.Infof("pool %q converted to an erasure coded pool, the data of its images is stored in pool %q", poolName, conversion.DataPool)
+	conversion.Phase = cephv1.PoolConversionCompleted
+	if err := r.updateConversionStatus(cephBlockPool, conversion); err != nil {
+		return opcontroller.ImmediateRetryResult, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// convertToReplicated migrates the data of the images of a converted pool back to the pool and deletes its data pool.
+// The pools created erasure coded are not converted, the headers of their images are stored in other pools.
+func (r *ReconcileCephBlockPool) convertToReplicated(cephBlockPool *cephv1.CephBlockPool, conversion *cephv1.PoolConversionStatus) (reconcile.Result, error) {
+	poolName := cephBlockPool.Name
+	if conversion.DataPool == "" {
+		return reconcile.Result{}, nil
+	}
+
+	if conversion.Target != cephv1.PoolConversionToReplicated {
+		logger.Infof("converting pool %q back to a replicated pool", poolName)
+		conversion.Phase = cephv1.PoolConversionMigrating
+		conversion.Target = cephv1.PoolConversionToReplicated
+		conversion.Images = nil
+	}
+
+	// the new images store their data in the pool
+	if err := cephclient.RemoveDefaultDataPool(r.context, r.clusterInfo, poolName); err != nil {
+		return opcontroller.ImmediateRetryResult, err
+	}
+
+	res, err := r.migrateImages(cephBlockPool, conversion, poolName)
+	if err != nil || res.Requeue {
+		return res, err
+	}
+
+	if err := deletePools(r.context, r.clusterInfo, conversion.DataPool); err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to delete data pool %q", conversion.DataPool)
+	}
+	ecProfileName := cephclient.GetErasureCodeProfileForPool(conversion.DataPool)
+	if err := cephclient.DeleteErasureCodeProfile(r.context, r.clusterInfo, ecProfileName); err != nil {
+		logger.Errorf("failed to delete erasure code profile %q. %v", ecProfileName, err)
+	}
+
+	logger.Infof("pool %q converted back to a replicated pool", poolName)
+	conversion.Phase = cephv1.PoolConversionCompleted
+	conversion.DataPool = ""
+	if err := r.updateConversionStatus(cephBlockPool, conversion); err != nil {
+		return opcontroller.ImmediateRetryResult, err
+	}
+	return reconcile.Result{}, nil
+}
+
+// migrateImages runs the next step of the migration of the images of the pool to the data pool, one image is
+// migrated at a time. The images in use or failing to migrate are skipped and reported in the status while the other
+// images keep migrating. The returned result requeues until all the images are migrated.
+func (r *ReconcileCephBlockPool) migrateImages(cephBlockPool *cephv1.CephBlockPool, conversion *cephv1.PoolConversionStatus, dataPoolName string) (reconcile.Result, error) {
+	poolName := cephBlockPool.Name
+	images, err := cephclient.ListImages(r.context, r.clusterInfo, poolName)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to list the images of pool %q", poolName)
+	}
+	conversion.Images = mergeImageMigrations(conversion.Images, images)
+
+	blocked := false
+	for i := range conversion.Images {
+		image := &conversion.Images[i]
+		if image.State == cephv1.ImageMigrationCommitted {
+			continue
+		}
+		if err := r.migrateImage(poolName, dataPoolName, image); err != nil {
+			logger.Warningf("failed to migrate image %q of pool %q. %v", image.Name, poolName, err)
+			image.Message = err.Error()
+			blocked = true
+			continue
+		}
+		image.Message = ""
+		if image.State == cephv1.ImageMigrationCommitted {
+			continue
+		}
+
+		// the next step of the image is run at the next reconcile
+		if err := r.updateConversionStatus(cephBlockPool, conversion); err != nil {
+			return opcontroller.ImmediateRetryResult, err
+		}
+		if image.State == cephv1.ImageMigrationExecuting {
+			return waitForRequeueIfImageExecuting, nil
+		}
+		return opcontroller.ImmediateRetryResult, nil
+	}
+
+	if err := r.updateConversionStatus(cephBlockPool, conversion); err != nil {
+		return opcontroller.ImmediateRetryResult, err
+	}
+	if blocked {
+		return waitForRequeueIfImageBlocked, nil
+	}
+	return reconcile.Result{}, nil
+}
+
+// migrateImage runs the next step of the live migration of an image. The step is found from the state of the image
+// in rbd rather than from the status of the pool, in case the operator stopped before the status was updated. The
+// data pool is the pool of the image to migrate its data back to the pool.
+func (r *ReconcileCephBlockPool) migrateImage(poolName, dataPoolName string, image *cephv1.ImageMigrationStatus) error {
+	status, err := cephclient.GetImageStatus(r.context, r.clusterInfo, poolName, image.Name)
+	if err != nil {
+		return err
+	}
+
+	if status.Migration == nil {
+		migrated, err := r.isImageMigrated(poolName, dataPoolName, image.Name)
+		if err != nil {
+			return err
+		}
+		if migrated {
+			image.State = cephv1.ImageMigrationCommitted
+			return nil
+		}
+		// the clients of the image must reopen it once the migration is prepared
+		if len(status.Watchers) > 0 {
+			return errors.Errorf("image %q is in use, its clients must be stopped to migrate it", image.Name)
+		}
+		if err := cephclient.PrepareImageMigration(r.context, r.clusterInfo, poolName, image.Name, dataPoolName); err != nil {
+			return err
+		}
+		image.State = cephv1.ImageMigrationPrepared
+		return nil
+	}
+
+	switch status.Migration.State {
+	case cephclient.ImageMigrationStatePrepared, cephclient.ImageMigrationStateExecuting:
+		// an interrupted copy, for instance by a restart of the operator, is resumed by executing the migration again
+		if err := r.executeImageMigration(poolName, image.Name); err != nil {
+			return err
+		}
+		image.State = cephv1.ImageMigrationExecuting
+		return nil
+	case cephclient.ImageMigrationStateExecuted:
+		if err := cephclient.CommitImageMigration(r.context, r.clusterInfo, poolName, image.Name); err != nil {
+			return err
+		}
+		// the migration may have been started before the pool was converted the other way
+		migrated, err := r.isImageMigrated(poolName, dataPoolName, image.Name)
+		if err != nil {
+			return err
+		}
+		image.State = cephv1.ImageMigrationPending
+		if migrated {
+			image.State = cephv1.ImageMigrationCommitted
+		}
+		return nil
+	}
+
+	return errors.Errorf("migration of image %q is %q", image.Name, status.Migration.State)
+}
+
+// executeImageMigration copies the data of an image in the background, the copy is started if it is not running. It
+// returns the error of the copy once it failed, the copy is started again by the next call.
+func (r *ReconcileCephBlockPool) executeImageMigration(poolName, imageName string) error {
+	r.imageExecutionsLock.Lock()
+	defer r.imageExecutionsLock.Unlock()
+	if r.imageExecutions == nil {
+		r.imageExecutions = map[string]*imageExecution{}
+	}
+
+	key := fmt.Sprintf("%s/%s", poolName, imageName)
+	if execution, ok := r.imageExecutions[key]; ok {
+		if !execution.done {
+			logger.Debugf("the data of image %q of pool %q is being copied", imageName, poolName)
+			return nil
+		}
+		delete(r.imageExecutions, key)
+		return execution.err
+	}
+
+	execution := &imageExecution{}
+	r.imageExecutions[key] = execution
+	clusterdContext, clusterInfo := r.context, r.clusterInfo
+	go func() {
+		err := cephclient.ExecuteImageMigration(clusterdContext, clusterInfo, poolName, imageName)
+		r.imageExecutionsLock.Lock()
+		defer r.imageExecutionsLock.Unlock()
+		if err == nil {
+			// rbd reports the migration as executed
+			delete(r.imageExecutions, key)
+			return
+		}
+		execution.done = true
+		execution.err = err
+	}()
+	return nil
+}
+
+// isImageMigrated returns whether the data of an image is stored in the data pool
+func (r *ReconcileCephBlockPool) isImageMigrated(poolName, dataPoolName, imageName string) (bool, error) {
+	info, err := cephclient.GetImageInfo(r.context, r.clusterInfo, poolName, imageName)
+	if err != nil {
+		return false, err
+	}
+	// the data of an image without data pool is stored in its pool
+	if info.DataPool == "" {
+		return poolName == dataPoolName, nil
+	}
+	return info.DataPool == dataPoolName, nil
+}
+
+// mergeImageMigrations returns the migration status of the images of the pool, the images created since the
+// conversion started are added and the deleted images are removed
+func mergeImageMigrations(migrations []cephv1.ImageMigrationStatus, images []cephclient.CephBlockImage) []cephv1.ImageMigrationStatus {
+	known := map[string]cephv1.ImageMigrationStatus{}
+	for _, migration := range migrations {
+		known[migration.Name] = migration
+	}
+	merged := []cephv1.ImageMigrationStatus{}
+	for _, image := range images {
+		migration, ok := known[image.Name]
+		if !ok {
+			migration = cephv1.ImageMigrationStatus{Name: image.Name, State: cephv1.ImageMigrationPending}
+		}
+		merged = append(merged, migration)
+	}
+	return merged
+}
+
+// updateConversionStatus updates the pool CR with the status of its conversion
+func (r *ReconcileCephBlockPool) updateConversionStatus(cephBlockPool *cephv1.CephBlockPool, conversion *cephv1.PoolConversionStatus) error {
+	poolName := types.NamespacedName{Name: cephBlockPool.Name, Namespace: cephBlockPool.Namespace}
+	pool := &cephv1.CephBlockPool{}
+	if err := r.client.Get(context.TODO(), poolName, pool); err != nil {
+		return errors.Wrapf(err, "failed to retrieve pool %q to update conversion status", poolName)
+	}
+	if pool.Status == nil {
+		pool.Status = &cephv1.CephBlockPoolStatus{}
+	}
+
+	conversion.LastChecked = time.Now().UTC().Format(time.RFC3339)
+	pool.Status.Conversion = conversion
+	if err := opcontroller.UpdateStatus(r.client, pool); err != nil {
+		return errors.Wrapf(err, "failed to set pool %q conversion status", poolName)
+	}
+	// the rest of the reconcile uses the data pool of the conversion
+	if cephBlockPool.Status == nil {
+		cephBlockPool.Status = &cephv1.CephBlockPoolStatus{}
+	}
+	cephBlockPool.Status.Conversion = conversion.DeepCopy()
+	logger.Debugf("pool %q conversion status updated", poolName)
+	return nil
+}
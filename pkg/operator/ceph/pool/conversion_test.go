@@ -0,0 +1,280 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestReconcileConversion(t *testing.T) {
+	namespace := "rook-ceph"
+	pool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace},
+		Spec: cephv1.PoolSpec{
+			ErasureCoded: cephv1.ErasureCodedSpec{DataChunks: 2, CodingChunks: 1},
+		},
+	}
+
+	// the pools and the images as seen by ceph
+	pools := map[string]string{"replicapool": ""}
+	rbdConfig := map[string]string{}
+	watchers := map[string]string{"img1": `[{"address":"10.0.0.1:0/123"}]`, "img2": "[]"}
+	migrationStates := map[string]string{}
+	migrationTargets := map[string]string{}
+	dataPools := map[string]string{}
+	interruptExecution := false
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			switch {
+			case args[0] == "osd" && args[1] == "lspools":
+				output := "["
+				for name := range pools {
+					if output != "[" {
+						output += ","
+					}
+					output += `{"poolnum":1,"poolname":"` + name + `"}`
+				}
+				return output + "]", nil
+			case args[0] == "osd" && args[1] == "pool" && args[2] == "get":
+				return `{"pool":"` + args[3] + `","erasure_code_profile":"` + pools[args[3]] + `"}`, nil
+			case args[0] == "osd" && args[1] == "erasure-code-profile" && args[2] == "get":
+				return `{"k":"2","m":"1","plugin":"jerasure","technique":"reed_sol_van"}`, nil
+			case args[0] == "osd" && args[1] == "pool" && args[2] == "create":
+				assert.Equal(t, "replicapool-data", args[3])
+				assert.Equal(t, "erasure", args[5])
+				pools[args[3]] = args[6]
+				return "", nil
+			case args[0] == "osd" && args[1] == "pool" && args[2] == "delete":
+				delete(pools, args[3])
+				return "", nil
+			}
+			return "", nil
+		},
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if command != "rbd" {
+				return "", nil
+			}
+			image := ""
+			if len(args) > 1 {
+				image = strings.TrimPrefix(args[1], "replicapool/")
+			}
+			switch {
+			case args[0] == "ls":
+				return `[{"image":"img1","size":1048576,"format":2},{"image":"img2","size":1048576,"format":2}]`, nil
+			case args[0] == "status":
+				if migrationStates[image] == "" {
+					return `{"watchers":` + watchers[image] + `}`, nil
+				}
+				return `{"watchers":[],"migration":{"state":"` + migrationStates[image] + `"}}`, nil
+			case args[0] == "info":
+				return `{"name":"` + image + `","data_pool":"` + dataPools[image] + `"}`, nil
+			case args[0] == "pool" && args[1] == "stats":
+				return `{"images":{"count":0,"provisioned_bytes":0,"snap_count":0}}`, nil
+			case args[0] == "config" && args[1] == "pool" && args[2] == "set":
+				rbdConfig[args[4]] = args[5]
+				return "", nil
+			case args[0] == "config" && args[1] == "pool" && args[2] == "remove":
+				delete(rbdConfig, args[4])
+				return "", nil
+			}
+			image = strings.TrimPrefix(args[2], "replicapool/")
+			switch {
+			case args[0] == "migration" && args[1] == "prepare":
+				migrationStates[image] = cephclient.ImageMigrationStatePrepared
+				migrationTargets[image] = args[4]
+				return "", nil
+			case args[0] == "migration" && args[1] == "execute":
+				if interruptExecution {
+					migrationStates[image] = cephclient.ImageMigrationStateExecuting
+					return "", errors.New("copy interrupted")
+				}
+				migrationStates[image] = cephclient.ImageMigrationStateExecuted
+				return "", nil
+			case args[0] == "migration" && args[1] == "commit":
+				migrationStates[image] = ""
+				// rbd ignores a data pool which is the pool of the image
+				dataPools[image] = migrationTargets[image]
+				if dataPools[image] == "replicapool" {
+					dataPools[image] = ""
+				}
+				return "", nil
+			}
+			return "", errors.Errorf("unexpected rbd command %q", args)
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pool).Build()
+	r := &ReconcileCephBlockPool{
+		client:      cl,
+		scheme:      s,
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminClusterInfo(namespace),
+	}
+	name := types.NamespacedName{Name: "replicapool", Namespace: namespace}
+	reconcileConversion := func() (reconcile.Result, *cephv1.PoolConversionStatus) {
+		err := cl.Get(context.TODO(), name, pool)
+		assert.NoError(t, err)
+		res, err := r.reconcileConversion(&cephv1.ClusterSpec{}, pool)
+		assert.NoError(t, err)
+		err = cl.Get(context.TODO(), name, pool)
+		assert.NoError(t, err)
+		return res, pool.Status.Conversion
+	}
+
+	// the copies of the data of the images run in the background
+	waitForExecutions := func() {
+		assert.Eventually(t, func() bool {
+			r.imageExecutionsLock.Lock()
+			defer r.imageExecutionsLock.Unlock()
+			for _, execution := range r.imageExecutions {
+				if !execution.done {
+					return false
+				}
+			}
+			return true
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+
+	// the image in use is skipped while the other image is migrated a step at each reconcile
+	steps := []struct {
+		state cephv1.ImageMigrationState
+		res   reconcile.Result
+	}{
+		{cephv1.ImageMigrationPrepared, opcontroller.ImmediateRetryResult},
+		{cephv1.ImageMigrationExecuting, waitForRequeueIfImageExecuting},
+		{cephv1.ImageMigrationCommitted, waitForRequeueIfImageBlocked},
+	}
+	for _, step := range steps {
+		res, conversion := reconcileConversion()
+		waitForExecutions()
+		assert.Equal(t, step.res, res)
+		assert.Equal(t, cephv1.PoolConversionMigrating, conversion.Phase)
+		assert.Equal(t, cephv1.PoolConversionToErasureCoded, conversion.Target)
+		assert.Equal(t, "replicapool-data", conversion.DataPool)
+		assert.Len(t, conversion.Images, 2)
+		assert.Equal(t, cephv1.ImageMigrationPending, conversion.Images[0].State)
+		assert.Contains(t, conversion.Images[0].Message, "in use")
+		assert.Equal(t, step.state, conversion.Images[1].State)
+		assert.Empty(t, conversion.Images[1].Message)
+	}
+	assert.Equal(t, "replicapool-data_ecprofile", pools["replicapool-data"])
+	assert.Equal(t, map[string]string{"rbd_default_data_pool": "replicapool-data"}, rbdConfig)
+	assert.Equal(t, "replicapool-data", dataPools["img2"])
+
+	// the image is migrated once it is not in use anymore
+	watchers["img1"] = "[]"
+	for _, step := range steps[:2] {
+		res, conversion := reconcileConversion()
+		waitForExecutions()
+		assert.Equal(t, step.res, res)
+		assert.Equal(t, step.state, conversion.Images[0].State)
+		assert.Empty(t, conversion.Images[0].Message)
+	}
+	res, conversion := reconcileConversion()
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.Equal(t, cephv1.PoolConversionCompleted, conversion.Phase)
+	assert.Equal(t, cephv1.ImageMigrationCommitted, conversion.Images[0].State)
+	assert.Equal(t, "replicapool-data", dataPools["img1"])
+	// the pool keeps its name and stays replicated, it keeps the headers of the images
+	assert.Equal(t, "", pools["replicapool"])
+	assert.Equal(t, "replicapool-data", convertedDataPool(pool))
+
+	// nothing to do once the pool is converted
+	res, _ = reconcileConversion()
+	assert.Equal(t, reconcile.Result{}, res)
+
+	// the pool is converted back to replicated, one image at a time
+	pool.Spec = cephv1.PoolSpec{Replicated: cephv1.ReplicatedSpec{Size: 3}}
+	err := cl.Update(context.TODO(), pool)
+	assert.NoError(t, err)
+	interruptExecution = true
+	for _, step := range steps[:2] {
+		res, conversion = reconcileConversion()
+		waitForExecutions()
+		assert.Equal(t, step.res, res)
+		assert.Equal(t, cephv1.PoolConversionToReplicated, conversion.Target)
+		assert.Equal(t, step.state, conversion.Images[0].State)
+		assert.Equal(t, cephv1.ImageMigrationPending, conversion.Images[1].State)
+	}
+	assert.Empty(t, rbdConfig)
+
+	// the interrupted copy is reported and the next image is migrated
+	res, conversion = reconcileConversion()
+	assert.Equal(t, opcontroller.ImmediateRetryResult, res)
+	assert.Contains(t, conversion.Images[0].Message, "copy interrupted")
+	assert.Equal(t, cephv1.ImageMigrationPrepared, conversion.Images[1].State)
+	assert.Equal(t, cephclient.ImageMigrationStateExecuting, migrationStates["img1"])
+
+	// the executing copy is resumed
+	interruptExecution = false
+	res, conversion = reconcileConversion()
+	waitForExecutions()
+	assert.Equal(t, waitForRequeueIfImageExecuting, res)
+	assert.Equal(t, cephv1.ImageMigrationExecuting, conversion.Images[0].State)
+	assert.Empty(t, conversion.Images[0].Message)
+	assert.Equal(t, cephclient.ImageMigrationStateExecuted, migrationStates["img1"])
+	res, conversion = reconcileConversion()
+	waitForExecutions()
+	assert.Equal(t, waitForRequeueIfImageExecuting, res)
+	assert.Equal(t, cephv1.ImageMigrationCommitted, conversion.Images[0].State)
+	assert.Equal(t, cephv1.ImageMigrationExecuting, conversion.Images[1].State)
+
+	res, conversion = reconcileConversion()
+	assert.Equal(t, reconcile.Result{}, res)
+	assert.Equal(t, cephv1.PoolConversionCompleted, conversion.Phase)
+	assert.Empty(t, conversion.DataPool)
+	assert.Empty(t, dataPools["img1"])
+	assert.Empty(t, dataPools["img2"])
+	assert.NotContains(t, pools, "replicapool-data")
+	assert.Empty(t, convertedDataPool(pool))
+
+	// nothing to do once the pool is converted back
+	res, _ = reconcileConversion()
+	assert.Equal(t, reconcile.Result{}, res)
+}
+
+func TestMergeImageMigrations(t *testing.T) {
+	migrations := []cephv1.ImageMigrationStatus{
+		{Name: "img1", State: cephv1.ImageMigrationCommitted},
+		{Name: "deleted", State: cephv1.ImageMigrationPending},
+	}
+	images := []cephclient.CephBlockImage{{Name: "img1"}, {Name: "img2"}}
+
+	merged := mergeImageMigrations(migrations, images)
+	assert.Equal(t, []cephv1.ImageMigrationStatus{
+		{Name: "img1", State: cephv1.ImageMigrationCommitted},
+		{Name: "img2", State: cephv1.ImageMigrationPending},
+	}, merged)
+}
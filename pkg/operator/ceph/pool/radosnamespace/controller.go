@@ -0,0 +1,271 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package radosnamespace to manage the RADOS namespaces of a rook pool.
+package radosnamespace
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/coreos/pkg/capnslog"
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	"github.com/rook/rook/pkg/operator/ceph/cluster/mon"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+const (
+	controllerName = "ceph-block-pool-rados-namespace-controller"
+	// clusterIDKey is the key of the status info holding the clusterID of the RADOS namespace in the csi config
+	clusterIDKey = "clusterID"
+)
+
+var logger = capnslog.NewPackageLogger("github.com/rook/rook", controllerName)
+
+var cephBlockPoolRadosNamespaceKind = reflect.TypeOf(cephv1.CephBlockPoolRadosNamespace{}).Name()
+
+// Sets the type meta for the controller main object
+var controllerTypeMeta = metav1.TypeMeta{
+	Kind:       cephBlockPoolRadosNamespaceKind,
+	APIVersion: fmt.Sprintf("%s/%s", cephv1.CustomResourceGroup, cephv1.Version),
+}
+
+// ReconcileCephBlockPoolRadosNamespace reconciles a CephBlockPoolRadosNamespace object
+type ReconcileCephBlockPoolRadosNamespace struct {
+	client      client.Client
+	scheme      *runtime.Scheme
+	context     *clusterd.Context
+	clusterInfo *cephclient.ClusterInfo
+}
+
+// Add creates a new CephBlockPoolRadosNamespace Controller and adds it to the Manager. The Manager will set fields on
+// the Controller and Start it when the Manager is Started.
+func Add(mgr manager.Manager, context *clusterd.Context) error {
+	return add(mgr, newReconciler(mgr, context))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager, context *clusterd.Context) reconcile.Reconciler {
+	// Add the cephv1 scheme to the manager scheme so that the controller knows about it
+	mgrScheme := mgr.GetScheme()
+	if err := cephv1.AddToScheme(mgr.GetScheme()); err != nil {
+		panic(err)
+	}
+
+	return &ReconcileCephBlockPoolRadosNamespace{
+		client:  mgr.GetClient(),
+		scheme:  mgrScheme,
+		context: context,
+	}
+}
+
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// Create a new controller
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+	logger.Info("successfully started")
+
+	// Watch for changes on the CephBlockPoolRadosNamespace CRD object
+	err = c.Watch(&source.Kind{Type: &cephv1.CephBlockPoolRadosNamespace{TypeMeta: controllerTypeMeta}}, &handler.EnqueueRequestForObject{}, opcontroller.WatchControllerPredicate())
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Reconcile reads that state of the cluster for a CephBlockPoolRadosNamespace object and makes changes based on the
+// state read and what is in the CephBlockPoolRadosNamespace.Spec
+// The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *ReconcileCephBlockPoolRadosNamespace) Reconcile(context context.Context, request reconcile.Request) (reconcile.Result, error) {
+	// workaround because the rook logging mechanism is not compatible with the controller-runtime logging interface
+	reconcileResponse, err := r.reconcile(request)
+	if err != nil {
+		logger.Errorf("failed to reconcile %v", err)
+	}
+
+	return reconcileResponse, err
+}
+
+func (r *ReconcileCephBlockPoolRadosNamespace) reconcile(request reconcile.Request) (reconcile.Result, error) {
+	// Fetch the CephBlockPoolRadosNamespace instance
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{}
+	err := r.client.Get(context.TODO(), request.NamespacedName, radosNamespace)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPoolRadosNamespace resource not found. Ignoring since object must be deleted.")
+			return reconcile.Result{}, nil
+		}
+		// Error reading the object - requeue the request.
+		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to get CephBlockPoolRadosNamespace")
+	}
+
+	// Set a finalizer so we can do cleanup before the object goes away
+	err = opcontroller.AddFinalizerIfNotPresent(r.client, radosNamespace)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to add finalizer")
+	}
+
+	// The CR was just created, initializing status fields
+	if radosNamespace.Status == nil {
+		updateStatus(r.client, request.NamespacedName, cephv1.ConditionProgressing, nil)
+	}
+
+	// Make sure a CephCluster is present otherwise do nothing
+	_, isReadyToReconcile, cephClusterExists, reconcileResponse := opcontroller.IsReadyToReconcile(r.client, r.context, request.NamespacedName, controllerName)
+	if !isReadyToReconcile {
+		// The RADOS namespace is gone with the CephCluster, only remove the finalizer
+		if !radosNamespace.GetDeletionTimestamp().IsZero() && !cephClusterExists {
+			err = opcontroller.RemoveFinalizer(r.client, radosNamespace)
+			if err != nil {
+				return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to remove finalizer")
+			}
+
+			// Return and do not requeue. Successful deletion.
+			return reconcile.Result{}, nil
+		}
+		return reconcileResponse, nil
+	}
+
+	// Populate clusterInfo during each reconcile
+	r.clusterInfo, _, _, err = mon.LoadClusterInfo(r.context, request.NamespacedName.Namespace)
+	if err != nil {
+		return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to populate cluster info")
+	}
+
+	// The clusterID a storage class uses to target the RADOS namespace
+	clusterID := buildClusterID(radosNamespace)
+
+	// DELETE: the CR was deleted
+	if !radosNamespace.GetDeletionTimestamp().IsZero() {
+		logger.Infof("deleting rados namespace %q of pool %q", radosNamespace.Name, radosNamespace.Spec.BlockPoolName)
+		err := cephclient.DeleteRadosNamespace(r.context, r.clusterInfo, radosNamespace.Spec.BlockPoolName, radosNamespace.Name)
+		if err != nil {
+			if strings.Contains(err.Error(), "contains images/snapshosts") {
+				// The images are owned by the users of the RADOS namespace, retry until they are deleted
+				logger.Errorf("failed to delete rados namespace %q, its images must be deleted first. %v", radosNamespace.Name, err)
+				updateStatus(r.client, request.NamespacedName, cephv1.ConditionDeleting, map[string]string{clusterIDKey: clusterID})
+				return opcontroller.WaitForRequeueIfFinalizerBlocked, nil
+			}
+			return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to delete rados namespace %q", radosNamespace.Name)
+		}
+
+		err = csi.RemoveRadosNamespaceConfig(r.context.Clientset, clusterID)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to remove rados namespace %q from the csi config", radosNamespace.Name)
+		}
+
+		// Remove finalizer
+		err = opcontroller.RemoveFinalizer(r.client, radosNamespace)
+		if err != nil {
+			return opcontroller.ImmediateRetryResult, errors.Wrap(err, "failed to remove finalizer")
+		}
+
+		// Return and do not requeue. Successful deletion.
+		return reconcile.Result{}, nil
+	}
+
+	// The RADOS namespace is created once its pool is ready
+	cephBlockPool := &cephv1.CephBlockPool{}
+	poolName := types.NamespacedName{Name: radosNamespace.Spec.BlockPoolName, Namespace: request.Namespace}
+	err = r.client.Get(context.TODO(), poolName, cephBlockPool)
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Infof("waiting for pool %q of rados namespace %q to be created", poolName.Name, radosNamespace.Name)
+			return opcontroller.WaitForRequeueIfCephClusterNotReady, nil
+		}
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to get CephBlockPool %q", poolName.Name)
+	}
+	if cephBlockPool.Status == nil || cephBlockPool.Status.Phase != cephv1.ConditionReady {
+		logger.Infof("waiting for pool %q of rados namespace %q to be ready", poolName.Name, radosNamespace.Name)
+		return opcontroller.WaitForRequeueIfCephClusterNotReady, nil
+	}
+
+	// CREATE/UPDATE
+	err = cephclient.CreateRadosNamespace(r.context, r.clusterInfo, radosNamespace.Spec.BlockPoolName, radosNamespace.Name)
+	if err != nil {
+		if strings.Contains(err.Error(), opcontroller.UninitializedCephConfigError) {
+			logger.Info("skipping reconcile since operator is still initializing")
+			return opcontroller.WaitForRequeueIfOperatorNotInitialized, nil
+		}
+		updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure, nil)
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to create rados namespace %q", radosNamespace.Name)
+	}
+
+	err = csi.SaveRadosNamespaceConfig(r.context.Clientset, clusterID, request.Namespace, radosNamespace.Name, r.clusterInfo)
+	if err != nil {
+		updateStatus(r.client, request.NamespacedName, cephv1.ConditionFailure, nil)
+		return opcontroller.ImmediateRetryResult, errors.Wrapf(err, "failed to save rados namespace %q in the csi config", radosNamespace.Name)
+	}
+
+	// Set Ready status, we are done reconciling
+	updateStatus(r.client, request.NamespacedName, cephv1.ConditionReady, map[string]string{clusterIDKey: clusterID})
+
+	// Return and do not requeue
+	logger.Debug("done reconciling")
+	return reconcile.Result{}, nil
+}
+
+// buildClusterID returns the clusterID of the RADOS namespace in the csi config
+func buildClusterID(radosNamespace *cephv1.CephBlockPoolRadosNamespace) string {
+	return k8sutil.Hash(fmt.Sprintf("%s-%s-%s", radosNamespace.Namespace, radosNamespace.Spec.BlockPoolName, radosNamespace.Name))
+}
+
+// updateStatus updates a RADOS namespace CR with the given status
+func updateStatus(client client.Client, name types.NamespacedName, status cephv1.ConditionType, info map[string]string) {
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{}
+	if err := client.Get(context.TODO(), name, radosNamespace); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPoolRadosNamespace resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve rados namespace %q to update status to %q. %v", name, status, err)
+		return
+	}
+
+	if radosNamespace.Status == nil {
+		radosNamespace.Status = &cephv1.RadosNamespaceStatus{}
+	}
+
+	radosNamespace.Status.Phase = status
+	radosNamespace.Status.Info = info
+	if err := opcontroller.UpdateStatus(client, radosNamespace); err != nil {
+		logger.Warningf("failed to set rados namespace %q status to %q. %v", name, status, err)
+		return
+	}
+	logger.Debugf("rados namespace %q status updated to %q", name, status)
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package radosnamespace
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	rookclient "github.com/rook/rook/pkg/client/clientset/versioned/fake"
+	"github.com/rook/rook/pkg/client/clientset/versioned/scheme"
+	"github.com/rook/rook/pkg/clusterd"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	"github.com/rook/rook/pkg/operator/ceph/csi"
+	"github.com/rook/rook/pkg/operator/k8sutil"
+	"github.com/rook/rook/pkg/operator/test"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestCephBlockPoolRadosNamespaceController(t *testing.T) {
+	ctx := context.TODO()
+	namespace := "rook-ceph"
+
+	os.Setenv(k8sutil.PodNamespaceEnvVar, namespace)
+	defer os.Unsetenv(k8sutil.PodNamespaceEnvVar)
+	csi.EnableRBD = true
+	defer func() { csi.EnableRBD = false }()
+
+	radosNamespace := &cephv1.CephBlockPoolRadosNamespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-a", Namespace: namespace},
+		Spec:       cephv1.RadosNamespaceSpec{BlockPoolName: "replicapool"},
+		TypeMeta:   controllerTypeMeta,
+	}
+	cephBlockPool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace},
+	}
+	cephCluster := &cephv1.CephCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: namespace, Namespace: namespace},
+		Status: cephv1.ClusterStatus{
+			Phase:      k8sutil.ReadyStatus,
+			CephStatus: &cephv1.CephStatus{Health: "HEALTH_OK"},
+		},
+	}
+	object := []runtime.Object{radosNamespace, cephBlockPool, cephCluster}
+
+	created, removed := false, false
+	imageCount := "1"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutput: func(command string, args ...string) (string, error) {
+			if command != "rbd" {
+				return "", nil
+			}
+			switch {
+			case args[0] == "namespace" && args[1] == "create":
+				created = true
+				return "", nil
+			case args[0] == "namespace" && args[1] == "remove":
+				removed = true
+				return "", nil
+			case args[0] == "pool" && args[1] == "stats":
+				return `{"images":{"count":` + imageCount + `,"provisioned_bytes":0,"snap_count":0},"trash":{"count":0,"provisioned_bytes":0,"snap_count":0}}`, nil
+			}
+			return "", errors.Errorf("unexpected rbd command %q", args)
+		},
+	}
+	clientset := test.New(t, 3)
+	c := &clusterd.Context{
+		Executor:      executor,
+		RookClientset: rookclient.NewSimpleClientset(),
+		Clientset:     clientset,
+	}
+
+	// Mock clusterInfo
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "rook-ceph-mon", Namespace: namespace},
+		Data: map[string][]byte{
+			"fsid":         []byte("fsid"),
+			"mon-secret":   []byte("monsecret"),
+			"admin-secret": []byte("adminsecret"),
+		},
+		Type: k8sutil.RookType,
+	}
+	_, err := clientset.CoreV1().Secrets(namespace).Create(ctx, secret, metav1.CreateOptions{})
+	assert.NoError(t, err)
+	csiConfig := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: csi.ConfigName, Namespace: namespace},
+		Data:       map[string]string{csi.ConfigKey: "[]"},
+	}
+	_, err = clientset.CoreV1().ConfigMaps(namespace).Create(ctx, csiConfig, metav1.CreateOptions{})
+	assert.NoError(t, err)
+
+	// Register operator types with the runtime scheme.
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPoolRadosNamespace{}, &cephv1.CephBlockPoolRadosNamespaceList{})
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{})
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephCluster{}, &cephv1.CephClusterList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(object...).Build()
+	r := &ReconcileCephBlockPoolRadosNamespace{client: cl, scheme: s, context: c}
+	req := reconcile.Request{NamespacedName: types.NamespacedName{Name: "team-a", Namespace: namespace}}
+
+	// The namespace is not created before its pool is ready
+	res, err := r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.True(t, res.Requeue)
+	assert.False(t, created)
+
+	// The namespace is created and published in the csi config
+	err = cl.Get(ctx, types.NamespacedName{Name: "replicapool", Namespace: namespace}, cephBlockPool)
+	assert.NoError(t, err)
+	cephBlockPool.Status = &cephv1.CephBlockPoolStatus{Phase: cephv1.ConditionReady}
+	err = cl.Update(ctx, cephBlockPool)
+	assert.NoError(t, err)
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.True(t, created)
+	err = cl.Get(ctx, req.NamespacedName, radosNamespace)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.ConditionReady, radosNamespace.Status.Phase)
+	clusterID := radosNamespace.Status.Info[clusterIDKey]
+	assert.Equal(t, buildClusterID(radosNamespace), clusterID)
+	csiConfig, err = clientset.CoreV1().ConfigMaps(namespace).Get(ctx, csi.ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Contains(t, csiConfig.Data[csi.ConfigKey], `"clusterID":"`+clusterID+`"`)
+	assert.Contains(t, csiConfig.Data[csi.ConfigKey], `"radosNamespace":"team-a"`)
+
+	// The namespace is not deleted while it contains images
+	radosNamespace.DeletionTimestamp = &metav1.Time{Time: time.Now()}
+	err = cl.Update(ctx, radosNamespace)
+	assert.NoError(t, err)
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.Equal(t, opcontroller.WaitForRequeueIfFinalizerBlocked, res)
+	assert.False(t, removed)
+	err = cl.Get(ctx, req.NamespacedName, radosNamespace)
+	assert.NoError(t, err)
+	assert.Equal(t, cephv1.ConditionDeleting, radosNamespace.Status.Phase)
+
+	// The namespace is deleted and removed from the csi config once its images are gone
+	imageCount = "0"
+	res, err = r.Reconcile(ctx, req)
+	assert.NoError(t, err)
+	assert.False(t, res.Requeue)
+	assert.True(t, removed)
+	csiConfig, err = clientset.CoreV1().ConfigMaps(namespace).Get(ctx, csi.ConfigName, metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "[]", csiConfig.Data[csi.ConfigKey])
+	radosNamespace = &cephv1.CephBlockPoolRadosNamespace{}
+	err = cl.Get(ctx, req.NamespacedName, radosNamespace)
+	assert.NoError(t, err)
+	assert.Empty(t, radosNamespace.Finalizers)
+}
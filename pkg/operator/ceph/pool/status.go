@@ -22,6 +22,7 @@ import (
 	"time"
 
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
 	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
 	kerrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
@@ -54,6 +55,98 @@ func updateStatus(client client.Client, poolName types.NamespacedName, status ce
 	logger.Debugf("pool %q status updated to %q", poolName, status)
 }
 
+// updatePGAutoscalerStatus updates a pool CR with the placement group counts reported by the autoscaler
+func (r *ReconcileCephBlockPool) updatePGAutoscalerStatus(poolName types.NamespacedName) {
+	autoscaleStatus, err := cephclient.GetPoolAutoscaleStatus(r.context, r.clusterInfo)
+	if err != nil {
+		// the status is not available when the pg_autoscaler mgr module is disabled
+		logger.Debugf("failed to get pg autoscaler status of pool %q. %v", poolName, err)
+		return
+	}
+
+	var status *cephv1.PGAutoscalerStatus
+	for _, s := range autoscaleStatus {
+		if s.PoolName == poolName.Name {
+			status = &cephv1.PGAutoscalerStatus{
+				Mode:        s.Mode,
+				PGNum:       s.PGNumTarget,
+				TargetPGNum: s.PGNumFinal,
+				LastChecked: time.Now().UTC().Format(time.RFC3339),
+			}
+			break
+		}
+	}
+	if status == nil {
+		logger.Debugf("pool %q not found in the pg autoscaler status", poolName)
+		return
+	}
+
+	pool := &cephv1.CephBlockPool{}
+	if err := r.client.Get(context.TODO(), poolName, pool); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve pool %q to update pg autoscaler status. %v", poolName, err)
+		return
+	}
+	if pool.Status == nil {
+		pool.Status = &cephv1.CephBlockPoolStatus{}
+	}
+
+	pool.Status.PGAutoscaler = status
+	if err := opcontroller.UpdateStatus(r.client, pool); err != nil {
+		logger.Warningf("failed to set pool %q pg autoscaler status. %v", poolName, err)
+		return
+	}
+	logger.Debugf("pool %q pg autoscaler status updated", poolName)
+}
+
+// updateErasureCodeProfileStatus updates an erasure coded pool CR with the settings of its erasure code profile which
+// differ from its spec, they cannot be changed while the profile is in use. The profile is the one of the erasure
+// coded pool, which is the data pool of a converted pool.
+func (r *ReconcileCephBlockPool) updateErasureCodeProfileStatus(poolName types.NamespacedName, ecPoolName string, spec cephv1.PoolSpec) {
+	profileName := cephclient.GetErasureCodeProfileForPool(ecPoolName)
+	drift, err := cephclient.GetErasureCodeProfileDrift(r.context, r.clusterInfo, profileName, spec)
+	if err != nil {
+		logger.Warningf("failed to check erasure code profile %q of pool %q. %v", profileName, poolName, err)
+		return
+	}
+	pools, err := cephclient.GetErasureCodeProfilePools(r.context, r.clusterInfo, profileName)
+	if err != nil {
+		logger.Warningf("failed to get the pools using erasure code profile %q. %v", profileName, err)
+		return
+	}
+	if len(drift) > 0 {
+		logger.Warningf("the settings of erasure code profile %q differ from the spec of pool %q: %v", profileName, poolName, drift)
+	}
+
+	pool := &cephv1.CephBlockPool{}
+	if err := r.client.Get(context.TODO(), poolName, pool); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve pool %q to update erasure code profile status. %v", poolName, err)
+		return
+	}
+	if pool.Status == nil {
+		pool.Status = &cephv1.CephBlockPoolStatus{}
+	}
+
+	pool.Status.ErasureCodeProfile = &cephv1.ErasureCodeProfileStatus{
+		Name:        profileName,
+		InUse:       len(pools) > 0,
+		Drift:       drift,
+		LastChecked: time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := opcontroller.UpdateStatus(r.client, pool); err != nil {
+		logger.Warningf("failed to set pool %q erasure code profile status. %v", poolName, err)
+		return
+	}
+	logger.Debugf("pool %q erasure code profile status updated", poolName)
+}
+
 // updateStatusBucket updates an object with a given status
 func (c *mirrorChecker) updateStatusMirroring(mirrorStatus *cephv1.PoolMirroringStatusSummarySpec, mirrorInfo *cephv1.PoolMirroringInfo, snapSchedStatus []cephv1.SnapshotSchedulesSpec, details string) {
 	blockPool := &cephv1.CephBlockPool{}
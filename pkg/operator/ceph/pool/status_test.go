@@ -18,10 +18,19 @@ limitations under the License.
 package pool
 
 import (
+	"context"
 	"testing"
 
+	"github.com/pkg/errors"
 	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 func TestToCustomResourceStatus(t *testing.T) {
@@ -58,3 +67,81 @@ func TestToCustomResourceStatus(t *testing.T) {
 		assert.NotEmpty(t, newSnapshotScheduleStatus)
 	}
 }
+
+func TestUpdatePGAutoscalerStatus(t *testing.T) {
+	namespace := "rook-ceph"
+	pool := &cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace}}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "osd" && args[1] == "pool" && args[2] == "autoscale-status" {
+				return `[{"pool_name":"replicapool","pg_autoscale_mode":"on","pg_num_target":32,"pg_num_final":128}]`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pool).Build()
+	r := &ReconcileCephBlockPool{
+		client:      cl,
+		scheme:      s,
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminClusterInfo(namespace),
+	}
+
+	name := types.NamespacedName{Name: "replicapool", Namespace: namespace}
+	r.updatePGAutoscalerStatus(name)
+	err := cl.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	assert.NotNil(t, pool.Status.PGAutoscaler)
+	assert.Equal(t, "on", pool.Status.PGAutoscaler.Mode)
+	assert.Equal(t, 32, pool.Status.PGAutoscaler.PGNum)
+	assert.Equal(t, 128, pool.Status.PGAutoscaler.TargetPGNum)
+	assert.NotEmpty(t, pool.Status.PGAutoscaler.LastChecked)
+}
+
+func TestUpdateErasureCodeProfileStatus(t *testing.T) {
+	namespace := "rook-ceph"
+	pool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "ecpool", Namespace: namespace},
+		Spec: cephv1.PoolSpec{
+			ErasureCoded: cephv1.ErasureCodedSpec{DataChunks: 4, CodingChunks: 2},
+		},
+	}
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			switch {
+			case args[0] == "osd" && args[1] == "erasure-code-profile" && args[2] == "get" && args[3] == "default":
+				return `{"k":"2","m":"1","plugin":"jerasure","technique":"reed_sol_van"}`, nil
+			case args[0] == "osd" && args[1] == "erasure-code-profile" && args[2] == "get":
+				assert.Equal(t, "ecpool_ecprofile", args[3])
+				return `{"k":"2","m":"1","plugin":"jerasure","technique":"reed_sol_van"}`, nil
+			case args[0] == "osd" && args[1] == "dump":
+				return `{"pools":[{"pool_name":"ecpool","erasure_code_profile":"ecpool_ecprofile"}]}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pool).Build()
+	r := &ReconcileCephBlockPool{
+		client:      cl,
+		scheme:      s,
+		context:     &clusterd.Context{Executor: executor},
+		clusterInfo: cephclient.AdminClusterInfo(namespace),
+	}
+
+	name := types.NamespacedName{Name: "ecpool", Namespace: namespace}
+	r.updateErasureCodeProfileStatus(name, "ecpool", pool.Spec)
+	err := cl.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	status := pool.Status.ErasureCodeProfile
+	assert.NotNil(t, status)
+	assert.Equal(t, "ecpool_ecprofile", status.Name)
+	assert.True(t, status.InUse)
+	assert.Equal(t, []string{"k=2 (spec: 4)", "m=1 (spec: 2)"}, status.Drift)
+	assert.NotEmpty(t, status.LastChecked)
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	opcontroller "github.com/rook/rook/pkg/operator/ceph/controller"
+	v1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultNearFullRatio = 0.85
+)
+
+type usageChecker struct {
+	context        *clusterd.Context
+	interval       time.Duration
+	client         client.Client
+	clusterInfo    *cephclient.ClusterInfo
+	namespacedName types.NamespacedName
+}
+
+// newUsageChecker creates a new checker of the capacity and quota utilization of a pool
+func newUsageChecker(context *clusterd.Context, client client.Client, clusterInfo *cephclient.ClusterInfo, namespacedName types.NamespacedName, poolSpec *cephv1.PoolSpec) *usageChecker {
+	c := &usageChecker{
+		context:        context,
+		interval:       defaultHealthCheckInterval,
+		clusterInfo:    clusterInfo,
+		namespacedName: namespacedName,
+		client:         client,
+	}
+
+	// allow overriding the check interval
+	checkInterval := poolSpec.StatusCheck.Usage.Interval
+	if checkInterval != "" {
+		if duration, err := time.ParseDuration(checkInterval); err == nil {
+			logger.Infof("pool usage check interval for block pool %q is %q", namespacedName.Name, checkInterval)
+			c.interval = duration
+		}
+	}
+	return c
+}
+
+// checkUsage periodically checks the capacity and quota utilization of the pool
+func (c *usageChecker) checkUsage(stopCh chan struct{}) {
+	// check the usage immediately before starting the loop
+	if err := c.checkPoolUsage(); err != nil {
+		c.updateStatusUsage(nil, nil, err.Error())
+		logger.Debugf("failed to check pool usage for ceph block pool %q. %v", c.namespacedName.Name, err)
+	}
+
+	for {
+		select {
+		case <-stopCh:
+			logger.Infof("stopping monitoring pool usage %q", c.namespacedName.Name)
+			return
+
+		case <-time.After(c.interval):
+			logger.Debugf("checking pool usage %q", c.namespacedName.Name)
+			if err := c.checkPoolUsage(); err != nil {
+				c.updateStatusUsage(nil, nil, err.Error())
+				logger.Debugf("failed to check pool usage for ceph block pool %q. %v", c.namespacedName.Name, err)
+			}
+		}
+	}
+}
+
+func (c *usageChecker) checkPoolUsage() error {
+	// the near full ratio and the quotas are read at each check to follow the updates of the pool spec
+	blockPool := &cephv1.CephBlockPool{}
+	if err := c.client.Get(context.TODO(), c.namespacedName, blockPool); err != nil {
+		return errors.Wrapf(err, "failed to retrieve ceph block pool %q", c.namespacedName.Name)
+	}
+	quotas := blockPool.Spec.Quotas
+	nearFullRatio := defaultNearFullRatio
+	if blockPool.Spec.StatusCheck.Usage.NearFullRatio != 0 {
+		nearFullRatio = blockPool.Spec.StatusCheck.Usage.NearFullRatio
+	}
+
+	poolStats, err := cephclient.GetPoolStats(c.context, c.clusterInfo)
+	if err != nil {
+		return errors.Wrap(err, "failed to get pool usage")
+	}
+
+	for _, pool := range poolStats.Pools {
+		if pool.Name != c.namespacedName.Name {
+			continue
+		}
+		usage := &cephv1.PoolUsageStatus{
+			StoredBytes:   uint64(pool.Stats.Stored),
+			Objects:       uint64(pool.Stats.Objects),
+			MaxAvailBytes: uint64(pool.Stats.MaxAvail),
+			PercentUsed:   toPercent(pool.Stats.PercentUsed),
+			LastChecked:   time.Now().UTC().Format(time.RFC3339),
+		}
+
+		// the ratios of the capacity and of the quotas that are over the near full ratio
+		nearFull := []string{}
+		if pool.Stats.PercentUsed >= nearFullRatio {
+			nearFull = append(nearFull, fmt.Sprintf("%.2f%% of its capacity", usage.PercentUsed))
+		}
+
+		maxBytes, err := quotaMaxBytes(quotas)
+		if err != nil {
+			return err
+		}
+		if maxBytes != 0 {
+			ratio := pool.Stats.Stored / float64(maxBytes)
+			percent := toPercent(ratio)
+			usage.QuotaBytesPercentUsed = &percent
+			if ratio >= nearFullRatio {
+				nearFull = append(nearFull, fmt.Sprintf("%.2f%% of its bytes quota", percent))
+			}
+		}
+		if quotas.MaxObjects != nil && *quotas.MaxObjects != 0 {
+			ratio := pool.Stats.Objects / float64(*quotas.MaxObjects)
+			percent := toPercent(ratio)
+			usage.QuotaObjectsPercentUsed = &percent
+			if ratio >= nearFullRatio {
+				nearFull = append(nearFull, fmt.Sprintf("%.2f%% of its objects quota", percent))
+			}
+		}
+
+		condition := &cephv1.Condition{
+			Type:    cephv1.ConditionPoolNearFull,
+			Status:  v1.ConditionFalse,
+			Reason:  cephv1.PoolUsageNormalReason,
+			Message: fmt.Sprintf("The usage of the pool is below the near full ratio %v", nearFullRatio),
+		}
+		if len(nearFull) > 0 {
+			condition.Status = v1.ConditionTrue
+			condition.Reason = cephv1.PoolNearFullReason
+			condition.Message = fmt.Sprintf("The pool uses %s", strings.Join(nearFull, ", "))
+		}
+
+		c.updateStatusUsage(usage, condition, "")
+		return nil
+	}
+
+	return errors.Errorf("pool %q not found in the pool usage", c.namespacedName.Name)
+}
+
+// quotaMaxBytes returns the bytes quota of a pool, the MaxSize quota has precedence over the deprecated MaxBytes
+func quotaMaxBytes(quotas cephv1.QuotaSpec) (uint64, error) {
+	if quotas.MaxSize != nil {
+		maxSize, err := resource.ParseQuantity(*quotas.MaxSize)
+		if err != nil {
+			return 0, errors.Wrapf(err, "failed to parse quota max size %q", *quotas.MaxSize)
+		}
+		return uint64(maxSize.Value()), nil
+	}
+	if quotas.MaxBytes != nil {
+		return *quotas.MaxBytes, nil
+	}
+	return 0, nil
+}
+
+// toPercent converts a ratio to a percentage rounded to two decimals
+func toPercent(ratio float64) float64 {
+	return math.Round(ratio*10000) / 100
+}
+
+// updateStatusUsage updates the pool CR with the usage of the pool. If the usage could not be retrieved, only the
+// details of the usage status are updated.
+func (c *usageChecker) updateStatusUsage(usage *cephv1.PoolUsageStatus, condition *cephv1.Condition, details string) {
+	blockPool := &cephv1.CephBlockPool{}
+	if err := c.client.Get(context.TODO(), c.namespacedName, blockPool); err != nil {
+		if kerrors.IsNotFound(err) {
+			logger.Debug("CephBlockPool resource not found. Ignoring since object must be deleted.")
+			return
+		}
+		logger.Warningf("failed to retrieve ceph block pool %q to update usage status. %v", c.namespacedName.Name, err)
+		return
+	}
+	if blockPool.Status == nil {
+		blockPool.Status = &cephv1.CephBlockPoolStatus{}
+	}
+
+	if usage == nil {
+		usage = &cephv1.PoolUsageStatus{}
+		if blockPool.Status.Usage != nil {
+			usage = blockPool.Status.Usage.DeepCopy()
+		}
+	}
+	usage.Details = details
+	blockPool.Status.Usage = usage
+	if condition != nil && setPoolCondition(blockPool, *condition) && condition.Status == v1.ConditionTrue {
+		logger.Warningf("ceph block pool %q is near full. %s", c.namespacedName.Name, condition.Message)
+	}
+	if err := opcontroller.UpdateStatus(c.client, blockPool); err != nil {
+		logger.Errorf("failed to set ceph block pool %q usage status. %v", c.namespacedName.Name, err)
+		return
+	}
+
+	logger.Debugf("ceph block pool %q usage status updated", c.namespacedName.Name)
+}
+
+// setPoolCondition replaces the condition of the same type in the status of the pool. The transition time is only
+// updated when the status of the condition changes, it returns true in that case.
+func setPoolCondition(blockPool *cephv1.CephBlockPool, condition cephv1.Condition) bool {
+	now := metav1.NewTime(time.Now())
+	condition.LastHeartbeatTime = now
+	condition.LastTransitionTime = now
+	transitioned := true
+	conditions := []cephv1.Condition{}
+	for _, existing := range blockPool.Status.Conditions {
+		if existing.Type != condition.Type {
+			conditions = append(conditions, existing)
+			continue
+		}
+		if existing.Status == condition.Status {
+			condition.LastTransitionTime = existing.LastTransitionTime
+			transitioned = false
+		}
+	}
+	blockPool.Status.Conditions = append(conditions, condition)
+	return transitioned
+}
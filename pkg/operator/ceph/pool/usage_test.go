@@ -0,0 +1,124 @@
+/*
+Copyright 2021 The Rook Authors. All rights reserved.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	cephv1 "github.com/rook/rook/pkg/apis/ceph.rook.io/v1"
+	"github.com/rook/rook/pkg/clusterd"
+	cephclient "github.com/rook/rook/pkg/daemon/ceph/client"
+	exectest "github.com/rook/rook/pkg/util/exec/test"
+	"github.com/stretchr/testify/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCheckPoolUsage(t *testing.T) {
+	namespace := "rook-ceph"
+	maxSize := "1Mi"
+	maxObjects := uint64(100)
+	pool := &cephv1.CephBlockPool{
+		ObjectMeta: metav1.ObjectMeta{Name: "replicapool", Namespace: namespace},
+		Spec: cephv1.PoolSpec{
+			Quotas: cephv1.QuotaSpec{MaxSize: &maxSize, MaxObjects: &maxObjects},
+		},
+	}
+	stored := "524288"
+	executor := &exectest.MockExecutor{
+		MockExecuteCommandWithOutputFile: func(command, outfile string, args ...string) (string, error) {
+			if args[0] == "df" && args[1] == "detail" {
+				return `{"pools":[{"name":"replicapool","id":1,"stats":{"stored":` + stored + `,"objects":10,"max_avail":10485760,"percent_used":0.0476}}]}`, nil
+			}
+			return "", errors.Errorf("unexpected ceph command %q", args)
+		},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(cephv1.SchemeGroupVersion, &cephv1.CephBlockPool{}, &cephv1.CephBlockPoolList{})
+	cl := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(pool).Build()
+	name := types.NamespacedName{Name: "replicapool", Namespace: namespace}
+	c := newUsageChecker(&clusterd.Context{Executor: executor}, cl, cephclient.AdminClusterInfo(namespace), name, &pool.Spec)
+
+	// the usage is below the near full ratio
+	err := c.checkPoolUsage()
+	assert.NoError(t, err)
+	err = cl.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	usage := pool.Status.Usage
+	assert.NotNil(t, usage)
+	assert.Equal(t, uint64(524288), usage.StoredBytes)
+	assert.Equal(t, uint64(10), usage.Objects)
+	assert.Equal(t, uint64(10485760), usage.MaxAvailBytes)
+	assert.Equal(t, 4.76, usage.PercentUsed)
+	assert.Equal(t, 50.0, *usage.QuotaBytesPercentUsed)
+	assert.Equal(t, 10.0, *usage.QuotaObjectsPercentUsed)
+	assert.NotEmpty(t, usage.LastChecked)
+	assert.Len(t, pool.Status.Conditions, 1)
+	assert.Equal(t, cephv1.ConditionPoolNearFull, pool.Status.Conditions[0].Type)
+	assert.Equal(t, v1.ConditionFalse, pool.Status.Conditions[0].Status)
+
+	// the bytes quota is over the near full ratio
+	stored = "996147"
+	err = c.checkPoolUsage()
+	assert.NoError(t, err)
+	err = cl.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, 95.0, *pool.Status.Usage.QuotaBytesPercentUsed)
+	assert.Len(t, pool.Status.Conditions, 1)
+	assert.Equal(t, v1.ConditionTrue, pool.Status.Conditions[0].Status)
+	assert.Equal(t, cephv1.PoolNearFullReason, pool.Status.Conditions[0].Reason)
+	assert.Contains(t, pool.Status.Conditions[0].Message, "bytes quota")
+
+	// the near full ratio can be raised in the pool spec
+	pool.Spec.StatusCheck.Usage.NearFullRatio = 0.99
+	err = cl.Update(context.TODO(), pool)
+	assert.NoError(t, err)
+	err = c.checkPoolUsage()
+	assert.NoError(t, err)
+	err = cl.Get(context.TODO(), name, pool)
+	assert.NoError(t, err)
+	assert.Equal(t, v1.ConditionFalse, pool.Status.Conditions[0].Status)
+
+	// the pool is missing from the stats
+	c.namespacedName.Name = "otherpool"
+	err = c.checkPoolUsage()
+	assert.Error(t, err)
+}
+
+func TestSetPoolCondition(t *testing.T) {
+	pool := &cephv1.CephBlockPool{Status: &cephv1.CephBlockPoolStatus{}}
+	condition := cephv1.Condition{Type: cephv1.ConditionPoolNearFull, Status: v1.ConditionFalse}
+
+	assert.True(t, setPoolCondition(pool, condition))
+	transitionTime := pool.Status.Conditions[0].LastTransitionTime
+
+	// the transition time is kept while the status does not change
+	assert.False(t, setPoolCondition(pool, condition))
+	assert.Len(t, pool.Status.Conditions, 1)
+	assert.Equal(t, transitionTime, pool.Status.Conditions[0].LastTransitionTime)
+
+	condition.Status = v1.ConditionTrue
+	assert.True(t, setPoolCondition(pool, condition))
+	assert.Len(t, pool.Status.Conditions, 1)
+	assert.Equal(t, v1.ConditionTrue, pool.Status.Conditions[0].Status)
+}
@@ -55,8 +55,9 @@ func ValidatePoolSpec(context *clusterd.Context, clusterInfo *client.ClusterInfo
 	// validate pools for stretch clusters
 	if clusterSpec.IsStretchCluster() {
 		if p.IsReplicated() {
-			if p.Replicated.Size != 4 {
-				return errors.New("pools in a stretch cluster must have replication size 4")
+			if p.Replicated.Size != clusterSpec.StretchPoolSize() {
+				return errors.Errorf("pools in a stretch cluster with %d data zones must have replication size %d",
+					len(clusterSpec.Mon.StretchCluster.DataZones()), clusterSpec.StretchPoolSize())
 			}
 		}
 		if p.IsErasureCoded() {
@@ -140,6 +141,21 @@ func ValidatePoolSpec(context *clusterd.Context, clusterInfo *client.ClusterInfo
 		}
 	}
 
+	// validate the pg autoscaler settings
+	if err := validateAutoscaler(p); err != nil {
+		return err
+	}
+
+	// validate the erasure code plugin settings
+	if err := validateErasureCodePlugin(p); err != nil {
+		return err
+	}
+
+	// validate the near full ratio of the usage check
+	if p.StatusCheck.Usage.NearFullRatio < 0 || p.StatusCheck.Usage.NearFullRatio > 1 {
+		return errors.Errorf("invalid near full ratio %v, must be between 0 and 1", p.StatusCheck.Usage.NearFullRatio)
+	}
+
 	// Validate mirroring settings
 	if p.Mirroring.Enabled {
 		switch p.Mirroring.Mode {
@@ -164,3 +180,118 @@ func ValidatePoolSpec(context *clusterd.Context, clusterInfo *client.ClusterInfo
 
 	return nil
 }
+
+// validateAutoscaler validates the placement group autoscaler settings of a pool
+func validateAutoscaler(p *cephv1.PoolSpec) error {
+	switch p.Autoscaler.Mode {
+	case "", "on", "warn", "off":
+		break
+	default:
+		return errors.Errorf("unrecognized pg autoscale mode %q", p.Autoscaler.Mode)
+	}
+
+	if p.Autoscaler.TargetSizeRatio < 0 {
+		return errors.Errorf("invalid target size ratio %v, must be positive", p.Autoscaler.TargetSizeRatio)
+	}
+	if p.Autoscaler.TargetSizeRatio != 0 && p.Replicated.TargetSizeRatio != 0 {
+		return errors.New("the target size ratio cannot be set in both the replicated and the autoscaler settings")
+	}
+	if p.Autoscaler.TargetSize != nil && (p.Autoscaler.TargetSizeRatio != 0 || p.Replicated.TargetSizeRatio != 0) {
+		return errors.New("the target size and the target size ratio cannot both be set")
+	}
+
+	parameters, err := cephclient.PGAutoscalerParameters(p.Autoscaler)
+	if err != nil {
+		return errors.Wrap(err, "invalid pg autoscaler settings")
+	}
+
+	// the typed settings would silently override the parameters
+	for propName := range parameters {
+		if _, ok := p.Parameters[propName]; ok {
+			return errors.Errorf("the pool property %q is set by the autoscaler settings and cannot also be set in the parameters", propName)
+		}
+	}
+
+	return nil
+}
+
+// erasureCodeTechniques are the techniques supported by each erasure code plugin
+var erasureCodeTechniques = map[string][]string{
+	"jerasure": {"reed_sol_van", "reed_sol_r6_op", "cauchy_orig", "cauchy_good", "liberation", "blaum_roth", "liber8tion"},
+	"isa":      {"reed_sol_van", "cauchy"},
+	"shec":     {"single", "multiple"},
+}
+
+// validateErasureCodePlugin validates the erasure code plugin of a pool and its options
+func validateErasureCodePlugin(p *cephv1.PoolSpec) error {
+	plugin := p.ErasureCoded.Plugin
+	if plugin == nil {
+		return nil
+	}
+	if !p.IsErasureCoded() {
+		return errors.New("the erasure code plugin can only be set for erasure coded pools")
+	}
+
+	k := p.ErasureCoded.DataChunks
+	m := p.ErasureCoded.CodingChunks
+	techniquePlugin := plugin.Name
+	switch plugin.Name {
+	case "jerasure", "isa":
+	case "lrc":
+		if plugin.Technique != "" {
+			return errors.New("the lrc erasure code plugin has no technique")
+		}
+		if plugin.Locality == 0 {
+			return errors.New("the locality must be set for the lrc erasure code plugin")
+		}
+		if (k+m)%plugin.Locality != 0 {
+			return errors.Errorf("the number of data and coding chunks %d must be a multiple of the locality %d", k+m, plugin.Locality)
+		}
+	case "shec":
+		if plugin.DurabilityEstimator > m {
+			return errors.Errorf("the durability estimator %d cannot be greater than the coding chunks %d", plugin.DurabilityEstimator, m)
+		}
+	case "clay":
+		if plugin.HelperChunks != 0 && (plugin.HelperChunks < k+1 || plugin.HelperChunks > k+m-1) {
+			return errors.Errorf("the helper chunks %d must be between %d and %d", plugin.HelperChunks, k+1, k+m-1)
+		}
+		techniquePlugin = plugin.ScalarMDS
+		if techniquePlugin == "" {
+			techniquePlugin = "jerasure"
+		}
+	default:
+		return errors.Errorf("unrecognized erasure code plugin %q", plugin.Name)
+	}
+
+	if plugin.Locality != 0 && plugin.Name != "lrc" {
+		return errors.Errorf("the locality is only supported by the lrc erasure code plugin, not %q", plugin.Name)
+	}
+	if plugin.DurabilityEstimator != 0 && plugin.Name != "shec" {
+		return errors.Errorf("the durability estimator is only supported by the shec erasure code plugin, not %q", plugin.Name)
+	}
+	if (plugin.HelperChunks != 0 || plugin.ScalarMDS != "") && plugin.Name != "clay" {
+		return errors.Errorf("the helper chunks and the scalar mds are only supported by the clay erasure code plugin, not %q", plugin.Name)
+	}
+
+	if plugin.Technique != "" {
+		found := false
+		for _, technique := range erasureCodeTechniques[techniquePlugin] {
+			if technique == plugin.Technique {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("unrecognized technique %q for erasure code plugin %q", plugin.Technique, techniquePlugin)
+		}
+		if plugin.Technique == "reed_sol_r6_op" && m != 2 {
+			return errors.Errorf("the reed_sol_r6_op technique requires 2 coding chunks, not %d", m)
+		}
+	}
+
+	if _, err := cephclient.ErasureCodePluginParameters(*plugin); err != nil {
+		return errors.Wrap(err, "invalid erasure code plugin settings")
+	}
+
+	return nil
+}
@@ -182,6 +182,108 @@ func TestValidatePool(t *testing.T) {
 		assert.EqualError(t, err, "failure and subfailure domain cannot be identical")
 	}
 
+	// Stretch cluster with three data zones
+	{
+		stretchSpec := &cephv1.ClusterSpec{Mon: cephv1.MonSpec{StretchCluster: &cephv1.StretchClusterSpec{Zones: []cephv1.StretchClusterZoneSpec{
+			{Name: "a", Arbiter: true}, {Name: "b"}, {Name: "c"}, {Name: "d"},
+		}}}}
+		p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
+		p.Spec.Replicated.Size = 4
+		err = ValidatePool(context, clusterInfo, stretchSpec, &p)
+		assert.EqualError(t, err, "pools in a stretch cluster with 3 data zones must have replication size 6")
+
+		p.Spec.Replicated.Size = 6
+		err = ValidatePool(context, clusterInfo, stretchSpec, &p)
+		assert.NoError(t, err)
+	}
+
+	// PG autoscaler settings
+	{
+		p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
+		p.Spec.Autoscaler.Mode = "foo"
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "unrecognized pg autoscale mode \"foo\"")
+
+		p.Spec.Autoscaler.Mode = "warn"
+		p.Spec.Autoscaler.TargetSizeRatio = 0.5
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+
+		// Error the target size ratio is also set in the replicated settings
+		p.Spec.Replicated.TargetSizeRatio = 0.5
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the target size ratio cannot be set in both the replicated and the autoscaler settings")
+
+		// Error both a target size and a target size ratio
+		p.Spec.Replicated.TargetSizeRatio = 0
+		targetSize := "10Ti"
+		p.Spec.Autoscaler.TargetSize = &targetSize
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the target size and the target size ratio cannot both be set")
+
+		// Error the property is also set in the parameters
+		p.Spec.Autoscaler.TargetSizeRatio = 0
+		p.Spec.Parameters = map[string]string{"target_size_bytes": "100"}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the pool property \"target_size_bytes\" is set by the autoscaler settings and cannot also be set in the parameters")
+
+		p.Spec.Parameters = map[string]string{"pg_num_min": "8"}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+	}
+
+	// Usage check settings
+	{
+		p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
+		p.Spec.StatusCheck.Usage.NearFullRatio = 1.5
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "invalid near full ratio 1.5, must be between 0 and 1")
+
+		p.Spec.StatusCheck.Usage.NearFullRatio = 0.9
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+	}
+
+	// Erasure code plugin settings
+	{
+		p := cephv1.CephBlockPool{ObjectMeta: metav1.ObjectMeta{Name: "mypool", Namespace: clusterInfo.Namespace}}
+		p.Spec.ErasureCoded.Plugin = &cephv1.ErasureCodePluginSpec{Name: "isa", Technique: "cauchy"}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the erasure code plugin can only be set for erasure coded pools")
+
+		p.Spec.ErasureCoded.DataChunks = 4
+		p.Spec.ErasureCoded.CodingChunks = 2
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+
+		p.Spec.ErasureCoded.Plugin.Technique = "liberation"
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "unrecognized technique \"liberation\" for erasure code plugin \"isa\"")
+
+		p.Spec.ErasureCoded.Plugin = &cephv1.ErasureCodePluginSpec{Name: "lrc", Locality: 4}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the number of data and coding chunks 6 must be a multiple of the locality 4")
+
+		p.Spec.ErasureCoded.Plugin.Locality = 3
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+
+		p.Spec.ErasureCoded.Plugin = &cephv1.ErasureCodePluginSpec{Name: "shec", DurabilityEstimator: 3}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the durability estimator 3 cannot be greater than the coding chunks 2")
+
+		p.Spec.ErasureCoded.Plugin = &cephv1.ErasureCodePluginSpec{Name: "clay", HelperChunks: 6}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the helper chunks 6 must be between 5 and 5")
+
+		p.Spec.ErasureCoded.Plugin = &cephv1.ErasureCodePluginSpec{Name: "clay", HelperChunks: 5, ScalarMDS: "isa", Technique: "cauchy"}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.NoError(t, err)
+
+		p.Spec.ErasureCoded.Plugin = &cephv1.ErasureCodePluginSpec{Name: "jerasure", Locality: 3}
+		err = ValidatePool(context, clusterInfo, clusterSpec, &p)
+		assert.EqualError(t, err, "the locality is only supported by the lrc erasure code plugin, not \"jerasure\"")
+	}
 }
 
 func TestValidateCrushProperties(t *testing.T) {
@@ -63,6 +63,9 @@ func UpdateService(
 	}
 	// ClusterIP is immutable for k8s services and cannot be left empty in k8s v1 API
 	serviceDefinition.Spec.ClusterIP = existing.Spec.ClusterIP
+	// The cluster IPs of a dual-stack service are immutable too, the IP of the second family is allocated when the
+	// service is converted to dual-stack
+	serviceDefinition.Spec.ClusterIPs = existing.Spec.ClusterIPs
 	// ResourceVersion required to update services in k8s v1 API to prevent race conditions
 	serviceDefinition.ResourceVersion = existing.ResourceVersion
 	return clientset.CoreV1().Services(namespace).Update(ctx, serviceDefinition, metav1.UpdateOptions{})
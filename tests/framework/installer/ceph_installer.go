@@ -607,17 +607,20 @@ func (h *CephInstaller) UninstallRookFromMultipleNS(manifests ...CephManifests)
 				logger.Infof("done deleting all the resources in the common external manifest")
 			}
 		} else {
+			h.k8shelper.PrintResources(namespace, "cephblockpoolradosnamespaces.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephblockpools.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephclients.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephclusters.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephfilesystemmirrors.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephfilesystems.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephnfses.ceph.rook.io")
+			h.k8shelper.PrintResources(namespace, "cephnodemaintenances.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephobjectrealms.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephobjectstores.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephobjectstoreusers.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephobjectzonegroups.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephobjectzones.ceph.rook.io")
+			h.k8shelper.PrintResources(namespace, "cephosdremovals.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "cephrbdmirrors.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "objectbucketclaims.ceph.rook.io")
 			h.k8shelper.PrintResources(namespace, "objectbuckets.ceph.rook.io")